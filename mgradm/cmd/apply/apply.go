@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	adm_utils "github.com/uyuni-project/uyuni-tools/mgradm/shared/utils"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+type applyFlags struct {
+	adm_utils.ServerFlags `mapstructure:",squash"`
+	File                  string
+	Diff                  bool
+	// imageChanged is true if the desired state defines the server image.
+	imageChanged bool
+}
+
+func newCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[applyFlags]) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "apply",
+		GroupID: "deploy",
+		Short:   L("Apply a declarative configuration to the server"),
+		Long: L(`Apply a declarative configuration to the server
+
+The file uses the same schema as the install and upgrade configuration files.
+Only the settings it defines are compared with the deployed server:
+  - the server image,
+  - the confidential computing, Hub XML-RPC API and Saline replicas,
+  - the TFTP server,
+  - the third party SSL server certificate.
+
+Only the actions needed to reach the desired state are run.
+Use --diff to show the changes without applying them.`),
+		Example: `  mgradm apply -f server.yaml --diff`,
+		Args:    cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var flags applyFlags
+			// The desired state file is read as the configuration file.
+			fileFlags := *globalFlags
+			fileFlags.ConfigPath, _ = cmd.Flags().GetString("file")
+			return utils.CommandHelper(&fileFlags, cmd, args, &flags, getFlagsUpdater(&flags), run)
+		},
+	}
+
+	cmd.Flags().StringP("file", "f", "", L("Path to the file describing the desired server configuration"))
+	_ = cmd.MarkFlagRequired("file")
+	cmd.Flags().Bool("diff", false, L("Only show the changes to apply, without applying them"))
+
+	adm_utils.AddServerFlags(cmd)
+	adm_utils.AddDBUpgradeImageFlag(cmd)
+	adm_utils.AddUpgradeCocoFlag(cmd)
+	adm_utils.AddUpgradeHubXmlrpcFlags(cmd)
+	adm_utils.AddUpgradeSalineFlag(cmd)
+	_ = utils.AddFlagHelpGroup(cmd, &utils.Group{ID: "tftpd-container", Title: L("TFTPD Flags")})
+	utils.AddTFTPDFlags(cmd, true, "tftpd-container")
+	adm_utils.AddDebugFlags(cmd)
+	utils.AddUpgradePolicyFlags(cmd)
+	return cmd
+}
+
+func getFlagsUpdater(flags *applyFlags) utils.FlagsUpdaterFunc {
+	return func(v *viper.Viper) {
+		flags.imageChanged = v.IsSet("image") || v.IsSet("tag") || v.IsSet("registry.host")
+		flags.Coco.IsChanged = v.IsSet("coco.replicas")
+		flags.HubXmlrpc.IsChanged = v.IsSet("hubxmlrpc.replicas")
+		flags.Saline.IsChanged = v.IsSet("saline.replicas")
+		flags.TFTPD.IsChanged = v.IsSet("tftpd.enable")
+
+		if flags.Installation.SSL.Ca.IsThirdParty() && !flags.Installation.SSL.DB.CA.IsThirdParty() {
+			flags.Installation.SSL.DB.CA.Root = flags.Installation.SSL.Ca.Root
+			flags.Installation.SSL.DB.CA.Intermediate = flags.Installation.SSL.Ca.Intermediate
+		}
+		if flags.Installation.SSL.Server.IsDefined() && !flags.Installation.SSL.DB.IsDefined() {
+			flags.Installation.SSL.DB.Cert = flags.Installation.SSL.Server.Cert
+			flags.Installation.SSL.DB.Key = flags.Installation.SSL.Server.Key
+		}
+	}
+}
+
+// NewCommand applies a declarative configuration to the server.
+func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
+	return newCmd(globalFlags, applyForPodman)
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"path"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils/flagstests"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+)
+
+func TestParamsParsing(t *testing.T) {
+	file := path.Join(t.TempDir(), "server.yaml")
+	testutils.WriteFile(t, file, "")
+
+	args := flagstests.ServerFlagsTestArgs()
+	args = append(args, "--file", file, "--diff", "--force")
+
+	// Test function asserting that the args are properly parsed
+	tester := func(_ *types.GlobalFlags, flags *applyFlags, _ *cobra.Command, _ []string) error {
+		testutils.AssertEquals(t, "Error parsing --file", file, flags.File)
+		testutils.AssertTrue(t, "Error parsing --diff", flags.Diff)
+		testutils.AssertTrue(t, "Error parsing --force", flags.UpgradePolicy.Force)
+		testutils.AssertTrue(t, "Image should be changed", flags.imageChanged)
+		flagstests.AssertServerFlags(t, &flags.ServerFlags)
+		return nil
+	}
+
+	globalFlags := types.GlobalFlags{}
+	cmd := newCmd(&globalFlags, tester)
+
+	testutils.AssertHasAllFlags(t, cmd, args)
+
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("command failed with error: %s", err)
+	}
+}
+
+func TestFileParsing(t *testing.T) {
+	file := path.Join(t.TempDir(), "server.yaml")
+	testutils.WriteFile(t, file, `hubxmlrpc:
+  replicas: 1
+tftpd:
+  enable: true
+ssl:
+  server:
+    cert: /path/to/server.crt
+upgradePaths:
+  - product: uyuni
+    from: "2025.10"
+    to: "2026.04"
+`)
+
+	tester := func(_ *types.GlobalFlags, flags *applyFlags, _ *cobra.Command, _ []string) error {
+		testutils.AssertTrue(t, "Image should not be changed", !flags.imageChanged)
+		testutils.AssertTrue(t, "Coco replicas should not be changed", !flags.Coco.IsChanged)
+		testutils.AssertTrue(t, "Hub XML-RPC replicas should be changed", flags.HubXmlrpc.IsChanged)
+		testutils.AssertEquals(t, "Wrong Hub XML-RPC replicas", 1, flags.HubXmlrpc.Replicas)
+		testutils.AssertTrue(t, "Saline replicas should not be changed", !flags.Saline.IsChanged)
+		testutils.AssertTrue(t, "TFTPD should be changed", flags.TFTPD.IsChanged)
+		testutils.AssertTrue(t, "TFTPD should be enabled", flags.TFTPD.Enable)
+		testutils.AssertEquals(t, "Wrong server certificate", "/path/to/server.crt", flags.Installation.SSL.Server.Cert)
+		testutils.AssertEquals(t, "Wrong upgrade paths",
+			[]types.UpgradePath{{Product: "uyuni", From: "2025.10", To: "2026.04"}}, flags.UpgradePolicy.UpgradePaths)
+		return nil
+	}
+
+	globalFlags := types.GlobalFlags{}
+	cmd := newCmd(&globalFlags, tester)
+	cmd.SetArgs([]string{"-f", file})
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("command failed with error: %s", err)
+	}
+}
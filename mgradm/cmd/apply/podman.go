@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/coco"
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/hub"
+	adm_podman "github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/saline"
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/tftp"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+func applyForPodman(globalFlags *types.GlobalFlags, flags *applyFlags, _ *cobra.Command, _ []string) error {
+	if !utils.FileExists(flags.File) {
+		return fmt.Errorf(L("%s does not exist"), flags.File)
+	}
+	if flags.HubXmlrpc.Replicas > 1 || flags.Saline.Replicas > 1 {
+		return errors.New(L("Multiple container replicas are not currently supported."))
+	}
+
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	systemd := podman.NewSystemdOnHost(host)
+
+	current, err := readServerState(systemd)
+	if err != nil {
+		return err
+	}
+	desired, err := desiredState(flags)
+	if err != nil {
+		return err
+	}
+
+	changes := diffState(flags, current, desired)
+	if flags.Diff || len(changes) == 0 {
+		return writeDiff(os.Stdout, changes)
+	}
+
+	hostData, err := podman.InspectHost(host)
+	if err != nil {
+		return err
+	}
+
+	authFile, cleaner, err := podman.PodmanLogin(hostData, flags.Image.Registry, flags.Installation.SCC)
+	if err != nil {
+		return err
+	}
+	defer cleaner()
+
+	signaturePolicy, cleanPolicy, err := podman.SetupSignatureVerification(host, &flags.Signatures)
+	if err != nil {
+		return err
+	}
+	defer cleanPolicy()
+
+	return applyChanges(systemd, authFile, signaturePolicy, flags, current, changes)
+}
+
+// applyChanges runs the actions needed for each of the changes.
+func applyChanges(
+	systemd podman.Systemd,
+	authFile string,
+	signaturePolicy string,
+	flags *applyFlags,
+	current *serverState,
+	changes []change,
+) error {
+	host := systemd.Host()
+	if !flags.imageChanged {
+		// Keep the components images in line with the deployed server
+		if tag := utils.ImageTag(current.Image); tag != "" {
+			flags.Image.Tag = tag
+		}
+	}
+
+	fqdn, err := host.GetFqdn([]string{})
+	if err != nil {
+		return err
+	}
+
+	upgraded := false
+	for _, change := range changes {
+		if upgraded && change.Setting != certificateSetting {
+			// The upgrade already changed the components
+			continue
+		}
+		log.Info().Msgf(L("Changing %[1]s from %[2]s to %[3]s"), change.Setting, change.Current, change.Desired)
+
+		var err error
+		switch change.Setting {
+		case imageSetting:
+			err = upgrade(systemd, authFile, signaturePolicy, flags)
+			upgraded = true
+		case cocoSetting:
+			err = scaleOrSetup(systemd, podman.ServerAttestationService, flags.Coco.Replicas, func() error {
+				return coco.SetupCocoContainer(
+					systemd, authFile, signaturePolicy, flags.Coco, flags.Image, flags.Installation.DB,
+				)
+			})
+		case hubXmlrpcSetting:
+			err = scaleOrSetup(systemd, podman.HubXmlrpcService, flags.HubXmlrpc.Replicas, func() error {
+				return hub.SetupHubXmlrpc(systemd, authFile, signaturePolicy, flags.Image, flags.HubXmlrpc)
+			})
+		case salineSetting:
+			err = scaleOrSetup(systemd, podman.SalineService, flags.Saline.Replicas, func() error {
+				return saline.SetupSalineContainer(
+					systemd, authFile, signaturePolicy, flags.Image, flags.Saline, host.GetTimezone(),
+				)
+			})
+		case tftpdSetting:
+			err = tftp.SetupTFTPContainer(
+				systemd, authFile, signaturePolicy, flags.Image, flags.TFTPD, fqdn, current.TFTPD,
+			)
+		case certificateSetting:
+			err = rotateCertificate(systemd, flags, fqdn)
+		}
+		if err != nil {
+			return utils.Errorf(err, L("failed to change %s"), change.Setting)
+		}
+	}
+	log.Info().Msg(L("The server is now in the desired state"))
+	return nil
+}
+
+func upgrade(systemd podman.Systemd, authFile string, signaturePolicy string, flags *applyFlags) error {
+	return adm_podman.Upgrade(
+		systemd, authFile, signaturePolicy,
+		flags.Installation.DB,
+		flags.Installation.ReportDB,
+		flags.Installation.SSL,
+		flags.Image,
+		flags.DBUpgradeImage,
+		flags.Coco,
+		flags.HubXmlrpc,
+		flags.Saline,
+		flags.Pgsql,
+		flags.TFTPD,
+		flags.Installation.TZ,
+		flags.Installation.Debug.Java,
+		&flags.UpgradePolicy,
+	)
+}
+
+// scaleOrSetup scales a templated service if installed or sets it up with the setup function.
+func scaleOrSetup(systemd podman.Systemd, service string, replicas int, setup func() error) error {
+	if systemd.HasService(service + "@") {
+		return systemd.ScaleService(replicas, service)
+	}
+	return setup()
+}
+
+func rotateCertificate(systemd podman.Systemd, flags *applyFlags, fqdn string) error {
+	if !flags.Installation.SSL.UseProvided() {
+		return errors.New(L("the server certificate, key and root CA need to be all provided"))
+	}
+	if err := adm_podman.SetThirdPartyCertificates(systemd.Host(), &flags.Installation.SSL, fqdn); err != nil {
+		return err
+	}
+	return adm_podman.ApplyNewCertificates(systemd, fqdn)
+}
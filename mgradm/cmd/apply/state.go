@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Settings compared between the desired and the deployed states.
+const (
+	imageSetting       = "image"
+	cocoSetting        = "coco-replicas"
+	hubXmlrpcSetting   = "hubxmlrpc-replicas"
+	salineSetting      = "saline-replicas"
+	tftpdSetting       = "tftpd-enable"
+	certificateSetting = "ssl-server-cert"
+)
+
+// serverState describes the settings of a server that apply can change.
+type serverState struct {
+	Image     string
+	Coco      int
+	HubXmlrpc int
+	Saline    int
+	TFTPD     bool
+	// Certificate is the content of the server SSL certificate.
+	Certificate string
+}
+
+// change is a setting of the deployed server differing from the desired state.
+type change struct {
+	Setting string
+	Current string
+	Desired string
+}
+
+// readServerState reads the deployed state from the systemd services and podman secrets.
+func readServerState(systemd podman.Systemd) (*serverState, error) {
+	host := systemd.Host()
+	if !systemd.HasService(podman.ServerService) {
+		return nil, errors.New(L("no server is installed: use the install command first"))
+	}
+	state := serverState{
+		Image:     podman.GetServiceImage(host, podman.ServerService),
+		Coco:      systemd.CurrentReplicaCount(podman.ServerAttestationService),
+		HubXmlrpc: systemd.CurrentReplicaCount(podman.HubXmlrpcService),
+		Saline:    systemd.CurrentReplicaCount(podman.SalineService),
+		TFTPD:     systemd.ServiceIsEnabled(podman.TFTPService),
+	}
+	if state.Image == "" {
+		return nil, fmt.Errorf(L("cannot find the image of the %s service"), podman.ServerService)
+	}
+	if podman.HasSecret(host, podman.SSLCertSecret) {
+		certificate, err := podman.GetSecret(host, podman.SSLCertSecret)
+		if err != nil {
+			return nil, err
+		}
+		state.Certificate = certificate
+	}
+	return &state, nil
+}
+
+// desiredState computes the state described by the flags.
+// The settings not defined in the flags are left empty and ignored when comparing the states.
+func desiredState(flags *applyFlags) (*serverState, error) {
+	state := serverState{
+		Coco:      flags.Coco.Replicas,
+		HubXmlrpc: flags.HubXmlrpc.Replicas,
+		Saline:    flags.Saline.Replicas,
+		TFTPD:     flags.TFTPD.Enable,
+	}
+	if flags.imageChanged {
+		image, err := utils.ComputeImage(flags.Image.Registry.Host, flags.Image.Tag, flags.Image)
+		if err != nil {
+			return nil, utils.Errorf(err, L("failed to compute image URL"))
+		}
+		state.Image = image
+	}
+	if cert := flags.Installation.SSL.Server.Cert; cert != "" {
+		data, err := os.ReadFile(cert)
+		if err != nil {
+			return nil, utils.Errorf(err, L("failed to read %s"), cert)
+		}
+		state.Certificate = strings.TrimSpace(string(data))
+	}
+	return &state, nil
+}
+
+// diffState lists the changes needed to reach the desired state.
+func diffState(flags *applyFlags, current *serverState, desired *serverState) []change {
+	changes := []change{}
+	if flags.imageChanged && current.Image != desired.Image {
+		changes = append(changes, change{imageSetting, current.Image, desired.Image})
+	}
+	replicas := []struct {
+		setting   string
+		isChanged bool
+		current   int
+		desired   int
+	}{
+		{cocoSetting, flags.Coco.IsChanged, current.Coco, desired.Coco},
+		{hubXmlrpcSetting, flags.HubXmlrpc.IsChanged, current.HubXmlrpc, desired.HubXmlrpc},
+		{salineSetting, flags.Saline.IsChanged, current.Saline, desired.Saline},
+	}
+	for _, replica := range replicas {
+		if replica.isChanged && replica.current != replica.desired {
+			changes = append(changes,
+				change{replica.setting, strconv.Itoa(replica.current), strconv.Itoa(replica.desired)})
+		}
+	}
+	if flags.TFTPD.IsChanged && current.TFTPD != desired.TFTPD {
+		changes = append(changes,
+			change{tftpdSetting, strconv.FormatBool(current.TFTPD), strconv.FormatBool(desired.TFTPD)})
+	}
+	// The certificate secret also contains the intermediate CAs.
+	if desired.Certificate != "" && !strings.Contains(current.Certificate, desired.Certificate) {
+		changes = append(changes,
+			change{certificateSetting, L("deployed certificate"), flags.Installation.SSL.Server.Cert})
+	}
+	return changes
+}
+
+// writeDiff prints the changes to apply.
+func writeDiff(out io.Writer, changes []change) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(out, L("The server is already in the desired state"))
+		return err
+	}
+	for _, change := range changes {
+		if _, err := fmt.Fprintf(out, "~ %s: %s -> %s\n", change.Setting, change.Current, change.Desired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"bytes"
+	"path"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestDiffState(t *testing.T) {
+	current := serverState{
+		Image:       "registry.opensuse.org/uyuni/server:2025.10",
+		Coco:        0,
+		HubXmlrpc:   1,
+		Saline:      0,
+		TFTPD:       false,
+		Certificate: "-----BEGIN CERTIFICATE-----\nserver\n-----END CERTIFICATE-----\nintermediate",
+	}
+
+	// Nothing defined in the file: nothing to change
+	flags := applyFlags{}
+	desired := serverState{Image: "registry.opensuse.org/uyuni/server:2026.01", Coco: 2}
+	testutils.AssertEquals(t, "Undefined settings should be ignored", 0, len(diffState(&flags, &current, &desired)))
+
+	flags.imageChanged = true
+	flags.Coco.IsChanged = true
+	flags.HubXmlrpc.IsChanged = true
+	flags.TFTPD.IsChanged = true
+	flags.Installation.SSL.Server.Cert = "/path/to/server.crt"
+	desired = serverState{
+		Image:       "registry.opensuse.org/uyuni/server:2026.01",
+		Coco:        2,
+		HubXmlrpc:   1,
+		TFTPD:       true,
+		Certificate: "-----BEGIN CERTIFICATE-----\nserver\n-----END CERTIFICATE-----",
+	}
+	changes := diffState(&flags, &current, &desired)
+	expected := []change{
+		{imageSetting, current.Image, desired.Image},
+		{cocoSetting, "0", "2"},
+		{tftpdSetting, "false", "true"},
+	}
+	testutils.AssertEquals(t, "Wrong changes", expected, changes)
+
+	desired.Certificate = "-----BEGIN CERTIFICATE-----\nnew\n-----END CERTIFICATE-----"
+	changes = diffState(&flags, &current, &desired)
+	testutils.AssertEquals(t, "Certificate change not detected", certificateSetting, changes[len(changes)-1].Setting)
+}
+
+func TestDesiredState(t *testing.T) {
+	cert := path.Join(t.TempDir(), "server.crt")
+	testutils.WriteFile(t, cert, "certificate\n")
+
+	flags := applyFlags{imageChanged: true}
+	flags.Image.Registry.Host = "registry.opensuse.org"
+	flags.Image.Name = "uyuni/server"
+	flags.Image.Tag = "2026.01"
+	flags.Saline.Replicas = 1
+	flags.Installation.SSL.Server.Cert = cert
+
+	desired, err := desiredState(&flags)
+	testutils.AssertNoError(t, "failed to compute the desired state", err)
+	testutils.AssertEquals(t, "Wrong image", "registry.opensuse.org/uyuni/server:2026.01", desired.Image)
+	testutils.AssertEquals(t, "Wrong saline replicas", 1, desired.Saline)
+	testutils.AssertEquals(t, "Wrong certificate", "certificate", desired.Certificate)
+}
+
+func TestWriteDiff(t *testing.T) {
+	var out bytes.Buffer
+	testutils.AssertNoError(t, "failed to write diff", writeDiff(&out, []change{}))
+	testutils.AssertEquals(t, "Wrong empty diff", "The server is already in the desired state\n", out.String())
+
+	out.Reset()
+	testutils.AssertNoError(t, "failed to write diff", writeDiff(&out, []change{{hubXmlrpcSetting, "0", "1"}}))
+	testutils.AssertEquals(t, "Wrong diff", "~ hubxmlrpc-replicas: 0 -> 1\n", out.String())
+}
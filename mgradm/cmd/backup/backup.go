@@ -7,27 +7,61 @@ package backup
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/browse"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/create"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/db"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/list"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/prune"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/restore"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/schedule"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/verify"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
 	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/ssl"
 	"github.com/uyuni-project/uyuni-tools/shared/types"
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
+// hooksHelp returns the description of the hooks for the commands help.
+func hooksHelp() string {
+	return L(`The --hooks-* command and the executables of the pre-*.d and post-*.d subdirectories
+of the hooks directory are run before and after the operation. A failing pre hook aborts it.
+The hooks get the UYUNI_BACKUP_HOOK, UYUNI_BACKUP_LOCATION and UYUNI_BACKUP_ARTIFACTS variables.
+The post hooks also get UYUNI_BACKUP_STATUS set to success, partial or aborted and UYUNI_BACKUP_ERROR.`)
+}
+
+// addTargetFlags adds the flags needed to access the remote backup locations.
+func addTargetFlags(cmd *cobra.Command) {
+	cmd.Flags().String("s3-endpoint", "",
+		L("URL of the S3-compatible storage. Defaults to the AWS_ENDPOINT_URL environment variable or AWS S3"))
+	cmd.Flags().String("s3-region", "", L("Region of the S3 storage. Defaults to the AWS_REGION environment variable"))
+	cmd.Flags().Int("s3-partsize", 0, L("Size in MiB of the parts of the S3 multipart uploads. Default is 64"))
+	cmd.Flags().String("ssh-identity", "", L("Private key file to use to connect to the SFTP backup location"))
+}
+
 func newCreateCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[shared.Flagpole]) *cobra.Command {
 	var flags shared.Flagpole
 
 	createCmd := &cobra.Command{
-		Use:   "create output-directory",
+		Use:   "create output-location",
 		Args:  cobra.ExactArgs(1),
 		Short: L("Create backup"),
-		Long:  L("Create backup of the already configured Uyuni system"),
+		Long: L(`Create backup of the already configured Uyuni system
+
+The output location can be a local directory, an sftp://[user@]host[:port]/path URL
+or an s3://bucket/prefix URL. The backup files are streamed to remote locations without
+using local disk space.
+
+SFTP locations are accessed using the SFTP subsystem of the ssh command and its configuration:
+SFTP only and chrooted accounts are supported.
+S3 credentials are read from the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+environment variables or the s3.accesskey and s3.secretkey configuration values.`) + "\n\n" + hooksHelp(),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
 		},
@@ -40,6 +74,29 @@ func newCreateCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[shared.F
 	createCmd.Flags().Bool("skipconfig", false, L("Do not backup podman configuration. On restore defaults will be used"))
 	createCmd.Flags().Bool("norestart", false, L("Do not restart services after backup is done"))
 	createCmd.Flags().Bool("dryrun", false, L("Print expected actions, but no action is done"))
+	createCmd.Flags().String("compress", backup.CompressionNone,
+		L("Compress the backup files. Possible values: none, gzip, zstd"))
+	createCmd.Flags().StringSlice("encrypt-recipient", []string{},
+		L("Encrypt the backup files for the GPG key of the recipient. Can be repeated"))
+	createCmd.Flags().String("passphrase-file", "",
+		L("Encrypt the backup files with the passphrase contained in the file"))
+	createCmd.Flags().String("sign-key", "",
+		L("GPG key to sign the backup manifest with. Required unless --unsigned is used"))
+	createCmd.Flags().Bool("unsigned", false,
+		L("Do not sign the backup manifest. Tampering with the backup will not be detected"))
+	createCmd.Flags().Bool("timestamped", false,
+		L("Create the backup in a new timestamped directory inside output-location, used as a backups repository"))
+	createCmd.Flags().Int("parallel", 1, L("Number of volumes to export at the same time"))
+	createCmd.Flags().Bool("snapshot", false,
+		L("Only stop the services to snapshot the volumes storage and export from the snapshots. "+
+			"Requires LVM thin volumes, btrfs or XFS with reflink"))
+	createCmd.Flags().String("hooks-dir", shared.DefaultHooksDir,
+		L("Directory containing the pre-backup.d and post-backup.d hook directories"))
+	createCmd.Flags().String("hooks-prebackup", "",
+		L("Shell command to run before the backup. A failure aborts the backup"))
+	createCmd.Flags().String("hooks-postbackup", "",
+		L("Shell command to run after the backup, whatever its outcome"))
+	addTargetFlags(createCmd)
 
 	return createCmd
 }
@@ -48,10 +105,24 @@ func newRestoreCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[shared.
 	var flags shared.Flagpole
 
 	restoreCmd := &cobra.Command{
-		Use:   "restore directory",
+		Use:   "restore location",
 		Args:  cobra.ExactArgs(1),
-		Short: L("Restore backup from the directory"),
-		Long:  L("Restore backup of the previously configured Uyuni system from a specified directory"),
+		Short: L("Restore backup from the location"),
+		Long: L(`Restore backup of the previously configured Uyuni system from a specified location
+
+The location can be a local directory, an sftp://[user@]host[:port]/path URL
+or an s3://bucket/prefix URL.
+
+Use --volume to only restore some volumes on a running server, --include to only
+restore some of their files and --output-dir to extract them aside of the live volumes.
+Use backup browse to find the files to restore.
+
+Use --fqdn to restore onto a host with a different name: the SSL certificates
+are regenerated if needed and the server configuration is updated like with server rename.
+The certificates are checked before restoring anything: either the backed up ones match
+the new name, third-party certificates are provided or --ssl-password allows to regenerate them.
+The podman network is recreated for the new host if it conflicts with the host network and
+the services are started to complete the rename.`) + "\n\n" + hooksHelp(),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
 		},
@@ -66,10 +137,125 @@ func newRestoreCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[shared.
 	restoreCmd.Flags().Bool("force", false, L("Force overwrite of existing items"))
 	restoreCmd.Flags().Bool("continue", false, L("Skip existing items and restore the rest"))
 	restoreCmd.Flags().Bool("skipverify", false, L("Skip verification of the backup files"))
+	restoreCmd.Flags().Int("parallel", 1, L("Number of volumes to import at the same time"))
+	restoreCmd.Flags().StringSlice("volume", []string{},
+		L("Only restore the selected volumes, without the images and configuration. Can be repeated"))
+	restoreCmd.Flags().StringSlice("include", []string{},
+		L("Only restore the files of the selected volumes matching the patterns, relative to the volume root"))
+	restoreCmd.Flags().String("output-dir", "",
+		L("Extract the selected volumes into subdirectories of this directory instead of the live volumes"))
+	restoreCmd.Flags().String("fqdn", "",
+		L("Rename the restored server to this FQDN, regenerating the SSL certificates if needed"))
+	ssl.AddSSLGenerationFlags(restoreCmd)
+	ssl.AddSSLThirdPartyFlags(restoreCmd)
+	ssl.AddSSLDBThirdPartyFlags(restoreCmd)
+	restoreCmd.Flags().String("ssl-password", "", L("Password for the CA key to generate"))
+	_ = utils.AddFlagToHelpGroupID(restoreCmd, "ssl-password", ssl.GeneratedFlagsGroup)
+	restoreCmd.Flags().String("passphrase-file", "",
+		L("Decrypt the backup files with the passphrase contained in the file"))
+	restoreCmd.Flags().String("hooks-dir", shared.DefaultHooksDir,
+		L("Directory containing the pre-restore.d and post-restore.d hook directories"))
+	restoreCmd.Flags().String("hooks-prerestore", "",
+		L("Shell command to run before the restore. A failure aborts the restore"))
+	restoreCmd.Flags().String("hooks-postrestore", "",
+		L("Shell command to run after the restore, whatever its outcome"))
+	addTargetFlags(restoreCmd)
 
 	return restoreCmd
 }
 
+func newVerifyCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[verify.Flagpole]) *cobra.Command {
+	var flags verify.Flagpole
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify location",
+		Args:  cobra.ExactArgs(1),
+		Short: L("Verify backup in the location"),
+		Long: L(`Verify the files of a backup in the location against its manifest.
+
+The running system is not used nor modified by this command.`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+
+	verifyCmd.Flags().Bool("require-signature", false, L("Fail if the backup manifest is not signed"))
+	verifyCmd.Flags().Bool("allow-partial", false,
+		L("Accept the partial backups, missing some optional data like images or configuration"))
+	verifyCmd.Flags().Bool("deep", false, L("Also decrypt and decompress the backup files to check their content"))
+	verifyCmd.Flags().String("passphrase-file", "",
+		L("Decrypt the backup files with the passphrase contained in the file"))
+	addTargetFlags(verifyCmd)
+
+	return verifyCmd
+}
+
+func newListCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[list.Flagpole]) *cobra.Command {
+	var flags list.Flagpole
+
+	listCmd := &cobra.Command{
+		Use:   "list repository",
+		Args:  cobra.ExactArgs(1),
+		Short: L("List backups in a repository"),
+		Long: L(`List the backups stored in a backups repository.
+
+A backups repository is a location filled by backup create --timestamped.`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	addTargetFlags(listCmd)
+	return listCmd
+}
+
+func newPruneCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[prune.Flagpole]) *cobra.Command {
+	var flags prune.Flagpole
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune repository",
+		Args:  cobra.ExactArgs(1),
+		Short: L("Remove old backups from a repository"),
+		Long: L(`Remove the backups of a repository which are not matching the retention policy.
+
+For each period, the latest complete backup is kept. Partial or aborted backups are never
+counted as kept backups and are removed once a newer complete backup exists.`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+
+	pruneCmd.Flags().Int("keep-daily", 0, L("Number of daily backups to keep"))
+	pruneCmd.Flags().Int("keep-weekly", 0, L("Number of weekly backups to keep"))
+	pruneCmd.Flags().Int("keep-monthly", 0, L("Number of monthly backups to keep"))
+	pruneCmd.Flags().Bool("dryrun", false, L("Print expected actions, but no action is done"))
+	addTargetFlags(pruneCmd)
+	return pruneCmd
+}
+
+func newBrowseCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[browse.Flagpole]) *cobra.Command {
+	var flags browse.Flagpole
+
+	browseCmd := &cobra.Command{
+		Use:   "browse location",
+		Args:  cobra.ExactArgs(1),
+		Short: L("List the content of a backup"),
+		Long: L(`List the volumes of the backup in the location, or the files of one of them
+with --volume, without importing anything.
+
+The listed paths can be passed to backup restore --include.`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+
+	browseCmd.Flags().String("volume", "", L("Volume to list the files of"))
+	browseCmd.Flags().StringSlice("include", []string{}, L("Only list the files matching the patterns"))
+	browseCmd.Flags().String("passphrase-file", "",
+		L("Decrypt the backup files with the passphrase contained in the file"))
+	addTargetFlags(browseCmd)
+	return browseCmd
+}
+
 // NewCommand command for distribution management.
 func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
 	backupCmd := &cobra.Command{
@@ -80,7 +266,12 @@ func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
 	}
 	backupCmd.AddCommand(newCreateCmd(globalFlags, doBackup))
 	backupCmd.AddCommand(newRestoreCmd(globalFlags, doRestore))
+	backupCmd.AddCommand(newVerifyCmd(globalFlags, verify.Verify))
+	backupCmd.AddCommand(newBrowseCmd(globalFlags, browse.Browse))
+	backupCmd.AddCommand(newListCmd(globalFlags, list.List))
+	backupCmd.AddCommand(newPruneCmd(globalFlags, prune.Prune))
 	backupCmd.AddCommand(db.NewDBCmd(globalFlags))
+	backupCmd.AddCommand(schedule.NewScheduleCmd(globalFlags))
 	return backupCmd
 }
 
@@ -92,9 +283,13 @@ func doBackup(
 	args []string,
 ) error {
 	outputDirectory := args[0]
+	if flags.Timestamped {
+		outputDirectory = backup.JoinLocation(outputDirectory, shared.NewBackupSetName(time.Now()))
+		args = []string{outputDirectory}
+	}
 	err := create.Create(global, flags, cmd, args)
 	if err != nil {
-		var backupError *shared.BackupError
+		var backupError *backup.BackupError
 		ok := errors.As(err, &backupError)
 		if ok {
 			// l10n-ignore
@@ -121,7 +316,7 @@ func doRestore(
 ) error {
 	err := restore.Restore(global, flags, cmd, args)
 	if err != nil {
-		var backupError *shared.BackupError
+		var backupError *backup.BackupError
 		ok := errors.As(err, &backupError)
 		if ok {
 			log.Warn().Err(backupError).Msgf(L("Encountered problems:"))
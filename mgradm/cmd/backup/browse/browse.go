@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package browse
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Flagpole holds the flags of the backup browse command.
+type Flagpole struct {
+	Volume    string               `mapstructure:"volume"`
+	Include   []string             `mapstructure:"include"`
+	Artifacts backup.ArtifactFlags `mapstructure:",squash"`
+	Target    backup.TargetFlags   `mapstructure:",squash"`
+}
+
+// Browse lists the volumes of a backup, or the files of one volume export, without importing anything.
+func Browse(
+	_ *types.GlobalFlags,
+	flags *Flagpole,
+	_ *cobra.Command,
+	args []string,
+) error {
+	if err := flags.Artifacts.CheckArtifactTools(); err != nil {
+		return err
+	}
+	target, err := backup.NewTarget(args[0], &flags.Target)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+	if flags.Volume == "" {
+		return listVolumes(os.Stdout, target)
+	}
+	return listVolumeFiles(os.Stdout, target, flags)
+}
+
+// listVolumes prints the volume exports of the backup.
+func listVolumes(out io.Writer, target backup.Target) error {
+	entries, err := target.List(backup.VolumesSubdir)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, L("VOLUME\tSIZE\tFILE"))
+	for _, entry := range entries {
+		if entry.IsDir || !backup.IsTarArtifact(entry.Name) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", backup.ArtifactBaseName(entry.Name), utils.FormatSize(entry.Size), entry.Name)
+	}
+	return w.Flush()
+}
+
+// listVolumeFiles prints the files of a volume export matching the include patterns.
+func listVolumeFiles(out io.Writer, target backup.Target, flags *Flagpole) error {
+	file := backup.FindArtifact(target, backup.VolumesSubdir, flags.Volume+".tar")
+	if file == "" {
+		return fmt.Errorf(L("volume %s not found in the backup"), flags.Volume)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, L("MODE\tOWNER\tSIZE\tMODIFIED\tPATH"))
+	err := backup.WalkTarArtifact(target, file, &flags.Artifacts, "", nil,
+		func(header *tar.Header, _ io.Reader) error {
+			name := backup.TarEntryName(header.Name)
+			if name == "" || !backup.MatchesInclude(name, flags.Include) {
+				return nil
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", header.FileInfo().Mode(), owner(header),
+				utils.FormatSize(header.Size), header.ModTime.Local().Format("2006-01-02 15:04"), entryPath(name, header))
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// owner returns the user and group of a tar entry, using the IDs if the names are not stored.
+func owner(header *tar.Header) string {
+	user := header.Uname
+	if user == "" {
+		user = fmt.Sprint(header.Uid)
+	}
+	group := header.Gname
+	if group == "" {
+		group = fmt.Sprint(header.Gid)
+	}
+	return user + "/" + group
+}
+
+// entryPath returns the path to show for a tar entry, including the target of links.
+func entryPath(name string, header *tar.Header) string {
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return name + "/"
+	case tar.TypeSymlink:
+		return name + " -> " + header.Linkname
+	case tar.TypeLink:
+		return name + " => " + backup.TarEntryName(header.Linkname)
+	}
+	return name
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package browse
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func writeVolumeExport(t *testing.T, dir string) {
+	buffer := bytes.Buffer{}
+	writer := tar.NewWriter(&buffer)
+	modTime := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, header := range []*tar.Header{
+		{Name: "./", Typeflag: tar.TypeDir, Mode: 0755, ModTime: modTime},
+		{Name: "./rhn.conf", Typeflag: tar.TypeReg, Mode: 0640, Size: 4, Uname: "root", Gname: "www", ModTime: modTime},
+		{Name: "./spacewalk/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: modTime},
+		{Name: "./spacewalk/link", Typeflag: tar.TypeSymlink, Linkname: "../rhn.conf", ModTime: modTime},
+	} {
+		testutils.AssertNoError(t, "failed to write tar header", writer.WriteHeader(header))
+		if header.Size > 0 {
+			_, err := writer.Write([]byte("data"))
+			testutils.AssertNoError(t, "failed to write tar content", err)
+		}
+	}
+	testutils.AssertNoError(t, "failed to close tar", writer.Close())
+
+	volumesDir := path.Join(dir, backup.VolumesSubdir)
+	testutils.AssertNoError(t, "failed to create volumes directory", os.MkdirAll(volumesDir, 0700))
+	testutils.WriteFile(t, path.Join(volumesDir, "etc-rhn.tar"), buffer.String())
+	testutils.WriteFile(t, path.Join(volumesDir, "etc-rhn.tar.sha256sum"), "checksum")
+}
+
+func TestListVolumes(t *testing.T) {
+	dir := t.TempDir()
+	writeVolumeExport(t, dir)
+	target, err := backup.NewTarget(dir, &backup.TargetFlags{})
+	testutils.AssertNoError(t, "failed to create target", err)
+
+	out := strings.Builder{}
+	testutils.AssertNoError(t, "failed to list volumes", listVolumes(&out, target))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	testutils.AssertEquals(t, "Unexpected number of lines", 2, len(lines))
+	testutils.AssertTrue(t, "Unexpected volume line: "+lines[1], strings.HasPrefix(lines[1], "etc-rhn "))
+}
+
+func TestListVolumeFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeVolumeExport(t, dir)
+	target, err := backup.NewTarget(dir, &backup.TargetFlags{})
+	testutils.AssertNoError(t, "failed to create target", err)
+
+	out := strings.Builder{}
+	flags := Flagpole{Volume: "etc-rhn"}
+	testutils.AssertNoError(t, "failed to list files", listVolumeFiles(&out, target, &flags))
+	listing := out.String()
+	testutils.AssertTrue(t, "rhn.conf should be listed with its owner",
+		strings.Contains(listing, "root/www") && strings.Contains(listing, "rhn.conf\n"))
+	testutils.AssertTrue(t, "symlink target should be listed", strings.Contains(listing, "spacewalk/link -> ../rhn.conf"))
+
+	out.Reset()
+	flags.Include = []string{"spacewalk"}
+	testutils.AssertNoError(t, "failed to list files", listVolumeFiles(&out, target, &flags))
+	testutils.AssertTrue(t, "rhn.conf should be filtered out", !strings.Contains(out.String(), " rhn.conf"))
+
+	flags.Volume = "var-cache"
+	testutils.AssertError(t, "not found", listVolumeFiles(&out, target, &flags))
+}
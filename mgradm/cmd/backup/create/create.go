@@ -9,12 +9,15 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
 	podman_mgradm "github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
 	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
 	"github.com/uyuni-project/uyuni-tools/shared/podman"
 	"github.com/uyuni-project/uyuni-tools/shared/types"
@@ -28,63 +31,120 @@ func Create(
 	flags *shared.Flagpole,
 	_ *cobra.Command,
 	args []string,
-) error {
+) (err error) {
 	dryRun := flags.DryRun
 	outputDirectory := args[0]
 	printIntro(outputDirectory, flags)
 
-	if err := SanityChecks(outputDirectory); err != nil {
-		return shared.AbortError(err, false)
+	target, err := backup.NewTarget(outputDirectory, &flags.Target)
+	if err != nil {
+		return backup.AbortError(err, false)
 	}
+	defer target.Close()
 
-	volumesBackupPath := path.Join(outputDirectory, shared.VolumesSubdir)
-	imagesBackupPath := path.Join(outputDirectory, shared.ImagesSubdir)
-
-	if err := prepareOuputDirs([]string{outputDirectory, volumesBackupPath, imagesBackupPath}, dryRun); err != nil {
-		return shared.AbortError(err, false)
+	if err := SanityChecks(target, &flags.Artifacts); err != nil {
+		return backup.AbortError(err, false)
+	}
+	if err := backup.CheckSignKey(flags.Sign.Key, flags.Unsigned); err != nil {
+		return backup.AbortError(err, false)
 	}
 
 	volumes := gatherVolumesToBackup(flags.ExtraVolumes, flags.SkipVolumes, flags.SkipDatabase)
 	images := gatherContainerImagesToBackup(flags.SkipImages)
+	manifest := backup.NewManifest(&flags.Artifacts)
 
-	if !dryRun {
-		if err := shared.StorageCheck(volumes, images, outputDirectory); err != nil {
-			return shared.AbortError(err, false)
+	// Remote targets don't need to be prepared, the files are streamed to them
+	if localDir, isLocal := target.LocalPath(""); isLocal && !dryRun {
+		if err := os.MkdirAll(localDir, 0700); err != nil {
+			return backup.AbortError(fmt.Errorf(L("unable to create target output directory: %w"), err), false)
+		}
+		if err := backup.StorageCheck(volumes, images, localDir); err != nil {
+			return backup.AbortError(err, false)
+		}
+	}
+
+	// Check the storage supports snapshots before stopping anything
+	var snapshots *volumeSnapshots
+	if flags.Snapshot {
+		if snapshots, err = prepareSnapshots(volumes); err != nil {
+			return backup.AbortError(err, false)
 		}
 	}
 
+	// The hooks may prepare the system for the backup, run them once it is known it can be done
+	hookContext := &shared.HookContext{Location: outputDirectory, DryRun: dryRun}
+	if err := shared.RunHooks(&flags.Hooks, shared.HookPreBackup, hookContext); err != nil {
+		return backup.AbortError(err, false)
+	}
+	defer func() {
+		hookContext.Manifest = manifest
+		hookContext.Err = err
+		err = shared.RunPostHooks(&flags.Hooks, shared.HookPostBackup, hookContext)
+	}()
+
+	inspectServer(manifest, dryRun)
+
 	// stop service if database is to be backed up. Otherwise do a live backup
 	serviceStopped := false
-	if !flags.SkipDatabase && !dryRun {
+	if (!flags.SkipDatabase || flags.Snapshot) && !dryRun {
 		log.Info().Msg(L("Stopping server service"))
-		if err := podman_mgradm.StopServices(); err != nil {
-			return shared.AbortError(err, false)
+		if err := podman_mgradm.StopServices(systemd); err != nil {
+			return backup.AbortError(err, false)
 		}
 		serviceStopped = true
 	}
 
-	if err := backupVolumes(volumes, volumesBackupPath, dryRun); err != nil {
-		return shared.AbortError(err, true)
+	if snapshots != nil && !dryRun {
+		err := snapshots.take()
+		// The services only need to be stopped while taking the snapshots
+		if serviceStopped && !flags.NoRestart {
+			log.Info().Msg(L("Restarting server service"))
+			err = utils.JoinErrors(err, podman_mgradm.StartServices(systemd))
+			serviceStopped = false
+		}
+		if err != nil {
+			return backup.AbortError(err, false)
+		}
+		defer func() {
+			if err := snapshots.release(); err != nil {
+				log.Warn().Err(err).Msg(L("Failed to remove the snapshots, manual cleanup is required"))
+			}
+		}()
+	}
+
+	if err := backupVolumes(volumes, target, manifest, &flags.Artifacts, flags.Parallel, snapshots,
+		dryRun); err != nil {
+		err = backup.AbortError(err, true)
+		// Keep track of the aborted backup for the backups catalog
+		manifest.SetStatus(err)
+		if errManifest := writeManifest(target, manifest, flags.Sign.Key, dryRun); errManifest != nil {
+			log.Warn().Err(errManifest).Msg(L("Failed to record the aborted backup"))
+		}
+		return err
 	}
 
 	// Remaining backups are not critical, restore can create default values
 	// so let's only track if there was an error
-	hasError := backupContainerImages(images, imagesBackupPath, dryRun)
+	hasError := backupContainerImages(images, target, manifest, &flags.Artifacts, dryRun)
 
 	// systemd configuration backup is optional as we have defaults to use
-	hasError = utils.JoinErrors(hasError, backupSystemdServices(outputDirectory, dryRun))
+	hasError = utils.JoinErrors(hasError, backupSystemdServices(target, manifest, &flags.Artifacts, dryRun))
 
 	// podman configuration backup is optional as we have defaults to use
-	hasError = utils.JoinErrors(hasError, backupPodmanConfiguration(outputDirectory, dryRun))
+	hasError = utils.JoinErrors(hasError, backupPodmanConfiguration(target, manifest, &flags.Artifacts, dryRun))
+
+	// the manifest is needed to verify the backup without restoring it
+	manifest.SetStatus(hasError)
+	hasError = utils.JoinErrors(hasError, writeManifest(target, manifest, flags.Sign.Key, dryRun))
 
 	// start service if it was stopped before
 	if serviceStopped && !flags.NoRestart && !dryRun {
 		log.Info().Msg(L("Restarting server service"))
-		hasError = utils.JoinErrors(hasError, podman_mgradm.StartServices())
+		hasError = utils.JoinErrors(hasError, podman_mgradm.StartServices(systemd))
 	}
 
 	log.Info().Msgf(L("Backup finished into %s"), outputDirectory)
-	return shared.ReportError(hasError)
+	return backup.ReportError(hasError)
 }
 
 func printIntro(outputDir string, flags *shared.Flagpole) {
@@ -97,19 +157,9 @@ func printIntro(outputDir string, flags *shared.Flagpole) {
 	log.Debug().Msgf("skip images: %t", flags.SkipImages)
 	log.Debug().Msgf("skip volumes: %s", flags.SkipVolumes)
 	log.Debug().Msgf("extra volumes: %s", flags.ExtraVolumes)
-}
-
-func prepareOuputDirs(outputDirs []string, dryRun bool) error {
-	for _, d := range outputDirs {
-		if dryRun {
-			log.Info().Msgf(L("Would create '%s' directory"), d)
-		} else {
-			if err := os.Mkdir(d, 0622); err != nil {
-				return fmt.Errorf(L("unable to create target output directory: %w"), err)
-			}
-		}
-	}
-	return nil
+	log.Debug().Msgf("compression: %s", flags.Artifacts.Compress)
+	log.Debug().Msgf("encrypted: %t", flags.Artifacts.IsEncrypted())
+	log.Debug().Msgf("snapshot: %t", flags.Snapshot)
 }
 
 func gatherVolumesToBackup(extraVolumes []string, skipVolumes []string, skipDatabase bool) []string {
@@ -145,15 +195,74 @@ func gatherVolumesToBackup(extraVolumes []string, skipVolumes []string, skipData
 	return uniqueVolumes
 }
 
-func backupVolumes(volumes []string, outputDirectory string, dryRun bool) error {
+// backupVolumes exports the volumes, running at most parallel exports at the same time.
+func backupVolumes(
+	volumes []string,
+	target backup.Target,
+	manifest *backup.Manifest,
+	flags *backup.ArtifactFlags,
+	parallel int,
+	snapshots *volumeSnapshots,
+	dryRun bool,
+) error {
 	log.Info().Msg(L("Backing up container volumes"))
+	presentVolumes := []string{}
 	for _, volume := range volumes {
-		log.Debug().Msgf("Backing up %s volume", volume)
-		if err := podman.ExportVolume(volume, outputDirectory, dryRun); err != nil {
+		if podman.IsVolumePresent(nil, volume) {
+			presentVolumes = append(presentVolumes, volume)
+		}
+	}
+
+	progress := shared.NewProgress()
+	if !dryRun {
+		progress.Start()
+	}
+	artifacts := make([]*backup.Artifact, len(presentVolumes))
+	err := shared.RunParallel(len(presentVolumes), parallel, func(index int) error {
+		volume := presentVolumes[index]
+		artifact, err := backupVolume(target, volume, flags, progress, snapshots.exportCommand(volume), dryRun)
+		if err != nil {
 			return err
 		}
+		artifacts[index] = &artifact
+		return nil
+	})
+	progress.Stop()
+
+	// Keep the manifest in the volumes order, whatever export finished first
+	for index, artifact := range artifacts {
+		if artifact != nil {
+			manifest.AddArtifact(backup.ArtifactVolume, presentVolumes[index], *artifact, nil)
+		}
 	}
-	return nil
+	return err
+}
+
+// backupVolume exports a volume, showing the exported size against the volume size.
+func backupVolume(
+	target backup.Target,
+	volume string,
+	flags *backup.ArtifactFlags,
+	progress *shared.Progress,
+	exportCommand []string,
+	dryRun bool,
+) (backup.Artifact, error) {
+	log.Debug().Msgf("Backing up %s volume", volume)
+	var size int64
+	if !dryRun {
+		var err error
+		if size, err = backup.VolumeSize(volume); err != nil {
+			log.Debug().Err(err).Msgf("Failed to compute the size of %s volume", volume)
+		}
+	}
+	task := progress.Add(volume, size)
+	outputFile := path.Join(backup.VolumesSubdir, volume+".tar")
+	artifact, err := backup.ExportArtifactWithProgress(target, outputFile, flags, dryRun, task, exportCommand...)
+	task.End(err)
+	if err != nil {
+		return artifact, utils.Errorf(err, L("Failed to export volume %s"), volume)
+	}
+	return artifact, nil
 }
 
 func gatherContainerImagesToBackup(skipImages bool) []string {
@@ -165,9 +274,9 @@ func gatherContainerImagesToBackup(skipImages bool) []string {
 			if skip {
 				continue
 			}
-			image := podman.GetServiceImage(serviceName)
+			image := podman.GetServiceImage(nil, serviceName)
 			if image != "" {
-				present, err := podman.IsImagePresent(image)
+				present, err := podman.IsImagePresent(nil, image)
 				if err == nil && len(present) > 0 {
 					images = append(images, image)
 				}
@@ -177,66 +286,117 @@ func gatherContainerImagesToBackup(skipImages bool) []string {
 	return images
 }
 
-func backupContainerImages(images []string, outputDirectory string, dryRun bool) error {
+func backupContainerImages(
+	images []string,
+	target backup.Target,
+	manifest *backup.Manifest,
+	flags *backup.ArtifactFlags,
+	dryRun bool,
+) error {
 	log.Info().Msg(L("Backing up container images"))
 	var hasError error
 	for _, image := range images {
 		log.Debug().Msgf("Backing up image %s", image)
-		if err := podman.ExportImage(image, outputDirectory, dryRun); err != nil {
+		baseName, _, _ := strings.Cut(filepath.Base(image), ":")
+		outputFile := path.Join(backup.ImagesSubdir, baseName+".tar")
+		saveCommand := []string{"podman", "image", "save", "--quiet", image}
+		artifact, err := backup.ExportArtifact(target, outputFile, flags, dryRun, saveCommand...)
+		if err != nil {
 			log.Warn().Err(err).Msgf(L("Not backing up image %s"), image)
 			hasError = utils.JoinErrors(hasError, err)
+			continue
 		}
+		manifest.AddArtifact(backup.ArtifactImage, image, artifact, nil)
 	}
 	return hasError
 }
 
-func backupSystemdServices(outputDirectory string, dryRun bool) error {
-	errorMessage := L("Systemd services and configuration was not backed up")
+func backupSystemdServices(
+	target backup.Target,
+	manifest *backup.Manifest,
+	flags *backup.ArtifactFlags,
+	dryRun bool,
+) error {
 	log.Info().Msg(L("Backing up Systemd services"))
 
-	if err := exportSystemdConfiguration(outputDirectory, dryRun); err != nil {
-		log.Warn().Err(err).Msg(errorMessage)
+	artifact, files, err := exportSystemdConfiguration(target, flags, dryRun)
+	if err != nil {
+		log.Warn().Err(err).Msg(L("Systemd services and configuration was not backed up"))
 		return err
 	}
-	if dryRun {
-		return nil
-	}
-	if err := utils.CreateChecksum(path.Join(outputDirectory, shared.SystemdConfBackupFile)); err != nil {
-		log.Warn().Err(err).Msg(errorMessage)
-		return err
+	if !dryRun {
+		manifest.AddArtifact(backup.ArtifactSystemd, backup.SystemdConfBackupFile, artifact, files)
 	}
 	return nil
 }
 
-func backupPodmanConfiguration(outputDirectory string, dryRun bool) error {
-	errorMessage := L("Podman configuration was not backed up")
+func backupPodmanConfiguration(
+	target backup.Target,
+	manifest *backup.Manifest,
+	flags *backup.ArtifactFlags,
+	dryRun bool,
+) error {
 	log.Info().Msg(L("Backing up podman configuration"))
-	if err := exportPodmanConfiguration(outputDirectory, dryRun); err != nil {
-		log.Warn().Err(err).Msg(errorMessage)
+	artifact, secrets, err := exportPodmanConfiguration(target, flags, dryRun)
+	if err != nil {
+		log.Warn().Err(err).Msg(L("Podman configuration was not backed up"))
 		return err
 	}
+	if !dryRun {
+		manifest.AddArtifact(backup.ArtifactPodman, backup.PodmanConfBackupFile, artifact, secrets)
+	}
+	return nil
+}
+
+// inspectServer stores the version of the server in the manifest.
+// The version is not critical for the backup, so failures are only logged.
+func inspectServer(manifest *backup.Manifest, dryRun bool) {
+	image := podman.GetServiceImage(nil, podman.ServerService)
+	if dryRun || image == "" {
+		return
+	}
+	serverData, err := podman.ImageInspect[utils.ServerInspectData](
+		nil,
+		image, utils.ServerVolumeMounts, utils.NewServerInspector(),
+	)
+	if err != nil {
+		log.Warn().Err(err).Msg(L("Failed to get the server version"))
+		return
+	}
+	manifest.UyuniRelease = serverData.UyuniRelease
+	manifest.SuseManagerRelease = serverData.SuseManagerRelease
+}
+
+func writeManifest(target backup.Target, manifest *backup.Manifest, signKey string, dryRun bool) error {
 	if dryRun {
+		log.Info().Msgf(L("Would write backup manifest to %s"), backup.JoinLocation(target.String(), backup.ManifestFile))
 		return nil
 	}
-	if err := utils.CreateChecksum(path.Join(outputDirectory, shared.PodmanConfBackupFile)); err != nil {
-		log.Warn().Err(err).Msg(errorMessage)
+	if err := manifest.Write(target, signKey); err != nil {
+		log.Warn().Err(err).Msg(L("Backup manifest was not written, the backup cannot be verified"))
 		return err
 	}
 	return nil
 }
 
-func SanityChecks(outputDirectory string) error {
-	if err := shared.SanityChecks(); err != nil {
+func SanityChecks(target backup.Target, flags *backup.ArtifactFlags) error {
+	if err := backup.SanityChecks(); err != nil {
 		return err
 	}
 
-	if utils.FileExists(outputDirectory) {
-		if !utils.IsEmptyDirectory(outputDirectory) {
-			return fmt.Errorf(L("output directory %s already exists and is not empty"), outputDirectory)
-		}
+	if err := flags.CheckArtifactTools(); err != nil {
+		return err
+	}
+
+	empty, err := backup.IsEmpty(target)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return fmt.Errorf(L("output directory %s already exists and is not empty"), target)
 	}
 
-	hostData, err := podman.InspectHost()
+	hostData, err := podman.InspectHost(nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,294 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package create
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+var getVolumeMountPoint = podman.GetVolumeMountPoint
+
+const (
+	snapshotLVM     = "lvm"
+	snapshotBtrfs   = "btrfs"
+	snapshotReflink = "reflink"
+)
+
+// filesystem describes the mounted filesystem storing a volume.
+type filesystem struct {
+	Source string
+	FsType string
+	Target string
+}
+
+// snapshot is a point in time copy of a filesystem, or of the volumes it stores for reflink copies.
+type snapshot struct {
+	fs      filesystem
+	method  string
+	name    string
+	root    string
+	volumes map[string]string
+	// device is the snapshot logical volume for LVM.
+	device string
+	// taken is set once the snapshot exists and needs to be released.
+	taken bool
+}
+
+// volumeSnapshots holds the snapshots of the filesystems storing the backed up volumes.
+type volumeSnapshots struct {
+	snapshots []*snapshot
+	// paths maps the volume names to the path of their data in the snapshots.
+	paths map[string]string
+}
+
+// prepareSnapshots checks that the storage of all the volumes can be snapshotted.
+// Nothing is changed on the system: this is meant to fail before stopping the services.
+func prepareSnapshots(volumes []string) (*volumeSnapshots, error) {
+	name := "uyuni-backup-" + time.Now().Format("20060102150405")
+	result := &volumeSnapshots{paths: map[string]string{}}
+	byTarget := map[string]*snapshot{}
+
+	for _, volume := range volumes {
+		if !podman.IsVolumePresent(nil, volume) {
+			continue
+		}
+		mountPoint, err := getVolumeMountPoint(nil, volume)
+		if err != nil {
+			return nil, err
+		}
+		fs, err := findFilesystem(mountPoint)
+		if err != nil {
+			return nil, err
+		}
+		method, err := detectSnapshotMethod(fs)
+		if err != nil {
+			return nil, utils.Errorf(err, L("cannot snapshot volume %s"), volume)
+		}
+		if method == snapshotBtrfs {
+			// A btrfs snapshot doesn't contain the nested subvolumes: snapshot the one holding the volume
+			if fs.Target, err = findSubvolume(mountPoint, fs.Target); err != nil {
+				return nil, utils.Errorf(err, L("cannot snapshot volume %s"), volume)
+			}
+		}
+		snap := byTarget[fs.Target]
+		if snap == nil {
+			snap = &snapshot{fs: fs, method: method, name: name, volumes: map[string]string{}}
+			snap.root = snap.defaultRoot()
+			byTarget[fs.Target] = snap
+			result.snapshots = append(result.snapshots, snap)
+		}
+		snap.volumes[volume] = mountPoint
+		result.paths[volume] = snap.volumePath(volume, mountPoint)
+	}
+	return result, nil
+}
+
+// findFilesystem returns the mounted filesystem containing path.
+func findFilesystem(path string) (filesystem, error) {
+	out, err := runCmdOutput(zerolog.DebugLevel, "findmnt", "-n", "-o", "SOURCE,FSTYPE,TARGET", "--target", path)
+	if err != nil {
+		return filesystem{}, utils.Errorf(err, L("failed to find the filesystem of %s"), path)
+	}
+	fields := strings.Fields(strings.TrimSpace(string(out)))
+	if len(fields) != 3 {
+		return filesystem{}, fmt.Errorf(L("failed to find the filesystem of %s"), path)
+	}
+	// btrfs sources contain the mounted subvolume: /dev/sda2[/@/var]
+	source, _, _ := strings.Cut(fields[0], "[")
+	return filesystem{Source: source, FsType: fields[1], Target: fields[2]}, nil
+}
+
+// findSubvolume returns the innermost btrfs subvolume containing path, looking up to the mount point.
+func findSubvolume(path string, mountPoint string) (string, error) {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := runCmdOutput(zerolog.DebugLevel, "btrfs", "subvolume", "show", dir); err == nil {
+			return dir, nil
+		}
+		if dir == mountPoint || dir == filepath.Dir(dir) {
+			return "", fmt.Errorf(L("failed to find the btrfs subvolume containing %s"), path)
+		}
+	}
+}
+
+// detectSnapshotMethod returns the way to snapshot the filesystem.
+func detectSnapshotMethod(fs filesystem) (string, error) {
+	if isThinVolume(fs.Source) {
+		return snapshotLVM, nil
+	}
+	if fs.FsType == "btrfs" {
+		return snapshotBtrfs, nil
+	}
+	if fs.FsType == "xfs" && hasReflink(fs.Target) {
+		return snapshotReflink, nil
+	}
+	return "", fmt.Errorf(
+		L("%[1]s filesystem mounted on %[2]s cannot be snapshotted: LVM thin volume, btrfs or XFS with reflink required"),
+		fs.FsType, fs.Target)
+}
+
+// isThinVolume returns true if the device is an LVM thin logical volume.
+func isThinVolume(device string) bool {
+	if !utils.IsInstalled("lvs") {
+		return false
+	}
+	out, err := runCmdOutput(zerolog.DebugLevel, "lvs", "--noheadings", "-o", "pool_lv", device)
+	return err == nil && strings.TrimSpace(string(out)) != ""
+}
+
+// hasReflink returns true if the XFS filesystem mounted on path supports reflink copies.
+func hasReflink(path string) bool {
+	out, err := runCmdOutput(zerolog.DebugLevel, "xfs_info", path)
+	return err == nil && strings.Contains(string(out), "reflink=1")
+}
+
+// defaultRoot returns the directory where the snapshot content will be available.
+func (s *snapshot) defaultRoot() string {
+	if s.method == snapshotLVM {
+		return filepath.Join(os.TempDir(), s.name)
+	}
+	// btrfs snapshots and reflink copies have to be on the same filesystem
+	return filepath.Join(s.fs.Target, "."+s.name)
+}
+
+// volumePath returns the path of the volume data in the snapshot.
+func (s *snapshot) volumePath(volume string, mountPoint string) string {
+	if s.method == snapshotReflink {
+		return filepath.Join(s.root, volume)
+	}
+	relative, err := filepath.Rel(s.fs.Target, mountPoint)
+	if err != nil {
+		relative = mountPoint
+	}
+	return filepath.Join(s.root, relative)
+}
+
+// take creates the snapshot.
+func (s *snapshot) take() error {
+	log.Info().Msgf(L("Taking %[1]s snapshot of %[2]s"), s.method, s.fs.Target)
+	switch s.method {
+	case snapshotLVM:
+		return s.takeLVM()
+	case snapshotBtrfs:
+		if err := runSnapshotCmd("btrfs", "subvolume", "snapshot", "-r", s.fs.Target, s.root); err != nil {
+			return err
+		}
+		s.taken = true
+	case snapshotReflink:
+		if err := os.Mkdir(s.root, 0700); err != nil {
+			return err
+		}
+		s.taken = true
+		for volume, mountPoint := range s.volumes {
+			dest := filepath.Join(s.root, volume)
+			if err := runSnapshotCmd("cp", "-a", "--reflink=always", mountPoint, dest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// takeLVM creates a thin snapshot of the logical volume and mounts it read-only.
+func (s *snapshot) takeLVM() error {
+	out, err := runCmdOutput(zerolog.DebugLevel, "lvs", "--noheadings", "-o", "vg_name,lv_name", s.fs.Source)
+	if err != nil {
+		return utils.Errorf(err, L("failed to find the logical volume of %s"), s.fs.Source)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return fmt.Errorf(L("failed to find the logical volume of %s"), s.fs.Source)
+	}
+	if err := runSnapshotCmd("lvcreate", "--snapshot", "--setactivationskip", "n", "--name", s.name,
+		fields[0]+"/"+fields[1]); err != nil {
+		return err
+	}
+	s.taken = true
+	s.device = fmt.Sprintf("/dev/%s/%s", fields[0], s.name)
+
+	if err := os.MkdirAll(s.root, 0700); err != nil {
+		return err
+	}
+	// XFS refuses to mount a filesystem with the same UUID as a mounted one
+	options := "ro"
+	if s.fs.FsType == "xfs" {
+		options += ",nouuid"
+	}
+	return runSnapshotCmd("mount", "-o", options, s.device, s.root)
+}
+
+// release removes the snapshot.
+func (s *snapshot) release() error {
+	if !s.taken {
+		return nil
+	}
+	var err error
+	switch s.method {
+	case snapshotLVM:
+		if errUmount := runSnapshotCmd("umount", s.root); errUmount == nil {
+			err = os.Remove(s.root)
+		} else {
+			err = errUmount
+		}
+		err = utils.JoinErrors(err, runSnapshotCmd("lvremove", "-y", s.device))
+	case snapshotBtrfs:
+		err = runSnapshotCmd("btrfs", "subvolume", "delete", s.root)
+	case snapshotReflink:
+		err = os.RemoveAll(s.root)
+	}
+	if err != nil {
+		return utils.Errorf(err, L("failed to remove the snapshot of %s"), s.fs.Target)
+	}
+	s.taken = false
+	return nil
+}
+
+// take snapshots all the filesystems, releasing them all in case of failure.
+func (v *volumeSnapshots) take() error {
+	for _, snap := range v.snapshots {
+		if err := snap.take(); err != nil {
+			return utils.JoinErrors(utils.Errorf(err, L("failed to snapshot %s"), snap.fs.Target), v.release())
+		}
+	}
+	return nil
+}
+
+// release removes all the taken snapshots.
+func (v *volumeSnapshots) release() error {
+	var hasError error
+	for _, snap := range v.snapshots {
+		hasError = utils.JoinErrors(hasError, snap.release())
+	}
+	return hasError
+}
+
+// exportCommand returns the command writing the volume data as a tarball on the standard output.
+// The volumes not in a snapshot are exported by podman.
+func (v *volumeSnapshots) exportCommand(volume string) []string {
+	if v != nil {
+		if dir, ok := v.paths[volume]; ok {
+			return []string{"tar", "--numeric-owner", "--xattrs", "--acls", "--selinux", "-cf", "-", "-C", dir, "."}
+		}
+	}
+	return []string{"podman", "volume", "export", volume}
+}
+
+// runSnapshotCmd runs a command handling the snapshots.
+func runSnapshotCmd(command string, args ...string) error {
+	if _, err := runCmdOutput(zerolog.DebugLevel, command, args...); err != nil {
+		return utils.Errorf(err, L("failed to run %s"), command)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package create
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+// fakeSnapshotCommands replaces the command runner with one returning the outputs by command line prefix
+// and recording the executed commands.
+func fakeSnapshotCommands(t *testing.T, outputs map[string]string) *[]string {
+	executed := []string{}
+	oldRunCmdOutput := runCmdOutput
+	t.Cleanup(func() { runCmdOutput = oldRunCmdOutput })
+	runCmdOutput = func(_ zerolog.Level, command string, args ...string) ([]byte, error) {
+		commandLine := strings.Join(append([]string{command}, args...), " ")
+		executed = append(executed, commandLine)
+		for prefix, out := range outputs {
+			if strings.HasPrefix(commandLine, prefix) {
+				return []byte(out), nil
+			}
+		}
+		return nil, errors.New("unexpected command " + commandLine)
+	}
+	return &executed
+}
+
+func TestFindFilesystem(t *testing.T) {
+	fakeSnapshotCommands(t, map[string]string{
+		"findmnt": "/dev/sda2[/@/var] btrfs /var\n",
+	})
+
+	fs, err := findFilesystem("/var/lib/containers/storage/volumes/var-pgsql/_data")
+	testutils.AssertNoError(t, "failed to find filesystem", err)
+	testutils.AssertEquals(t, "Unexpected source", "/dev/sda2", fs.Source)
+	testutils.AssertEquals(t, "Unexpected type", "btrfs", fs.FsType)
+	testutils.AssertEquals(t, "Unexpected target", "/var", fs.Target)
+}
+
+func TestFindSubvolume(t *testing.T) {
+	executed := []string{}
+	oldRunCmdOutput := runCmdOutput
+	t.Cleanup(func() { runCmdOutput = oldRunCmdOutput })
+	runCmdOutput = func(_ zerolog.Level, command string, args ...string) ([]byte, error) {
+		commandLine := strings.Join(append([]string{command}, args...), " ")
+		executed = append(executed, commandLine)
+		if commandLine == "btrfs subvolume show /var/lib/containers" || commandLine == "btrfs subvolume show /var" {
+			return []byte{}, nil
+		}
+		return nil, errors.New("ERROR: Not a Btrfs subvolume")
+	}
+
+	subvolume, err := findSubvolume("/var/lib/containers/storage/volumes/var-pgsql/_data", "/var")
+	testutils.AssertNoError(t, "failed to find subvolume", err)
+	testutils.AssertEquals(t, "Unexpected subvolume", "/var/lib/containers", subvolume)
+	testutils.AssertEquals(t, "Unexpected number of checked directories", 5, len(executed))
+
+	_, err = findSubvolume("/srv/volumes/var-pgsql/_data", "/srv")
+	testutils.AssertError(t, "failed to find the btrfs subvolume containing /srv/volumes/var-pgsql/_data", err)
+}
+
+func TestDetectSnapshotMethod(t *testing.T) {
+	fakeSnapshotCommands(t, map[string]string{
+		"lvs":                   "",
+		"xfs_info /srv/reflink": "meta-data=/dev/sdb1 crc=1 finobt=1, sparse=1, rmapbt=0\n = reflink=1\n",
+		"xfs_info /srv/plain":   "meta-data=/dev/sdc1 crc=1 finobt=1, sparse=1, rmapbt=0\n = reflink=0\n",
+	})
+
+	method, err := detectSnapshotMethod(filesystem{Source: "/dev/sda2", FsType: "btrfs", Target: "/var"})
+	testutils.AssertNoError(t, "btrfs should be supported", err)
+	testutils.AssertEquals(t, "Unexpected btrfs method", snapshotBtrfs, method)
+
+	method, err = detectSnapshotMethod(filesystem{Source: "/dev/sdb1", FsType: "xfs", Target: "/srv/reflink"})
+	testutils.AssertNoError(t, "XFS with reflink should be supported", err)
+	testutils.AssertEquals(t, "Unexpected XFS method", snapshotReflink, method)
+
+	_, err = detectSnapshotMethod(filesystem{Source: "/dev/sdc1", FsType: "xfs", Target: "/srv/plain"})
+	testutils.AssertError(t, "cannot be snapshotted", err)
+
+	_, err = detectSnapshotMethod(filesystem{Source: "/dev/sdd1", FsType: "ext4", Target: "/srv/ext4"})
+	testutils.AssertError(t, "cannot be snapshotted", err)
+}
+
+func TestSnapshotVolumePath(t *testing.T) {
+	mountPoint := "/var/lib/containers/storage/volumes/var-pgsql/_data"
+	btrfs := &snapshot{fs: filesystem{Target: "/var"}, method: snapshotBtrfs, name: "uyuni-backup-1"}
+	btrfs.root = btrfs.defaultRoot()
+	testutils.AssertEquals(t, "Unexpected btrfs path",
+		"/var/.uyuni-backup-1/lib/containers/storage/volumes/var-pgsql/_data", btrfs.volumePath("var-pgsql", mountPoint))
+
+	reflink := &snapshot{fs: filesystem{Target: "/var"}, method: snapshotReflink, name: "uyuni-backup-1"}
+	reflink.root = reflink.defaultRoot()
+	testutils.AssertEquals(t, "Unexpected reflink path",
+		"/var/.uyuni-backup-1/var-pgsql", reflink.volumePath("var-pgsql", mountPoint))
+}
+
+func TestSnapshotTakeAndRelease(t *testing.T) {
+	executed := fakeSnapshotCommands(t, map[string]string{
+		"btrfs": "",
+	})
+	root := filepath.Join(t.TempDir(), ".uyuni-backup-1")
+	snapshots := &volumeSnapshots{
+		snapshots: []*snapshot{{fs: filesystem{Target: "/var"}, method: snapshotBtrfs, root: root}},
+		paths:     map[string]string{"var-pgsql": root + "/var-pgsql"},
+	}
+
+	testutils.AssertNoError(t, "failed to take snapshots", snapshots.take())
+	testutils.AssertNoError(t, "failed to release snapshots", snapshots.release())
+	testutils.AssertEquals(t, "Unexpected commands",
+		"btrfs subvolume snapshot -r /var "+root+"\nbtrfs subvolume delete "+root, strings.Join(*executed, "\n"))
+
+	testutils.AssertEquals(t, "Snapshotted volume should be exported with tar", "tar",
+		snapshots.exportCommand("var-pgsql")[0])
+	testutils.AssertEquals(t, "Other volumes should be exported by podman", "podman",
+		snapshots.exportCommand("etc-rhn")[0])
+	var noSnapshots *volumeSnapshots
+	testutils.AssertEquals(t, "Volumes should be exported by podman without snapshot", "podman",
+		noSnapshots.exportCommand("var-pgsql")[0])
+}
+
+func TestSnapshotTakeFailureReleases(t *testing.T) {
+	executed := fakeSnapshotCommands(t, map[string]string{
+		"btrfs subvolume snapshot -r /var": "",
+		"btrfs subvolume delete":           "",
+	})
+	snapshots := &volumeSnapshots{
+		snapshots: []*snapshot{
+			{fs: filesystem{Target: "/var"}, method: snapshotBtrfs, root: "/var/.snap"},
+			{fs: filesystem{Target: "/srv"}, method: snapshotBtrfs, root: "/srv/.snap"},
+		},
+	}
+
+	testutils.AssertError(t, "failed to snapshot /srv", snapshots.take())
+	testutils.AssertEquals(t, "The taken snapshot should be released", "btrfs subvolume delete /var/.snap",
+		(*executed)[len(*executed)-1])
+}
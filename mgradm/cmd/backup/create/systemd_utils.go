@@ -6,16 +6,10 @@ package create
 
 import (
 	"archive/tar"
-	"encoding/csv"
 	"fmt"
-	"io"
-	"os"
-	"path"
-	"path/filepath"
-	"strings"
 
 	"github.com/rs/zerolog/log"
-	backup "github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
 	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
 	"github.com/uyuni-project/uyuni-tools/shared/podman"
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
@@ -23,50 +17,36 @@ import (
 
 var systemd podman.Systemd = podman.NewSystemd()
 
-func exportSystemdConfiguration(outputDir string, dryRun bool) error {
-	filesToBackup := gatherSystemdItems()
+// exportSystemdConfiguration creates a tarball with the systemd services and their configuration.
+// The created artifact and the list of backed up files are returned.
+func exportSystemdConfiguration(
+	target backup.Target,
+	flags *backup.ArtifactFlags,
+	dryRun bool,
+) (artifact backup.Artifact, filesToBackup []string, err error) {
+	filesToBackup = gatherSystemdItems()
 
 	if dryRun {
 		log.Info().Msgf(L("Would backup %s"), filesToBackup)
-		return nil
+		return
 	}
 	// Create output file
-	out, err := os.Create(path.Join(outputDir, backup.SystemdConfBackupFile))
+	out, err := backup.CreateArtifact(target, backup.SystemdConfBackupFile, flags)
 	if err != nil {
-		return fmt.Errorf(L("failed to create Systemd backup tarball: %w"), err)
+		err = fmt.Errorf(L("failed to create Systemd backup tarball: %w"), err)
+		return
 	}
-	defer out.Close()
+	defer func() {
+		err = out.Finish(err)
+		artifact = out.Artifact()
+	}()
 
 	// Prepare tar buffer
 	tw := tar.NewWriter(out)
 	defer tw.Close()
 
-	for _, fileToBackup := range filesToBackup {
-		f, err := os.Open(fileToBackup)
-		if err != nil {
-			return err
-		}
-		fstat, _ := f.Stat()
-		h, err := tar.FileInfoHeader(fstat, "")
-		if err != nil {
-			return err
-		}
-		// Produced header does not have full path, overwrite it
-		h.Name = fileToBackup
-		if fstat.IsDir() {
-			h.Name += "/"
-		}
-		if err := tw.WriteHeader(h); err != nil {
-			return err
-		}
-		if fstat.IsDir() {
-			continue
-		}
-		if _, err := io.Copy(tw, f); err != nil {
-			return err
-		}
-	}
-	return nil
+	err = backup.WriteFilesTar(tw, filesToBackup)
+	return
 }
 
 // For each container get service file, service.d and its content.
@@ -79,36 +59,7 @@ func gatherSystemdItems() []string {
 			continue
 		}
 
-		servicePath, err := systemd.GetServiceProperty(serviceName, podman.FragmentPath)
-		if err != nil {
-			log.Debug().Err(err).Msgf("failed to get the path to the %s service file", serviceName)
-			// Skipping the dropins since we would likely get a similar error.
-			continue
-		}
-		result = append(result, servicePath)
-
-		// Get the drop in files
-		dropIns, err := systemd.GetServiceProperty(serviceName, podman.DropInPaths)
-		if err != nil {
-			log.Debug().Err(err).Msgf("failed to get the path to the %s service configuration files", serviceName)
-		} else {
-			r := csv.NewReader(strings.NewReader(dropIns))
-			r.Comma = ' '
-			dropIns, err := r.Read()
-			if err != nil {
-				log.Debug().Err(err).Msgf("failed to parse the drop-in paths for %s service", serviceName)
-			}
-			if len(dropIns) > 0 {
-				result = append(result, filepath.Dir(dropIns[0]))
-				result = append(result, dropIns[:]...)
-			}
-		}
-
-		// Get the environment file
-		envFile := path.Join(servicePath+".d", podman.ServerEnvironmentFile)
-		if _, err := os.Stat(envFile); err == nil {
-			result = append(result, envFile)
-		}
+		result = append(result, backup.SystemdServiceFiles(systemd, serviceName)...)
 	}
 	return result
 }
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
 	"github.com/uyuni-project/uyuni-tools/shared/podman"
 	"github.com/uyuni-project/uyuni-tools/shared/testutils"
 	"github.com/uyuni-project/uyuni-tools/shared/types"
@@ -232,9 +233,11 @@ func TestExportSystemdConfiguration(t *testing.T) {
 	})
 
 	outputDir := t.TempDir()
+	target, err := backup.NewTarget(outputDir, &backup.TargetFlags{})
+	testutils.AssertNoError(t, "failed to create backup target", err)
 
 	// dryRun = true
-	err = exportSystemdConfiguration(outputDir, true)
+	_, _, err = exportSystemdConfiguration(target, &backup.ArtifactFlags{}, true)
 	testutils.AssertNoError(t, "exportSystemdConfiguration with dryRun=true failed", err)
 
 	backupFilePath := filepath.Join(outputDir, "systemdBackup.tar")
@@ -243,7 +246,7 @@ func TestExportSystemdConfiguration(t *testing.T) {
 	}
 
 	// dryRun = false
-	err = exportSystemdConfiguration(outputDir, false)
+	_, _, err = exportSystemdConfiguration(target, &backup.ArtifactFlags{}, false)
 	testutils.AssertNoError(t, "exportSystemdConfiguration with dryRun=false failed", err)
 
 	if _, err := os.Stat(backupFilePath); os.IsNotExist(err) {
@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+const (
+	baseBackupPrefix   = "base-"
+	baseBackupFile     = "base.tar.gz"
+	baseBackupManifest = "backup_manifest"
+)
+
+// walFileRegexp matches the archived WAL segments, partial segments and backup history files.
+var walFileRegexp = regexp.MustCompile(`^[0-9A-F]{24}(\.partial|\.[0-9A-F]{8}\.backup)?$`)
+
+// startWALRegexp extracts the start location of a base backup from its backup_label.
+var startWALRegexp = regexp.MustCompile(`^START WAL LOCATION: ([0-9A-F]+/[0-9A-F]+) \(file ([0-9A-F]{24})\)`)
+
+// BaseBackup is a base backup stored in the backup volume.
+type BaseBackup struct {
+	// Dir is the directory of the base backup relative to the backup volume.
+	// It is empty for the base backup created by the previous versions directly in the volume.
+	Dir string
+	// Time is the time the base backup finished.
+	Time time.Time
+	// StartLSN is the WAL location where the base backup started.
+	StartLSN string
+	// StartWAL is the first WAL file needed to recover the base backup.
+	StartWAL string
+	// Size is the size of the base backup files.
+	Size int64
+}
+
+// Name returns a name identifying the base backup in the messages.
+func (b *BaseBackup) Name() string {
+	if b.Dir == "" {
+		return baseBackupFile
+	}
+	return b.Dir
+}
+
+// NewBaseBackupName returns the name of the directory for a base backup created at a given time.
+func NewBaseBackupName(now time.Time) string {
+	return baseBackupPrefix + now.Format("20060102-150405")
+}
+
+// ListBaseBackups returns the complete base backups in the backup volume, from the newest to the oldest.
+func ListBaseBackups(root string) ([]BaseBackup, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to list %s"), root)
+	}
+
+	dirs := []string{}
+	if utils.FileExists(path.Join(root, baseBackupFile)) {
+		dirs = append(dirs, "")
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), baseBackupPrefix) {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+
+	backups := []BaseBackup{}
+	for _, dir := range dirs {
+		backup, err := readBaseBackup(root, dir)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Ignoring incomplete base backup %s", path.Join(root, dir))
+			continue
+		}
+		backups = append(backups, backup)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+func readBaseBackup(root string, dir string) (BaseBackup, error) {
+	backup := BaseBackup{Dir: dir}
+	files := []string{baseBackupFile, baseBackupManifest}
+	if dir != "" {
+		// The manifest is written last: a base backup without it is still running or failed
+		if !utils.FileExists(path.Join(root, dir, baseBackupManifest)) {
+			return backup, errors.New(L("missing backup manifest"))
+		}
+		entries, err := os.ReadDir(path.Join(root, dir))
+		if err != nil {
+			return backup, err
+		}
+		files = []string{}
+		for _, entry := range entries {
+			files = append(files, entry.Name())
+		}
+	}
+
+	for _, file := range files {
+		info, err := os.Stat(path.Join(root, dir, file))
+		if err != nil {
+			continue
+		}
+		backup.Size += info.Size()
+		if file == baseBackupFile {
+			backup.Time = info.ModTime()
+		}
+	}
+
+	var err error
+	backup.StartLSN, backup.StartWAL, err = readStartWAL(path.Join(root, dir, baseBackupFile))
+	return backup, err
+}
+
+// readStartWAL extracts the start WAL location and file from the backup_label of a base backup archive.
+func readStartWAL(archive string) (lsn string, wal string, err error) {
+	file, err := os.Open(archive)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return "", "", err
+	}
+	reader := tar.NewReader(gzipReader)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return "", "", fmt.Errorf(L("no backup_label in %s"), archive)
+		} else if err != nil {
+			return "", "", err
+		}
+		if path.Clean(header.Name) != "backup_label" {
+			continue
+		}
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			if match := startWALRegexp.FindStringSubmatch(scanner.Text()); match != nil {
+				return match[1], match[2], nil
+			}
+		}
+		return "", "", fmt.Errorf(L("no start WAL location in the backup_label of %s"), archive)
+	}
+}
+
+// parseLSN converts a WAL location like 0/16B3748 into a comparable value.
+func parseLSN(lsn string) (uint64, error) {
+	high, low, found := strings.Cut(lsn, "/")
+	if !found {
+		return 0, fmt.Errorf(L("invalid WAL location %s"), lsn)
+	}
+	highValue, err := strconv.ParseUint(high, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf(L("invalid WAL location %s"), lsn)
+	}
+	lowValue, err := strconv.ParseUint(low, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf(L("invalid WAL location %s"), lsn)
+	}
+	return highValue<<32 | lowValue, nil
+}
+
+// SelectBaseBackup returns the base backup to restore to reach the recovery target.
+// The backups are expected to be sorted from the newest to the oldest.
+func SelectBaseBackup(backups []BaseBackup, target *RecoveryTarget, now time.Time) (*BaseBackup, error) {
+	if len(backups) == 0 {
+		return nil, errors.New(L("no database base backup found"))
+	}
+
+	switch {
+	case target.Time != "":
+		targetTime, err := target.ParseTime(now)
+		if err != nil {
+			return nil, err
+		}
+		for i := range backups {
+			if !backups[i].Time.After(targetTime) {
+				return &backups[i], nil
+			}
+		}
+		oldest := backups[len(backups)-1]
+		return nil, fmt.Errorf(L("target time %[1]s is before the oldest base backup of %[2]s: "+
+			"the database cannot be recovered to it"),
+			targetTime.Format(time.DateTime), oldest.Time.Format(time.DateTime))
+	case target.LSN != "":
+		targetLSN, err := parseLSN(target.LSN)
+		if err != nil {
+			return nil, err
+		}
+		for i := range backups {
+			if startLSN, err := parseLSN(backups[i].StartLSN); err == nil && startLSN <= targetLSN {
+				return &backups[i], nil
+			}
+		}
+		return nil, fmt.Errorf(L("target LSN %s is before the oldest base backup"), target.LSN)
+	case target.Name != "":
+		// There is no way to know where the restore point is: start from the oldest base backup
+		return &backups[len(backups)-1], nil
+	}
+	return &backups[0], nil
+}
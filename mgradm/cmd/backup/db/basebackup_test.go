@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+// writeBaseBackup creates a fake base backup archive containing a backup_label.
+func writeBaseBackup(t *testing.T, root string, dir string, startWAL string, finished time.Time) {
+	if err := os.MkdirAll(path.Join(root, dir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	archive := path.Join(root, dir, baseBackupFile)
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	label := fmt.Sprintf("START WAL LOCATION: 0/%s000028 (file %s)\nCHECKPOINT LOCATION: 0/2000060\n",
+		startWAL[23:], startWAL)
+	_ = tarWriter.WriteHeader(&tar.Header{Name: "backup_label", Mode: 0600, Size: int64(len(label))})
+	_, _ = tarWriter.Write([]byte(label))
+	_ = tarWriter.Close()
+	_ = gzipWriter.Close()
+	_ = file.Close()
+	testutils.WriteFile(t, path.Join(root, dir, baseBackupManifest), "{}")
+	if err := os.Chtimes(archive, finished, finished); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListBaseBackups(t *testing.T) {
+	root := t.TempDir()
+	day := func(d int) time.Time { return time.Date(2026, time.March, d, 2, 0, 0, 0, time.UTC) }
+
+	writeBaseBackup(t, root, "", "000000010000000000000002", day(1))
+	writeBaseBackup(t, root, "base-20260303-020000", "000000010000000000000008", day(3))
+	// Still running base backup without manifest
+	if err := os.Mkdir(path.Join(root, "base-20260304-020000"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	testutils.WriteFile(t, path.Join(root, "base-20260304-020000", baseBackupFile), "partial")
+
+	backups, err := ListBaseBackups(root)
+	testutils.AssertNoError(t, "failed to list base backups", err)
+	testutils.AssertEquals(t, "Unexpected number of base backups", 2, len(backups))
+	testutils.AssertEquals(t, "Unexpected newest base backup", "base-20260303-020000", backups[0].Name())
+	testutils.AssertEquals(t, "Unexpected start WAL", "000000010000000000000008", backups[0].StartWAL)
+	testutils.AssertEquals(t, "Unexpected start LSN", "0/8000028", backups[0].StartLSN)
+	testutils.AssertEquals(t, "Unexpected legacy base backup", baseBackupFile, backups[1].Name())
+}
+
+func TestSelectBaseBackup(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 2, 0, 0, 0, time.UTC)
+	backups := []BaseBackup{
+		{Dir: "base-20260305-020000", Time: now.AddDate(0, 0, -5), StartLSN: "0/9000028"},
+		{Dir: "base-20260301-020000", Time: now.AddDate(0, 0, -9), StartLSN: "0/2000028"},
+	}
+
+	data := []struct {
+		target   RecoveryTarget
+		expected string
+	}{
+		{RecoveryTarget{}, "base-20260305-020000"},
+		{RecoveryTarget{Time: "-5m"}, "base-20260305-020000"},
+		{RecoveryTarget{Time: "2026-03-03T00:00:00Z"}, "base-20260301-020000"},
+		{RecoveryTarget{LSN: "0/5000000"}, "base-20260301-020000"},
+		{RecoveryTarget{LSN: "1/0"}, "base-20260305-020000"},
+		{RecoveryTarget{Name: "before-cleanup"}, "base-20260301-020000"},
+	}
+	for i, test := range data {
+		backup, err := SelectBaseBackup(backups, &test.target, now)
+		testutils.AssertNoError(t, fmt.Sprintf("failed to select base backup for case %d", i), err)
+		testutils.AssertEquals(t, fmt.Sprintf("Unexpected base backup for case %d", i), test.expected, backup.Dir)
+	}
+
+	_, err := SelectBaseBackup(backups, &RecoveryTarget{Time: "2026-02-01T00:00:00Z"}, now)
+	testutils.AssertError(t, "is before the oldest base backup", err)
+	_, err = SelectBaseBackup([]BaseBackup{}, &RecoveryTarget{}, now)
+	testutils.AssertError(t, "no database base backup found", err)
+}
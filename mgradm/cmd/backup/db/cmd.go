@@ -24,6 +24,9 @@ func NewDBCmd(globalFlags *types.GlobalFlags) *cobra.Command {
 	dbCmd.AddCommand(newDBDisableCmd(globalFlags, doDBDisable))
 	dbCmd.AddCommand(newDBStatusCmd(globalFlags, doDBStatus))
 	dbCmd.AddCommand(newDBRestoreCmd(globalFlags, doDBRestore))
+	dbCmd.AddCommand(newDBRestorePointCmd(globalFlags, doDBCreateRestorePoint))
+	dbCmd.AddCommand(newDBPruneCmd(globalFlags, doDBPrune))
+	dbCmd.AddCommand(newDBVerifyCmd(globalFlags, doDBVerify))
 	return dbCmd
 }
 
@@ -74,10 +77,17 @@ func newDBStatusCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[Flagpo
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: L("Check WAL based database backup status"),
+		Long: L(`Check WAL based database backup status
+
+The command fails when one of the thresholds is exceeded, for monitoring purposes.
+The archive lag is the time since the oldest WAL file waiting to be archived is complete.`),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
 		},
 	}
+	cmd.Flags().String("output", "text", L("Output format. Possible values: text, json"))
+	cmd.Flags().String("max-archive-lag", "", L("Fail if the archive lag exceeds this duration, like 30m"))
+	cmd.Flags().String("max-base-backup-age", "", L("Fail if the latest base backup is older than this duration, like 7d"))
 	return cmd
 }
 
@@ -91,6 +101,76 @@ func newDBRestoreCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[Flagp
 		},
 	}
 	cmd.Flags().Bool("force", false, L("Don't ask for confirmation"))
+	cmd.Flags().String("target-time", "",
+		L("Recover the database to this time, like '2026-03-01 14:30:00' or -5m for five minutes ago"))
+	cmd.Flags().String("target-lsn", "", L("Recover the database to this write-ahead log location"))
+	cmd.Flags().String("target-name", "", L("Recover the database to this named restore point"))
+	return cmd
+}
+
+func newDBRestorePointCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[Flagpole]) *cobra.Command {
+	restorePointCmd := &cobra.Command{
+		Use:   "restore-point",
+		Short: L("Database restore points management"),
+	}
+
+	var flags Flagpole
+	createCmd := &cobra.Command{
+		Use:   "create name",
+		Short: L("Create a named restore point"),
+		Long: L(`Create a named restore point in the database continuous archive.
+
+The database can later be recovered to this point using backup db restore --target-name.`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	restorePointCmd.AddCommand(createCmd)
+	return restorePointCmd
+}
+
+func newDBPruneCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[Flagpole]) *cobra.Command {
+	var flags Flagpole
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: L("Remove old base backups and archived WAL files"),
+		Long: L(`Remove the old base backups and the archived WAL files they need from the backup volume.
+
+The latest base backup is always kept. When a retention window is set, the base backups
+needed to recover the database to any point in the window are kept.
+The WAL files older than the oldest kept base backup are removed.`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	cmd.Flags().Int("keep-base-backups", 1, L("Number of base backups to keep"))
+	cmd.Flags().String("keep-within", "",
+		L("Keep what is needed to recover to any point in this duration, like 7d or 12h"))
+	cmd.Flags().Bool("dryrun", false, L("Print expected actions, but no action is done"))
+	return cmd
+}
+
+func newDBVerifyCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[Flagpole]) *cobra.Command {
+	var flags Flagpole
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: L("Verify the database backup by restoring it"),
+		Long: L(`Verify the database backup by restoring it
+
+The latest base backup and the archived WAL files are restored in a throwaway database
+container with an isolated network and a temporary volume. Sanity queries are then run
+on the restored database and everything is removed.
+
+The running database is not modified.`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	cmd.Flags().String("db-name", "susemanager", L("Name of the database to check"))
+	cmd.Flags().String("timeout", "1h", L("Maximum duration of the WAL files replay"))
 	return cmd
 }
 
@@ -125,11 +205,11 @@ func doDBDisable(
 
 func doDBStatus(
 	_ *types.GlobalFlags,
-	_ *Flagpole,
+	flags *Flagpole,
 	_ *cobra.Command,
 	_ []string,
 ) error {
-	return Status()
+	return Status(flags)
 }
 
 func doDBRestore(
@@ -138,5 +218,32 @@ func doDBRestore(
 	_ *cobra.Command,
 	_ []string,
 ) error {
-	return Restore(flags.Force)
+	return Restore(flags)
+}
+
+func doDBCreateRestorePoint(
+	_ *types.GlobalFlags,
+	_ *Flagpole,
+	_ *cobra.Command,
+	args []string,
+) error {
+	return CreateRestorePoint(args[0])
+}
+
+func doDBPrune(
+	_ *types.GlobalFlags,
+	flags *Flagpole,
+	_ *cobra.Command,
+	_ []string,
+) error {
+	return Prune(flags)
+}
+
+func doDBVerify(
+	_ *types.GlobalFlags,
+	flags *Flagpole,
+	_ *cobra.Command,
+	_ []string,
+) error {
+	return Verify(flags)
 }
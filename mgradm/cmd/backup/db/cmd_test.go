@@ -77,18 +77,23 @@ func TestDisableParamsParsing(t *testing.T) {
 }
 
 func TestStatusParamsParsing(t *testing.T) {
-	args := []string{}
+	args := []string{"--output", "json", "--max-archive-lag", "30m", "--max-base-backup-age", "7d"}
 
 	// Test function asserting that the args are properly parsed
-	tester := func(_ *types.GlobalFlags, _ *Flagpole,
+	tester := func(_ *types.GlobalFlags, flags *Flagpole,
 		_ *cobra.Command, _ []string,
 	) error {
+		testutils.AssertEquals(t, "Error parsing --output", "json", flags.Output)
+		testutils.AssertEquals(t, "Error parsing --max-archive-lag", "30m", flags.Max.Archive.Lag)
+		testutils.AssertEquals(t, "Error parsing --max-base-backup-age", "7d", flags.Max.Base.Backup.Age)
 		return nil
 	}
 
 	globalFlags := types.GlobalFlags{}
 	cmd := newDBStatusCmd(&globalFlags, tester)
 
+	testutils.AssertHasAllFlags(t, cmd, args)
+
 	cmd.SetArgs(args)
 	if err := cmd.Execute(); err != nil {
 		t.Errorf("command failed with error: %s", err)
@@ -96,13 +101,16 @@ func TestStatusParamsParsing(t *testing.T) {
 }
 
 func TestRestoreParamsParsing(t *testing.T) {
-	args := []string{"--force"}
+	args := []string{"--force", "--target-time", "-5m", "--target-lsn", "0/16B3748", "--target-name", "point"}
 
 	// Test function asserting that the args are properly parsed
 	tester := func(_ *types.GlobalFlags, flags *Flagpole,
 		_ *cobra.Command, _ []string,
 	) error {
 		testutils.AssertTrue(t, "Force flag not true", flags.Force)
+		testutils.AssertEquals(t, "Error parsing --target-time", "-5m", flags.Target.Time)
+		testutils.AssertEquals(t, "Error parsing --target-lsn", "0/16B3748", flags.Target.LSN)
+		testutils.AssertEquals(t, "Error parsing --target-name", "point", flags.Target.Name)
 		return nil
 	}
 
@@ -116,3 +124,50 @@ func TestRestoreParamsParsing(t *testing.T) {
 		t.Errorf("command failed with error: %s", err)
 	}
 }
+
+func TestPruneParamsParsing(t *testing.T) {
+	args := []string{"--keep-base-backups", "3", "--keep-within", "7d", "--dryrun"}
+
+	// Test function asserting that the args are properly parsed
+	tester := func(_ *types.GlobalFlags, flags *Flagpole,
+		_ *cobra.Command, _ []string,
+	) error {
+		testutils.AssertEquals(t, "Error parsing --keep-base-backups", 3, flags.Keep.Base.Backups)
+		testutils.AssertEquals(t, "Error parsing --keep-within", "7d", flags.Keep.Within)
+		testutils.AssertTrue(t, "DryRun flag not true", flags.DryRun)
+		return nil
+	}
+
+	globalFlags := types.GlobalFlags{}
+	cmd := newDBPruneCmd(&globalFlags, tester)
+
+	testutils.AssertHasAllFlags(t, cmd, args)
+
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("command failed with error: %s", err)
+	}
+}
+
+func TestVerifyParamsParsing(t *testing.T) {
+	args := []string{"--db-name", "uyuni", "--timeout", "2h"}
+
+	// Test function asserting that the args are properly parsed
+	tester := func(_ *types.GlobalFlags, flags *Flagpole,
+		_ *cobra.Command, _ []string,
+	) error {
+		testutils.AssertEquals(t, "Error parsing --db-name", "uyuni", flags.DB.Name)
+		testutils.AssertEquals(t, "Error parsing --timeout", "2h", flags.Timeout)
+		return nil
+	}
+
+	globalFlags := types.GlobalFlags{}
+	cmd := newDBVerifyCmd(&globalFlags, tester)
+
+	testutils.AssertHasAllFlags(t, cmd, args)
+
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("command failed with error: %s", err)
+	}
+}
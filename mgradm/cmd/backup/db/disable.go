@@ -48,7 +48,7 @@ func Disable(flags *Flagpole) error {
 				return nil
 			}
 		}
-		if err := podman.DeleteVolume(utils.VarPgsqlBackupVolumeMount.Name, false); err != nil {
+		if err := podman.DeleteVolume(nil, utils.VarPgsqlBackupVolumeMount.Name, false); err != nil {
 			return err
 		}
 		log.Info().Msgf(L("Backup volume '%s' removed"), utils.VarPgsqlBackupVolumeMount.Name)
@@ -63,5 +63,5 @@ func Disable(flags *Flagpole) error {
 			return err
 		}
 	}
-	return Status()
+	return Status(&Flagpole{})
 }
@@ -6,7 +6,9 @@ package db
 
 import (
 	"errors"
+	"path"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
@@ -72,7 +74,7 @@ func Enable(force bool) error {
 		return err
 	}
 
-	if err := RunBaseBackup(cnx); err != nil {
+	if err := RunBaseBackup(cnx, true); err != nil {
 		return err
 	}
 
@@ -89,9 +91,13 @@ func Enable(force bool) error {
 	return nil
 }
 
-func RunBaseBackup(cnx *shared.Connection) error {
+// RunBaseBackup creates a new base backup in the backup volume.
+// If clean is true, all the previous base backups and archived WAL files are removed.
+func RunBaseBackup(cnx *shared.Connection, clean bool) error {
 	data := templates.EnablePostgresTemplateData{
-		BackupDir: utils.VarPgsqlBackupVolumeMount.MountPath,
+		BackupDir:     utils.VarPgsqlBackupVolumeMount.MountPath,
+		BaseBackupDir: path.Join(utils.VarPgsqlBackupVolumeMount.MountPath, NewBaseBackupName(time.Now())),
+		Clean:         clean,
 	}
 
 	scriptBuilder := new(strings.Builder)
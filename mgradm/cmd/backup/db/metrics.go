@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sys/unix"
+
+	"github.com/uyuni-project/uyuni-tools/shared"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+)
+
+// ErrThresholdExceeded is reported when one of the monitoring thresholds is exceeded.
+var ErrThresholdExceeded = errors.New(L("database backup monitoring threshold exceeded"))
+
+// archiverQuery fetches the archiver statistics separated by pipes.
+const archiverQuery = `SELECT current_setting('archive_mode'), archived_count,
+	coalesce(last_archived_wal, ''), coalesce(extract(epoch from last_archived_time)::bigint::text, ''),
+	failed_count, coalesce(last_failed_wal, ''), coalesce(extract(epoch from last_failed_time)::bigint::text, ''),
+	pg_walfile_name(pg_current_wal_lsn()),
+	(SELECT setting FROM pg_settings WHERE name = 'wal_segment_size')
+	FROM pg_stat_archiver;`
+
+// Metrics are the continuous archiving values to monitor.
+type Metrics struct {
+	Status      string `json:"status"`
+	ArchiveMode string `json:"archiveMode,omitempty"`
+
+	ArchivedCount    int64      `json:"archivedCount"`
+	LastArchivedWAL  string     `json:"lastArchivedWal,omitempty"`
+	LastArchivedTime *time.Time `json:"lastArchivedTime,omitempty"`
+	FailedCount      int64      `json:"failedCount"`
+	LastFailedWAL    string     `json:"lastFailedWal,omitempty"`
+	LastFailedTime   *time.Time `json:"lastFailedTime,omitempty"`
+	CurrentWAL       string     `json:"currentWal,omitempty"`
+	// ArchiveFailing is set when the last archiving attempt failed.
+	ArchiveFailing bool `json:"archiveFailing"`
+	// PendingWALCount is the number of complete WAL files waiting to be archived.
+	PendingWALCount int64 `json:"pendingWalCount"`
+	// ArchiveLagSeconds is the time since the oldest WAL file waiting to be archived was completed at least.
+	ArchiveLagSeconds int64 `json:"archiveLagSeconds"`
+
+	BaseBackupCount      int        `json:"baseBackupCount"`
+	BaseBackupTime       *time.Time `json:"baseBackupTime,omitempty"`
+	BaseBackupAgeSeconds int64      `json:"baseBackupAgeSeconds,omitempty"`
+	BaseBackupSize       int64      `json:"baseBackupSize,omitempty"`
+
+	VolumeSize      uint64 `json:"volumeSize,omitempty"`
+	VolumeFreeSpace uint64 `json:"volumeFreeSpace,omitempty"`
+
+	// Alerts lists the exceeded thresholds.
+	Alerts []string `json:"alerts,omitempty"`
+}
+
+// Thresholds are the limits above which the status command fails.
+type Thresholds struct {
+	ArchiveLag    time.Duration
+	BaseBackupAge time.Duration
+}
+
+// collectArchiverMetrics fills the metrics from the running database statistics.
+func collectArchiverMetrics(cnx *shared.Connection, metrics *Metrics, now time.Time) error {
+	out, err := cnx.Exec("/usr/bin/psql", "-U", "postgres", "-tAc", archiverQuery)
+	if err != nil {
+		return err
+	}
+	return parseArchiverStats(strings.TrimSpace(string(out)), metrics, now)
+}
+
+// parseArchiverStats parses the output of the archiverQuery.
+func parseArchiverStats(out string, metrics *Metrics, now time.Time) error {
+	fields := strings.Split(out, "|")
+	if len(fields) != 9 {
+		return fmt.Errorf(L("unexpected archiver statistics: %s"), out)
+	}
+	parseTime := func(value string) *time.Time {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil
+		}
+		result := time.Unix(seconds, 0).UTC()
+		return &result
+	}
+
+	metrics.ArchiveMode = fields[0]
+	metrics.ArchivedCount, _ = strconv.ParseInt(fields[1], 10, 64)
+	metrics.LastArchivedWAL = fields[2]
+	metrics.LastArchivedTime = parseTime(fields[3])
+	metrics.FailedCount, _ = strconv.ParseInt(fields[4], 10, 64)
+	metrics.LastFailedWAL = fields[5]
+	metrics.LastFailedTime = parseTime(fields[6])
+	metrics.CurrentWAL = fields[7]
+
+	segmentSize, err := strconv.ParseInt(fields[8], 10, 64)
+	if err != nil || segmentSize <= 0 {
+		return fmt.Errorf(L("unexpected WAL segment size: %s"), fields[8])
+	}
+	current, err := walSegmentNumber(metrics.CurrentWAL, segmentSize)
+	if err != nil {
+		return err
+	}
+	// The archiver retries the oldest pending WAL file until it succeeds
+	metrics.ArchiveFailing = metrics.LastFailedTime != nil &&
+		(metrics.LastArchivedTime == nil || metrics.LastFailedTime.After(*metrics.LastArchivedTime))
+
+	switch {
+	case metrics.LastArchivedWAL != "":
+		archived, err := walSegmentNumber(metrics.LastArchivedWAL, segmentSize)
+		if err != nil {
+			return err
+		}
+		// The current WAL file is still being written and can't be archived yet
+		metrics.PendingWALCount = max(current-archived-1, 0)
+	case metrics.LastFailedWAL != "":
+		// Nothing archived yet: the failing WAL file is the oldest pending one
+		failed, err := walSegmentNumber(metrics.LastFailedWAL, segmentSize)
+		if err != nil {
+			return err
+		}
+		metrics.PendingWALCount = max(current-failed, 0)
+	case metrics.ArchiveMode != "off":
+		// Nothing archived nor attempted: all the WAL files completed since the first one are pending
+		metrics.PendingWALCount = max(current-1, 0)
+	}
+
+	if metrics.PendingWALCount > 0 {
+		if metrics.LastArchivedTime != nil {
+			metrics.ArchiveLagSeconds = int64(now.Sub(*metrics.LastArchivedTime).Seconds())
+		} else if metrics.LastFailedTime != nil {
+			// The pending WAL file was already complete when its archiving failed
+			metrics.ArchiveLagSeconds = int64(now.Sub(*metrics.LastFailedTime).Seconds())
+		}
+	}
+	return nil
+}
+
+// walSegmentNumber computes the absolute segment number of a WAL file name, ignoring the timeline.
+func walSegmentNumber(name string, segmentSize int64) (int64, error) {
+	if len(name) < 24 {
+		return 0, fmt.Errorf(L("invalid WAL file name %s"), name)
+	}
+	logID, err := strconv.ParseInt(name[8:16], 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf(L("invalid WAL file name %s"), name)
+	}
+	segment, err := strconv.ParseInt(name[16:24], 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf(L("invalid WAL file name %s"), name)
+	}
+	return logID*(0x100000000/segmentSize) + segment, nil
+}
+
+// collectVolumeMetrics fills the metrics from the backup volume content.
+func collectVolumeMetrics(mountPoint string, metrics *Metrics, now time.Time) error {
+	backups, err := ListBaseBackups(mountPoint)
+	if err != nil {
+		return err
+	}
+	metrics.BaseBackupCount = len(backups)
+	if len(backups) > 0 {
+		latest := backups[0].Time.UTC()
+		metrics.BaseBackupTime = &latest
+		metrics.BaseBackupAgeSeconds = int64(now.Sub(latest).Seconds())
+		metrics.BaseBackupSize = backups[0].Size
+	}
+
+	var stat unix.Statfs_t
+	if err := unix.Statfs(mountPoint, &stat); err != nil {
+		log.Warn().Err(err).Msgf(L("unable to determine %s storage size"), mountPoint)
+	} else {
+		metrics.VolumeSize = stat.Blocks * uint64(stat.Bsize)
+		metrics.VolumeFreeSpace = stat.Bavail * uint64(stat.Bsize)
+	}
+	return nil
+}
+
+// check adds an alert to the metrics for each exceeded threshold.
+//
+// Failing archiving and complete WAL files never archived are always alerted.
+func (t *Thresholds) check(metrics *Metrics) {
+	if metrics.ArchiveFailing {
+		metrics.Alerts = append(metrics.Alerts, fmt.Sprintf(L("archiving WAL file %[1]s failed at %[2]s"),
+			metrics.LastFailedWAL, metrics.LastFailedTime.Local().Format(time.DateTime)))
+	} else if metrics.LastArchivedWAL == "" && metrics.PendingWALCount > 0 {
+		metrics.Alerts = append(metrics.Alerts, fmt.Sprintf(L("%d complete WAL files, none archived yet"),
+			metrics.PendingWALCount))
+	}
+	if t.ArchiveLag > 0 && metrics.ArchiveLagSeconds > int64(t.ArchiveLag.Seconds()) {
+		metrics.Alerts = append(metrics.Alerts, fmt.Sprintf(L("archive lag of %[1]s exceeds %[2]s"),
+			time.Duration(metrics.ArchiveLagSeconds)*time.Second, t.ArchiveLag))
+	}
+	if t.BaseBackupAge > 0 {
+		if metrics.BaseBackupTime == nil {
+			metrics.Alerts = append(metrics.Alerts, L("no base backup found"))
+		} else if metrics.BaseBackupAgeSeconds > int64(t.BaseBackupAge.Seconds()) {
+			metrics.Alerts = append(metrics.Alerts, fmt.Sprintf(L("base backup age of %[1]s exceeds %[2]s"),
+				time.Duration(metrics.BaseBackupAgeSeconds)*time.Second, t.BaseBackupAge))
+		}
+	}
+}
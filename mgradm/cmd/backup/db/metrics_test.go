@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestParseArchiverStats(t *testing.T) {
+	now := time.Unix(1772370000, 0)
+	metrics := Metrics{}
+	out := "on|42|0000000100000001000000FE|1772366400|3|0000000100000001000000FF|1772369000|" +
+		"000000010000000200000002|16777216"
+
+	testutils.AssertNoError(t, "failed to parse archiver statistics", parseArchiverStats(out, &metrics, now))
+	testutils.AssertEquals(t, "Unexpected archive mode", "on", metrics.ArchiveMode)
+	testutils.AssertEquals(t, "Unexpected archived count", int64(42), metrics.ArchivedCount)
+	testutils.AssertEquals(t, "Unexpected failed count", int64(3), metrics.FailedCount)
+	testutils.AssertEquals(t, "Unexpected last failed WAL", "0000000100000001000000FF", metrics.LastFailedWAL)
+	// 01/FF, 02/00 and 02/01 are complete and not archived, 02/02 is the current one
+	testutils.AssertEquals(t, "Unexpected pending WAL count", int64(3), metrics.PendingWALCount)
+	testutils.AssertEquals(t, "Unexpected archive lag", int64(3600), metrics.ArchiveLagSeconds)
+	testutils.AssertTrue(t, "Failure after the last archived WAL should be failing", metrics.ArchiveFailing)
+}
+
+func TestParseArchiverStatsNeverArchived(t *testing.T) {
+	now := time.Unix(1772370000, 0)
+	metrics := Metrics{}
+	out := "on|0|||4|000000010000000000000003|1772369400|000000010000000000000005|16777216"
+
+	testutils.AssertNoError(t, "failed to parse archiver statistics", parseArchiverStats(out, &metrics, now))
+	// 03 is failing, 04 is complete and 05 is the current one
+	testutils.AssertEquals(t, "Unexpected pending WAL count", int64(2), metrics.PendingWALCount)
+	testutils.AssertEquals(t, "Unexpected archive lag", int64(600), metrics.ArchiveLagSeconds)
+	testutils.AssertTrue(t, "Failure without archived WAL should be failing", metrics.ArchiveFailing)
+
+	metrics = Metrics{}
+	out = "on|0|||0|||000000010000000000000005|16777216"
+	testutils.AssertNoError(t, "failed to parse archiver statistics", parseArchiverStats(out, &metrics, now))
+	testutils.AssertEquals(t, "Unexpected pending WAL count", int64(4), metrics.PendingWALCount)
+	testutils.AssertTrue(t, "No failure expected", !metrics.ArchiveFailing)
+}
+
+func TestParseArchiverStatsUpToDate(t *testing.T) {
+	now := time.Unix(1772370000, 0)
+	metrics := Metrics{}
+	out := "on|42|000000010000000000000004|1772366400|0|||000000010000000000000005|16777216"
+
+	testutils.AssertNoError(t, "failed to parse archiver statistics", parseArchiverStats(out, &metrics, now))
+	testutils.AssertEquals(t, "No pending WAL expected", int64(0), metrics.PendingWALCount)
+	testutils.AssertEquals(t, "No lag expected on idle database", int64(0), metrics.ArchiveLagSeconds)
+	testutils.AssertTrue(t, "No failure time expected", metrics.LastFailedTime == nil)
+
+	testutils.AssertError(t, "unexpected archiver statistics", parseArchiverStats("on|42", &metrics, now))
+}
+
+func TestThresholdsCheck(t *testing.T) {
+	thresholds := Thresholds{ArchiveLag: 30 * time.Minute, BaseBackupAge: 7 * 24 * time.Hour}
+
+	metrics := Metrics{ArchiveLagSeconds: 600}
+	thresholds.check(&metrics)
+	testutils.AssertEquals(t, "Missing base backup should alert", []string{"no base backup found"}, metrics.Alerts)
+
+	backupTime := time.Now()
+	metrics = Metrics{ArchiveLagSeconds: 3600, BaseBackupTime: &backupTime, BaseBackupAgeSeconds: 8 * 24 * 3600}
+	thresholds.check(&metrics)
+	testutils.AssertEquals(t, "Unexpected alerts count", 2, len(metrics.Alerts))
+
+	metrics = Metrics{ArchiveLagSeconds: 3600, LastArchivedWAL: "000000010000000000000004"}
+	(&Thresholds{}).check(&metrics)
+	testutils.AssertEquals(t, "No alert expected without thresholds", 0, len(metrics.Alerts))
+
+	failedTime := time.Now()
+	metrics = Metrics{ArchiveFailing: true, LastFailedWAL: "000000010000000000000005", LastFailedTime: &failedTime}
+	(&Thresholds{}).check(&metrics)
+	testutils.AssertEquals(t, "Failing archiving should alert without thresholds", 1, len(metrics.Alerts))
+
+	metrics = Metrics{PendingWALCount: 4}
+	(&Thresholds{}).check(&metrics)
+	testutils.AssertEquals(t, "Never archived WAL files should alert without thresholds",
+		[]string{"4 complete WAL files, none archived yet"}, metrics.Alerts)
+}
@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Prune removes the base backups and WAL files not needed by the retention policy.
+func Prune(flags *Flagpole) error {
+	if flags.Keep.Base.Backups < 1 {
+		return errors.New(L("at least one base backup has to be kept"))
+	}
+	var window time.Duration
+	if flags.Keep.Within != "" {
+		var err error
+		if window, err = parseDuration(flags.Keep.Within); err != nil {
+			return err
+		}
+	}
+
+	root, err := podman.GetVolumeMountPoint(nil, utils.VarPgsqlBackupVolumeMount.Name)
+	if err != nil {
+		return err
+	}
+	return prune(root, flags.Keep.Base.Backups, window, time.Now(), flags.DryRun)
+}
+
+func prune(root string, keepCount int, window time.Duration, now time.Time, dryRun bool) error {
+	backups, err := ListBaseBackups(root)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return errors.New(L("no database base backup found"))
+	}
+
+	keep, remove := selectBaseBackups(backups, keepCount, window, now)
+	oldestWAL := keep[len(keep)-1].StartWAL
+	log.Debug().Msgf("Oldest needed WAL file: %s", oldestWAL)
+
+	var reclaimed int64
+	var hasError error
+	removeFile := func(name string, size int64) {
+		if dryRun {
+			log.Info().Msgf(L("Would remove %s"), name)
+			reclaimed += size
+			return
+		}
+		log.Debug().Msgf("Removing %s", name)
+		if err := os.RemoveAll(path.Join(root, name)); err != nil {
+			hasError = utils.JoinErrors(hasError, utils.Errorf(err, L("failed to remove %s"), name))
+			return
+		}
+		reclaimed += size
+	}
+
+	for _, backup := range remove {
+		log.Info().Msgf(L("Removing base backup %[1]s from %[2]s"), backup.Name(), backup.Time.Format(time.DateTime))
+		if backup.Dir == "" {
+			for _, file := range []string{baseBackupFile, baseBackupManifest} {
+				if info, err := os.Stat(path.Join(root, file)); err == nil {
+					removeFile(file, info.Size())
+				}
+			}
+		} else {
+			removeFile(backup.Dir, backup.Size)
+		}
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return utils.JoinErrors(hasError, utils.Errorf(err, L("failed to list %s"), root))
+	}
+	walCount := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !isWALFileBefore(entry.Name(), oldestWAL) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(path.Join(root, entry.Name())); err != nil {
+				hasError = utils.JoinErrors(hasError, utils.Errorf(err, L("failed to remove %s"), entry.Name()))
+				continue
+			}
+		}
+		walCount++
+		reclaimed += info.Size()
+	}
+
+	if dryRun {
+		log.Info().Msgf(L("%[1]d base backups would be removed, %[2]d kept, %[3]d WAL files would be removed, "+
+			"%[4]s would be reclaimed"), len(remove), len(keep), walCount, utils.FormatSize(reclaimed))
+	} else {
+		log.Info().Msgf(L("%[1]d base backups removed, %[2]d kept, %[3]d WAL files removed, %[4]s reclaimed"),
+			len(remove), len(keep), walCount, utils.FormatSize(reclaimed))
+	}
+	return hasError
+}
+
+// selectBaseBackups splits the base backups in the ones to keep and the ones to remove.
+// The backups are expected to be sorted from the newest to the oldest.
+//
+// The keepCount newest base backups are kept. If the window is not zero, the base backups
+// finished in the window are kept as well as the newest one before the window, needed to
+// recover to the beginning of the window.
+func selectBaseBackups(
+	backups []BaseBackup, keepCount int, window time.Duration, now time.Time,
+) (keep []BaseBackup, remove []BaseBackup) {
+	cutoff := now.Add(-window)
+	keptBeforeWindow := false
+	for i, backup := range backups {
+		switch {
+		case i < keepCount:
+			keep = append(keep, backup)
+		case window > 0 && backup.Time.After(cutoff):
+			keep = append(keep, backup)
+		case window > 0 && !keptBeforeWindow:
+			keep = append(keep, backup)
+		default:
+			remove = append(remove, backup)
+			continue
+		}
+		if !backup.Time.After(cutoff) {
+			keptBeforeWindow = true
+		}
+	}
+	return keep, remove
+}
+
+// isWALFileBefore returns true if the name is an archived WAL file older than the reference WAL file.
+//
+// Like pg_archivecleanup, the timeline is ignored in the comparison and the timeline history files are kept.
+func isWALFileBefore(name string, reference string) bool {
+	if !walFileRegexp.MatchString(name) || len(reference) != 24 {
+		return false
+	}
+	return name[8:24] < reference[8:24]
+}
+
+// parseDuration parses a duration, also accepting days like 7d.
+func parseDuration(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		if count, err := strconv.Atoi(days); err == nil && count > 0 {
+			return time.Duration(count) * 24 * time.Hour, nil
+		}
+	} else if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return duration, nil
+	}
+	return 0, fmt.Errorf(L("invalid duration %s: expected a value like 7d or 12h"), value)
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+func backupNames(backups []BaseBackup) []string {
+	names := []string{}
+	for _, backup := range backups {
+		names = append(names, backup.Name())
+	}
+	return names
+}
+
+func TestSelectBaseBackups(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	day := func(d int) BaseBackup {
+		return BaseBackup{Dir: NewBaseBackupName(time.Date(2026, time.March, d, 2, 0, 0, 0, time.UTC)),
+			Time: time.Date(2026, time.March, d, 2, 0, 0, 0, time.UTC)}
+	}
+	backups := []BaseBackup{day(9), day(7), day(5), day(3), day(1)}
+
+	keep, remove := selectBaseBackups(backups, 2, 0, now)
+	testutils.AssertEquals(t, "Unexpected kept backups", []string{"base-20260309-020000", "base-20260307-020000"},
+		backupNames(keep))
+	testutils.AssertEquals(t, "Unexpected removed backups", 3, len(remove))
+
+	// The backup before the window is needed to recover to the beginning of the window
+	keep, remove = selectBaseBackups(backups, 1, 4*24*time.Hour, now)
+	testutils.AssertEquals(t, "Unexpected kept backups in window",
+		[]string{"base-20260309-020000", "base-20260307-020000", "base-20260305-020000"}, backupNames(keep))
+	testutils.AssertEquals(t, "Unexpected removed backups in window",
+		[]string{"base-20260303-020000", "base-20260301-020000"}, backupNames(remove))
+}
+
+func TestIsWALFileBefore(t *testing.T) {
+	const reference = "000000020000000000000008"
+	testutils.AssertTrue(t, "Older segment should be removed", isWALFileBefore("000000010000000000000007", reference))
+	testutils.AssertTrue(t, "Older backup history should be removed",
+		isWALFileBefore("000000010000000000000002.00000028.backup", reference))
+	testutils.AssertTrue(t, "Same segment should be kept", !isWALFileBefore("000000010000000000000008", reference))
+	testutils.AssertTrue(t, "Newer segment should be kept", !isWALFileBefore("000000020000000000000009", reference))
+	testutils.AssertTrue(t, "Timeline history should be kept", !isWALFileBefore("00000002.history", reference))
+}
+
+func TestParseDuration(t *testing.T) {
+	window, err := parseDuration("7d")
+	testutils.AssertNoError(t, "failed to parse days", err)
+	testutils.AssertEquals(t, "Unexpected window", 7*24*time.Hour, window)
+
+	window, err = parseDuration("12h")
+	testutils.AssertNoError(t, "failed to parse hours", err)
+	testutils.AssertEquals(t, "Unexpected window", 12*time.Hour, window)
+
+	_, err = parseDuration("a week")
+	testutils.AssertError(t, "invalid duration", err)
+}
+
+func TestPrune(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	writeBaseBackup(t, root, "", "000000010000000000000002", now.AddDate(0, 0, -9))
+	writeBaseBackup(t, root, "base-20260305-020000", "000000010000000000000005", now.AddDate(0, 0, -5))
+	writeBaseBackup(t, root, "base-20260309-020000", "000000010000000000000009", now.AddDate(0, 0, -1))
+	wals := []string{
+		"000000010000000000000002", "000000010000000000000002.00000028.backup", "000000010000000000000004",
+		"000000010000000000000005", "000000010000000000000005.00000028.backup", "000000010000000000000009",
+	}
+	for _, wal := range wals {
+		testutils.WriteFile(t, path.Join(root, wal), "wal")
+	}
+
+	testutils.AssertNoError(t, "failed to simulate prune", prune(root, 2, 0, now, true))
+	testutils.AssertTrue(t, "Dry run should not remove anything", utils.FileExists(path.Join(root, baseBackupFile)))
+
+	testutils.AssertNoError(t, "failed to prune", prune(root, 2, 0, now, false))
+	testutils.AssertTrue(t, "Legacy base backup should be removed", !utils.FileExists(path.Join(root, baseBackupFile)))
+	for i, wal := range wals {
+		testutils.AssertEquals(t, "Unexpected WAL file presence: "+wal, i >= 3, utils.FileExists(path.Join(root, wal)))
+	}
+	backups, err := ListBaseBackups(root)
+	testutils.AssertNoError(t, "failed to list base backups", err)
+	testutils.AssertEquals(t, "Unexpected remaining base backups", 2, len(backups))
+}
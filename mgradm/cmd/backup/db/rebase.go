@@ -35,7 +35,7 @@ func Rebase() error {
 		return err
 	}
 
-	if err := RunBaseBackup(cnx); err != nil {
+	if err := RunBaseBackup(cnx, false); err != nil {
 		return err
 	}
 
@@ -46,5 +46,6 @@ func Rebase() error {
 		}
 	}
 
+	log.Info().Msg(L("New base backup created. Run backup db prune to remove the older ones"))
 	return nil
 }
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+)
+
+var lsnRegexp = regexp.MustCompile(`^[0-9A-Fa-f]{1,8}/[0-9A-Fa-f]{1,8}$`)
+
+// Time formats accepted for the recovery target time, interpreted in the local time zone if no offset is given.
+var targetTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+}
+
+// IsSet returns true if a recovery target has been defined.
+func (t *RecoveryTarget) IsSet() bool {
+	return t.Time != "" || t.LSN != "" || t.Name != ""
+}
+
+// ParseTime parses the target time.
+// Negative durations like -5m are relative to now.
+func (t *RecoveryTarget) ParseTime(now time.Time) (time.Time, error) {
+	if strings.HasPrefix(t.Time, "-") {
+		if duration, err := time.ParseDuration(t.Time); err == nil {
+			return now.Add(duration), nil
+		}
+	}
+	for _, layout := range targetTimeLayouts {
+		if value, err := time.ParseInLocation(layout, t.Time, time.Local); err == nil {
+			return value, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf(
+		L("invalid target time %s: expected a date like 2026-03-01 14:30:00 or a duration like -5m"), t.Time,
+	)
+}
+
+// config computes the postgresql recovery settings for the target.
+//
+// All the recovery_target_* settings are always written to reset the ones of a previous recovery.
+func (t *RecoveryTarget) config(now time.Time) (map[string]string, error) {
+	count := 0
+	for _, value := range []string{t.Time, t.LSN, t.Name} {
+		if value != "" {
+			count++
+		}
+	}
+	if count > 1 {
+		return nil, errors.New(L("only one of target-time, target-lsn and target-name can be used"))
+	}
+
+	config := map[string]string{
+		"recovery_target_time":   "''",
+		"recovery_target_lsn":    "''",
+		"recovery_target_name":   "''",
+		"recovery_target_action": "'promote'",
+	}
+	switch {
+	case t.Time != "":
+		targetTime, err := t.ParseTime(now)
+		if err != nil {
+			return nil, err
+		}
+		if targetTime.After(now) {
+			return nil, fmt.Errorf(L("target time %s is in the future"), targetTime.Format(time.DateTime))
+		}
+		config["recovery_target_time"] = quoteConfigValue(targetTime.Format("2006-01-02 15:04:05.999999-07:00"))
+	case t.LSN != "":
+		if !lsnRegexp.MatchString(t.LSN) {
+			return nil, fmt.Errorf(L("invalid target LSN %s: expected a value like 0/16B3748"), t.LSN)
+		}
+		config["recovery_target_lsn"] = quoteConfigValue(t.LSN)
+	case t.Name != "":
+		config["recovery_target_name"] = quoteConfigValue(t.Name)
+	}
+	return config, nil
+}
+
+// quoteConfigValue quotes a string value for postgresql.conf.
+func quoteConfigValue(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
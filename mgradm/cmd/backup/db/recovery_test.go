@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestRecoveryTargetConfig(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 14, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	config, err := (&RecoveryTarget{}).config(now)
+	testutils.AssertNoError(t, "failed to compute config without target", err)
+	testutils.AssertEquals(t, "Time target should be reset", "''", config["recovery_target_time"])
+	testutils.AssertEquals(t, "Name target should be reset", "''", config["recovery_target_name"])
+
+	config, err = (&RecoveryTarget{Time: "-5m"}).config(now)
+	testutils.AssertNoError(t, "failed to compute config with relative time", err)
+	testutils.AssertEquals(t, "Unexpected target time", "'2026-03-01 14:25:00+01:00'", config["recovery_target_time"])
+	testutils.AssertEquals(t, "Unexpected target action", "'promote'", config["recovery_target_action"])
+
+	config, err = (&RecoveryTarget{Time: "2026-03-01T10:00:00Z"}).config(now)
+	testutils.AssertNoError(t, "failed to compute config with absolute time", err)
+	testutils.AssertEquals(t, "Unexpected target time", "'2026-03-01 10:00:00+00:00'", config["recovery_target_time"])
+
+	config, err = (&RecoveryTarget{LSN: "0/16B3748"}).config(now)
+	testutils.AssertNoError(t, "failed to compute config with LSN", err)
+	testutils.AssertEquals(t, "Unexpected target LSN", "'0/16B3748'", config["recovery_target_lsn"])
+
+	config, err = (&RecoveryTarget{Name: "before'cleanup"}).config(now)
+	testutils.AssertNoError(t, "failed to compute config with name", err)
+	testutils.AssertEquals(t, "Unexpected target name", "'before''cleanup'", config["recovery_target_name"])
+}
+
+func TestRecoveryTargetConfigErrors(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 14, 30, 0, 0, time.UTC)
+
+	_, err := (&RecoveryTarget{Time: "-5m", Name: "before-cleanup"}).config(now)
+	testutils.AssertError(t, "only one of", err)
+
+	_, err = (&RecoveryTarget{Time: "yesterday"}).config(now)
+	testutils.AssertError(t, "invalid target time", err)
+
+	_, err = (&RecoveryTarget{Time: "2027-01-01T00:00:00Z"}).config(now)
+	testutils.AssertError(t, "is in the future", err)
+
+	_, err = (&RecoveryTarget{LSN: "16B3748"}).config(now)
+	testutils.AssertError(t, "invalid target LSN", err)
+}
@@ -21,10 +21,30 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-func Restore(force bool) error {
+func Restore(flags *Flagpole) error {
 	log.Info().Msg(L("Restoring DB backup"))
 
-	if !force {
+	// Compute the recovery settings first to fail before touching anything
+	now := time.Now()
+	recoveryConfig, err := flags.Target.config(now)
+	if err != nil {
+		return err
+	}
+	backupMountPoint, err := podman.GetVolumeMountPoint(nil, utils.VarPgsqlBackupVolumeMount.Name)
+	if err != nil {
+		return err
+	}
+	baseBackups, err := ListBaseBackups(backupMountPoint)
+	if err != nil {
+		return err
+	}
+	baseBackup, err := SelectBaseBackup(baseBackups, &flags.Target, now)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf(L("Using base backup %[1]s from %[2]s"), baseBackup.Name(), baseBackup.Time.Format(time.DateTime))
+
+	if !flags.Force {
 		if res, err := utils.YesNo(L("Restoring from backup is a destructive operation. Proceed")); err != nil || !res {
 			log.Info().Msg(L("Aborting"))
 			return nil
@@ -35,7 +55,7 @@ func Restore(force bool) error {
 		return err
 	}
 
-	image := podman.GetServiceImage(podman.DBService)
+	image := podman.GetServiceImage(nil, podman.DBService)
 	if image == "" {
 		return errors.New(L("failed to determine database image"))
 	}
@@ -48,7 +68,7 @@ func Restore(force bool) error {
 	// Actual data moving is in the restore script rendered and executed below
 	data := templates.RestorePostgresTemplateData{
 		Datadir:    utils.VarPgsqlDataVolumeMount.MountPath,
-		Basebackup: path.Join(utils.VarPgsqlBackupVolumeMount.MountPath, "base.tar.gz"),
+		Basebackup: path.Join(utils.VarPgsqlBackupVolumeMount.MountPath, baseBackup.Dir, baseBackupFile),
 	}
 
 	scriptBuilder := new(strings.Builder)
@@ -57,16 +77,14 @@ func Restore(force bool) error {
 	}
 
 	log.Info().Msg(L("Restoring base backup…"))
-	if err := podman.RunContainer("uyuni-restore", image, volumes, []string{},
+	if err := podman.RunContainer(nil, "uyuni-restore", image, volumes, []string{},
 		[]string{"bash", "-e", "-c", scriptBuilder.String()}); err != nil {
 		return err
 	}
 
-	// Modify postgresql.conf and set restore_command to RestoreCommand
-	updates := map[string]string{
-		"restore_command": RestoreCommand(),
-	}
-	if err := UpdatePostgresConfig(updates); err != nil {
+	// Modify postgresql.conf and set restore_command to RestoreCommand and the recovery target
+	recoveryConfig["restore_command"] = RestoreCommand()
+	if err := UpdatePostgresConfig(recoveryConfig); err != nil {
 		return err
 	}
 
@@ -77,7 +95,7 @@ func Restore(force bool) error {
 
 	log.Info().Msg(L("Base backup restore complete. Database is recovering."))
 
-	mountPoint, err := podman.GetVolumeMountPoint(utils.VarPgsqlDataVolumeMount.Name)
+	mountPoint, err := podman.GetVolumeMountPoint(nil, utils.VarPgsqlDataVolumeMount.Name)
 	if err != nil {
 		return err
 	}
@@ -85,7 +103,7 @@ func Restore(force bool) error {
 
 	if _, err := os.Stat(recoverySignalPath); err != nil {
 		if os.IsNotExist(err) {
-			log.Info().Msg(L("Database is restored."))
+			restoreDone(&flags.Target)
 			return nil
 		}
 		return utils.Error(err, L("failed to check database recovery status"))
@@ -98,11 +116,23 @@ func Restore(force bool) error {
 		if _, err := os.Stat(recoverySignalPath); err != nil {
 			s.Stop()
 			if os.IsNotExist(err) {
-				log.Info().Msg(L("Database is restored."))
+				restoreDone(&flags.Target)
 				return nil
 			}
 			return utils.Error(err, L("error while waiting for database recovery to complete, check database logs"))
 		}
+		// The database stops if the recovery target cannot be reached
+		if !systemd.IsServiceRunning(podman.DBService) {
+			s.Stop()
+			return errors.New(L("database stopped during the recovery, check database logs"))
+		}
 		time.Sleep(2 * time.Second)
 	}
 }
+
+func restoreDone(target *RecoveryTarget) {
+	log.Info().Msg(L("Database is restored."))
+	if target.IsSet() {
+		log.Info().Msg(L("The database is now on a new timeline: run backup db rebase to create a fresh base backup"))
+	}
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/uyuni-project/uyuni-tools/shared"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+)
+
+// CreateRestorePoint creates a named restore point to use as recovery target.
+func CreateRestorePoint(name string) error {
+	if name == "" {
+		return errors.New(L("restore point name cannot be empty"))
+	}
+
+	cnx := shared.NewUserConnection("podman", podman.DBContainerName, "", podman.DBRuntimeUser)
+	if _, err := cnx.GetPodName(); err != nil {
+		return errors.New(L("the database needs to be running to create a restore point"))
+	}
+	if err := CheckStatus(); err != nil {
+		return fmt.Errorf(L("database backup is not correctly configured: %w"), err)
+	}
+
+	query := fmt.Sprintf("SELECT pg_create_restore_point(%s);", quoteConfigValue(name))
+	out, err := cnx.Exec("/usr/bin/psql", "-U", "postgres", "-tAc", query)
+	if err != nil {
+		return fmt.Errorf(L("failed to create restore point %[1]s: %[2]w"), name, err)
+	}
+
+	// Switch to a new WAL file to archive the restore point right away
+	if _, err := cnx.Exec("/usr/bin/psql", "-U", "postgres", "-tAc", "SELECT pg_switch_wal();"); err != nil {
+		log.Warn().Err(err).Msg(L("failed to archive the current write-ahead log file"))
+	}
+
+	log.Info().Msgf(L("Restore point %[1]s created at %[2]s"), name, strings.TrimSpace(string(out)))
+	return nil
+}
@@ -17,7 +17,7 @@ import (
 )
 
 func getPostgresConfigPath() (string, error) {
-	mountPoint, err := podman.GetVolumeMountPoint(utils.VarPgsqlDataVolumeMount.Name)
+	mountPoint, err := podman.GetVolumeMountPoint(nil, utils.VarPgsqlDataVolumeMount.Name)
 	if err != nil {
 		return "", err
 	}
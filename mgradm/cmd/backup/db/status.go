@@ -5,10 +5,12 @@
 package db
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -20,12 +22,30 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-func Status() error {
-	log.Info().Msg(L("Checking DB backup status"))
+func Status(flags *Flagpole) error {
+	if flags.Output != "" && flags.Output != "text" && flags.Output != "json" {
+		return fmt.Errorf(L("unsupported output format %s: possible values are text and json"), flags.Output)
+	}
+	thresholds := Thresholds{}
+	var err error
+	if flags.Max.Archive.Lag != "" {
+		if thresholds.ArchiveLag, err = parseDuration(flags.Max.Archive.Lag); err != nil {
+			return err
+		}
+	}
+	if flags.Max.Base.Backup.Age != "" {
+		if thresholds.BaseBackupAge, err = parseDuration(flags.Max.Base.Backup.Age); err != nil {
+			return err
+		}
+	}
+	jsonOutput := flags.Output == "json"
+	if !jsonOutput {
+		log.Info().Msg(L("Checking DB backup status"))
+	}
 
 	status := "enabled"
 	var result error
-	err := CheckStatus()
+	err = CheckStatus()
 	if err != nil {
 		if errors.Is(err, ErrArchiveModeOff) {
 			status = "disabled"
@@ -36,16 +56,76 @@ func Status() error {
 			result = err
 		}
 	}
-	if zerolog.GlobalLevel() < zerolog.WarnLevel {
+
+	metrics := Metrics{Status: status}
+	now := time.Now()
+	cnx := shared.NewUserConnection("podman", podman.DBContainerName, "", podman.DBRuntimeUser)
+	if _, err := cnx.GetPodName(); err == nil {
+		if err := collectArchiverMetrics(cnx, &metrics, now); err != nil {
+			log.Warn().Err(err).Msg(L("failed to get the archiver statistics"))
+		}
+	}
+	if podman.IsVolumePresent(nil, utils.VarPgsqlBackupVolumeMount.Name) {
+		if mountPoint, err := podman.GetVolumeMountPoint(nil, utils.VarPgsqlBackupVolumeMount.Name); err == nil {
+			if err := collectVolumeMetrics(mountPoint, &metrics, now); err != nil {
+				log.Warn().Err(err).Msg(L("failed to inspect the backup volume"))
+			}
+		}
+	}
+	thresholds.check(&metrics)
+
+	if jsonOutput {
+		out, err := json.MarshalIndent(metrics, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else if zerolog.GlobalLevel() < zerolog.WarnLevel {
 		log.Info().Err(err).Msgf(L("Database continuous backup is %[1]s. Backup volume is %[2]s"), status,
 			utils.VarPgsqlBackupVolumeMount.Name)
+		logMetrics(&metrics)
 	} else {
 		// Assuming this is called by a script when logLevel is higher then info
 		fmt.Println(status)
 	}
+
+	if result == nil && len(metrics.Alerts) > 0 {
+		if !jsonOutput {
+			for _, alert := range metrics.Alerts {
+				log.Error().Msg(alert)
+			}
+		}
+		result = ErrThresholdExceeded
+	}
 	return result
 }
 
+// logMetrics shows the metrics in a human readable way.
+func logMetrics(metrics *Metrics) {
+	formatTime := func(value *time.Time) string {
+		if value == nil {
+			return L("never")
+		}
+		return value.Local().Format(time.DateTime)
+	}
+	if metrics.CurrentWAL != "" {
+		log.Info().Msgf(L("Last archived WAL: %[1]s at %[2]s, %[3]d archived"),
+			metrics.LastArchivedWAL, formatTime(metrics.LastArchivedTime), metrics.ArchivedCount)
+		log.Info().Msgf(L("Last failed WAL: %[1]s at %[2]s, %[3]d failures"),
+			metrics.LastFailedWAL, formatTime(metrics.LastFailedTime), metrics.FailedCount)
+		log.Info().Msgf(L("WAL files waiting to be archived: %[1]d, archive lag: %[2]s"),
+			metrics.PendingWALCount, time.Duration(metrics.ArchiveLagSeconds)*time.Second)
+	}
+	if metrics.BaseBackupTime != nil {
+		log.Info().Msgf(L("Latest base backup: %[1]s, %[2]s, %[3]d base backups"),
+			formatTime(metrics.BaseBackupTime), utils.FormatSize(metrics.BaseBackupSize), metrics.BaseBackupCount)
+	}
+	if metrics.VolumeSize > 0 {
+		log.Info().Msgf(L("Backup volume free space: %[1]s of %[2]s"),
+			utils.FormatSize(int64(metrics.VolumeFreeSpace)), utils.FormatSize(int64(metrics.VolumeSize)))
+	}
+}
+
 // Check status of the database wal backup.
 // Reports nil if backup is enabled and correctly configured. Otherwise reports an error.
 func CheckStatus() error {
@@ -112,7 +192,7 @@ func CheckBackupMountPresence() error {
 		return err
 	}
 
-	if !podman.IsVolumePresent(utils.VarPgsqlBackupVolumeMount.Name) {
+	if !podman.IsVolumePresent(nil, utils.VarPgsqlBackupVolumeMount.Name) {
 		log.Debug().Msg("backup volume not mounted")
 		return ErrArchiveMountMisconfigured
 	}
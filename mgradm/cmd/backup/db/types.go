@@ -18,6 +18,37 @@ type Flagpole struct {
 	Purge struct {
 		Volume bool `mapstructure:"volume"`
 	} `mapstructure:"purge"`
+	Target RecoveryTarget `mapstructure:"target"`
+	Keep   struct {
+		Base struct {
+			Backups int `mapstructure:"backups"`
+		} `mapstructure:"base"`
+		Within string `mapstructure:"within"`
+	} `mapstructure:"keep"`
+	DryRun bool   `mapstructure:"dryrun"`
+	Output string `mapstructure:"output"`
+	Max    struct {
+		Archive struct {
+			Lag string `mapstructure:"lag"`
+		} `mapstructure:"archive"`
+		Base struct {
+			Backup struct {
+				Age string `mapstructure:"age"`
+			} `mapstructure:"backup"`
+		} `mapstructure:"base"`
+	} `mapstructure:"max"`
+	Timeout string `mapstructure:"timeout"`
+	DB      struct {
+		Name string `mapstructure:"name"`
+	} `mapstructure:"db"`
+}
+
+// RecoveryTarget defines the point in time to recover the database to.
+// When none of the values are set, the database is recovered to the latest archived state.
+type RecoveryTarget struct {
+	Time string `mapstructure:"time"`
+	LSN  string `mapstructure:"lsn"`
+	Name string `mapstructure:"name"`
 }
 
 var (
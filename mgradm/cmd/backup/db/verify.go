@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/rs/zerolog/log"
+
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/templates"
+	"github.com/uyuni-project/uyuni-tools/shared"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+const verifyPrefix = "uyuni-db-verify"
+
+// schemaVersionQuery returns the version of the Uyuni schema.
+const schemaVersionQuery = `SELECT pn.name || '-' || evr.version || '-' || evr.release
+	FROM rhnVersionInfo vi
+	JOIN rhnPackageName pn ON pn.id = vi.name_id
+	JOIN rhnPackageEVR evr ON evr.id = vi.evr_id
+	WHERE vi.label = 'schema';`
+
+// verifiedTables are the key tables counted to check the restored database content.
+var verifiedTables = []string{"web_customer", "web_contact", "rhnServer", "rhnChannel", "rhnPackage", "rhnErrata"}
+
+// Verify restores the latest base backup and the archived WAL files in a throwaway database
+// and runs sanity queries on it.
+func Verify(flags *Flagpole) error {
+	timeout := time.Hour
+	if flags.Timeout != "" {
+		var err error
+		if timeout, err = parseDuration(flags.Timeout); err != nil {
+			return err
+		}
+	}
+	dbName := flags.DB.Name
+	if dbName == "" {
+		dbName = "susemanager"
+	}
+
+	backupMountPoint, err := podman.GetVolumeMountPoint(nil, utils.VarPgsqlBackupVolumeMount.Name)
+	if err != nil {
+		return err
+	}
+	baseBackups, err := ListBaseBackups(backupMountPoint)
+	if err != nil {
+		return err
+	}
+	baseBackup, err := SelectBaseBackup(baseBackups, &RecoveryTarget{}, time.Now())
+	if err != nil {
+		return err
+	}
+
+	image := podman.GetServiceImage(nil, podman.DBService)
+	if image == "" {
+		return errors.New(L("failed to determine database image"))
+	}
+
+	suffix := time.Now().Format("20060102150405")
+	name := verifyPrefix + "-" + suffix
+	dataVolume := types.VolumeMount{Name: name, MountPath: utils.VarPgsqlDataVolumeMount.MountPath}
+	defer tearDownVerify(name)
+
+	// The throwaway database must not reach the other containers nor the outside world
+	if err := utils.RunCmd("podman", "network", "create", "--internal", name); err != nil {
+		return utils.Errorf(err, L("failed to create %s network"), name)
+	}
+	if err := utils.RunCmd("podman", "volume", "create", name); err != nil {
+		return utils.Errorf(err, L("failed to create %s volume"), name)
+	}
+	// The backup volume is only read
+	backupVolumeArg := fmt.Sprintf("%s:%s:ro", utils.VarPgsqlBackupVolumeMount.Name,
+		utils.VarPgsqlBackupVolumeMount.MountPath)
+	extraArgs := []string{"--network", name, "-v", backupVolumeArg}
+
+	restoreData := templates.RestorePostgresTemplateData{
+		Datadir:    dataVolume.MountPath,
+		Basebackup: path.Join(utils.VarPgsqlBackupVolumeMount.MountPath, baseBackup.Dir, baseBackupFile),
+	}
+	verifyData := templates.VerifyPostgresTemplateData{
+		Datadir:        dataVolume.MountPath,
+		RestoreCommand: RestoreCommand(),
+	}
+	scriptBuilder := new(strings.Builder)
+	if err := restoreData.Render(scriptBuilder); err != nil {
+		return utils.Error(err, L("failed to generate postgresql restore script"))
+	}
+	if err := verifyData.Render(scriptBuilder); err != nil {
+		return utils.Error(err, L("failed to generate postgresql restore script"))
+	}
+
+	log.Info().Msgf(L("Restoring base backup %s in a throwaway database…"), baseBackup.Name())
+	if err := podman.RunContainer(nil, name+"-restore", image, []types.VolumeMount{dataVolume}, extraArgs,
+		[]string{"bash", "-e", "-c", scriptBuilder.String()}); err != nil {
+		return err
+	}
+
+	log.Info().Msg(L("Starting the throwaway database…"))
+	startArgs := append([]string{"-d"}, extraArgs...)
+	startCommand := fmt.Sprintf("postgres -D %s", dataVolume.MountPath)
+	if err := podman.RunContainer(nil, name, image, []types.VolumeMount{dataVolume}, startArgs,
+		[]string{"su", "-", podman.DBRuntimeUser, "-c", startCommand}); err != nil {
+		return err
+	}
+
+	if err := waitForRecovery(name, timeout); err != nil {
+		return err
+	}
+	return checkRestoredDatabase(shared.NewUserConnection("podman", name, "", podman.DBRuntimeUser), dbName)
+}
+
+// waitForRecovery waits for the throwaway database to replay all the archived WAL files.
+func waitForRecovery(name string, timeout time.Duration) error {
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	s.Suffix = L(" Replaying the archived WAL files…")
+	s.Start()
+	defer s.Stop()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		cnx := shared.NewUserConnection("podman", name, "", podman.DBRuntimeUser)
+		if _, err := cnx.GetPodName(); err != nil {
+			return errors.New(L("the throwaway database stopped during the recovery: the backup cannot be restored"))
+		}
+		// Connections are refused until the database is consistent
+		out, err := cnx.Exec("/usr/bin/psql", "-U", "postgres", "-tAc", "SELECT pg_is_in_recovery();")
+		if err == nil && strings.TrimSpace(string(out)) == "f" {
+			return nil
+		}
+		time.Sleep(5 * time.Second)
+	}
+	return fmt.Errorf(L("the throwaway database recovery did not finish in %s"), timeout)
+}
+
+// checkRestoredDatabase runs the sanity queries on the restored database.
+func checkRestoredDatabase(cnx *shared.Connection, dbName string) error {
+	out, err := cnx.Exec("/usr/bin/psql", "-U", "postgres", "-d", dbName, "-tAc", schemaVersionQuery)
+	if err != nil {
+		return utils.Errorf(err, L("failed to read the schema version of the restored %s database"), dbName)
+	}
+	schema := strings.TrimSpace(string(out))
+	if schema == "" {
+		return fmt.Errorf(L("no schema version found in the restored %s database"), dbName)
+	}
+	log.Info().Msgf(L("Restored schema version: %s"), schema)
+
+	for _, table := range verifiedTables {
+		out, err := cnx.Exec("/usr/bin/psql", "-U", "postgres", "-d", dbName, "-tAc",
+			fmt.Sprintf("SELECT count(*) FROM %s;", table))
+		if err != nil {
+			return utils.Errorf(err, L("failed to count the rows of the restored %s table"), table)
+		}
+		log.Info().Msgf(L("Restored %[1]s table: %[2]s rows"), table, strings.TrimSpace(string(out)))
+	}
+	log.Info().Msg(L("Database backup successfully restored in the throwaway database"))
+	return nil
+}
+
+// tearDownVerify removes the throwaway database container, volume and network.
+func tearDownVerify(name string) {
+	log.Info().Msg(L("Removing the throwaway database…"))
+	for _, command := range [][]string{
+		{"rm", "--force", "--ignore", name},
+		{"volume", "rm", "--force", name},
+		{"network", "rm", "--force", name},
+	} {
+		if err := utils.RunCmd("podman", command...); err != nil {
+			log.Warn().Err(err).Msgf(L("failed to run podman %s"), strings.Join(command, " "))
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+)
+
+func TestCheckRestoredDatabase(t *testing.T) {
+	defer func() { shared.ResetRunner() }()
+
+	// The connection looks for podman in the PATH
+	binDir := t.TempDir()
+	testutils.WriteFile(t, path.Join(binDir, "podman"), "#!/bin/sh\n")
+	if err := os.Chmod(path.Join(binDir, "podman"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+
+	schema := "susemanager-schema-5.1.0-1"
+	queries := []string{}
+	shared.SetRunner(func(command string, args ...string) types.Runner {
+		if command == "podman" && len(args) > 0 {
+			switch args[0] {
+			case "ps":
+				return testutils.FakeRunnerGenerator("uyuni-db-verify", nil)(command, args...)
+			case "exec":
+				query := args[len(args)-1]
+				queries = append(queries, query)
+				if strings.Contains(query, "rhnVersionInfo") {
+					return testutils.FakeRunnerGenerator(schema, nil)(command, args...)
+				}
+				return testutils.FakeRunnerGenerator("12\n", nil)(command, args...)
+			}
+		}
+		return testutils.FakeRunnerGenerator("", nil)(command, args...)
+	})
+
+	cnx := shared.NewUserConnection("podman", "uyuni-db-verify", "", podman.DBRuntimeUser)
+	testutils.AssertNoError(t, "failed to check restored database", checkRestoredDatabase(cnx, "susemanager"))
+	testutils.AssertEquals(t, "Unexpected number of queries", len(verifiedTables)+1, len(queries))
+
+	schema = ""
+	cnx = shared.NewUserConnection("podman", "uyuni-db-verify", "", podman.DBRuntimeUser)
+	err := checkRestoredDatabase(cnx, "susemanager")
+	testutils.AssertEquals(t, "Unexpected empty schema error",
+		"no schema version found in the restored susemanager database", err.Error())
+}
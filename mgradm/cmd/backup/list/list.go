@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package list
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Flagpole holds the flags of the backup list command.
+type Flagpole struct {
+	Target backup.TargetFlags `mapstructure:",squash"`
+}
+
+// List prints the backup sets of a backups repository.
+func List(
+	_ *types.GlobalFlags,
+	flags *Flagpole,
+	_ *cobra.Command,
+	args []string,
+) error {
+	repository := args[0]
+	target, err := backup.NewTarget(repository, &flags.Target)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+	sets, err := shared.ListBackupSets(target)
+	if err != nil {
+		return utils.Errorf(err, L("failed to list backups in %s"), repository)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, L("NAME\tCREATED\tSIZE\tSERVER VERSION\tSTATUS"))
+	for _, set := range sets {
+		version := ""
+		if set.Manifest != nil {
+			version = set.Manifest.ServerVersion()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", set.Name, set.Time.Local().Format("2006-01-02 15:04:05"),
+			utils.FormatSize(set.Size), version, set.Status())
+	}
+	return w.Flush()
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Flagpole holds the flags of the backup prune command.
+type Flagpole struct {
+	Keep   shared.RetentionPolicy `mapstructure:"keep"`
+	DryRun bool                   `mapstructure:"dryrun"`
+	Target backup.TargetFlags     `mapstructure:",squash"`
+}
+
+// Prune removes the backup sets of a backups repository not matching the retention policy.
+func Prune(
+	_ *types.GlobalFlags,
+	flags *Flagpole,
+	_ *cobra.Command,
+	args []string,
+) error {
+	repository := args[0]
+	if flags.Keep.IsEmpty() {
+		return errors.New(L("at least one of the keep-daily, keep-weekly or keep-monthly parameters is required"))
+	}
+
+	target, err := backup.NewTarget(repository, &flags.Target)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+	sets, err := shared.ListBackupSets(target)
+	if err != nil {
+		return utils.Errorf(err, L("failed to list backups in %s"), repository)
+	}
+
+	keep, remove := flags.Keep.Select(sets)
+	for _, set := range keep {
+		log.Debug().Msgf("Keeping %s backup %s", set.Status(), set.Name)
+	}
+
+	var reclaimed int64
+	var hasError error
+	for _, set := range remove {
+		if flags.DryRun {
+			log.Info().Msgf(L("Would remove %[1]s backup %[2]s"), set.Status(), set.Name)
+			reclaimed += set.Size
+			continue
+		}
+		log.Info().Msgf(L("Removing %[1]s backup %[2]s"), set.Status(), set.Name)
+		if err := target.RemoveAll(set.Name); err != nil {
+			hasError = utils.JoinErrors(hasError, utils.Errorf(err, L("failed to remove %s"), set.Target))
+			continue
+		}
+		reclaimed += set.Size
+	}
+
+	log.Info().Msgf(L("%[1]d backups kept, %[2]d removed, %[3]s reclaimed"),
+		len(keep), len(remove), utils.FormatSize(reclaimed))
+	return hasError
+}
@@ -6,28 +6,38 @@ package restore
 
 import (
 	"archive/tar"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
-	"os"
+	"net"
 
 	"github.com/rs/zerolog/log"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
 	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
 	"github.com/uyuni-project/uyuni-tools/shared/podman"
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-func restorePodmanConfiguration(podmanBackupFile string, flags *shared.Flagpole) error {
+// hostAddrs and hostInterface are function pointers to replace the host network in unit tests.
+var hostAddrs = net.InterfaceAddrs
+var hostInterface = net.InterfaceByName
+
+func restorePodmanConfiguration(
+	target backup.Target,
+	podmanBackupFile string,
+	flags *shared.Flagpole,
+) (hasError error) {
 	// Read tarball
-	backupFile, err := os.Open(podmanBackupFile)
+	backupFile, err := backup.OpenArtifact(target, podmanBackupFile, &flags.Artifacts, "")
 	if err != nil {
 		return err
 	}
-	defer backupFile.Close()
 
-	var hasError error
+	defer func() {
+		hasError = utils.JoinErrors(hasError, backupFile.Close())
+	}()
 
 	tr := tar.NewReader(backupFile)
 	for {
@@ -39,9 +49,9 @@ func restorePodmanConfiguration(podmanBackupFile string, flags *shared.Flagpole)
 			return err
 		}
 		switch header.Name {
-		case shared.NetworkOutputFile:
+		case backup.NetworkOutputFile:
 			hasError = utils.JoinErrors(hasError, restorePodmanNetwork(header, tr, flags))
-		case shared.SecretBackupFile:
+		case backup.SecretBackupFile:
 			hasError = utils.JoinErrors(hasError, restorePodmanSecrets(header, tr, flags))
 		default:
 			log.Warn().Msgf(L("Ignoring unexpected file in the podman backup %s"), header.Name)
@@ -89,14 +99,14 @@ func parseNetworkData(data []byte) (networkDetails shared.PodmanNetworkConfigDat
 }
 
 func defaultPodmanNetwork(flags *shared.Flagpole) error {
-	if podman.IsNetworkPresent(podman.UyuniNetwork) {
+	if podman.IsNetworkPresent(nil, podman.UyuniNetwork) {
 		if flags.ForceRestore {
-			podman.DeleteNetwork(false)
+			podman.DeleteNetwork(nil, false)
 		} else {
 			return errors.New(L("podman network already exists"))
 		}
 	}
-	if err := podman.SetupNetwork(false); err != nil {
+	if err := podman.SetupNetwork(nil, false); err != nil {
 		log.Error().
 			Msg(L("Unable to create podman network! Check the error and create network manually before starting the service"))
 		return err
@@ -104,13 +114,13 @@ func defaultPodmanNetwork(flags *shared.Flagpole) error {
 	return nil
 }
 
-func restorePodmanNetwork(header *tar.Header, tr *tar.Reader, flags *shared.Flagpole) error {
+func restorePodmanNetwork(_ *tar.Header, tr *tar.Reader, flags *shared.Flagpole) error {
 	if flags.DryRun {
 		log.Info().Msgf(L("Would restore network configuration"))
 		return nil
 	}
-	data := make([]byte, header.Size)
-	if _, err := tr.Read(data); err != io.EOF {
+	data, err := io.ReadAll(tr)
+	if err != nil {
 		log.Warn().Msg(L("Failed to read backed up network configuration, trying default"))
 		return utils.JoinErrors(err, defaultPodmanNetwork(flags))
 	}
@@ -121,19 +131,32 @@ func restorePodmanNetwork(header *tar.Header, tr *tar.Reader, flags *shared.Flag
 		return utils.JoinErrors(err, defaultPodmanNetwork(flags))
 	}
 
-	if podman.IsNetworkPresent(podman.UyuniNetwork) {
+	if podman.IsNetworkPresent(nil, podman.UyuniNetwork) {
 		if flags.ForceRestore {
-			podman.DeleteNetwork(false)
+			podman.DeleteNetwork(nil, false)
 		} else {
 			log.Warn().Msg(L("Podman network already exists, not restoring unless forced"))
 			return errors.New(L("podman network already exists"))
 		}
 	}
 
+	// The network of the previous host may conflict with the interfaces of the new one
+	if flags.FQDN != "" {
+		if err := checkNetworkOnHost(networkDetails); err != nil {
+			log.Warn().Err(err).Msg(L("Not restoring the podman network, creating a new one for this host"))
+			return podman.SetupNetwork(nil, false)
+		}
+	}
+
 	command := []string{"podman", "network", "create", "--interface-name", networkDetails.NetworkInsterface}
 	for _, v := range networkDetails.Subnets {
 		command = append(command, "--subnet", v.Subnet, "--gateway", v.Gateway)
 	}
+	// The DNS servers of the previous host may not be reachable from the new one
+	if flags.FQDN != "" && len(networkDetails.NetworkDNSServers) > 0 {
+		log.Info().Msg(L("Not restoring the DNS servers of the podman network when restoring with a new FQDN"))
+		networkDetails.NetworkDNSServers = nil
+	}
 	for _, v := range networkDetails.NetworkDNSServers {
 		command = append(command, "--dns", v)
 	}
@@ -146,62 +169,84 @@ func restorePodmanNetwork(header *tar.Header, tr *tar.Reader, flags *shared.Flag
 	return nil
 }
 
-func parseSecretsData(data []byte) ([]shared.BackupSecretMap, error) {
-	secrets := []shared.BackupSecretMap{}
-	if err := json.Unmarshal(data, &secrets); err != nil {
-		log.Warn().Err(err).Msg(L("Unable to decode podman secrets"))
+func restorePodmanSecrets(_ *tar.Header, tr *tar.Reader, flags *shared.Flagpole) error {
+	if flags.DryRun {
+		log.Info().Msgf(L("Would restore podman secrets"))
+		return nil
+	}
+
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		log.Warn().Msg(L("Failed to read backed up podman secrets, no secrets were restored"))
+		return err
+	}
+	return backup.RestorePodmanSecrets(data, flags.ForceRestore)
+}
+
+// readBackupSecrets returns the podman secrets of the backup indexed by their name.
+func readBackupSecrets(target backup.Target, flags *shared.Flagpole) (secrets map[string]string, err error) {
+	secrets = map[string]string{}
+	podmanConfigFile := backup.FindArtifact(target, "", backup.PodmanConfBackupFile)
+	if podmanConfigFile == "" {
+		return secrets, nil
+	}
+	backupFile, err := backup.OpenArtifact(target, podmanConfigFile, &flags.Artifacts, "")
+	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		err = utils.JoinErrors(err, backupFile.Close())
+	}()
 
-	decodedSecrets := make([]shared.BackupSecretMap, len(secrets))
-	for i, v := range secrets {
-		decoded, err := base64.StdEncoding.DecodeString(v.Secret)
+	tr := tar.NewReader(backupFile)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return secrets, nil
+		}
 		if err != nil {
-			log.Warn().Msgf(L("Unable to decode secret %s, using as is"), v.Name)
-		} else {
-			decodedSecrets[i] = shared.BackupSecretMap{
-				Name:   v.Name,
-				Secret: string(decoded[:]),
-			}
+			return nil, err
+		}
+		if header.Name != backup.SecretBackupFile {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		values, err := backup.ParseSecretsData(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range values {
+			secrets[secret.Name] = secret.Secret
 		}
 	}
-	return decodedSecrets, nil
 }
 
-func restorePodmanSecrets(header *tar.Header, tr *tar.Reader, flags *shared.Flagpole) error {
-	if flags.DryRun {
-		log.Info().Msgf(L("Would restore podman secrets"))
-		return nil
+// checkNetworkOnHost returns an error if the backed up podman network conflicts with the host network.
+func checkNetworkOnHost(networkDetails shared.PodmanNetworkConfigData) error {
+	if _, err := hostInterface(networkDetails.NetworkInsterface); err == nil {
+		return fmt.Errorf(L("the %s network interface already exists"), networkDetails.NetworkInsterface)
 	}
-
-	data := make([]byte, header.Size)
-	if _, err := tr.Read(data); err != io.EOF {
-		log.Warn().Msg(L("Failed to read backed up podman secrets, no secrets were restored"))
-		return err
-	}
-	secrets, err := parseSecretsData(data)
+	addrs, err := hostAddrs()
 	if err != nil {
-		log.Warn().Msg(L("Failed to decode backed up podman secrets, no secrets were restored"))
 		return err
 	}
-
-	var hasError error
-	log.Info().Msg(L("Restoring podman secrets"))
-	baseCommand := []string{"podman", "secret", "create"}
-	for _, v := range secrets {
-		if podman.IsSecretPresent(v.Name) {
-			if flags.ForceRestore {
-				baseCommand = append(baseCommand, "--replace")
-			} else {
-				log.Error().Msgf(L("Podman secret %s is already present, not restoring unless forced"), v.Name)
+	for _, subnet := range networkDetails.Subnets {
+		_, network, err := net.ParseCIDR(subnet.Subnet)
+		if err != nil {
+			return utils.Errorf(err, L("invalid subnet %s"), subnet.Subnet)
+		}
+		for _, addr := range addrs {
+			hostNetwork, ok := addr.(*net.IPNet)
+			if !ok {
 				continue
 			}
-		}
-		command := append(baseCommand, v.Name, "-")
-		if err := runCmdInput(command[0], v.Secret, command[1:]...); err != nil {
-			log.Error().Msg(L("Unable to create podman secret"))
-			hasError = utils.JoinErrors(hasError, err)
+			if network.Contains(hostNetwork.IP) || hostNetwork.Contains(network.IP) {
+				return fmt.Errorf(L("the %[1]s subnet overlaps the %[2]s host network"), subnet.Subnet, hostNetwork)
+			}
 		}
 	}
-	return hasError
+	return nil
 }
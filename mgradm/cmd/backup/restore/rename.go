@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package restore
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
+	adm_podman "github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/ssl"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// checkRename verifies that the restored server can be renamed, before anything is overwritten.
+//
+// The backed up certificates are reused if they match the new FQDN.
+// Otherwise the provided third-party certificates are validated,
+// or the CA password is required to regenerate the certificates with the backed up CA.
+func checkRename(target backup.Target, flags *shared.Flagpole) error {
+	if err := adm_podman.CheckThirdPartyCertificates(&flags.SSL, flags.FQDN); err != nil {
+		return utils.Errorf(err, L("the provided certificates cannot be used for %s"), flags.FQDN)
+	}
+
+	secrets, err := readBackupSecrets(target, flags)
+	if err != nil {
+		return utils.Error(err, L("failed to read the certificates of the backup"))
+	}
+	serverReady := flags.SSL.UseProvided() ||
+		certificateMatches(secrets[podman.CASecret], secrets[podman.SSLCertSecret], flags.FQDN)
+	dbReady := flags.SSL.UseProvidedDB() ||
+		certificateMatches(secrets[podman.DBCASecret], secrets[podman.DBSSLCertSecret], flags.FQDN)
+	if serverReady && dbReady {
+		return nil
+	}
+	if flags.SSL.Password == "" {
+		return fmt.Errorf(L("the certificates of the backup are not valid for %s: "+
+			"provide third-party certificates or the CA password with --ssl-password to regenerate them"), flags.FQDN)
+	}
+	log.Info().Msgf(L("The certificates will be regenerated for %s"), flags.FQDN)
+	return nil
+}
+
+// certificateMatches returns true if the certificate is issued by the CA for the FQDN.
+func certificateMatches(caCert string, cert string, fqdn string) bool {
+	if caCert == "" || cert == "" {
+		return false
+	}
+	tempDir, cleaner, err := utils.TempDir()
+	if err != nil {
+		log.Error().Err(err).Send()
+		return false
+	}
+	defer cleaner()
+
+	caPath := path.Join(tempDir, "ca.crt")
+	certPath := path.Join(tempDir, "server.crt")
+	if err := utils.JoinErrors(
+		os.WriteFile(caPath, []byte(caCert), 0600), os.WriteFile(certPath, []byte(cert), 0600),
+	); err != nil {
+		log.Error().Err(err).Send()
+		return false
+	}
+	if err := ssl.VerifyHostname(caPath, certPath, fqdn); err != nil {
+		log.Debug().Msgf("SSL verification error: %s", err)
+		return false
+	}
+	return true
+}
+
+// renameServer changes the FQDN of the restored server, before it is started.
+//
+// The SSL certificates are reused if they match the new name or regenerated,
+// then the new name is set in the server configuration.
+// Starting the server completes the rename inside the container.
+func renameServer(flags *shared.Flagpole) error {
+	if flags.DryRun {
+		log.Info().Msgf(L("Would rename the restored server to %s"), flags.FQDN)
+		return nil
+	}
+
+	err := adm_podman.PrepareServerRename(nil, &flags.SSL, flags.FQDN)
+	if err != nil {
+		err = utils.Errorf(err, L("failed to prepare the SSL certificates for %s"), flags.FQDN)
+	} else if err = adm_podman.SetServerHostname(systemd, flags.FQDN); err != nil {
+		err = utils.Errorf(err, L("failed to rename the restored server to %s"), flags.FQDN)
+	}
+	if err != nil {
+		return utils.Errorf(err, L("the restored server still has its previous name, "+
+			"run mgradm server rename %s once the problem is fixed"), flags.FQDN)
+	}
+	log.Info().Msgf(L("Restored server renamed to %s, the rename completes when starting the server"), flags.FQDN)
+	return nil
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package restore
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestCheckNetworkOnHost(t *testing.T) {
+	defer func() {
+		hostAddrs = net.InterfaceAddrs
+		hostInterface = net.InterfaceByName
+	}()
+	hostAddrs = func() ([]net.Addr, error) {
+		_, network, _ := net.ParseCIDR("10.89.0.0/16")
+		return []net.Addr{
+			&net.IPNet{IP: net.ParseIP("192.168.1.10"), Mask: net.CIDRMask(24, 32)},
+			&net.IPNet{IP: net.ParseIP("10.89.3.2"), Mask: network.Mask},
+		}, nil
+	}
+	hostInterface = func(name string) (*net.Interface, error) {
+		if name == "eth0" {
+			return &net.Interface{Name: name}, nil
+		}
+		return nil, errors.New("no such network interface")
+	}
+
+	network := shared.PodmanNetworkConfigData{
+		NetworkInsterface: "podman1",
+		Subnets:           []shared.NetworkSubnet{{Subnet: "10.88.0.0/16", Gateway: "10.88.0.1"}},
+	}
+	testutils.AssertNoError(t, "network should fit the host", checkNetworkOnHost(network))
+
+	network.Subnets = []shared.NetworkSubnet{{Subnet: "10.89.0.0/24", Gateway: "10.89.0.1"}}
+	testutils.AssertError(t, "overlaps the 10.89.3.2/16 host network", checkNetworkOnHost(network))
+
+	network.Subnets = []shared.NetworkSubnet{{Subnet: "192.168.0.0/16", Gateway: "192.168.0.1"}}
+	testutils.AssertError(t, "overlaps the 192.168.1.10/24 host network", checkNetworkOnHost(network))
+
+	network.NetworkInsterface = "eth0"
+	testutils.AssertError(t, "the eth0 network interface already exists", checkNetworkOnHost(network))
+}
+
+func TestCheckRenameWithoutCertificates(t *testing.T) {
+	target, err := backup.NewTarget(t.TempDir(), &backup.TargetFlags{})
+	testutils.AssertNoError(t, "failed to create target", err)
+
+	flags := &shared.Flagpole{FQDN: "new.example.com"}
+	testutils.AssertError(t, "the certificates of the backup are not valid for new.example.com",
+		checkRename(target, flags))
+
+	flags.SSL.Password = "secret"
+	testutils.AssertNoError(t, "certificates can be regenerated with the CA password", checkRename(target, flags))
+}
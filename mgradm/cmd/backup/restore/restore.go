@@ -5,10 +5,13 @@
 package restore
 
 import (
+	"archive/tar"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -16,14 +19,18 @@ import (
 
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
 	podman_mgradm "github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
 	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
 	"github.com/uyuni-project/uyuni-tools/shared/podman"
 	"github.com/uyuni-project/uyuni-tools/shared/types"
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-var runCmdInput = utils.RunCmdInput
 var runCmd = utils.RunCmd
+
+// imageStagingDir is where the images to verify are staged.
+// Like podman, the big image files are not staged in /tmp which may be in memory.
+var imageStagingDir = "/var/tmp"
 var systemd = podman.NewSystemd()
 
 func Restore(
@@ -31,57 +38,108 @@ func Restore(
 	flags *shared.Flagpole,
 	_ *cobra.Command,
 	args []string,
-
-) error {
+) (err error) {
 	inputDirectory := args[0]
 	printIntro(inputDirectory, flags)
 	dryRun := flags.DryRun
+	target, err := backup.NewTarget(inputDirectory, &flags.Target)
+	if err != nil {
+		return backup.AbortError(err, false)
+	}
+	defer target.Close()
+
 	// SanityCheck
-	if err := sanityChecks(inputDirectory, flags); err != nil {
-		return shared.AbortError(err, false)
+	if err := sanityChecks(target, flags); err != nil {
+		return backup.AbortError(err, false)
 	}
 
 	// Gather the list of volumes and images from the backup location
 	// Both parses provided flags and the produced list has volumes or images
 	// already skipped over if needed.
-	volumes, err := gatherVolumesToRestore(inputDirectory, flags)
+	volumes, err := gatherVolumesToRestore(target, flags)
 	if err != nil {
-		return shared.AbortError(err, false)
+		return backup.AbortError(err, false)
 	}
-	images, err := gatherImagesToRestore(inputDirectory, flags)
+	images, err := gatherImagesToRestore(target, flags)
 	if err != nil {
-		return shared.AbortError(err, false)
+		return backup.AbortError(err, false)
+	}
+
+	// Fail before overwriting anything if the server cannot be renamed
+	if flags.FQDN != "" {
+		if err := checkRename(target, flags); err != nil {
+			return backup.AbortError(err, false)
+		}
 	}
 
+	hookContext := &shared.HookContext{Location: inputDirectory, DryRun: dryRun}
+	if backup.FileExists(target, backup.ManifestFile) {
+		manifest, errManifest := backup.ReadManifest(target)
+		if errManifest != nil {
+			log.Warn().Err(errManifest).Msg(L("The list of artifacts will not be passed to the hooks"))
+		}
+		hookContext.Manifest = manifest
+	}
+	if err := shared.RunHooks(&flags.Hooks, shared.HookPreRestore, hookContext); err != nil {
+		return backup.AbortError(err, false)
+	}
+	defer func() {
+		hookContext.Err = err
+		err = shared.RunPostHooks(&flags.Hooks, shared.HookPostRestore, hookContext)
+	}()
+
 	// Restore provided volumes
 	// An error with volume restore is considered serious so we abort
 	// --continue can be used to skip over already imported images once error
 	// is resolved
-	if err := restoreVolumes(volumes, flags, dryRun); err != nil {
-		return shared.AbortError(err, true)
+	if err := restoreVolumes(target, volumes, flags, dryRun); err != nil {
+		return backup.AbortError(err, true)
+	}
+
+	// A selective restore only brings back the requested data into a possibly running server
+	if flags.IsSelective() {
+		if flags.Output.Dir == "" && !dryRun {
+			log.Info().Msg(L("Restart the services for them to use the restored files"))
+		}
+		return nil
 	}
 
 	// Everything below is not considered a serious error as it can be recreated from
 	// defaults, but there may be a data loss
 	var hasError error
-	if err := restoreImages(images, dryRun); err != nil {
+	if err := restoreImages(target, images, flags, dryRun); err != nil {
 		hasError = err
 	}
 
 	// Restore podman config or generate defaults
-	if err := restorePodmanConfig(inputDirectory, flags); err != nil {
+	if err := restorePodmanConfig(target, flags); err != nil {
 		hasError = utils.JoinErrors(hasError, err)
 	}
 	// Restore systemd config or generate defaults
-	if err := restoreSystemdConfig(inputDirectory, flags); err != nil {
+	if err := restoreSystemdConfig(target, flags); err != nil {
 		hasError = utils.JoinErrors(hasError, err)
 	}
 
-	if flags.Restart {
-		hasError = podman_mgradm.StartServices()
+	// The server identity has to be changed before starting it on the new host
+	if flags.FQDN != "" {
+		if err := renameServer(flags); err != nil {
+			return backup.AbortError(utils.JoinErrors(err, hasError), true)
+		}
 	}
 
-	return shared.ReportError(hasError)
+	// Starting the server completes the rename
+	if flags.Restart || flags.FQDN != "" {
+		if dryRun {
+			log.Info().Msg(L("Would start the services"))
+		} else if err := podman_mgradm.StartServices(systemd); err != nil {
+			hasError = utils.JoinErrors(hasError, err)
+		} else if flags.FQDN != "" {
+			log.Info().Msg(L(`The renaming continues inside the server container.
+The logs can be found in journalctl -u uyuni-config-update.service output.`))
+		}
+	}
+
+	return backup.ReportError(hasError)
 }
 
 func printIntro(dir string, flags *shared.Flagpole) {
@@ -95,23 +153,49 @@ func printIntro(dir string, flags *shared.Flagpole) {
 	log.Debug().Msgf("skip volumes: %s", flags.SkipVolumes)
 	log.Debug().Msgf("extra volumes: %s", flags.ExtraVolumes)
 	log.Debug().Msgf("skip existing: %t", flags.SkipExisting)
+	log.Debug().Msgf("volumes: %s", flags.Volume)
+	log.Debug().Msgf("include: %s", flags.Include)
+	log.Debug().Msgf("output directory: %s", flags.Output.Dir)
+	log.Debug().Msgf("fqdn: %s", flags.FQDN)
 }
 
-func sanityChecks(inputDirectory string, flags *shared.Flagpole) error {
-	if err := shared.SanityChecks(); err != nil {
+func sanityChecks(target backup.Target, flags *shared.Flagpole) error {
+	if err := backup.SanityChecks(); err != nil {
+		return err
+	}
+
+	if err := flags.Artifacts.CheckArtifactTools(); err != nil {
 		return err
 	}
 
-	if !utils.FileExists(inputDirectory) {
-		return fmt.Errorf(L("input directory %s does not exists"), inputDirectory)
+	if (len(flags.Include) > 0 || flags.Output.Dir != "") && !flags.IsSelective() {
+		return errors.New(L("--include and --output-dir can only be used with --volume"))
+	}
+
+	if flags.FQDN != "" {
+		if flags.IsSelective() {
+			return errors.New(L("--fqdn cannot be used with --volume"))
+		}
+		if err := utils.IsValidFQDN(flags.FQDN); err != nil {
+			return err
+		}
 	}
 
-	hostData, err := podman.InspectHost()
+	empty, err := backup.IsEmpty(target)
 	if err != nil {
 		return err
 	}
+	if empty {
+		return fmt.Errorf(L("input directory %s does not exists"), target)
+	}
 
-	if hostData.HasUyuniServer {
+	hostData, err := podman.InspectHost(nil)
+	if err != nil {
+		return err
+	}
+
+	// Selective restores are meant to recover some data of a running server
+	if hostData.HasUyuniServer && !flags.IsSelective() {
 		if flags.ForceRestore {
 			log.Warn().Msg(L("Restoring over already initialized server"))
 		} else {
@@ -125,30 +209,37 @@ func sanityChecks(inputDirectory string, flags *shared.Flagpole) error {
 // It takes a list from the backup source, checks if volume already exists and if it is
 // to be skipped.
 // Special `--skipvolume all` handing will cause to return empty list.
-func gatherVolumesToRestore(source string, flags *shared.Flagpole) ([]string, error) {
+func gatherVolumesToRestore(target backup.Target, flags *shared.Flagpole) ([]string, error) {
 	skipVolumes := flags.SkipVolumes
 	if len(skipVolumes) == 1 && skipVolumes[0] == "all" {
 		log.Debug().Msg("Skipping restoring of volumes")
 		return []string{}, nil
 	}
 
-	volumeDir := path.Join(source, "volumes")
-	if !utils.FileExists(volumeDir) {
-		return []string{}, errors.New(L("No volumes found in the backup"))
-	}
-
-	volumes, err := os.ReadDir(volumeDir)
+	volumes, err := target.List(backup.VolumesSubdir)
 	if err != nil {
 		return nil, errors.New(L("Unable to read directory with the volumes"))
 	}
+	if len(volumes) == 0 {
+		return []string{}, errors.New(L("No volumes found in the backup"))
+	}
 
 	output := []string{}
+	found := map[string]bool{}
 	for _, v := range volumes {
-		if strings.HasSuffix(v.Name(), "sha256sum") {
+		if v.IsDir || strings.HasSuffix(v.Name, backup.ChecksumSuffix) {
 			// This is checksum file, ignore
 			continue
 		}
-		volName := strings.TrimSuffix(v.Name(), ".tar")
+		volName := backup.ArtifactBaseName(v.Name)
+
+		// Only restore the volumes set as volume option
+		if flags.IsSelective() {
+			if !utils.Contains(flags.Volume, volName) {
+				continue
+			}
+			found[volName] = true
+		}
 
 		// Skip volumes set as skipvolume option
 		if utils.Contains(skipVolumes, volName) {
@@ -165,7 +256,9 @@ func gatherVolumesToRestore(source string, flags *shared.Flagpole) ([]string, er
 				}
 			}
 		}
-		if podman.IsVolumePresent(volName) {
+		// Extracting some files or into a side directory doesn't overwrite the whole volume
+		overwrites := len(flags.Include) == 0 && flags.Output.Dir == ""
+		if overwrites && podman.IsVolumePresent(nil, volName) {
 			if flags.SkipExisting {
 				log.Info().Msgf(L("Not restoring existing volume %s"), volName)
 				continue
@@ -175,89 +268,265 @@ func gatherVolumesToRestore(source string, flags *shared.Flagpole) ([]string, er
 			}
 			log.Info().Msgf(L("Volume %s will be overwriten"), volName)
 		}
-		output = append(output, path.Join(volumeDir, v.Name()))
+		output = append(output, path.Join(backup.VolumesSubdir, v.Name))
+	}
+
+	for _, volume := range flags.Volume {
+		if !found[volume] {
+			return nil, fmt.Errorf(L("volume %s not found in the backup"), volume)
+		}
 	}
 	return output, nil
 }
 
 // gatherImagesTorRestore produces a list of images to be imported.
 // It checks if images are to be skipped, in which case it returns empty list.
-func gatherImagesToRestore(source string, flags *shared.Flagpole) ([]string, error) {
-	if flags.SkipImages {
+func gatherImagesToRestore(target backup.Target, flags *shared.Flagpole) ([]string, error) {
+	if flags.SkipImages || flags.IsSelective() {
 		log.Debug().Msg("Skipping restoring of images")
 		return []string{}, nil
 	}
 
-	imagesDir := path.Join(source, "images")
-	if !utils.FileExists(imagesDir) {
-		return []string{}, errors.New(L("No images found in the backup"))
-	}
-	images, err := os.ReadDir(imagesDir)
+	images, err := target.List(backup.ImagesSubdir)
 	if err != nil {
 		return nil, errors.New(L("Unable to read directory with the images"))
 	}
+	if len(images) == 0 {
+		return []string{}, errors.New(L("No images found in the backup"))
+	}
 
 	output := []string{}
 	for _, image := range images {
-		if !strings.HasSuffix(image.Name(), ".tar") {
+		if image.IsDir || !backup.IsTarArtifact(image.Name) {
 			continue
 		}
-		output = append(output, path.Join(imagesDir, image.Name()))
+		output = append(output, path.Join(backup.ImagesSubdir, image.Name))
 	}
 	return output, nil
 }
 
-func restoreVolumes(volumes []string, flags *shared.Flagpole, dryRun bool) error {
-	for _, volume := range volumes {
-		volName := strings.TrimSuffix(volume, ".tar")
-		_, volName = path.Split(volName)
-		if err := podman.ImportVolume(volName, volume, flags.SkipVerify, dryRun); err != nil {
+// restoreVolumes imports the volumes, running at most flags.Parallel imports at the same time.
+func restoreVolumes(target backup.Target, volumes []string, flags *shared.Flagpole, dryRun bool) error {
+	progress := shared.NewProgress()
+	if !dryRun {
+		progress.Start()
+		defer progress.Stop()
+	}
+	sizes := artifactSizes(target, volumes)
+	return shared.RunParallel(len(volumes), flags.Parallel, func(index int) error {
+		volume := volumes[index]
+		volName := backup.ArtifactBaseName(volume)
+		task := progress.Add(volName, sizes[volume])
+		err := restoreVolume(target, volName, volume, flags, task, dryRun)
+		task.End(err)
+		return err
+	})
+}
+
+// artifactSizes returns the size of the artifact files, indexed by their path.
+// Sizes are only used to show the progress: the files that can't be listed are ignored.
+func artifactSizes(target backup.Target, files []string) map[string]int64 {
+	sizes := map[string]int64{}
+	listed := map[string]bool{}
+	for _, file := range files {
+		dir := path.Dir(file)
+		if listed[dir] {
+			continue
+		}
+		listed[dir] = true
+		entries, err := target.List(dir)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Failed to list %s", dir)
+			continue
+		}
+		for _, entry := range entries {
+			sizes[path.Join(dir, entry.Name)] = entry.Size
+		}
+	}
+	return sizes
+}
+
+// restoreVolume imports the volume from a possibly compressed or encrypted export.
+func restoreVolume(
+	target backup.Target,
+	name string,
+	volumePath string,
+	flags *shared.Flagpole,
+	task *shared.ProgressTask,
+	dryRun bool,
+) error {
+	if dryRun {
+		log.Info().Msgf(L("Would restore volume %[1]s from %[2]s"), name, backup.JoinLocation(target.String(), volumePath))
+		return nil
+	}
+
+	checksum, err := artifactChecksum(target, volumePath, flags)
+	if err != nil {
+		return utils.Errorf(err, L("Checksum does not match for volume %s"), volumePath)
+	}
+	targetPath, err := prepareVolumeTarget(name, flags.Output.Dir)
+	if err != nil {
+		return err
+	}
+
+	// Artifacts verified while streaming are extracted next to the volume
+	// and only moved into it once the checksum matches.
+	extractPath := targetPath
+	if checksum != "" {
+		if extractPath, err = backup.PrepareStagingDir(targetPath); err != nil {
 			return err
 		}
+		defer os.RemoveAll(extractPath)
+	}
+
+	if len(flags.Include) > 0 {
+		err = extractVolumeFiles(target, name, volumePath, extractPath, flags, checksum, task)
+	} else {
+		importCommand := []string{"tar", "xf", "-", "-C", extractPath}
+		err = backup.ImportArtifactWithProgress(target, volumePath, &flags.Artifacts, checksum, dryRun, task,
+			importCommand...)
+	}
+	if err != nil {
+		return utils.Errorf(err, L("Failed to import volume %s"), name)
+	}
+	if extractPath != targetPath {
+		if err := backup.MoveStagedFiles(extractPath, targetPath); err != nil {
+			return utils.Errorf(err, L("Failed to import volume %s"), name)
+		}
+	}
+	if flags.Output.Dir == "" {
+		podman.RestoreVolumeContext(nil, targetPath)
 	}
 	return nil
 }
 
-func restoreImages(images []string, dryRun bool) error {
+// artifactChecksum verifies the artifact if it is a local file or returns the checksum to verify it when streaming.
+// Remote artifacts are validated while streaming to avoid downloading them twice.
+// An empty checksum is returned if there is nothing left to verify.
+func artifactChecksum(target backup.Target, file string, flags *shared.Flagpole) (string, error) {
+	if flags.SkipVerify {
+		return "", nil
+	}
+	if _, isLocal := target.LocalPath(file); isLocal {
+		return "", backup.ValidateArtifact(target, file)
+	}
+	return backup.ReadChecksum(target, file)
+}
+
+// prepareVolumeTarget returns the directory to extract the volume to:
+// the live volume or a subdirectory of the output directory if set.
+func prepareVolumeTarget(name string, outputDir string) (string, error) {
+	if outputDir == "" {
+		return podman.PrepareVolumeImport(nil, name)
+	}
+	targetPath := filepath.Join(outputDir, name)
+	if err := os.MkdirAll(targetPath, 0700); err != nil {
+		return "", utils.Errorf(err, L("failed to create %s directory"), targetPath)
+	}
+	return targetPath, nil
+}
+
+// extractVolumeFiles extracts the files of the volume export matching the include patterns.
+func extractVolumeFiles(
+	target backup.Target,
+	name string,
+	volumePath string,
+	targetPath string,
+	flags *shared.Flagpole,
+	checksum string,
+	task *shared.ProgressTask,
+) error {
+	count := 0
+	err := backup.WalkTarArtifact(target, volumePath, &flags.Artifacts, checksum, task,
+		func(header *tar.Header, content io.Reader) error {
+			if !backup.MatchesInclude(header.Name, flags.Include) {
+				return nil
+			}
+			log.Debug().Msgf("Extracting %s", header.Name)
+			count++
+			return backup.ExtractTarEntry(targetPath, header, content)
+		})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf(L("no file matching %[1]s in volume %[2]s"), strings.Join(flags.Include, ", "), name)
+	}
+	log.Info().Msgf(L("Extracted %[1]d files of volume %[2]s into %[3]s"), count, name, targetPath)
+	return nil
+}
+
+func restoreImages(target backup.Target, images []string, flags *shared.Flagpole, dryRun bool) error {
 	var hasErrors error
 	for _, image := range images {
-		if err := podman.RestoreImage(image, dryRun); err != nil {
-			hasErrors = utils.JoinErrors(hasErrors, err)
+		if err := restoreImage(target, image, flags, dryRun); err != nil {
+			hasErrors = utils.JoinErrors(hasErrors, utils.Errorf(err, L("Failed to restore image %s"), image))
 		}
 	}
 	return hasErrors
 }
 
-func restorePodmanConfig(inputDirectory string, flags *shared.Flagpole) error {
-	podmanConfigFile := path.Join(inputDirectory, shared.PodmanConfBackupFile)
-	if !utils.FileExists(podmanConfigFile) {
+// restoreImage loads an image export.
+//
+// The exports to verify while streaming are first staged in a file and only loaded once their checksum matches:
+// loading an image moves its tags, this cannot be undone after the fact.
+func restoreImage(target backup.Target, image string, flags *shared.Flagpole, dryRun bool) error {
+	loadCommand := []string{"podman", "image", "load", "--quiet"}
+	if dryRun {
+		return backup.ImportArtifact(target, image, &flags.Artifacts, "", dryRun, loadCommand...)
+	}
+	checksum, err := artifactChecksum(target, image, flags)
+	if err != nil {
+		return err
+	}
+	if checksum == "" {
+		return backup.ImportArtifact(target, image, &flags.Artifacts, "", dryRun, loadCommand...)
+	}
+
+	stagingDir, err := os.MkdirTemp(imageStagingDir, "mgradm-image-*")
+	if err != nil {
+		return utils.Errorf(err, L("failed to create temporary directory"))
+	}
+	defer os.RemoveAll(stagingDir)
+
+	staged := path.Join(stagingDir, "image.tar")
+	log.Info().Msgf(L("Verifying image export %s"), image)
+	if err := backup.StageArtifact(target, image, &flags.Artifacts, checksum, staged); err != nil {
+		return err
+	}
+	return runCmd("podman", "image", "load", "--quiet", "--input", staged)
+}
+
+func restorePodmanConfig(target backup.Target, flags *shared.Flagpole) error {
+	podmanConfigFile := backup.FindArtifact(target, "", backup.PodmanConfBackupFile)
+	if podmanConfigFile == "" {
 		log.Warn().Msg(L("podman config backup not found in the backup location, trying defaults"))
 		return defaultPodmanNetwork(flags)
 	}
 
 	if !flags.SkipVerify {
-		if err := utils.ValidateChecksum(podmanConfigFile); err != nil {
+		if err := backup.ValidateArtifact(target, podmanConfigFile); err != nil {
 			return utils.JoinErrors(err, errors.New(L("Unable to validate podman backup file")))
 		}
 	}
 
-	return restorePodmanConfiguration(podmanConfigFile, flags)
+	return restorePodmanConfiguration(target, podmanConfigFile, flags)
 }
 
-func restoreSystemdConfig(inputDirectory string, flags *shared.Flagpole) error {
+func restoreSystemdConfig(target backup.Target, flags *shared.Flagpole) error {
 	log.Info().Msgf(L("Restoring systemd configuration"))
-	systemdConfigFile := path.Join(inputDirectory, shared.SystemdConfBackupFile)
-	if !utils.FileExists(systemdConfigFile) {
+	systemdConfigFile := backup.FindArtifact(target, "", backup.SystemdConfBackupFile)
+	if systemdConfigFile == "" {
 		log.Warn().Msg(L("systemd backup not found in the backup location, generating defaults"))
 		return generateDefaultSystemdServices(flags)
 	}
 	if !flags.SkipVerify {
-		if err := utils.ValidateChecksum(systemdConfigFile); err != nil {
+		if err := backup.ValidateArtifact(target, systemdConfigFile); err != nil {
 			return utils.JoinErrors(err, errors.New(L("Unable to validate systemd backup file")))
 		}
 	}
 
-	if err := restoreSystemdConfiguration(systemdConfigFile, flags); err != nil {
+	if err := restoreSystemdConfiguration(target, systemdConfigFile, flags); err != nil {
 		return err
 	}
 	return systemd.ReloadDaemon(flags.DryRun)
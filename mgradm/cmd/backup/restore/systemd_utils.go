@@ -7,94 +7,32 @@ package restore
 import (
 	"archive/tar"
 	"fmt"
-	"io"
-	"os"
-	"path/filepath"
 
 	"github.com/rs/zerolog/log"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
 	"github.com/uyuni-project/uyuni-tools/mgradm/shared/pgsql"
 	"github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
 	adm_utils "github.com/uyuni-project/uyuni-tools/mgradm/shared/utils"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
 	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-func restoreSystemdConfiguration(backupSource string, flags *shared.Flagpole) error {
-	backupFile, err := os.Open(backupSource)
+func restoreSystemdConfiguration(
+	target backup.Target,
+	backupSource string,
+	flags *shared.Flagpole,
+) (hasError error) {
+	backupFile, err := backup.OpenArtifact(target, backupSource, &flags.Artifacts, "")
 	if err != nil {
 		return err
 	}
-	defer backupFile.Close()
 
-	var hasError error
+	defer func() {
+		hasError = utils.JoinErrors(hasError, backupFile.Close())
+	}()
 
-	tr := tar.NewReader(backupFile)
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-		if flags.DryRun {
-			log.Info().Msgf(L("Would restore %s"), header.Name)
-			continue
-		}
-
-		log.Debug().Msgf("Restoring systemd file %s", header.Name)
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(header.Name, header.FileInfo().Mode()); err != nil {
-				log.Warn().Msgf(L("Unable to create directory %s"), header.Name)
-				hasError = utils.JoinErrors(hasError, err)
-				continue
-			}
-		case tar.TypeReg:
-			if err := restoreSystemdFile(header, tr); err != nil {
-				hasError = utils.JoinErrors(hasError, err)
-				continue
-			}
-		default:
-			log.Warn().Msgf(L("Unknown filetype of %s"), header.Name)
-			continue
-		}
-
-		if err := restoreFileAttributes(header.Name, header); err != nil {
-			log.Warn().Err(err).Msgf(L("Unable to restore file details for %s"), header.Name)
-			hasError = utils.JoinErrors(hasError, err)
-		}
-	}
-	return hasError
-}
-
-func restoreSystemdFile(header *tar.Header, tr *tar.Reader) error {
-	// Systemd backup may not package directories anymore, so be sure they are present
-	if err := os.MkdirAll(filepath.Dir(header.Name), 0750); err != nil {
-		log.Warn().Msgf(L("Unable to create directories for %s"), header.Name)
-	}
-	fh, err := os.Create(header.Name)
-	if err != nil {
-		log.Warn().Err(err).Msgf(L("Unable to create %s"), header.Name)
-		return err
-	}
-	if _, err := io.Copy(fh, tr); err != nil {
-		log.Warn().Err(err).Msgf(L("Unable to restore content of %s"), header.Name)
-		fh.Close()
-		os.Remove(header.Name)
-		return err
-	}
-	fh.Close()
-	return nil
-}
-
-func restoreFileAttributes(filename string, th *tar.Header) error {
-	var e error
-	e = utils.JoinErrors(e, os.Chmod(filename, th.FileInfo().Mode()))
-	e = utils.JoinErrors(e, os.Chown(filename, th.Uid, th.Gid))
-	e = utils.JoinErrors(e, os.Chtimes(filename, th.AccessTime, th.ModTime))
-	return e
+	return backup.RestoreFilesTar(tar.NewReader(backupFile), flags.DryRun)
 }
 
 func generateDefaultSystemdServices(flags *shared.Flagpole) error {
@@ -113,7 +51,7 @@ func generateDefaultSystemdServices(flags *shared.Flagpole) error {
 	// No need for server environment file and everything should just work.
 
 	return utils.JoinErrors(
-		podman.GenerateUpgradeServerEnvironmentFile(false),
+		podman.GenerateUpgradeServerEnvironmentFile(nil, false),
 		podman.GenerateSystemdService(systemd, serverImage, adm_utils.InstallationFlags{}, []string{}, ""),
 		pgsql.GeneratePgsqlSystemdService(systemd, dbImage),
 		systemd.ReloadDaemon(false),
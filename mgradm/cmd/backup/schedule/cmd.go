@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schedule
+
+import (
+	"github.com/spf13/cobra"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// CLI definitions
+
+// NewScheduleCmd creates the command managing the scheduled backups.
+func NewScheduleCmd(globalFlags *types.GlobalFlags) *cobra.Command {
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule",
+		Short: L("Scheduled backup management"),
+		Long:  L("Tools to run backups periodically using a systemd timer"),
+	}
+	scheduleCmd.AddCommand(newEnableCmd(globalFlags, doEnable))
+	scheduleCmd.AddCommand(newDisableCmd(globalFlags, doDisable))
+	scheduleCmd.AddCommand(newStatusCmd(globalFlags, doStatus))
+	scheduleCmd.AddCommand(newRecordCmd(globalFlags, doRecord))
+	return scheduleCmd
+}
+
+func newEnableCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[Flagpole]) *cobra.Command {
+	var flags Flagpole
+	cmd := &cobra.Command{
+		Use:   "enable",
+		Short: L("Enable scheduled backups"),
+		Long: L(`Enable scheduled backups
+
+A systemd timer runs backup create --timestamped to the target location at the time
+defined by the calendar. When a retention is set, backup prune is run after each
+successful backup.
+
+The calendar is a systemd calendar event expression like daily, weekly or "*-*-* 02:00:00".
+The retention is a list like daily=7,weekly=4,monthly=12.
+
+The S3 credentials have to be set in the configuration file as the environment
+variables are not passed to the timer.`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	cmd.Flags().String("calendar", "daily", L("Systemd calendar event expression defining when to run the backup"))
+	cmd.Flags().String("target", "", L("Location of the backups repository to create the backups in"))
+	cmd.Flags().String("retention", "", L("Backups to keep after each run, for instance daily=7,weekly=4,monthly=12"))
+	cmd.Flags().Bool("skipimages", false, L("Do not backup container images"))
+	cmd.Flags().String("compress", "", L("Compress the backup files. Possible values: none, gzip, zstd"))
+	cmd.Flags().StringSlice("encrypt-recipient", []string{},
+		L("Encrypt the backup files for the GPG key of the recipient. Can be repeated"))
+	cmd.Flags().String("passphrase-file", "",
+		L("Encrypt the backup files with the passphrase contained in the file"))
+	cmd.Flags().String("sign-key", "",
+		L("GPG key to sign the backup manifest with. Required unless --unsigned is used"))
+	cmd.Flags().Bool("unsigned", false,
+		L("Do not sign the backup manifest. Tampering with the backup will not be detected"))
+	cmd.Flags().String("s3-endpoint", "", L("URL of the S3-compatible storage"))
+	cmd.Flags().String("s3-region", "", L("Region of the S3 storage"))
+	cmd.Flags().Int("s3-partsize", 0, L("Size in MiB of the parts of the S3 multipart uploads. Default is 64"))
+	cmd.Flags().String("ssh-identity", "", L("Private key file to use to connect to the SFTP backup location"))
+	_ = cmd.MarkFlagRequired("target")
+
+	return cmd
+}
+
+func newDisableCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[Flagpole]) *cobra.Command {
+	var flags Flagpole
+	cmd := &cobra.Command{
+		Use:   "disable",
+		Short: L("Disable scheduled backups"),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	return cmd
+}
+
+func newStatusCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[Flagpole]) *cobra.Command {
+	var flags Flagpole
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: L("Show the scheduled backups status and the result of the last run"),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	return cmd
+}
+
+func newRecordCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[Flagpole]) *cobra.Command {
+	var flags Flagpole
+	cmd := &cobra.Command{
+		Use:    "record",
+		Short:  L("Record the result of a scheduled backup run"),
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	return cmd
+}
+
+// Actual actions
+
+func doEnable(
+	_ *types.GlobalFlags,
+	flags *Flagpole,
+	_ *cobra.Command,
+	_ []string,
+) error {
+	return Enable(flags)
+}
+
+func doDisable(
+	_ *types.GlobalFlags,
+	_ *Flagpole,
+	_ *cobra.Command,
+	_ []string,
+) error {
+	return Disable()
+}
+
+func doStatus(
+	_ *types.GlobalFlags,
+	_ *Flagpole,
+	_ *cobra.Command,
+	_ []string,
+) error {
+	return Status()
+}
+
+func doRecord(
+	_ *types.GlobalFlags,
+	_ *Flagpole,
+	_ *cobra.Command,
+	_ []string,
+) error {
+	return Record()
+}
@@ -0,0 +1,240 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/templates"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Flagpole holds the flags of the backup schedule commands.
+type Flagpole struct {
+	Calendar   string `mapstructure:"calendar"`
+	Location   string `mapstructure:"target"`
+	Retention  string `mapstructure:"retention"`
+	SkipImages bool   `mapstructure:"skipimages"`
+
+	Artifacts backup.ArtifactFlags `mapstructure:",squash"`
+	Target    backup.TargetFlags   `mapstructure:",squash"`
+	Sign      struct {
+		Key string `mapstructure:"key"`
+	} `mapstructure:"sign"`
+	Unsigned bool `mapstructure:"unsigned"`
+}
+
+// ScheduleService is the name of the systemd service running the scheduled backups.
+const ScheduleService = "uyuni-backup"
+
+// TimerUnit is the name of the systemd timer triggering the scheduled backups.
+const TimerUnit = ScheduleService + ".timer"
+
+// For unit testing, enable overridable systemd.
+var systemd podman.Systemd = podman.NewSystemd()
+
+// Enable installs and starts the systemd timer running the backups.
+func Enable(flags *Flagpole) error {
+	if flags.Location == "" {
+		return errors.New(L("the target location of the backups is required"))
+	}
+	if err := checkCalendar(flags.Calendar); err != nil {
+		return err
+	}
+	if err := backup.CheckSignKey(flags.Sign.Key, flags.Unsigned); err != nil {
+		return err
+	}
+	policy := shared.RetentionPolicy{}
+	if flags.Retention != "" {
+		var err error
+		if policy, err = shared.ParseRetentionPolicy(flags.Retention); err != nil {
+			return err
+		}
+	}
+
+	location := flags.Location
+	if !strings.Contains(location, "://") {
+		// The service doesn't run in the current directory
+		absLocation, err := filepath.Abs(location)
+		if err != nil {
+			return utils.Errorf(err, L("failed to get the absolute path of %s"), location)
+		}
+		location = absLocation
+	}
+	// Check the target parameters before running the first backup
+	if _, err := backup.NewTarget(location, &flags.Target); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return utils.Errorf(err, L("failed to find the path to the mgradm command"))
+	}
+
+	createArgs, pruneArgs := backupArgs(flags, &policy)
+	for _, arg := range append(createArgs, pruneArgs...) {
+		if strings.ContainsAny(arg, " \t\n") {
+			return fmt.Errorf(L("scheduled backup parameters cannot contain spaces: %s"), arg)
+		}
+	}
+
+	serviceData := templates.BackupScheduleServiceTemplateData{
+		Executable: executable,
+		Prune:      !policy.IsEmpty(),
+	}
+	if err := utils.WriteTemplateToFile(serviceData, podman.GetServicePath(ScheduleService), 0644, true); err != nil {
+		return utils.Errorf(err, L("failed to generate systemd service unit file"))
+	}
+
+	environment := []string{
+		environmentLine("UYUNI_BACKUP_LOCATION", location),
+		environmentLine("UYUNI_BACKUP_CREATE_ARGS", strings.Join(createArgs, " ")),
+		environmentLine("UYUNI_BACKUP_PRUNE_ARGS", strings.Join(pruneArgs, " ")),
+	}
+	if err := podman.GenerateSystemdConfFile(
+		nil,
+		ScheduleService, podman.GeneratedConf, strings.Join(environment, "\n"), true,
+	); err != nil {
+		return utils.Errorf(err, L("cannot generate systemd conf file"))
+	}
+
+	timerData := templates.BackupScheduleTimerTemplateData{Calendar: flags.Calendar}
+	if err := utils.WriteTemplateToFile(timerData, podman.GetTimerPath(ScheduleService), 0644, true); err != nil {
+		return utils.Errorf(err, L("failed to generate systemd timer unit file"))
+	}
+
+	if err := systemd.ReloadDaemon(false); err != nil {
+		return err
+	}
+	if err := systemd.EnableService(TimerUnit); err != nil {
+		return err
+	}
+	log.Info().Msgf(L("Backups to %[1]s scheduled with calendar %[2]s"), location, flags.Calendar)
+	return nil
+}
+
+// backupArgs computes the parameters of the backup create and backup prune commands.
+func backupArgs(flags *Flagpole, policy *shared.RetentionPolicy) (createArgs []string, pruneArgs []string) {
+	targetArgs := []string{}
+	if flags.Target.S3.Endpoint != "" {
+		targetArgs = append(targetArgs, "--s3-endpoint", flags.Target.S3.Endpoint)
+	}
+	if flags.Target.S3.Region != "" {
+		targetArgs = append(targetArgs, "--s3-region", flags.Target.S3.Region)
+	}
+	if flags.Target.S3.PartSize > 0 {
+		targetArgs = append(targetArgs, "--s3-partsize", strconv.Itoa(flags.Target.S3.PartSize))
+	}
+	if flags.Target.SSH.Identity != "" {
+		targetArgs = append(targetArgs, "--ssh-identity", flags.Target.SSH.Identity)
+	}
+
+	if flags.SkipImages {
+		createArgs = append(createArgs, "--skipimages")
+	}
+	if flags.Artifacts.Compress != "" {
+		createArgs = append(createArgs, "--compress", flags.Artifacts.Compress)
+	}
+	for _, recipient := range flags.Artifacts.Encrypt.Recipient {
+		createArgs = append(createArgs, "--encrypt-recipient", recipient)
+	}
+	if flags.Artifacts.Passphrase.File != "" {
+		createArgs = append(createArgs, "--passphrase-file", flags.Artifacts.Passphrase.File)
+	}
+	if flags.Sign.Key != "" {
+		createArgs = append(createArgs, "--sign-key", flags.Sign.Key)
+	}
+	if flags.Unsigned {
+		createArgs = append(createArgs, "--unsigned")
+	}
+	createArgs = append(createArgs, targetArgs...)
+
+	if !policy.IsEmpty() {
+		pruneArgs = append(policy.Args(), targetArgs...)
+	}
+	return createArgs, pruneArgs
+}
+
+// environmentLine generates a systemd Environment setting, escaping the value.
+func environmentLine(name string, value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%")
+	return fmt.Sprintf(`Environment="%s=%s"`, name, replacer.Replace(value))
+}
+
+// checkCalendar validates the calendar expression if systemd-analyze is available.
+func checkCalendar(calendar string) error {
+	if calendar == "" {
+		return errors.New(L("the calendar of the scheduled backups is required"))
+	}
+	if !utils.IsInstalled("systemd-analyze") {
+		return nil
+	}
+	if err := utils.RunCmd("systemd-analyze", "calendar", calendar); err != nil {
+		return utils.Errorf(err, L("invalid calendar expression %s"), calendar)
+	}
+	return nil
+}
+
+// Disable stops and removes the systemd timer running the backups.
+func Disable() error {
+	if !systemd.HasService(TimerUnit) {
+		log.Info().Msg(L("Scheduled backups are not enabled"))
+		return nil
+	}
+	if err := systemd.DisableService(TimerUnit); err != nil {
+		return err
+	}
+	timerPath := podman.GetTimerPath(ScheduleService)
+	if err := os.Remove(timerPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return utils.Errorf(err, L("failed to remove %s"), timerPath)
+	}
+	systemd.UninstallService(ScheduleService, false)
+	if err := systemd.ReloadDaemon(false); err != nil {
+		return err
+	}
+	log.Info().Msg(L("Scheduled backups disabled"))
+	return nil
+}
+
+// Status shows whether the backups are scheduled and the result of the last run.
+func Status() error {
+	if !systemd.HasService(TimerUnit) {
+		log.Info().Msg(L("Scheduled backups are not enabled"))
+	} else {
+		state := L("disabled")
+		if systemd.ServiceIsEnabled(TimerUnit) {
+			state = L("enabled")
+		}
+		log.Info().Msgf(L("Scheduled backups are %s"), state)
+		if next, err := systemd.GetServiceProperty(TimerUnit, "NextElapseUSecRealtime"); err == nil && next != "" {
+			log.Info().Msgf(L("Next backup: %s"), next)
+		}
+	}
+
+	lastRun, err := ReadLastRun()
+	if err != nil {
+		return err
+	}
+	if lastRun == nil {
+		log.Info().Msg(L("No scheduled backup has run yet"))
+		return nil
+	}
+	ReportLastRun(lastRun)
+	if lastRun.Failed() {
+		return errors.New(L("last scheduled backup failed"))
+	}
+	return nil
+}
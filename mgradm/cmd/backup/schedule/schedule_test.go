@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schedule
+
+import (
+	"path"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/shared"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestBackupArgs(t *testing.T) {
+	flags := Flagpole{SkipImages: true}
+	flags.Artifacts.Compress = "zstd"
+	flags.Artifacts.Encrypt.Recipient = []string{"backup@example.com"}
+	flags.Sign.Key = "signer@example.com"
+	flags.Target.S3.Endpoint = "http://minio:9000"
+
+	createArgs, pruneArgs := backupArgs(&flags, &shared.RetentionPolicy{KeepDaily: 7, KeepMonthly: 12})
+	testutils.AssertEquals(t, "Unexpected create args", []string{
+		"--skipimages", "--compress", "zstd", "--encrypt-recipient", "backup@example.com",
+		"--sign-key", "signer@example.com", "--s3-endpoint", "http://minio:9000",
+	}, createArgs)
+	testutils.AssertEquals(t, "Unexpected prune args", []string{
+		"--keep-daily", "7", "--keep-monthly", "12", "--s3-endpoint", "http://minio:9000",
+	}, pruneArgs)
+
+	_, pruneArgs = backupArgs(&flags, &shared.RetentionPolicy{})
+	testutils.AssertEquals(t, "No prune args expected without retention", 0, len(pruneArgs))
+
+	unsignedFlags := Flagpole{Unsigned: true}
+	createArgs, _ = backupArgs(&unsignedFlags, &shared.RetentionPolicy{})
+	testutils.AssertEquals(t, "Unexpected unsigned create args", []string{"--unsigned"}, createArgs)
+}
+
+func TestEnvironmentLine(t *testing.T) {
+	testutils.AssertEquals(t, "Unexpected environment line", `Environment="NAME=50%% \"quoted\""`,
+		environmentLine("NAME", `50% "quoted"`))
+}
+
+func TestRecord(t *testing.T) {
+	lastRunPath = path.Join(t.TempDir(), "state", "backup-schedule.json")
+
+	lastRun, err := ReadLastRun()
+	testutils.AssertNoError(t, "missing state should not fail", err)
+	testutils.AssertTrue(t, "No last run expected", lastRun == nil)
+
+	t.Setenv("SERVICE_RESULT", "")
+	testutils.AssertError(t, "meant to be run by systemd", Record())
+
+	t.Setenv("UYUNI_BACKUP_LOCATION", "/var/backups")
+	t.Setenv("SERVICE_RESULT", "exit-code")
+	t.Setenv("EXIT_CODE", "exited")
+	t.Setenv("EXIT_STATUS", "1")
+	testutils.AssertNoError(t, "failed to record", Record())
+
+	lastRun, err = ReadLastRun()
+	testutils.AssertNoError(t, "failed to read last run", err)
+	testutils.AssertEquals(t, "Unexpected location", "/var/backups", lastRun.Location)
+	testutils.AssertEquals(t, "Unexpected exit status", "1", lastRun.ExitStatus)
+	testutils.AssertTrue(t, "Last run should be failed", lastRun.Failed())
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// lastRunPath is the file where the result of the last scheduled backup is recorded.
+var lastRunPath = "/var/lib/uyuni-tools/backup-schedule.json"
+
+// LastRun is the result of the last scheduled backup.
+type LastRun struct {
+	Time     time.Time `json:"time"`
+	Location string    `json:"location"`
+	// Result is the systemd service result: success, exit-code, signal, timeout...
+	Result string `json:"result"`
+	// ExitCode and ExitStatus are the systemd description of how the backup process ended.
+	ExitCode   string `json:"exitCode,omitempty"`
+	ExitStatus string `json:"exitStatus,omitempty"`
+}
+
+// Failed returns true if the backup didn't succeed.
+func (r *LastRun) Failed() bool {
+	return r.Result != "success"
+}
+
+// Record writes the result of the scheduled backup.
+// It is meant to be run by the systemd service once the backup is finished.
+func Record() error {
+	lastRun := LastRun{
+		Time:       time.Now(),
+		Location:   os.Getenv("UYUNI_BACKUP_LOCATION"),
+		Result:     os.Getenv("SERVICE_RESULT"),
+		ExitCode:   os.Getenv("EXIT_CODE"),
+		ExitStatus: os.Getenv("EXIT_STATUS"),
+	}
+	if lastRun.Result == "" {
+		return errors.New(L("no service result to record: this command is meant to be run by systemd"))
+	}
+	return writeLastRun(&lastRun)
+}
+
+func writeLastRun(lastRun *LastRun) error {
+	data, err := json.MarshalIndent(lastRun, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(lastRunPath), 0700); err != nil {
+		return utils.Errorf(err, L("failed to create %s folder"), path.Dir(lastRunPath))
+	}
+	if err := os.WriteFile(lastRunPath, data, 0600); err != nil {
+		return utils.Errorf(err, L("cannot write %s file"), lastRunPath)
+	}
+	return nil
+}
+
+// ReadLastRun returns the result of the last scheduled backup or nil if none ran yet.
+func ReadLastRun() (*LastRun, error) {
+	data, err := os.ReadFile(lastRunPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, utils.Errorf(err, L("failed to read %s"), lastRunPath)
+	}
+	var lastRun LastRun
+	if err := json.Unmarshal(data, &lastRun); err != nil {
+		return nil, utils.Errorf(err, L("failed to parse %s"), lastRunPath)
+	}
+	return &lastRun, nil
+}
+
+// ReportLastRun logs the result of the last scheduled backup, as a warning if it failed.
+func ReportLastRun(lastRun *LastRun) {
+	when := lastRun.Time.Local().Format(time.DateTime)
+	if lastRun.Failed() {
+		log.Warn().Msgf(L("Last scheduled backup to %[1]s on %[2]s failed: %[3]s %[4]s"),
+			lastRun.Location, when, lastRun.Result, lastRun.ExitStatus)
+	} else {
+		log.Info().Msgf(L("Last scheduled backup to %[1]s on %[2]s succeeded"), lastRun.Location, when)
+	}
+}
@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+)
+
+// BackupSetLayout is the time layout of the backup sets directory names in a backups repository.
+const BackupSetLayout = "20060102-150405"
+
+// BackupSet is one backup stored in a backups repository.
+type BackupSet struct {
+	Name     string
+	Target   backup.Target
+	Time     time.Time
+	Size     int64
+	Manifest *backup.Manifest
+}
+
+// IsComplete returns true if the backup set contains all the requested data.
+// Backups without manifest are either still running or failed before writing it.
+func (s *BackupSet) IsComplete() bool {
+	return s.Manifest != nil && s.Manifest.IsComplete()
+}
+
+// Status returns the status of the backup set.
+func (s *BackupSet) Status() string {
+	if s.Manifest == nil || s.Manifest.Status == "" {
+		return "unknown"
+	}
+	return s.Manifest.Status
+}
+
+// NewBackupSetName returns the name of a new timestamped backup set in a repository.
+func NewBackupSetName(now time.Time) string {
+	return now.UTC().Format(BackupSetLayout)
+}
+
+// ListBackupSets returns the backup sets found in the repository, sorted from the newest to the oldest.
+//
+// Directories are considered as backup sets if they either contain a manifest or have a timestamped name.
+func ListBackupSets(repository backup.Target) ([]BackupSet, error) {
+	entries, err := repository.List("")
+	if err != nil {
+		return nil, err
+	}
+
+	sets := []BackupSet{}
+	for _, entry := range entries {
+		if !entry.IsDir {
+			continue
+		}
+		set := BackupSet{
+			Name:   entry.Name,
+			Target: repository.Sub(entry.Name),
+		}
+		if manifest, err := backup.ReadManifest(set.Target); err == nil {
+			set.Manifest = manifest
+			set.Time = manifest.Created
+		} else if setTime, err := time.Parse(BackupSetLayout, entry.Name); err == nil {
+			set.Time = setTime
+		} else {
+			log.Debug().Msgf("Ignoring %s: not a backup set", set.Target)
+			continue
+		}
+		if set.Size, err = backup.TargetSize(set.Target, ""); err != nil {
+			log.Debug().Err(err).Msgf("Failed to compute the size of %s", set.Target)
+		}
+		sets = append(sets, set)
+	}
+
+	sort.Slice(sets, func(i, j int) bool {
+		return sets[i].Time.After(sets[j].Time)
+	})
+	return sets, nil
+}
+
+// RetentionPolicy defines how many generations of backups to keep.
+type RetentionPolicy struct {
+	KeepDaily   int `mapstructure:"daily"`
+	KeepWeekly  int `mapstructure:"weekly"`
+	KeepMonthly int `mapstructure:"monthly"`
+}
+
+// IsEmpty returns true if the policy does not keep anything.
+func (p *RetentionPolicy) IsEmpty() bool {
+	return p.KeepDaily <= 0 && p.KeepWeekly <= 0 && p.KeepMonthly <= 0
+}
+
+// ParseRetentionPolicy parses a retention policy like daily=7,weekly=4,monthly=12.
+func ParseRetentionPolicy(value string) (RetentionPolicy, error) {
+	policy := RetentionPolicy{}
+	for _, item := range strings.Split(value, ",") {
+		period, countValue, found := strings.Cut(strings.TrimSpace(item), "=")
+		count, err := strconv.Atoi(countValue)
+		if !found || err != nil || count < 0 {
+			return policy, fmt.Errorf(L("invalid retention %s: expected a list like daily=7,weekly=4,monthly=12"), item)
+		}
+		switch period {
+		case "daily":
+			policy.KeepDaily = count
+		case "weekly":
+			policy.KeepWeekly = count
+		case "monthly":
+			policy.KeepMonthly = count
+		default:
+			return policy, fmt.Errorf(L("invalid retention period %s: possible values are daily, weekly and monthly"),
+				period)
+		}
+	}
+	return policy, nil
+}
+
+// Args returns the backup prune parameters matching the policy.
+func (p *RetentionPolicy) Args() []string {
+	args := []string{}
+	for _, keep := range []struct {
+		flag  string
+		count int
+	}{{"--keep-daily", p.KeepDaily}, {"--keep-weekly", p.KeepWeekly}, {"--keep-monthly", p.KeepMonthly}} {
+		if keep.count > 0 {
+			args = append(args, keep.flag, strconv.Itoa(keep.count))
+		}
+	}
+	return args
+}
+
+// Select splits the backup sets in the ones to keep and the ones to remove.
+// The sets are expected to be sorted from the newest to the oldest.
+//
+// Only complete backup sets count as kept generations.
+// Incomplete sets newer than the latest complete one are kept as they may still be running,
+// the older ones are removed.
+func (p *RetentionPolicy) Select(sets []BackupSet) (keep []BackupSet, remove []BackupSet) {
+	buckets := []struct {
+		count int
+		key   func(time.Time) string
+	}{
+		{p.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{p.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%02d", year, week)
+		}},
+		{p.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+
+	kept := make(map[string]bool)
+	for _, bucket := range buckets {
+		seen := make(map[string]bool)
+		for _, set := range sets {
+			if len(seen) >= bucket.count {
+				break
+			}
+			if !set.IsComplete() {
+				continue
+			}
+			key := bucket.key(set.Time.UTC())
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			kept[set.Name] = true
+		}
+	}
+
+	foundComplete := false
+	for _, set := range sets {
+		if set.IsComplete() {
+			foundComplete = true
+		}
+		if kept[set.Name] || (!foundComplete && !set.IsComplete()) {
+			keep = append(keep, set)
+		} else {
+			remove = append(remove, set)
+		}
+	}
+	return
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func newTestSet(t time.Time, status string) BackupSet {
+	set := BackupSet{Name: t.Format(BackupSetLayout), Time: t}
+	if status != "" {
+		set.Manifest = &backup.Manifest{Created: t, Status: status}
+	}
+	return set
+}
+
+func setNames(sets []BackupSet) []string {
+	names := []string{}
+	for _, set := range sets {
+		names = append(names, set.Name)
+	}
+	return names
+}
+
+func TestRetentionSelect(t *testing.T) {
+	day := func(d int, status string) BackupSet {
+		return newTestSet(time.Date(2026, time.March, d, 2, 0, 0, 0, time.UTC), status)
+	}
+
+	// Newest to oldest
+	sets := []BackupSet{
+		day(31, ""), // Still running
+		day(30, backup.BackupPartial),
+		day(29, backup.BackupComplete),
+		day(28, backup.BackupComplete),
+		day(27, backup.BackupAborted),
+		day(26, backup.BackupComplete),
+		day(10, backup.BackupComplete),
+		day(2, backup.BackupComplete),
+	}
+
+	policy := RetentionPolicy{KeepDaily: 2, KeepWeekly: 2}
+	keep, remove := policy.Select(sets)
+
+	keptNames := setNames(keep)
+	testutils.AssertEquals(t, "Unexpected number of kept sets", 5, len(keep))
+	testutils.AssertContains(t, "Running backup should be kept", keptNames, day(31, "").Name)
+	testutils.AssertContains(t, "Partial backup newer than complete ones should be kept", keptNames, day(30, "").Name)
+	testutils.AssertContains(t, "Latest daily backup should be kept", keptNames, day(29, "").Name)
+	testutils.AssertContains(t, "Second daily backup should be kept", keptNames, day(28, "").Name)
+	testutils.AssertNotContains(t, "Same week backup should not be kept", keptNames, day(26, "").Name)
+
+	removedNames := setNames(remove)
+	testutils.AssertContains(t, "Aborted backup should be removed", removedNames, day(27, "").Name)
+	testutils.AssertContains(t, "Old backup should be removed", removedNames, day(2, "").Name)
+	testutils.AssertNotContains(t, "Previous week backup should be kept", removedNames, day(10, "").Name)
+}
+
+func TestRetentionIgnoresIncomplete(t *testing.T) {
+	sets := []BackupSet{
+		newTestSet(time.Date(2026, time.March, 3, 2, 0, 0, 0, time.UTC), backup.BackupComplete),
+		newTestSet(time.Date(2026, time.March, 2, 2, 0, 0, 0, time.UTC), backup.BackupPartial),
+		newTestSet(time.Date(2026, time.March, 1, 2, 0, 0, 0, time.UTC), backup.BackupComplete),
+	}
+
+	policy := RetentionPolicy{KeepDaily: 2}
+	keep, remove := policy.Select(sets)
+
+	testutils.AssertEquals(t, "Partial backup should not count as a generation",
+		[]string{sets[0].Name, sets[2].Name}, setNames(keep))
+	testutils.AssertEquals(t, "Partial backup should be removed", []string{sets[1].Name}, setNames(remove))
+}
+
+func TestParseRetentionPolicy(t *testing.T) {
+	policy, err := ParseRetentionPolicy("daily=7, weekly=4,monthly=12")
+	testutils.AssertNoError(t, "failed to parse retention", err)
+	testutils.AssertEquals(t, "Unexpected policy", RetentionPolicy{KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12}, policy)
+
+	policy, err = ParseRetentionPolicy("weekly=4")
+	testutils.AssertNoError(t, "failed to parse retention", err)
+	testutils.AssertEquals(t, "Unexpected prune args", []string{"--keep-weekly", "4"}, policy.Args())
+
+	_, err = ParseRetentionPolicy("yearly=2")
+	testutils.AssertError(t, "invalid retention period yearly", err)
+	_, err = ParseRetentionPolicy("daily")
+	testutils.AssertError(t, "invalid retention daily", err)
+}
+
+func TestListBackupSets(t *testing.T) {
+	repository := t.TempDir()
+
+	older := NewBackupSetName(time.Date(2026, time.March, 1, 2, 0, 0, 0, time.UTC))
+	newer := NewBackupSetName(time.Date(2026, time.March, 2, 2, 0, 0, 0, time.UTC))
+	for _, dir := range []string{older, newer, "not-a-backup"} {
+		if err := os.Mkdir(path.Join(repository, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	target, err := backup.NewTarget(repository, &backup.TargetFlags{})
+	testutils.AssertNoError(t, "failed to create target", err)
+	manifest := backup.Manifest{
+		Created: time.Date(2026, time.March, 2, 2, 0, 0, 0, time.UTC),
+		Status:  backup.BackupComplete,
+	}
+	testutils.AssertNoError(t, "failed to write manifest", manifest.Write(target.Sub(newer), ""))
+
+	sets, err := ListBackupSets(target)
+	testutils.AssertNoError(t, "failed to list backup sets", err)
+	testutils.AssertEquals(t, "Unexpected backup sets", []string{newer, older}, setNames(sets))
+	testutils.AssertTrue(t, "Backup with complete manifest should be complete", sets[0].IsComplete())
+	testutils.AssertTrue(t, "Backup without manifest should not be complete", !sets[1].IsComplete())
+	testutils.AssertEquals(t, "Unexpected status of backup without manifest", "unknown", sets[1].Status())
+}
@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import (
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Names of the hooks, also used as name of the hook directories with a .d suffix.
+const (
+	HookPreBackup   = "pre-backup"
+	HookPostBackup  = "post-backup"
+	HookPreRestore  = "pre-restore"
+	HookPostRestore = "post-restore"
+)
+
+// Outcomes of the backup or restore passed to the post hooks.
+const (
+	HookSuccess = "success"
+	HookPartial = "partial"
+	HookAborted = "aborted"
+)
+
+// DefaultHooksDir is the directory containing the hook directories.
+const DefaultHooksDir = "/etc/uyuni/backup/hooks"
+
+// HookFlags configures the commands to run around the backup and restore.
+type HookFlags struct {
+	Dir         string `mapstructure:"dir"`
+	PreBackup   string `mapstructure:"prebackup"`
+	PostBackup  string `mapstructure:"postbackup"`
+	PreRestore  string `mapstructure:"prerestore"`
+	PostRestore string `mapstructure:"postrestore"`
+}
+
+// HookContext describes the backup or restore to the hooks.
+type HookContext struct {
+	// Location is the backup directory or URL.
+	Location string
+	// Manifest provides the list of artifacts, if any.
+	Manifest *backup.Manifest
+	// Err is the error of the backup or restore for the post hooks.
+	Err    error
+	DryRun bool
+}
+
+// command returns the shell command configured for the hook.
+func (f *HookFlags) command(hook string) string {
+	switch hook {
+	case HookPreBackup:
+		return f.PreBackup
+	case HookPostBackup:
+		return f.PostBackup
+	case HookPreRestore:
+		return f.PreRestore
+	case HookPostRestore:
+		return f.PostRestore
+	}
+	return ""
+}
+
+// hookScripts returns the executable files of the hook directory, sorted by name.
+func (f *HookFlags) hookScripts(hook string) []string {
+	dir := path.Join(f.Dir, hook+".d")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warn().Err(err).Msgf(L("Failed to list the hooks in %s"), dir)
+		}
+		return nil
+	}
+	scripts := []string{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			log.Debug().Msgf("Ignoring non executable hook %s", entry.Name())
+			continue
+		}
+		scripts = append(scripts, path.Join(dir, entry.Name()))
+	}
+	sort.Strings(scripts)
+	return scripts
+}
+
+// HookOutcome returns the outcome of a backup or restore from its error, mirroring BackupError.
+func HookOutcome(err error) string {
+	var backupError *backup.BackupError
+	switch {
+	case err == nil:
+		return HookSuccess
+	case errors.As(err, &backupError) && !backupError.Abort:
+		return HookPartial
+	default:
+		return HookAborted
+	}
+}
+
+// hookEnvironment returns the environment variables describing the backup to the hooks.
+func hookEnvironment(hook string, context *HookContext) []string {
+	env := []string{
+		"UYUNI_BACKUP_HOOK=" + hook,
+		"UYUNI_BACKUP_LOCATION=" + context.Location,
+	}
+	if context.Manifest != nil {
+		artifacts := []string{}
+		for _, artifact := range context.Manifest.Artifacts {
+			artifacts = append(artifacts, artifact.File)
+		}
+		env = append(env, "UYUNI_BACKUP_ARTIFACTS="+strings.Join(artifacts, "\n"))
+	}
+	if hook == HookPostBackup || hook == HookPostRestore {
+		env = append(env, "UYUNI_BACKUP_STATUS="+HookOutcome(context.Err))
+		if context.Err != nil {
+			env = append(env, "UYUNI_BACKUP_ERROR="+context.Err.Error())
+		}
+	}
+	return env
+}
+
+// RunHooks runs the command configured for the hook and the executables of its directory.
+//
+// The command is run with sh -c, then the executables of the hook directory in lexical order.
+// All the hooks are stopped at the first failure.
+func RunHooks(flags *HookFlags, hook string, context *HookContext) error {
+	commands := [][]string{}
+	if command := flags.command(hook); command != "" {
+		commands = append(commands, []string{"sh", "-c", command})
+	}
+	for _, script := range flags.hookScripts(hook) {
+		commands = append(commands, []string{script})
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+
+	env := hookEnvironment(hook, context)
+	for _, command := range commands {
+		commandLine := strings.Join(command, " ")
+		if context.DryRun {
+			log.Info().Msgf(L("Would run %[1]s hook %[2]s"), hook, commandLine)
+			continue
+		}
+		log.Info().Msgf(L("Running %[1]s hook %[2]s"), hook, commandLine)
+		if _, err := utils.NewRunner(command[0], command[1:]...).Env(env).StdMapping().Exec(); err != nil {
+			return utils.Errorf(err, L("%[1]s hook %[2]s failed"), hook, commandLine)
+		}
+	}
+	return nil
+}
+
+// RunPostHooks runs the post hooks with the outcome of the backup or restore.
+// A failing post hook doesn't change an aborted outcome, but makes a successful one partial.
+func RunPostHooks(flags *HookFlags, hook string, context *HookContext) error {
+	err := RunHooks(flags, hook, context)
+	if err == nil {
+		return context.Err
+	}
+	log.Warn().Err(err).Msgf(L("%s hooks failed"), hook)
+	if HookOutcome(context.Err) == HookAborted {
+		return context.Err
+	}
+	return backup.ReportError(utils.JoinErrors(context.Err, err))
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestHookOutcome(t *testing.T) {
+	testutils.AssertEquals(t, "Unexpected outcome without error", HookSuccess, HookOutcome(nil))
+	testutils.AssertEquals(t, "Unexpected outcome for reported error", HookPartial,
+		HookOutcome(backup.ReportError(errors.New("partial"))))
+	testutils.AssertEquals(t, "Unexpected outcome for aborting error", HookAborted,
+		HookOutcome(backup.AbortError(errors.New("aborted"), true)))
+}
+
+// writeHook writes a hook script in the hook directory.
+func writeHook(t *testing.T, dir string, hook string, name string, script string, mode os.FileMode) {
+	hookDir := path.Join(dir, hook+".d")
+	testutils.AssertNoError(t, "failed to create hook directory", os.MkdirAll(hookDir, 0755))
+	testutils.AssertNoError(t, "failed to write hook",
+		os.WriteFile(path.Join(hookDir, name), []byte("#!/bin/sh\n"+script), mode))
+}
+
+func TestRunHooks(t *testing.T) {
+	dir := t.TempDir()
+	output := path.Join(dir, "output")
+	writeHook(t, dir, HookPostBackup, "20-second", `echo "second $UYUNI_BACKUP_STATUS" >>`+output+"\n", 0755)
+	writeHook(t, dir, HookPostBackup, "10-first", `echo "first $UYUNI_BACKUP_ARTIFACTS" >>`+output+"\n", 0755)
+	writeHook(t, dir, HookPostBackup, "30-disabled", "echo disabled >>"+output+"\n", 0644)
+
+	flags := HookFlags{Dir: dir, PostBackup: `echo "command $UYUNI_BACKUP_LOCATION" >>` + output}
+	context := HookContext{
+		Location: "/backup",
+		Manifest: &backup.Manifest{Artifacts: []backup.ManifestArtifact{{File: "volumes/etc-rhn.tar"}}},
+		Err:      backup.ReportError(errors.New("some images were not saved")),
+	}
+	err := RunPostHooks(&flags, HookPostBackup, &context)
+	testutils.AssertEquals(t, "The backup error should be returned", context.Err, err)
+
+	content, err := os.ReadFile(output)
+	testutils.AssertNoError(t, "hooks should write the output", err)
+	testutils.AssertEquals(t, "Unexpected hooks output",
+		"command /backup\nfirst volumes/etc-rhn.tar\nsecond partial\n", string(content))
+}
+
+func TestRunHooksFailure(t *testing.T) {
+	dir := t.TempDir()
+	output := path.Join(dir, "output")
+	writeHook(t, dir, HookPreRestore, "10-fail", "exit 1\n", 0755)
+	writeHook(t, dir, HookPreRestore, "20-next", "echo next >>"+output+"\n", 0755)
+
+	flags := HookFlags{Dir: dir}
+	err := RunHooks(&flags, HookPreRestore, &HookContext{Location: "/backup"})
+	testutils.AssertError(t, "pre-restore hook", err)
+	_, err = os.Stat(output)
+	testutils.AssertTrue(t, "Hooks after a failing one should not run", os.IsNotExist(err))
+
+	// A failing post hook makes a successful restore partial
+	flags.PostRestore = "false"
+	err = RunPostHooks(&flags, HookPostRestore, &HookContext{Location: "/backup"})
+	testutils.AssertEquals(t, "Unexpected outcome", HookPartial, HookOutcome(err))
+	testutils.AssertTrue(t, "Unexpected error: "+err.Error(), strings.Contains(err.Error(), "post-restore hook"))
+}
+
+func TestRunHooksDryRun(t *testing.T) {
+	dir := t.TempDir()
+	output := path.Join(dir, "output")
+	flags := HookFlags{Dir: dir, PreBackup: "echo run >" + output}
+	testutils.AssertNoError(t, "dry run should not fail",
+		RunHooks(&flags, HookPreBackup, &HookContext{Location: "/backup", DryRun: true}))
+	_, err := os.Stat(output)
+	testutils.AssertTrue(t, "Hooks should not run in dry run", os.IsNotExist(err))
+}
@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// progressRefresh is the delay between two updates of the progress spinner.
+const progressRefresh = time.Second
+
+// progressLogInterval is the delay between two progress messages in the log file.
+const progressLogInterval = 30 * time.Second
+
+// Progress shows the progress of concurrent transfers in a spinner and in the log file.
+type Progress struct {
+	mutex   sync.Mutex
+	tasks   []*ProgressTask
+	spinner *spinner.Spinner
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// ProgressTask counts the bytes transferred for one item.
+// It is an io.Writer to be fed with a copy of the transferred data.
+type ProgressTask struct {
+	Name  string
+	Total int64
+	start time.Time
+	done  atomic.Int64
+	ended atomic.Bool
+}
+
+// NewProgress creates a progress display. Start needs to be called to show it.
+func NewProgress() *Progress {
+	return &Progress{}
+}
+
+// Add registers a new transfer. The total is the expected number of bytes, 0 if unknown.
+func (p *Progress) Add(name string, total int64) *ProgressTask {
+	task := &ProgressTask{Name: name, Total: total, start: time.Now()}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.tasks = append(p.tasks, task)
+	return task
+}
+
+// Start shows the spinner and refreshes it until Stop is called.
+func (p *Progress) Start() {
+	p.spinner = spinner.New(spinner.CharSets[14], 100*time.Millisecond)
+	p.stop = make(chan struct{})
+	p.stopped = make(chan struct{})
+	p.spinner.Start()
+
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(progressRefresh)
+		defer ticker.Stop()
+		lastLog := time.Now()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case now := <-ticker.C:
+				status := p.status(now)
+				p.spinner.Lock()
+				p.spinner.Suffix = " " + strings.Join(status, " | ")
+				p.spinner.Unlock()
+				if now.Sub(lastLog) >= progressLogInterval {
+					for _, line := range status {
+						log.Debug().Msg(line)
+					}
+					lastLog = now
+				}
+			}
+		}
+	}()
+}
+
+// Stop hides the spinner.
+func (p *Progress) Stop() {
+	if p.spinner == nil {
+		return
+	}
+	close(p.stop)
+	<-p.stopped
+	p.spinner.Stop()
+	p.spinner = nil
+}
+
+// status returns the progress of the running transfers.
+func (p *Progress) status(now time.Time) []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	status := []string{}
+	for _, task := range p.tasks {
+		if !task.ended.Load() {
+			status = append(status, task.status(now))
+		}
+	}
+	return status
+}
+
+// Write counts the transferred bytes.
+func (t *ProgressTask) Write(p []byte) (int, error) {
+	t.done.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Done returns the number of bytes transferred so far.
+func (t *ProgressTask) Done() int64 {
+	return t.done.Load()
+}
+
+// End stops showing the transfer in the progress and logs its summary if some data were transferred.
+func (t *ProgressTask) End(err error) {
+	t.ended.Store(true)
+	if err != nil || t.Done() == 0 {
+		return
+	}
+	elapsed := time.Since(t.start)
+	log.Info().Msgf(L("%[1]s: transferred %[2]s in %[3]s (%[4]s)"), t.Name, utils.FormatSize(t.Done()),
+		elapsed.Round(time.Second), formatThroughput(t.Done(), elapsed))
+}
+
+// status returns a line describing the bytes done, the throughput and the estimated remaining time.
+func (t *ProgressTask) status(now time.Time) string {
+	done := t.Done()
+	elapsed := now.Sub(t.start)
+	status := fmt.Sprintf("%s: %s", t.Name, utils.FormatSize(done))
+	if t.Total > 0 {
+		status += "/" + utils.FormatSize(t.Total)
+	}
+	status += ", " + formatThroughput(done, elapsed)
+	if t.Total > done && done > 0 && elapsed > 0 {
+		remaining := time.Duration(float64(elapsed) * float64(t.Total-done) / float64(done))
+		status += ", " + fmt.Sprintf(L("ETA %s"), remaining.Round(time.Second))
+	}
+	return status
+}
+
+// formatThroughput returns the average transfer rate as a human readable string.
+func formatThroughput(done int64, elapsed time.Duration) string {
+	if elapsed <= 0 {
+		return utils.FormatSize(0) + "/s"
+	}
+	return utils.FormatSize(int64(float64(done)/elapsed.Seconds())) + "/s"
+}
+
+// RunParallel calls run for each index from 0 to count-1, with at most parallel calls running at the same time.
+//
+// No new call is started after a failure, but the running ones are awaited.
+// The errors are returned joined in the index order.
+func RunParallel(count int, parallel int, run func(index int) error) error {
+	parallel = max(parallel, 1)
+	errs := make([]error, count)
+	slots := make(chan struct{}, parallel)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+
+	for i := 0; i < count; i++ {
+		slots <- struct{}{}
+		if failed.Load() {
+			break
+		}
+		wg.Add(1)
+		go func(index int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := run(index); err != nil {
+				errs[index] = err
+				failed.Store(true)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var hasError error
+	for _, err := range errs {
+		hasError = utils.JoinErrors(hasError, err)
+	}
+	return hasError
+}
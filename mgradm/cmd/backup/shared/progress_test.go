@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestProgressTaskStatus(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	task := &ProgressTask{Name: "var-spacewalk", Total: 4 * 1024 * 1024, start: start}
+	_, _ = task.Write(make([]byte, 1024*1024))
+
+	testutils.AssertEquals(t, "Unexpected status",
+		"var-spacewalk: 1.0 MiB/4.0 MiB, 512.0 KiB/s, ETA 6s", task.status(start.Add(2*time.Second)))
+
+	unknown := &ProgressTask{Name: "etc-rhn", start: start}
+	_, _ = unknown.Write(make([]byte, 2048))
+	testutils.AssertEquals(t, "Unexpected status without total",
+		"etc-rhn: 2.0 KiB, 1.0 KiB/s", unknown.status(start.Add(2*time.Second)))
+}
+
+func TestProgressStatusSkipsEndedTasks(t *testing.T) {
+	progress := NewProgress()
+	progress.Add("var-pgsql", 0)
+	progress.Add("etc-rhn", 0).End(nil)
+
+	status := progress.status(time.Now())
+	testutils.AssertEquals(t, "Only the running task should be shown", 1, len(status))
+	testutils.AssertTrue(t, "Unexpected running task", status[0][:len("var-pgsql")] == "var-pgsql")
+}
+
+func TestRunParallelLimit(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	done := make([]bool, 6)
+	err := RunParallel(len(done), 2, func(index int) error {
+		current := running.Add(1)
+		for {
+			previous := maxRunning.Load()
+			if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		done[index] = true
+		return nil
+	})
+
+	testutils.AssertNoError(t, "unexpected error", err)
+	testutils.AssertTrue(t, "too many parallel calls", maxRunning.Load() <= 2)
+	for i, value := range done {
+		testutils.AssertTrue(t, fmt.Sprintf("call %d not done", i), value)
+	}
+}
+
+func TestRunParallelErrors(t *testing.T) {
+	var calls atomic.Int32
+	err := RunParallel(5, 1, func(index int) error {
+		calls.Add(1)
+		if index == 1 {
+			return errors.New("export failed")
+		}
+		return nil
+	})
+
+	testutils.AssertError(t, "export failed", err)
+	testutils.AssertEquals(t, "No call should be started after a failure", int32(2), calls.Load())
+}
+
+func TestExportArtifactWithProgress(t *testing.T) {
+	dir := t.TempDir()
+	target, err := backup.NewTarget(dir, &backup.TargetFlags{})
+	testutils.AssertNoError(t, "failed to create target", err)
+	task := &ProgressTask{Name: "content"}
+
+	artifact, err := backup.ExportArtifactWithProgress(target, "content.txt", &backup.ArtifactFlags{}, false, task,
+		"echo", "-n", "some content")
+	testutils.AssertNoError(t, "failed to export", err)
+	testutils.AssertEquals(t, "Unexpected exported size", int64(12), artifact.Size)
+	testutils.AssertEquals(t, "Unexpected progress", int64(12), task.Done())
+
+	importTask := &ProgressTask{Name: "content"}
+	err = backup.ImportArtifactWithProgress(target, artifact.File, &backup.ArtifactFlags{}, artifact.Checksum, false,
+		importTask, "cat", "-")
+	testutils.AssertNoError(t, "failed to import", err)
+	testutils.AssertEquals(t, "Unexpected import progress", int64(12), importTask.Done())
+	testutils.AssertEquals(t, "Unexpected artifact file", "content.txt", path.Base(artifact.File))
+}
@@ -4,6 +4,11 @@
 
 package shared
 
+import (
+	adm_utils "github.com/uyuni-project/uyuni-tools/mgradm/shared/utils"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+)
+
 type Flagpole struct {
 	SkipVolumes  []string `mapstructure:"skipvolumes"`
 	ExtraVolumes []string `mapstructure:"extravolumes"`
@@ -16,51 +21,29 @@ type Flagpole struct {
 	ForceRestore bool     `mapstructure:"force"`
 	SkipExisting bool     `mapstructure:"continue"`
 	SkipVerify   bool     `mapstructure:"skipverify"`
-}
-
-// Backup error indicating if something was already backed up (resp. restored) or not.
-type BackupError struct {
-	Err         error
-	DataRemains bool
-	Abort       bool
-}
-
-func (e *BackupError) Error() string {
-	return e.Err.Error()
-}
-
-func (e *BackupError) Unwrap() error {
-	return e.Err
-}
-
-// Wrap error with metadata indicating this error was fatal and job was aborted.
-func AbortError(err error, dataRemains bool) error {
-	if err == nil {
-		return nil
-	}
-	return &BackupError{
-		Err:         err,
-		DataRemains: dataRemains,
-		Abort:       true,
-	}
-}
-
-// Wrap error with metadata indicating this error was not fatal.
-func ReportError(err error) error {
-	if err == nil {
-		return nil
-	}
-	return &BackupError{
-		Err:         err,
-		DataRemains: true,
-		Abort:       false,
-	}
-}
-
-// Map of podman secret name and value.
-type BackupSecretMap struct {
-	Name   string
-	Secret string
+	Timestamped  bool     `mapstructure:"timestamped"`
+	Parallel     int      `mapstructure:"parallel"`
+	Snapshot     bool     `mapstructure:"snapshot"`
+	Volume       []string `mapstructure:"volume"`
+	Include      []string `mapstructure:"include"`
+	Output       struct {
+		Dir string `mapstructure:"dir"`
+	} `mapstructure:"output"`
+	FQDN  string                    `mapstructure:"fqdn"`
+	SSL   adm_utils.InstallSSLFlags `mapstructure:"ssl"`
+	Hooks HookFlags                 `mapstructure:"hooks"`
+
+	Artifacts backup.ArtifactFlags `mapstructure:",squash"`
+	Target    backup.TargetFlags   `mapstructure:",squash"`
+	Sign      struct {
+		Key string `mapstructure:"key"`
+	} `mapstructure:"sign"`
+	Unsigned bool `mapstructure:"unsigned"`
+}
+
+// IsSelective returns true if only some volumes or files of the backup are to be restored.
+func (f *Flagpole) IsSelective() bool {
+	return len(f.Volume) > 0
 }
 
 type NetworkSubnet struct {
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package verify
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Flagpole holds the flags of the backup verify command.
+type Flagpole struct {
+	Require struct {
+		Signature bool `mapstructure:"signature"`
+	} `mapstructure:"require"`
+	Allow struct {
+		Partial bool `mapstructure:"partial"`
+	} `mapstructure:"allow"`
+	Deep      bool                 `mapstructure:"deep"`
+	Artifacts backup.ArtifactFlags `mapstructure:",squash"`
+	Target    backup.TargetFlags   `mapstructure:",squash"`
+}
+
+// Verify checks a backup against its manifest without touching the running system.
+func Verify(
+	_ *types.GlobalFlags,
+	flags *Flagpole,
+	_ *cobra.Command,
+	args []string,
+) error {
+	backupDir := args[0]
+
+	if err := flags.Artifacts.CheckArtifactTools(); err != nil {
+		return err
+	}
+
+	target, err := backup.NewTarget(backupDir, &flags.Target)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	manifest, err := backup.ReadManifest(target)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf(L("Backup created on %[1]s by version %[2]s of the tools"), manifest.Created, manifest.ToolVersion)
+	if version := manifest.ServerVersion(); version != "" {
+		log.Info().Msgf(L("Backed up server version: %s"), version)
+	}
+	if err := checkStatus(manifest, flags.Allow.Partial); err != nil {
+		return err
+	}
+
+	var hasError error
+	if backup.IsManifestSigned(target) {
+		if err := backup.VerifyManifestSignature(target); err != nil {
+			return err
+		}
+		log.Info().Msg(L("Backup manifest signature is valid"))
+	} else if flags.Require.Signature {
+		return fmt.Errorf(L("backup manifest in %s is not signed"), backupDir)
+	} else {
+		log.Warn().Msg(L("Backup manifest is not signed, tampering cannot be detected"))
+	}
+
+	failed := 0
+	for _, artifact := range manifest.Artifacts {
+		if err := verifyArtifact(target, &artifact, flags); err != nil {
+			log.Error().Err(err).Msgf(L("%[1]s %[2]s is not valid"), artifact.Kind, artifact.Name)
+			hasError = utils.JoinErrors(hasError, err)
+			failed++
+			continue
+		}
+		log.Info().Msgf(L("%[1]s %[2]s is valid"), artifact.Kind, artifact.Name)
+	}
+
+	if hasError != nil {
+		return utils.Errorf(hasError, L("%[1]d of %[2]d backup files failed the verification"),
+			failed, len(manifest.Artifacts))
+	}
+	if !hasVolumes(manifest) {
+		return fmt.Errorf(L("no volume found in the manifest of the backup in %s"), backupDir)
+	}
+	log.Info().Msgf(L("Backup in %s is valid"), backupDir)
+	return nil
+}
+
+func verifyArtifact(target backup.Target, artifact *backup.ManifestArtifact, flags *Flagpole) error {
+	if !flags.Deep {
+		return artifact.Verify(target)
+	}
+
+	if err := artifact.CheckSize(target); err != nil {
+		return err
+	}
+	// Decode the whole artifact to ensure it can be decrypted and decompressed,
+	// checking its checksum on the way to read it only once.
+	return backup.ImportArtifact(target, artifact.File, &flags.Artifacts, artifact.Checksum, false, "tar", "tf", "-")
+}
+
+// checkStatus refuses the backups missing data, unless partial ones are allowed.
+// Aborted backups are always refused.
+func checkStatus(manifest *backup.Manifest, allowPartial bool) error {
+	switch {
+	case manifest.IsComplete():
+		return nil
+	case manifest.Status == backup.BackupPartial && allowPartial:
+		log.Warn().Msgf(L("Backup status is %s, some data may be missing"), manifest.Status)
+		return nil
+	case manifest.Status == backup.BackupPartial:
+		return fmt.Errorf(L("backup status is %s, some data are missing: use --allow-partial to accept it"),
+			manifest.Status)
+	}
+	return fmt.Errorf(L("backup status is %s, it cannot be used"), manifest.Status)
+}
+
+func hasVolumes(manifest *backup.Manifest) bool {
+	for _, artifact := range manifest.Artifacts {
+		if artifact.Kind == backup.ArtifactVolume {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package verify
+
+import (
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestCheckStatus(t *testing.T) {
+	complete := backup.Manifest{Status: backup.BackupComplete}
+	partial := backup.Manifest{Status: backup.BackupPartial}
+	aborted := backup.Manifest{Status: backup.BackupAborted}
+
+	testutils.AssertNoError(t, "complete backup should be accepted", checkStatus(&complete, false))
+	testutils.AssertError(t, "use --allow-partial to accept it", checkStatus(&partial, false))
+	testutils.AssertNoError(t, "allowed partial backup should be accepted", checkStatus(&partial, true))
+	testutils.AssertError(t, "backup status is aborted, it cannot be used", checkStatus(&aborted, true))
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package check
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/check"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+type checkFlags struct {
+	Output string
+}
+
+func newCmd(
+	globalFlags *types.GlobalFlags,
+	operation check.Operation,
+	run func(*types.GlobalFlags, *checkFlags, check.Operation, []string) error,
+) *cobra.Command {
+	var flags checkFlags
+	cmd := &cobra.Command{
+		Use:  string(operation),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil,
+				func(globalFlags *types.GlobalFlags, flags *checkFlags, _ *cobra.Command, args []string) error {
+					return run(globalFlags, flags, operation, args)
+				})
+		},
+	}
+	if operation == check.Install {
+		cmd.Use = "install [fqdn]"
+		cmd.Short = L("Check the host before installing a server")
+		cmd.Args = cobra.MaximumNArgs(1)
+	} else {
+		cmd.Short = L("Check the host before upgrading the server")
+	}
+	cmd.Flags().String("output", "text", L("Output format. Possible values: text, json"))
+	return cmd
+}
+
+// NewCommand to check the host before installing or upgrading a server.
+func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "check",
+		GroupID: "deploy",
+		Short:   L("Check the host before installing or upgrading a server"),
+		Long: L(`Check the host before installing or upgrading a server
+
+Each check passes, warns or fails, with a hint to fix the issue.
+The command fails if one of the checks failed.
+The install and upgrade commands run these checks unless --skipchecks is passed.`),
+		Args: cobra.ExactArgs(0),
+	}
+	cmd.AddCommand(newCmd(globalFlags, check.Install, runChecks))
+	cmd.AddCommand(newCmd(globalFlags, check.Upgrade, runChecks))
+	return cmd
+}
+
+func runChecks(globalFlags *types.GlobalFlags, flags *checkFlags, operation check.Operation, args []string) error {
+	if flags.Output != "text" && flags.Output != "json" {
+		return fmt.Errorf(L("unsupported output format %s: possible values are text and json"), flags.Output)
+	}
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+
+	ctx := check.Context{
+		Operation: operation,
+		Host:      host,
+		FQDN:      check.Fqdn(host, args),
+		Ports:     check.ServerPorts(false, false, false),
+	}
+	results := check.Run(&ctx)
+	if flags.Output == "json" {
+		if err := check.Write(os.Stdout, results); err != nil {
+			return err
+		}
+	} else {
+		check.Log(results)
+	}
+	return check.Failed(results)
+}
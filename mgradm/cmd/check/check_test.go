@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package check
+
+import (
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/check"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+)
+
+func TestParamsParsing(t *testing.T) {
+	args := []string{"--output", "json", "srv.fq.dn"}
+
+	// Test function asserting that the args are properly parsed
+	tester := func(_ *types.GlobalFlags, flags *checkFlags, operation check.Operation, args []string) error {
+		testutils.AssertEquals(t, "Error parsing --output", "json", flags.Output)
+		testutils.AssertEquals(t, "Wrong operation", check.Install, operation)
+		testutils.AssertEquals(t, "Wrong FQDN", "srv.fq.dn", args[0])
+		return nil
+	}
+
+	globalFlags := types.GlobalFlags{}
+	cmd := newCmd(&globalFlags, check.Install, tester)
+
+	testutils.AssertHasAllFlags(t, cmd, args)
+
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("command failed with error: %s", err)
+	}
+}
+
+func TestInvalidOutput(t *testing.T) {
+	err := runChecks(&types.GlobalFlags{}, &checkFlags{Output: "yaml"}, check.Upgrade, nil)
+	testutils.AssertError(t, "unsupported output format yaml", err)
+}
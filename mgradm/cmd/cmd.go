@@ -5,15 +5,19 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/apply"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/check"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/distro"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/gpg"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/images"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/inspect"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/install"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/restart"
@@ -59,7 +63,7 @@ func NewUyuniadmCommand() (*cobra.Command, error) {
 
 	rootCmd.SetUsageTemplate(utils.GetLocalizedUsageTemplate())
 
-	rootCmd.PersistentPreRun = func(cmd *cobra.Command, _ []string) {
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
 		// do not log if running the completion cmd as the output is redirected to create a file to source
 		if cmd.Name() != "completion" {
 			utils.LogInit(true)
@@ -67,9 +71,13 @@ func NewUyuniadmCommand() (*cobra.Command, error) {
 			log.Info().Msgf(L("Starting %s"), strings.Join(os.Args, " "))
 			log.Info().Msgf(L("Use of this software implies acceptance of the End User License Agreement."))
 		}
+		return checkRemoteHost(cmd, globalFlags.Host)
 	}
 
 	rootCmd.PersistentFlags().StringVarP(&globalFlags.ConfigPath, "config", "c", "", L("configuration file path"))
+	rootCmd.PersistentFlags().StringVar(&globalFlags.Host, "host", "",
+		L("ssh://[user@]host[:port] URL of the remote host to manage the server on, instead of the local one. "+
+			"The podman.socket service needs to be enabled on the remote host"))
 	utils.AddLogLevelFlags(rootCmd, &globalFlags.LogLevel)
 
 	installCmd := install.NewCommand(globalFlags)
@@ -90,7 +98,10 @@ func NewUyuniadmCommand() (*cobra.Command, error) {
 	rootCmd.AddCommand(status.NewCommand(globalFlags))
 	rootCmd.AddCommand(inspect.NewCommand(globalFlags))
 	rootCmd.AddCommand(upgrade.NewCommand(globalFlags))
+	rootCmd.AddCommand(check.NewCommand(globalFlags))
+	rootCmd.AddCommand(apply.NewCommand(globalFlags))
 	rootCmd.AddCommand(gpg.NewCommand(globalFlags))
+	rootCmd.AddCommand(images.NewCommand(globalFlags))
 	rootCmd.AddCommand(backup.NewCommand(globalFlags))
 	rootCmd.AddCommand(server.NewCommand(globalFlags))
 	rootCmd.AddCommand(ssl.NewCommand(globalFlags))
@@ -99,3 +110,24 @@ func NewUyuniadmCommand() (*cobra.Command, error) {
 
 	return rootCmd, err
 }
+
+// localOnlyCommands lists the commands directly handling the files of the server host,
+// and thus not supporting a remote host.
+var localOnlyCommands = []string{"backup", "support", "gpg", "images", "distro"}
+
+// checkRemoteHost validates the remote host URL and refuses it for the commands not supporting it.
+func checkRemoteHost(cmd *cobra.Command, host string) error {
+	if host == "" {
+		return nil
+	}
+	topCmd := cmd
+	for topCmd.HasParent() && topCmd.Parent().HasParent() {
+		topCmd = topCmd.Parent()
+	}
+	if utils.Contains(localOnlyCommands, topCmd.Name()) {
+		return fmt.Errorf(L("%s command does not support managing a server on a remote host"), topCmd.Name())
+	}
+
+	_, err := utils.NewHost(host)
+	return err
+}
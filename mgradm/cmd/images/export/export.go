@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package imagesexport
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/images/shared"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+type exportFlags struct {
+	shared.ImagesFlags `mapstructure:",squash"`
+	Sign               struct {
+		Key string `mapstructure:"key"`
+	} `mapstructure:"sign"`
+	Unsigned bool `mapstructure:"unsigned"`
+}
+
+func newCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[exportFlags]) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export bundle.tar",
+		Short: L("Export the images needed to install or upgrade a server into a bundle"),
+		Long: L(`Export the images needed to install or upgrade a server into a bundle
+
+The images are pulled if needed and saved in a tar bundle with a manifest listing their checksums.
+The manifest is signed with a GPG key to detect tampering, unless --unsigned is used.
+Copy the bundle to a disconnected host and run the images import command to load them there.`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var flags exportFlags
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	shared.AddImagesFlags(cmd)
+	cmd.Flags().String("sign-key", "", L("GPG key to sign the bundle manifest with. Required unless --unsigned is used"))
+	cmd.Flags().Bool("unsigned", false,
+		L("Do not sign the bundle manifest. Tampering with the bundle will not be detected"))
+	return cmd
+}
+
+// NewCommand creates the command exporting the images into a bundle.
+func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
+	return newCmd(globalFlags, exportImages)
+}
+
+func exportImages(_ *types.GlobalFlags, flags *exportFlags, _ *cobra.Command, args []string) error {
+	if err := utils.CheckSignKey(flags.Sign.Key, flags.Unsigned, L("bundle manifest")); err != nil {
+		return err
+	}
+
+	images, err := shared.ListImages(&flags.ImagesFlags)
+	if err != nil {
+		return err
+	}
+
+	hostData, err := podman.InspectHost(nil)
+	if err != nil {
+		return err
+	}
+	authFile, cleaner, err := podman.PodmanLogin(hostData, flags.Image.Registry, flags.SCC)
+	if err != nil {
+		return err
+	}
+	defer cleaner()
+
+	signaturePolicy, cleanPolicy, err := podman.SetupSignatureVerification(nil, &flags.Signatures)
+	if err != nil {
+		return err
+	}
+	defer cleanPolicy()
+
+	for i, image := range images {
+		preparedImage, err := podman.PrepareImage(
+			nil, authFile, signaturePolicy, image.Name, flags.Image.PullPolicy, true,
+		)
+		if err != nil {
+			return utils.Errorf(err, L("cannot prepare image %s"), image.Name)
+		}
+		images[i].Name = preparedImage
+	}
+
+	if err := shared.WriteBundle(args[0], images, flags.Sign.Key); err != nil {
+		return err
+	}
+	log.Info().Msgf(L("%[1]d images exported to %[2]s"), len(images), args[0])
+	return nil
+}
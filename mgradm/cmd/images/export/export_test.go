@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package imagesexport
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils/flagstests"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+)
+
+func TestParamsParsing(t *testing.T) {
+	args := []string{
+		"--scc-user", "mysccuser",
+		"--scc-password", "mysccpass",
+		"--coco-image", "cocoimg",
+		"--coco-tag", "cocotag",
+		"--hubxmlrpc-image", "hubimg",
+		"--hubxmlrpc-tag", "hubtag",
+		"--saline-image", "salineimg",
+		"--saline-tag", "salinetag",
+		"--tftpd-image", "tftpdimg",
+		"--tftpd-tag", "tftpdtag",
+		"--proxy",
+		"--sign-key", "admin@example.com",
+		"--unsigned",
+		"bundle.tar",
+	}
+	args = append(args, flagstests.ImageFlagsTestArgs...)
+	args = append(args, flagstests.DBUpdateImageFlagTestArgs...)
+	args = append(args, flagstests.SignatureFlagsTestArgs...)
+	args = append(args, flagstests.PgsqlFlagsTestArgs...)
+
+	// Test function asserting that the args are properly parsed
+	tester := func(_ *types.GlobalFlags, flags *exportFlags, _ *cobra.Command, args []string) error {
+		flagstests.AssertImageFlag(t, &flags.Image)
+		flagstests.AssertDBUpgradeImageFlag(t, &flags.DBUpgradeImage)
+		flagstests.AssertPgsqlFlag(t, &flags.Pgsql)
+		flagstests.AssertSignatureFlags(t, &flags.Signatures)
+		testutils.AssertEquals(t, "Error parsing --scc-user", "mysccuser", flags.SCC.User)
+		testutils.AssertEquals(t, "Error parsing --scc-password", "mysccpass", flags.SCC.Password)
+		testutils.AssertEquals(t, "Error parsing --coco-image", "cocoimg", flags.Coco.Image.Name)
+		testutils.AssertEquals(t, "Error parsing --coco-tag", "cocotag", flags.Coco.Image.Tag)
+		testutils.AssertEquals(t, "Error parsing --hubxmlrpc-image", "hubimg", flags.HubXmlrpc.Image.Name)
+		testutils.AssertEquals(t, "Error parsing --hubxmlrpc-tag", "hubtag", flags.HubXmlrpc.Image.Tag)
+		testutils.AssertEquals(t, "Error parsing --saline-image", "salineimg", flags.Saline.Image.Name)
+		testutils.AssertEquals(t, "Error parsing --saline-tag", "salinetag", flags.Saline.Image.Tag)
+		testutils.AssertEquals(t, "Error parsing --tftpd-image", "tftpdimg", flags.TFTPD.Image.Name)
+		testutils.AssertEquals(t, "Error parsing --tftpd-tag", "tftpdtag", flags.TFTPD.Image.Tag)
+		testutils.AssertTrue(t, "Error parsing --proxy", flags.Proxy)
+		testutils.AssertEquals(t, "Error parsing --sign-key", "admin@example.com", flags.Sign.Key)
+		testutils.AssertTrue(t, "Error parsing --unsigned", flags.Unsigned)
+		testutils.AssertEquals(t, "Wrong bundle path", []string{"bundle.tar"}, args)
+		return nil
+	}
+
+	globalFlags := types.GlobalFlags{}
+	cmd := newCmd(&globalFlags, tester)
+
+	testutils.AssertHasAllFlags(t, cmd, args)
+
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("command failed with error: %s", err)
+	}
+}
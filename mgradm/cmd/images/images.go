@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package images
+
+import (
+	"github.com/spf13/cobra"
+	imagesexport "github.com/uyuni-project/uyuni-tools/mgradm/cmd/images/export"
+	imagesimport "github.com/uyuni-project/uyuni-tools/mgradm/cmd/images/import"
+	imagesmirror "github.com/uyuni-project/uyuni-tools/mgradm/cmd/images/mirror"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+)
+
+// NewCommand creates the command managing the container images for disconnected hosts.
+func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
+	imagesCmd := &cobra.Command{
+		Use:     "images",
+		GroupID: "tool",
+		Short:   L("Move the container images to disconnected hosts or private registries"),
+		Args:    cobra.ExactArgs(1),
+	}
+
+	imagesCmd.AddCommand(imagesexport.NewCommand(globalFlags))
+	imagesCmd.AddCommand(imagesimport.NewCommand(globalFlags))
+	imagesCmd.AddCommand(imagesmirror.NewCommand(globalFlags))
+
+	return imagesCmd
+}
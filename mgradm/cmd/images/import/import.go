@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package imagesimport
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/images/shared"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+type importFlags struct {
+	Require struct {
+		Signature bool `mapstructure:"signature"`
+	} `mapstructure:"require"`
+}
+
+func newCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[importFlags]) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import bundle.tar",
+		Short: L("Load the images of a bundle"),
+		Long: L(`Load the images of a bundle created by the images export command
+
+The checksum of each image is verified against the bundle manifest before loading it.
+The signature of the manifest is verified using the keys of the GPG keyring.
+The images are loaded one after the other: if one of them fails, the previous ones stay loaded.
+
+To use the loaded images, run the install or upgrade commands with --pullPolicy IfNotPresent,
+or Never if the registry cannot be reached.
+The loaded images cannot be checked with --verify-signatures: it pulls the images to verify them.`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var flags importFlags
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	cmd.Flags().Bool("require-signature", false, L("Fail if the bundle manifest is not signed"))
+	return cmd
+}
+
+// NewCommand creates the command loading the images of a bundle.
+func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
+	return newCmd(globalFlags, importImages)
+}
+
+func importImages(_ *types.GlobalFlags, flags *importFlags, _ *cobra.Command, args []string) error {
+	manifest, err := shared.ImportBundle(args[0], flags.Require.Signature)
+	if err != nil {
+		return utils.Errorf(err, L("failed to import %s"), args[0])
+	}
+	for _, image := range manifest.Images {
+		log.Info().Msgf(L("Imported %[1]s image %[2]s"), image.Component, image.Name)
+	}
+	log.Info().Msg(L("Use --pullPolicy IfNotPresent, or Never if the registry cannot be reached, " +
+		"to install or upgrade with the imported images"))
+	log.Warn().Msg(L("The imported images cannot be used with --verify-signatures: it pulls the images to verify them"))
+	return nil
+}
@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package imagesmirror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/images/shared"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+type mirrorFlags struct {
+	shared.ImagesFlags `mapstructure:",squash"`
+	// Target is the registry to copy the images to.
+	Target types.Registry
+	// Dry is mapped from the --dry-run flag.
+	Dry struct {
+		Run bool
+	}
+}
+
+// mirroredImage is an image to copy to the target registry.
+type mirroredImage struct {
+	shared.Image
+	Destination string
+}
+
+// Functions pointers to mock podman in unit tests.
+var (
+	runCmdOutput = utils.RunCmdOutput
+	prepareImage = podman.PrepareImage
+)
+
+func newCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[mirrorFlags]) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mirror",
+		Short: L("Copy the images needed to install or upgrade a server to a private registry"),
+		Long: L(`Copy the images needed to install or upgrade a server to a private registry
+
+The images are pulled from the source registry, pushed to the target one and the registry configuration
+to use for the installation and upgrades is printed.`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var flags mirrorFlags
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	shared.AddImagesFlags(cmd)
+
+	cmd.Flags().String("target-host", "", L("Registry to copy the images to, optionally with a path"))
+	cmd.Flags().String("target-user", "", L("User if the target registry requires an authentication"))
+	cmd.Flags().String("target-password", "", L("Password if the target registry requires an authentication"))
+	cmd.Flags().Bool("dry-run", false, L("Only list the source and destination images, without copying them"))
+
+	_ = utils.AddFlagHelpGroup(cmd, &utils.Group{ID: "target", Title: L("Target Registry Flags")})
+	_ = utils.AddFlagToHelpGroupID(cmd, "target-host", "target")
+	_ = utils.AddFlagToHelpGroupID(cmd, "target-user", "target")
+	_ = utils.AddFlagToHelpGroupID(cmd, "target-password", "target")
+	return cmd
+}
+
+// NewCommand creates the command copying the images to a private registry.
+func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
+	return newCmd(globalFlags, mirrorImages)
+}
+
+func mirrorImages(_ *types.GlobalFlags, flags *mirrorFlags, _ *cobra.Command, _ []string) error {
+	return mirror(os.Stdout, flags)
+}
+
+func mirror(out io.Writer, flags *mirrorFlags) error {
+	if flags.Target.Host == "" {
+		return errors.New(L("the target registry is required: use --target-host"))
+	}
+	images, err := mirroredImages(flags)
+	if err != nil {
+		return err
+	}
+
+	if flags.Dry.Run {
+		for _, image := range images {
+			fmt.Fprintf(out, "%s -> %s\n", image.Name, image.Destination)
+		}
+		return nil
+	}
+
+	hostData, err := podman.InspectHost(nil)
+	if err != nil {
+		return err
+	}
+	authFile, cleaner, err := podman.PodmanLogin(hostData, flags.Image.Registry, flags.SCC)
+	if err != nil {
+		return err
+	}
+	defer cleaner()
+
+	// Only the target registry credentials are used to push
+	targetAuthFile, targetCleaner, err := podman.PodmanLogin(&podman.HostInspectData{}, flags.Target,
+		types.SCCCredentials{})
+	if err != nil {
+		return err
+	}
+	defer targetCleaner()
+
+	signaturePolicy, cleanPolicy, err := podman.SetupSignatureVerification(nil, &flags.Signatures)
+	if err != nil {
+		return err
+	}
+	defer cleanPolicy()
+
+	for _, image := range images {
+		if err := copyImage(authFile, signaturePolicy, targetAuthFile, flags.Image.PullPolicy, &image); err != nil {
+			return err
+		}
+	}
+	return writeRegistryConfig(out, len(images), &flags.Target)
+}
+
+// mirroredImages computes the images and their reference in the target registry.
+func mirroredImages(flags *mirrorFlags) ([]mirroredImage, error) {
+	images, err := shared.ListImages(&flags.ImagesFlags)
+	if err != nil {
+		return nil, err
+	}
+	result := []mirroredImage{}
+	for _, image := range images {
+		result = append(result, mirroredImage{
+			Image:       image,
+			Destination: mirrorReference(image.Name, flags.Image.Registry.Host, flags.Target.Host),
+		})
+	}
+	return result, nil
+}
+
+// mirrorReference moves the image from the source registry to the target one.
+//
+// The path in the source registry is kept for the image to be found using the target as registry host.
+// An image outside of the source registry only loses its registry domain.
+func mirrorReference(image string, sourceRegistry string, targetRegistry string) string {
+	name := strings.TrimPrefix(image, strings.ToLower(strings.TrimSuffix(sourceRegistry, "/"))+"/")
+	if name == image {
+		if _, rest, found := strings.Cut(image, "/"); found {
+			name = rest
+		}
+	}
+	return strings.ToLower(path.Join(targetRegistry, name))
+}
+
+func copyImage(
+	authFile string, signaturePolicy string, targetAuthFile string, pullPolicy string, image *mirroredImage,
+) error {
+	preparedImage, err := prepareImage(nil, authFile, signaturePolicy, image.Name, pullPolicy, true)
+	if err != nil {
+		return utils.Errorf(err, L("cannot prepare image %s"), image.Name)
+	}
+
+	log.Info().Msgf(L("Copying %[1]s to %[2]s"), preparedImage, image.Destination)
+	if _, err := runCmdOutput(zerolog.DebugLevel, "podman", "tag", preparedImage, image.Destination); err != nil {
+		return utils.Errorf(err, L("failed to tag image %s"), preparedImage)
+	}
+	// The target tag is only needed to push the image
+	defer func() {
+		if _, err := runCmdOutput(zerolog.DebugLevel, "podman", "untag", preparedImage, image.Destination); err != nil {
+			log.Warn().Err(err).Msgf(L("failed to remove the %s tag"), image.Destination)
+		}
+	}()
+
+	args := []string{"push", "--quiet"}
+	if targetAuthFile != "" {
+		args = append(args, "--authfile", targetAuthFile)
+	}
+	args = append(args, image.Destination)
+	if _, err := runCmdOutput(zerolog.DebugLevel, "podman", args...); err != nil {
+		return utils.Errorf(err, L("failed to push image %s"), image.Destination)
+	}
+	return nil
+}
+
+// writeRegistryConfig prints the registry configuration to use the mirrored images.
+func writeRegistryConfig(out io.Writer, count int, target *types.Registry) error {
+	fmt.Fprintf(out, L("%[1]d images copied to %[2]s.")+"\n", count, target.Host)
+	fmt.Fprintln(out, L("Install and upgrade with the following parameters to use them:"))
+	params := "--registry-host " + target.Host
+	config := "registry:\n  host: " + target.Host + "\n"
+	if target.User != "" {
+		params += fmt.Sprintf(" --registry-user %s --registry-password <password>", target.User)
+		config += fmt.Sprintf("  user: %s\n  password: <password>\n", target.User)
+	}
+	fmt.Fprintf(out, "\n  %s\n\n", params)
+	fmt.Fprintln(out, L("or add this to the configuration file:"))
+	_, err := fmt.Fprintf(out, "\n%s", config)
+	return err
+}
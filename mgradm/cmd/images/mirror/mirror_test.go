@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package imagesmirror
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/images/shared"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils/flagstests"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+func TestParamsParsing(t *testing.T) {
+	args := []string{
+		"--scc-user", "mysccuser",
+		"--scc-password", "mysccpass",
+		"--coco-image", "cocoimg",
+		"--coco-tag", "cocotag",
+		"--hubxmlrpc-image", "hubimg",
+		"--hubxmlrpc-tag", "hubtag",
+		"--saline-image", "salineimg",
+		"--saline-tag", "salinetag",
+		"--tftpd-image", "tftpdimg",
+		"--tftpd-tag", "tftpdtag",
+		"--proxy",
+		"--target-host", "mirror.example.com:5000",
+		"--target-user", "mirroruser",
+		"--target-password", "mirrorpass",
+		"--dry-run",
+	}
+	args = append(args, flagstests.ImageFlagsTestArgs...)
+	args = append(args, flagstests.DBUpdateImageFlagTestArgs...)
+	args = append(args, flagstests.SignatureFlagsTestArgs...)
+	args = append(args, flagstests.PgsqlFlagsTestArgs...)
+
+	// Test function asserting that the args are properly parsed
+	tester := func(_ *types.GlobalFlags, flags *mirrorFlags, _ *cobra.Command, _ []string) error {
+		flagstests.AssertImageFlag(t, &flags.Image)
+		flagstests.AssertDBUpgradeImageFlag(t, &flags.DBUpgradeImage)
+		flagstests.AssertPgsqlFlag(t, &flags.Pgsql)
+		flagstests.AssertSignatureFlags(t, &flags.Signatures)
+		testutils.AssertEquals(t, "Error parsing --scc-user", "mysccuser", flags.SCC.User)
+		testutils.AssertEquals(t, "Error parsing --scc-password", "mysccpass", flags.SCC.Password)
+		testutils.AssertEquals(t, "Error parsing --coco-image", "cocoimg", flags.Coco.Image.Name)
+		testutils.AssertEquals(t, "Error parsing --coco-tag", "cocotag", flags.Coco.Image.Tag)
+		testutils.AssertEquals(t, "Error parsing --hubxmlrpc-image", "hubimg", flags.HubXmlrpc.Image.Name)
+		testutils.AssertEquals(t, "Error parsing --hubxmlrpc-tag", "hubtag", flags.HubXmlrpc.Image.Tag)
+		testutils.AssertEquals(t, "Error parsing --saline-image", "salineimg", flags.Saline.Image.Name)
+		testutils.AssertEquals(t, "Error parsing --saline-tag", "salinetag", flags.Saline.Image.Tag)
+		testutils.AssertEquals(t, "Error parsing --tftpd-image", "tftpdimg", flags.TFTPD.Image.Name)
+		testutils.AssertEquals(t, "Error parsing --tftpd-tag", "tftpdtag", flags.TFTPD.Image.Tag)
+		testutils.AssertTrue(t, "Error parsing --proxy", flags.Proxy)
+		testutils.AssertEquals(t, "Error parsing --target-host", "mirror.example.com:5000", flags.Target.Host)
+		testutils.AssertEquals(t, "Error parsing --target-user", "mirroruser", flags.Target.User)
+		testutils.AssertEquals(t, "Error parsing --target-password", "mirrorpass", flags.Target.Password)
+		testutils.AssertTrue(t, "Error parsing --dry-run", flags.Dry.Run)
+		return nil
+	}
+
+	globalFlags := types.GlobalFlags{}
+	cmd := newCmd(&globalFlags, tester)
+
+	testutils.AssertHasAllFlags(t, cmd, args)
+
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("command failed with error: %s", err)
+	}
+}
+
+func TestMirrorReference(t *testing.T) {
+	data := []struct {
+		image    string
+		source   string
+		expected string
+	}{
+		{"registry.opensuse.org/uyuni/server:latest", "registry.opensuse.org",
+			"mirror.example.com:5000/uyuni/server:latest"},
+		{"registry.opensuse.org/uyuni/server:latest", "registry.opensuse.org/",
+			"mirror.example.com:5000/uyuni/server:latest"},
+		{"registry.suse.com/suse/manager/5.0/x86_64/server:5.0.2", "registry.suse.com",
+			"mirror.example.com:5000/suse/manager/5.0/x86_64/server:5.0.2"},
+		{"other.example.com/path/to/image:1.0", "registry.opensuse.org",
+			"mirror.example.com:5000/path/to/image:1.0"},
+	}
+	for _, test := range data {
+		testutils.AssertEquals(t, "Unexpected destination for "+test.image, test.expected,
+			mirrorReference(test.image, test.source, "mirror.example.com:5000"))
+	}
+}
+
+func TestMirrorDryRun(t *testing.T) {
+	runCmdOutput = func(_ zerolog.Level, command string, args ...string) ([]byte, error) {
+		t.Errorf("unexpected command in dry run: %s %s", command, strings.Join(args, " "))
+		return nil, nil
+	}
+	defer func() { runCmdOutput = utils.RunCmdOutput }()
+
+	flags := mirrorFlags{
+		ImagesFlags: shared.ImagesFlags{
+			Image: types.ImageFlags{
+				Name:     "uyuni/server",
+				Tag:      "2026.10",
+				Registry: types.Registry{Host: "registry.opensuse.org"},
+			},
+			Pgsql: types.PgsqlFlags{Image: types.ImageFlags{Name: "uyuni/server-postgresql"}},
+		},
+		Target: types.Registry{Host: "mirror.example.com"},
+	}
+	flags.Dry.Run = true
+	var out bytes.Buffer
+	testutils.AssertNoError(t, "dry run failed", mirror(&out, &flags))
+	testutils.AssertEquals(t, "Unexpected dry run output",
+		"registry.opensuse.org/uyuni/server:2026.10 -> mirror.example.com/uyuni/server:2026.10\n"+
+			"registry.opensuse.org/uyuni/server-postgresql:2026.10 -> "+
+			"mirror.example.com/uyuni/server-postgresql:2026.10\n",
+		out.String())
+}
+
+func TestMirrorNoTarget(t *testing.T) {
+	testutils.AssertError(t, "the target registry is required", mirror(&bytes.Buffer{}, &mirrorFlags{}))
+}
+
+func TestCopyImage(t *testing.T) {
+	commands := []string{}
+	runCmdOutput = func(_ zerolog.Level, command string, args ...string) ([]byte, error) {
+		commands = append(commands, command+" "+strings.Join(args, " "))
+		return nil, nil
+	}
+	prepareImage = func(_ *utils.Host, _ string, _ string, image string, _ string, _ bool) (string, error) {
+		return image, nil
+	}
+	defer func() {
+		runCmdOutput = utils.RunCmdOutput
+		prepareImage = podman.PrepareImage
+	}()
+
+	image := mirroredImage{
+		Image:       shared.Image{Component: "server", Name: "registry.opensuse.org/uyuni/server:latest"},
+		Destination: "mirror.example.com/uyuni/server:latest",
+	}
+	testutils.AssertNoError(t, "copy failed", copyImage("", "", "/tmp/auth.json", "IfNotPresent", &image))
+	testutils.AssertEquals(t, "Unexpected commands", []string{
+		"podman tag registry.opensuse.org/uyuni/server:latest mirror.example.com/uyuni/server:latest",
+		"podman push --quiet --authfile /tmp/auth.json mirror.example.com/uyuni/server:latest",
+		"podman untag registry.opensuse.org/uyuni/server:latest mirror.example.com/uyuni/server:latest",
+	}, commands)
+}
+
+func TestWriteRegistryConfig(t *testing.T) {
+	var out bytes.Buffer
+	testutils.AssertNoError(t, "failed to write config",
+		writeRegistryConfig(&out, 2, &types.Registry{Host: "mirror.example.com", User: "admin", Password: "secret"}))
+	testutils.AssertTrue(t, "Missing parameters: "+out.String(), strings.Contains(out.String(),
+		"--registry-host mirror.example.com --registry-user admin --registry-password <password>"))
+	testutils.AssertTrue(t, "Missing configuration: "+out.String(),
+		strings.Contains(out.String(), "registry:\n  host: mirror.example.com\n  user: admin\n"))
+	testutils.AssertTrue(t, "Password should not be printed", !strings.Contains(out.String(), "secret"))
+}
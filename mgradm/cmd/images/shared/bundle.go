@@ -0,0 +1,340 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// bundleManifestFile is the first entry of the bundle, describing the images it contains.
+const bundleManifestFile = "manifest.json"
+
+// bundleSignatureFile is the detached signature of the manifest, following it in signed bundles.
+const bundleSignatureFile = bundleManifestFile + ".asc"
+
+// bundleVersion is the version of the bundle format.
+const bundleVersion = 1
+
+// BundleImage describes an image archive of a bundle.
+type BundleImage struct {
+	Component string `json:"component"`
+	Name      string `json:"name"`
+	// File is the path of the image archive in the bundle.
+	File     string `json:"file"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"sha256"`
+}
+
+// BundleManifest describes the content of an images bundle.
+type BundleManifest struct {
+	Version int           `json:"version"`
+	Created time.Time     `json:"created"`
+	Images  []BundleImage `json:"images"`
+}
+
+// Functions pointers to mock podman and gpg in unit tests.
+var (
+	saveImage = func(image string, file string) error {
+		return utils.RunCmd("podman", "image", "save", "--quiet", "-o", file, image)
+	}
+	loadImage = func(file string) error {
+		return utils.RunCmd("podman", "image", "load", "--quiet", "-i", file)
+	}
+	signFile            = utils.SignFile
+	verifyFileSignature = utils.VerifyFileSignature
+)
+
+// WriteBundle saves the images in a tar bundle with a manifest listing their checksums.
+//
+// The manifest is signed with the signKey GPG key, unless empty.
+func WriteBundle(bundlePath string, images []Image, signKey string) error {
+	tempDir, cleaner, err := stagingDir(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer cleaner()
+
+	manifest := BundleManifest{Version: bundleVersion, Created: time.Now()}
+	for _, image := range images {
+		log.Info().Msgf(L("Saving image %s"), image.Name)
+		file := path.Join("images", image.Component+".tar")
+		localFile := path.Join(tempDir, path.Base(file))
+		if err := saveImage(image.Name, localFile); err != nil {
+			return utils.Errorf(err, L("failed to save image %s"), image.Name)
+		}
+		info, err := os.Stat(localFile)
+		if err != nil {
+			return err
+		}
+		checksum, err := utils.ComputeChecksum(localFile)
+		if err != nil {
+			return err
+		}
+		manifest.Images = append(manifest.Images, BundleImage{
+			Component: image.Component,
+			Name:      image.Name,
+			File:      file,
+			Size:      info.Size(),
+			Checksum:  checksum,
+		})
+	}
+
+	data, err := json.MarshalIndent(&manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	var signature []byte
+	if signKey != "" {
+		if signature, err = signManifest(tempDir, data, signKey); err != nil {
+			return err
+		}
+	} else {
+		log.Info().Msg(L("Bundle manifest is not signed as requested"))
+	}
+
+	out, err := os.Create(bundlePath)
+	if err != nil {
+		return utils.Errorf(err, L("failed to create %s"), bundlePath)
+	}
+	defer out.Close()
+
+	log.Info().Msgf(L("Writing bundle %s"), bundlePath)
+	tw := tar.NewWriter(out)
+	if err := writeTarData(tw, bundleManifestFile, data, manifest.Created); err != nil {
+		return err
+	}
+	if signature != nil {
+		if err := writeTarData(tw, bundleSignatureFile, signature, manifest.Created); err != nil {
+			return err
+		}
+	}
+	for _, image := range manifest.Images {
+		if err := addTarFile(tw, path.Join(tempDir, path.Base(image.File)), image.File); err != nil {
+			return utils.Errorf(err, L("failed to add %s to the bundle"), image.File)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// stagingDir creates a temporary folder next to the bundle.
+// The images are big: they are staged there rather than in a possibly small /tmp.
+func stagingDir(bundlePath string) (string, func(), error) {
+	dir, err := os.MkdirTemp(path.Dir(bundlePath), ".mgradm-images-*")
+	if err != nil {
+		return "", nil, utils.Error(err, L("failed to create temporary directory"))
+	}
+	return dir, func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Error().Err(err).Msgf(L("failed to remove temporary directory %s"), dir)
+		}
+	}, nil
+}
+
+// signManifest returns the detached signature of the manifest data made with the GPG key.
+func signManifest(tempDir string, data []byte, signKey string) ([]byte, error) {
+	manifestPath := path.Join(tempDir, bundleManifestFile)
+	signaturePath := path.Join(tempDir, bundleSignatureFile)
+	if err := os.WriteFile(manifestPath, data, 0600); err != nil {
+		return nil, err
+	}
+	if err := signFile(signKey, manifestPath, signaturePath); err != nil {
+		return nil, utils.Error(err, L("failed to sign the bundle manifest"))
+	}
+	return os.ReadFile(signaturePath)
+}
+
+// verifyManifestSignature checks the signature of the manifest data using the keys of the GPG keyring.
+func verifyManifestSignature(tempDir string, data []byte, signature []byte) error {
+	manifestPath := path.Join(tempDir, bundleManifestFile)
+	signaturePath := path.Join(tempDir, bundleSignatureFile)
+	if err := os.WriteFile(manifestPath, data, 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(signaturePath, signature, 0600); err != nil {
+		return err
+	}
+	if err := verifyFileSignature(manifestPath, signaturePath); err != nil {
+		return utils.Error(err, L("invalid bundle manifest signature"))
+	}
+	return nil
+}
+
+func writeTarData(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime}
+	if err := tw.WriteHeader(&header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func addTarFile(tw *tar.Writer, localFile string, name string) error {
+	in, err := os.Open(localFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	header := tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(&header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, in)
+	return err
+}
+
+// ImportBundle loads the images of a bundle after verifying them against the bundle manifest.
+//
+// The manifest signature is verified if the bundle has one: unsigned bundles are refused if requireSignature is set.
+// Each image is extracted next to the bundle and verified before being loaded.
+// The images are loaded one after the other: an error on an image leaves the previous ones loaded.
+func ImportBundle(bundlePath string, requireSignature bool) (*BundleManifest, error) {
+	in, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to open %s"), bundlePath)
+	}
+	defer in.Close()
+
+	tempDir, cleaner, err := stagingDir(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer cleaner()
+
+	tr := tar.NewReader(in)
+	manifest, data, err := readManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := tr.Next()
+	if err == nil && header.Name == bundleSignatureFile {
+		signature, readErr := io.ReadAll(tr)
+		if readErr != nil {
+			return nil, utils.Errorf(readErr, L("failed to read %s"), bundleSignatureFile)
+		}
+		if err := verifyManifestSignature(tempDir, data, signature); err != nil {
+			return nil, err
+		}
+		log.Info().Msg(L("Bundle manifest signature is valid"))
+		header, err = tr.Next()
+	} else if requireSignature {
+		return nil, errors.New(L("the bundle manifest is not signed"))
+	} else {
+		log.Warn().Msg(L("Bundle manifest is not signed, tampering cannot be detected"))
+	}
+
+	pending := map[string]BundleImage{}
+	for _, image := range manifest.Images {
+		pending[image.File] = image
+	}
+	loaded := []string{}
+	for ; ; header, err = tr.Next() {
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, partialImportError(utils.Errorf(err, L("failed to read %s"), bundlePath), loaded)
+		}
+		image, ok := pending[header.Name]
+		if !ok {
+			return nil, partialImportError(
+				fmt.Errorf(L("%s is not listed in the bundle manifest"), header.Name), loaded,
+			)
+		}
+		delete(pending, header.Name)
+
+		localFile := path.Join(tempDir, path.Base(image.File))
+		if err := extractVerified(tr, localFile, &image); err != nil {
+			return nil, partialImportError(err, loaded)
+		}
+		log.Info().Msgf(L("Loading image %s"), image.Name)
+		if err := loadImage(localFile); err != nil {
+			return nil, partialImportError(utils.Errorf(err, L("failed to load image %s"), image.Name), loaded)
+		}
+		loaded = append(loaded, image.Name)
+		if err := os.Remove(localFile); err != nil {
+			return nil, partialImportError(err, loaded)
+		}
+	}
+
+	if len(pending) > 0 {
+		missing := []string{}
+		for file := range pending {
+			missing = append(missing, file)
+		}
+		sort.Strings(missing)
+		return nil, partialImportError(
+			fmt.Errorf(L("missing from the bundle: %s"), strings.Join(missing, ", ")), loaded,
+		)
+	}
+	return manifest, nil
+}
+
+// partialImportError tells which images were already loaded when the import of a bundle failed.
+func partialImportError(err error, loaded []string) error {
+	if len(loaded) == 0 {
+		return err
+	}
+	return utils.Errorf(err, L("partial import, these images are already loaded: %s"), strings.Join(loaded, ", "))
+}
+
+// readManifest parses the manifest at the start of the bundle and returns it with its raw data.
+func readManifest(tr *tar.Reader) (*BundleManifest, []byte, error) {
+	header, err := tr.Next()
+	if err != nil || header.Name != bundleManifestFile {
+		return nil, nil, fmt.Errorf(L("not an images bundle: %s is missing"), bundleManifestFile)
+	}
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, nil, utils.Errorf(err, L("failed to read %s"), bundleManifestFile)
+	}
+	var manifest BundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, utils.Errorf(err, L("failed to parse %s"), bundleManifestFile)
+	}
+	if manifest.Version != bundleVersion {
+		return nil, nil, fmt.Errorf(L("unsupported bundle version %d"), manifest.Version)
+	}
+	return &manifest, data, nil
+}
+
+// extractVerified writes the current tar entry to localFile and checks its size and checksum.
+func extractVerified(tr *tar.Reader, localFile string, image *BundleImage) error {
+	out, err := os.Create(localFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	hashed := utils.NewHashingWriter(out)
+	if _, err := io.Copy(hashed, tr); err != nil {
+		return utils.Errorf(err, L("failed to extract %s"), image.File)
+	}
+	checksum := hashed.Checksum()
+	if hashed.Size() != image.Size || checksum != image.Checksum {
+		return fmt.Errorf(L("%[1]s is corrupted: expected checksum %[2]s but got %[3]s"),
+			image.File, image.Checksum, checksum)
+	}
+	return out.Close()
+}
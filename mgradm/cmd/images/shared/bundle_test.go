@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import (
+	"archive/tar"
+	"errors"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+// mockGpg fakes signing files with the key as signature and checks the signature is the key.
+func mockGpg(key string) {
+	signFile = func(signKey string, _ string, signature string) error {
+		return os.WriteFile(signature, []byte(signKey), 0600)
+	}
+	verifyFileSignature = func(_ string, signature string) error {
+		data, err := os.ReadFile(signature)
+		if err != nil {
+			return err
+		}
+		if string(data) != key {
+			return errors.New("BAD signature")
+		}
+		return nil
+	}
+}
+
+// mockPodman fakes saving images with their name as content and records the loaded images content.
+func mockPodman(t *testing.T) *[]string {
+	loaded := []string{}
+	saveImage = func(image string, file string) error {
+		return os.WriteFile(file, []byte(image), 0600)
+	}
+	loadImage = func(file string) error {
+		data, err := os.ReadFile(file)
+		testutils.AssertNoError(t, "failed to read loaded image", err)
+		loaded = append(loaded, string(data))
+		return nil
+	}
+	return &loaded
+}
+
+var testImages = []Image{
+	{Component: "server", Name: "registry.opensuse.org/uyuni/server:latest"},
+	{Component: "pgsql", Name: "registry.opensuse.org/uyuni/server-postgresql:latest"},
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	loaded := mockPodman(t)
+	bundle := path.Join(t.TempDir(), "bundle.tar")
+
+	testutils.AssertNoError(t, "failed to write bundle", WriteBundle(bundle, testImages, ""))
+
+	manifest, err := ImportBundle(bundle, false)
+	testutils.AssertNoError(t, "failed to import bundle", err)
+	testutils.AssertEquals(t, "Unexpected manifest version", bundleVersion, manifest.Version)
+	testutils.AssertEquals(t, "Unexpected number of images", 2, len(manifest.Images))
+	testutils.AssertEquals(t, "Unexpected image file", "images/pgsql.tar", manifest.Images[1].File)
+	testutils.AssertEquals(t, "Unexpected loaded images",
+		[]string{testImages[0].Name, testImages[1].Name}, *loaded)
+
+	entries, err := os.ReadDir(path.Dir(bundle))
+	testutils.AssertNoError(t, "failed to list bundle folder", err)
+	testutils.AssertEquals(t, "Temporary folder should be removed", 1, len(entries))
+}
+
+// writeTestBundle writes a bundle with the manifest of testImages but different files.
+func writeTestBundle(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	mockPodman(t)
+	reference := path.Join(dir, "reference.tar")
+	testutils.AssertNoError(t, "failed to write bundle", WriteBundle(reference, testImages, ""))
+	in, err := os.Open(reference)
+	testutils.AssertNoError(t, "failed to open bundle", err)
+	defer in.Close()
+	_, data, err := readManifest(tar.NewReader(in))
+	testutils.AssertNoError(t, "failed to read manifest", err)
+
+	bundle := path.Join(dir, "bundle.tar")
+	out, err := os.Create(bundle)
+	testutils.AssertNoError(t, "failed to create bundle", err)
+	defer out.Close()
+	tw := tar.NewWriter(out)
+	testutils.AssertNoError(t, "failed to write manifest", writeTarData(tw, bundleManifestFile, data, time.Now()))
+	for _, name := range []string{"images/server.tar", "images/pgsql.tar", "images/extra.tar"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		testutils.AssertNoError(t, "failed to write header",
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		testutils.AssertNoError(t, "failed to write content", err)
+	}
+	testutils.AssertNoError(t, "failed to close bundle", tw.Close())
+	return bundle
+}
+
+func TestImportCorruptedBundle(t *testing.T) {
+	bundle := writeTestBundle(t, map[string]string{
+		"images/server.tar": "registry.opensuse.org/uyuni/server:tampered",
+	})
+	loaded := mockPodman(t)
+	_, err := ImportBundle(bundle, false)
+	testutils.AssertError(t, "images/server.tar is corrupted", err)
+	testutils.AssertEquals(t, "No image should be loaded", 0, len(*loaded))
+}
+
+func TestImportPartialBundle(t *testing.T) {
+	bundle := writeTestBundle(t, map[string]string{
+		"images/server.tar": testImages[0].Name,
+		"images/pgsql.tar":  "registry.opensuse.org/uyuni/server-postgresql:tampered",
+	})
+	loaded := mockPodman(t)
+	_, err := ImportBundle(bundle, false)
+	testutils.AssertError(t, "images/pgsql.tar is corrupted", err)
+	testutils.AssertError(t, "partial import, these images are already loaded: "+testImages[0].Name, err)
+	testutils.AssertEquals(t, "Only the first image should be loaded", []string{testImages[0].Name}, *loaded)
+}
+
+func TestImportIncompleteBundle(t *testing.T) {
+	bundle := writeTestBundle(t, map[string]string{
+		"images/server.tar": testImages[0].Name,
+	})
+	mockPodman(t)
+	_, err := ImportBundle(bundle, false)
+	testutils.AssertError(t, "missing from the bundle: images/pgsql.tar", err)
+}
+
+func TestImportUnexpectedFile(t *testing.T) {
+	bundle := writeTestBundle(t, map[string]string{
+		"images/server.tar": testImages[0].Name,
+		"images/pgsql.tar":  testImages[1].Name,
+		"images/extra.tar":  "extra",
+	})
+	mockPodman(t)
+	_, err := ImportBundle(bundle, false)
+	testutils.AssertError(t, "images/extra.tar is not listed in the bundle manifest", err)
+}
+
+func TestImportNotABundle(t *testing.T) {
+	file := path.Join(t.TempDir(), "bundle.tar")
+	testutils.WriteFile(t, file, "not a tar")
+	_, err := ImportBundle(file, false)
+	testutils.AssertError(t, "not an images bundle", err)
+}
+
+func TestSignedBundle(t *testing.T) {
+	loaded := mockPodman(t)
+	mockGpg("admin@example.com")
+	bundle := path.Join(t.TempDir(), "bundle.tar")
+
+	testutils.AssertNoError(t, "failed to write bundle", WriteBundle(bundle, testImages, "admin@example.com"))
+	_, err := ImportBundle(bundle, true)
+	testutils.AssertNoError(t, "failed to import signed bundle", err)
+	testutils.AssertEquals(t, "Unexpected number of loaded images", 2, len(*loaded))
+
+	mockGpg("other@example.com")
+	loaded = mockPodman(t)
+	_, err = ImportBundle(bundle, false)
+	testutils.AssertError(t, "invalid bundle manifest signature", err)
+	testutils.AssertEquals(t, "No image should be loaded", 0, len(*loaded))
+}
+
+func TestImportUnsignedBundle(t *testing.T) {
+	loaded := mockPodman(t)
+	bundle := path.Join(t.TempDir(), "bundle.tar")
+	testutils.AssertNoError(t, "failed to write bundle", WriteBundle(bundle, testImages, ""))
+
+	_, err := ImportBundle(bundle, true)
+	testutils.AssertError(t, "the bundle manifest is not signed", err)
+	testutils.AssertEquals(t, "No image should be loaded", 0, len(*loaded))
+}
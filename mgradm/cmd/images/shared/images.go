@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import (
+	"path"
+
+	"github.com/spf13/cobra"
+	adm_utils "github.com/uyuni-project/uyuni-tools/mgradm/shared/utils"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// ImagesFlags are the flags selecting the images needed to install or upgrade a server.
+type ImagesFlags struct {
+	Image types.ImageFlags `mapstructure:",squash"`
+	// DBUpgradeImage is the image used to upgrade the database to a new PostgreSQL major version.
+	DBUpgradeImage types.ImageFlags `mapstructure:"dbupgrade"`
+	Pgsql          types.PgsqlFlags
+	Coco           adm_utils.CocoFlags
+	HubXmlrpc      adm_utils.HubXmlrpcFlags
+	Saline         adm_utils.SalineFlags
+	TFTPD          adm_utils.TFTPDFlags
+	SCC            types.SCCCredentials
+	Signatures     types.SignatureFlags
+	// Proxy is true to also include the proxy images.
+	Proxy bool
+}
+
+// Image is a container image used by a component.
+type Image struct {
+	Component string
+	Name      string
+}
+
+// proxyComponents are the proxy images in addition to the TFTP one shared with the server.
+var proxyComponents = []string{"httpd", "salt-broker", "squid", "ssh"}
+
+// AddImagesFlags adds the flags selecting the images to a command.
+func AddImagesFlags(cmd *cobra.Command) {
+	adm_utils.AddImageFlag(cmd)
+	utils.AddSignatureFlags(cmd)
+	adm_utils.AddSCCFlag(cmd)
+	adm_utils.AddPgsqlFlags(cmd)
+	adm_utils.AddDBUpgradeImageFlag(cmd)
+
+	_ = utils.AddFlagHelpGroup(cmd, &utils.Group{ID: "coco-container", Title: L("Confidential Computing Flags")})
+	utils.AddContainerImageFlags(cmd, "coco", L("Confidential computing attestation"),
+		"coco-container", "server-attestation")
+	_ = utils.AddFlagHelpGroup(cmd, &utils.Group{ID: "hubxmlrpc-container", Title: L("Hub XML-RPC API")})
+	utils.AddContainerImageFlags(cmd, "hubxmlrpc", L("Hub XML-RPC API"), "hubxmlrpc-container", "server-hub-xmlrpc-api")
+	_ = utils.AddFlagHelpGroup(cmd, &utils.Group{ID: "saline-container", Title: L("Saline Flags")})
+	utils.AddContainerImageFlags(cmd, "saline", L("Saline"), "saline-container", "server-saline")
+	_ = utils.AddFlagHelpGroup(cmd, &utils.Group{ID: "tftpd-container", Title: L("TFTPD Flags")})
+	utils.AddTFTPDFlags(cmd, false, "tftpd-container")
+
+	cmd.Flags().Bool("proxy", false, L("Also include the proxy images, using the same registry and tag"))
+}
+
+// ListImages computes the images install and upgrade would use with the flags.
+func ListImages(flags *ImagesFlags) ([]Image, error) {
+	globalTag := utils.DefaultTag
+	if flags.Image.Tag != "" {
+		globalTag = flags.Image.Tag
+	}
+
+	components := []struct {
+		name  string
+		image types.ImageFlags
+	}{
+		{"server", flags.Image},
+		{"pgsql", flags.Pgsql.Image},
+		{"dbupgrade", flags.DBUpgradeImage},
+		{"coco", flags.Coco.Image},
+		{"hubxmlrpc", flags.HubXmlrpc.Image},
+		{"saline", flags.Saline.Image},
+		{"tftpd", flags.TFTPD.Image},
+	}
+	if flags.Proxy {
+		for _, name := range proxyComponents {
+			components = append(components, struct {
+				name  string
+				image types.ImageFlags
+			}{"proxy-" + name, types.ImageFlags{Name: path.Join(utils.DefaultImagePrefix, "proxy-"+name)}})
+		}
+	}
+
+	images := []Image{}
+	for _, component := range components {
+		if component.image.Name == "" {
+			continue
+		}
+		name, err := utils.ComputeImage(flags.Image.Registry.Host, globalTag, component.image)
+		if err != nil {
+			return nil, utils.Errorf(err, L("failed to compute the %s image"), component.name)
+		}
+		images = append(images, Image{Component: component.name, Name: name})
+	}
+	return images, nil
+}
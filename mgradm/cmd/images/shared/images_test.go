@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package shared
+
+import (
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+)
+
+func TestListImages(t *testing.T) {
+	flags := ImagesFlags{
+		Image: types.ImageFlags{
+			Name:     "path/to/server",
+			Tag:      "5.1",
+			Registry: types.Registry{Host: "registry.example.com"},
+		},
+		Pgsql:          types.PgsqlFlags{Image: types.ImageFlags{Name: "path/to/pgsql"}},
+		DBUpgradeImage: types.ImageFlags{Name: "path/to/dbupgrade", Tag: "5.0"},
+		Proxy:          true,
+	}
+	flags.TFTPD.Image.Name = "path/to/tftpd"
+
+	images, err := ListImages(&flags)
+	testutils.AssertNoError(t, "failed to list images", err)
+	testutils.AssertEquals(t, "Unexpected images", []Image{
+		{"server", "registry.example.com/path/to/server:5.1"},
+		{"pgsql", "registry.example.com/path/to/pgsql:5.1"},
+		{"dbupgrade", "registry.example.com/path/to/dbupgrade:5.0"},
+		{"tftpd", "registry.example.com/path/to/tftpd:5.1"},
+		{"proxy-httpd", "registry.example.com/uyuni/proxy-httpd:5.1"},
+		{"proxy-salt-broker", "registry.example.com/uyuni/proxy-salt-broker:5.1"},
+		{"proxy-squid", "registry.example.com/uyuni/proxy-squid:5.1"},
+		{"proxy-ssh", "registry.example.com/uyuni/proxy-ssh:5.1"},
+	}, images)
+}
@@ -16,12 +16,16 @@ import (
 )
 
 func podmanInspect(
-	_ *types.GlobalFlags,
+	globalFlags *types.GlobalFlags,
 	flags *inspectFlags,
 	_ *cobra.Command,
 	_ []string,
 ) error {
-	hostData, err := podman.InspectHost()
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	hostData, err := podman.InspectHost(host)
 	if err != nil {
 		return err
 	}
@@ -32,11 +36,11 @@ func podmanInspect(
 	}
 	defer cleaner()
 
-	preparedServerImage, preparedDBImage, err := podman.PrepareImages(authFile, flags.Image, flags.Pgsql)
+	preparedServerImage, preparedDBImage, err := podman.PrepareImages(host, authFile, "", flags.Image, flags.Pgsql)
 	if err != nil {
 		return err
 	}
-	inspectResult, err := podman.Inspect(preparedServerImage, preparedDBImage)
+	inspectResult, err := podman.Inspect(host, preparedServerImage, preparedDBImage)
 	if err != nil {
 		return utils.Errorf(err, L("inspect command failed"))
 	}
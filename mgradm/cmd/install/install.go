@@ -17,6 +17,7 @@ import (
 type podmanInstallFlags struct {
 	adm_utils.ServerFlags `mapstructure:",squash"`
 	Podman                podman.PodmanFlags
+	SkipChecks            bool
 }
 
 func newCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[podmanInstallFlags]) *cobra.Command {
@@ -28,8 +29,7 @@ func newCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[podmanInstallF
 		Long: L(`Install a new server on podman
 
 The command assumes podman is installed locally.
-
-NOTE: installing on a remote podman is not supported yet!
+Use --host to install the server on a remote host over SSH: podman and systemd need to be installed there.
 `),
 		Args: func(cmd *cobra.Command, args []string) error {
 			// ensure the right amount of args, managing podman
@@ -52,6 +52,7 @@ NOTE: installing on a remote podman is not supported yet!
 	adm_utils.AddMirrorFlag(cmd)
 	AddInstallFlags(cmd)
 	adm_utils.AddDebugFlags(cmd)
+	adm_utils.AddSkipChecksFlag(cmd)
 	podman.AddPodmanArgFlag(cmd)
 	return cmd
 }
@@ -19,7 +19,7 @@ func TestParamsParsing(t *testing.T) {
 	args := flagstests.InstallFlagsTestArgs()
 	args = append(args, flagstests.MirrorFlagTestArgs...)
 	args = append(args, flagstests.PodmanFlagsTestArgs...)
-	args = append(args, "srv.fq.dn")
+	args = append(args, "--skipchecks", "srv.fq.dn")
 
 	// Test function asserting that the args are properly parsed
 	tester := func(_ *types.GlobalFlags, flags *podmanInstallFlags,
@@ -28,6 +28,7 @@ func TestParamsParsing(t *testing.T) {
 		flagstests.AssertMirrorFlag(t, flags.Mirror)
 		flagstests.AssertInstallFlags(t, &flags.ServerFlags)
 		flagstests.AssertPodmanInstallFlags(t, &flags.Podman)
+		testutils.AssertTrue(t, "Error parsing --skipchecks", flags.SkipChecks)
 		testutils.AssertEquals(t, "Wrong FQDN", "srv.fq.dn", args[0])
 		return nil
 	}
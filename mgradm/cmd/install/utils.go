@@ -12,6 +12,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/db"
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/check"
 	"github.com/uyuni-project/uyuni-tools/mgradm/shared/coco"
 	"github.com/uyuni-project/uyuni-tools/mgradm/shared/hub"
 	"github.com/uyuni-project/uyuni-tools/mgradm/shared/pgsql"
@@ -25,62 +26,89 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-var systemd shared_podman.Systemd = shared_podman.NewSystemd()
-
 func installForPodman(
-	_ *types.GlobalFlags,
+	globalFlags *types.GlobalFlags,
 	flags *podmanInstallFlags,
 	cmd *cobra.Command,
 	args []string,
 ) error {
-	hostData, err := shared_podman.InspectHost()
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	// The database backup setup needs direct access to the files of the server host.
+	if flags.Installation.DB.Walbackup && host.IsRemote() {
+		return errors.New(L("database backups cannot be enabled on a remote host"))
+	}
+	systemd := shared_podman.NewSystemdOnHost(host)
+
+	hostData, err := shared_podman.InspectHost(host)
 	if err != nil {
 		return err
 	}
 
-	flags.Installation.CheckParameters(cmd, "podman")
+	flags.Installation.CheckParameters(cmd, "podman", host)
 	if _, err := exec.LookPath("podman"); err != nil {
 		return errors.New(L("install podman before running this command"))
 	}
 
+	if !flags.SkipChecks {
+		if err := check.RunAndLog(&check.Context{
+			Operation: check.Install,
+			Host:      host,
+			FQDN:      check.Fqdn(host, args),
+			Ports:     check.ServerPorts(flags.Installation.Debug.Java, flags.TFTPD.Enable, flags.HubXmlrpc.Replicas > 0),
+		}); err != nil {
+			return err
+		}
+	}
+
 	authFile, cleaner, err := shared_podman.PodmanLogin(hostData, flags.Image.Registry, flags.Installation.SCC)
 	if err != nil {
 		return err
 	}
 	defer cleaner()
 
+	signaturePolicy, cleanPolicy, err := shared_podman.SetupSignatureVerification(host, &flags.Signatures)
+	if err != nil {
+		return err
+	}
+	defer cleanPolicy()
+
 	if hostData.HasUyuniServer {
 		return errors.New(
 			L("Server is already initialized! Uninstall before attempting new installation or use upgrade command"),
 		)
 	}
-	fqdn, err := utils.GetFqdn(args)
+	fqdn, err := host.GetFqdn(args)
 	if err != nil {
 		return err
 	}
 	log.Info().Msgf(L("Setting up the server with the FQDN '%s'"), fqdn)
 
-	preparedImage, preparedPgsqlImage, err := shared_podman.PrepareImages(authFile, flags.Image, flags.Pgsql)
+	preparedImage, preparedPgsqlImage, err := shared_podman.PrepareImages(
+		host, authFile, signaturePolicy, flags.Image, flags.Pgsql,
+	)
 	if err != nil {
 		return utils.Errorf(err, L("cannot prepare images"))
 	}
 
-	if err := shared_podman.SetupNetwork(false); err != nil {
+	if err := shared_podman.SetupNetwork(host, false); err != nil {
 		return utils.Error(err, L("cannot setup network"))
 	}
 
 	if err := podman.PrepareSSLCertificates(
-		preparedImage, &flags.Installation.SSL, flags.Installation.TZ, fqdn); err != nil {
+		host, preparedImage, &flags.Installation.SSL, flags.Installation.TZ, fqdn); err != nil {
 		return err
 	}
 
 	// Create all the database credentials secrets and setup the DB
-	if err := setupDatabase(flags.Installation.DB, flags.Installation.ReportDB, preparedPgsqlImage); err != nil {
+	if err := setupDatabase(systemd, flags.Installation.DB, flags.Installation.ReportDB, preparedPgsqlImage); err != nil {
 		return err
 	}
 
 	// This creates installation values to be passed as envvars
-	if err := podman.GenerateServerEnvironmentFile(flags.Installation, fqdn, flags.Mirror != ""); err != nil {
+	if err := podman.GenerateServerEnvironmentFile(host, flags.Installation, fqdn, flags.Mirror != ""); err != nil {
 		return err
 	}
 
@@ -93,7 +121,7 @@ func installForPodman(
 	log.Info().Msg(L("Waiting for server to start. This include service setup operations and can take a very long time."))
 	log.Info().Msg(L("Use `journalctl -f -u uyuni-server` for tracking progress"))
 
-	cnx := shared.NewConnection("podman", shared_podman.ServerContainerName, "")
+	cnx := shared.NewHostConnection(host, "podman", shared_podman.ServerContainerName, "")
 	if err := podman.WaitForSystemStart(systemd, cnx); err != nil {
 		return utils.Error(err, L("cannot wait for system start"))
 	}
@@ -101,25 +129,32 @@ func installForPodman(
 		return utils.Error(err, L("failed to add SSL CA certificate to host trusted certificates"))
 	}
 
-	if path, err := exec.LookPath("uyuni-payg-extract-data"); err == nil {
+	if host.IsInstalled("uyuni-payg-extract-data") {
 		// the binary is installed
-		err = utils.RunCmdStdMapping(zerolog.DebugLevel, path)
+		err := host.RunCmdStdMapping(zerolog.DebugLevel, "uyuni-payg-extract-data")
 		if err != nil {
 			return utils.Error(err, L("failed to extract payg data"))
 		}
 	}
 
 	return utils.JoinErrors(
-		shared_podman.EnablePodmanSocket(),
-		coco.SetupCocoContainer(systemd, authFile, flags.Coco, flags.Image, flags.Installation.DB),
-		hub.SetupHubXmlrpc(systemd, authFile, flags.Image, flags.HubXmlrpc),
-		saline.SetupSalineContainer(systemd, authFile, flags.Image, flags.Saline, flags.Installation.TZ),
-		tftp.SetupTFTPContainer(systemd, authFile, flags.Image, flags.TFTPD, fqdn, false),
+		shared_podman.EnablePodmanSocket(host),
+		coco.SetupCocoContainer(systemd, authFile, signaturePolicy, flags.Coco, flags.Image, flags.Installation.DB),
+		hub.SetupHubXmlrpc(systemd, authFile, signaturePolicy, flags.Image, flags.HubXmlrpc),
+		saline.SetupSalineContainer(systemd, authFile, signaturePolicy, flags.Image, flags.Saline, flags.Installation.TZ),
+		tftp.SetupTFTPContainer(systemd, authFile, signaturePolicy, flags.Image, flags.TFTPD, fqdn, false),
 	)
 }
 
-func setupDatabase(dbFlags types.DBFlags, reportdbFlags types.DBFlags, preparedImage string) error {
+func setupDatabase(
+	systemd shared_podman.Systemd,
+	dbFlags types.DBFlags,
+	reportdbFlags types.DBFlags,
+	preparedImage string,
+) error {
+	host := systemd.Host()
 	if err := shared_podman.CreateCredentialsSecrets(
+		host,
 		shared_podman.DBUserSecret, dbFlags.User,
 		shared_podman.DBPassSecret, dbFlags.Password,
 	); err != nil {
@@ -127,6 +162,7 @@ func setupDatabase(dbFlags types.DBFlags, reportdbFlags types.DBFlags, preparedI
 	}
 
 	if err := shared_podman.CreateCredentialsSecrets(
+		host,
 		shared_podman.ReportDBUserSecret, reportdbFlags.User,
 		shared_podman.ReportDBPassSecret, reportdbFlags.Password,
 	); err != nil {
@@ -143,6 +179,7 @@ func setupDatabase(dbFlags types.DBFlags, reportdbFlags types.DBFlags, preparedI
 
 	// The admin password is not needed for external databases
 	if err := shared_podman.CreateCredentialsSecrets(
+		host,
 		shared_podman.DBAdminUserSecret, dbFlags.Admin.User,
 		shared_podman.DBAdminPassSecret, dbFlags.Admin.Password,
 	); err != nil {
@@ -11,14 +11,17 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-var systemd podman.Systemd = podman.NewSystemd()
-
 func podmanRestart(
-	_ *types.GlobalFlags,
+	globalFlags *types.GlobalFlags,
 	_ *restartFlags,
 	_ *cobra.Command,
 	_ []string,
 ) error {
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	systemd := podman.NewSystemdOnHost(host)
 	return utils.JoinErrors(
 		systemd.RestartService(podman.DBService),
 		systemd.RestartService(podman.ServerService),
@@ -12,18 +12,22 @@ import (
 
 	"github.com/uyuni-project/uyuni-tools/shared/podman"
 	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
 
 	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
 )
 
-var systemd podman.Systemd = podman.NewSystemd()
-
 func podmanScale(
-	_ *types.GlobalFlags,
+	globalFlags *types.GlobalFlags,
 	flags *scaleFlags,
 	_ *cobra.Command,
 	args []string,
 ) error {
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	systemd := podman.NewSystemdOnHost(host)
 	newReplicas := flags.Replicas
 	service := args[0]
 	if service == podman.ServerAttestationService {
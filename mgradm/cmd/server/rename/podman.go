@@ -5,11 +5,6 @@
 package rename
 
 import (
-	"fmt"
-	"os"
-	"path"
-	"regexp"
-
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	adm_podman "github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
@@ -19,19 +14,20 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-var systemd podman.Systemd = podman.NewSystemd()
+func renameForPodman(globalFlags *types.GlobalFlags, flags *renameFlags, _ *cobra.Command, args []string) error {
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	systemd := podman.NewSystemdOnHost(host)
 
-func renameForPodman(_ *types.GlobalFlags, flags *renameFlags, _ *cobra.Command, args []string) error {
-	fqdn, err := utils.GetFqdn(args)
+	fqdn, err := host.GetFqdn(args)
 	if err != nil {
 		return err
 	}
 
 	// Regenerate Server SSL certificate if requested
-	image := podman.GetServiceImage(podman.ServerService)
-	tz := adm_podman.GetContainerTimezone()
-	log.Info().Msg(L("Preparing SSL certificates to match the new hostname"))
-	if err := adm_podman.PrepareSSLCertificates(image, &flags.SSL, tz, fqdn); err != nil {
+	if err := adm_podman.PrepareServerRename(host, &flags.SSL, fqdn); err != nil {
 		return err
 	}
 
@@ -40,14 +36,9 @@ func renameForPodman(_ *types.GlobalFlags, flags *renameFlags, _ *cobra.Command,
 		return err
 	}
 
-	log.Info().Msgf(L("Changing the UYUNI_HOSTNAME to %s"), fqdn)
-	if err := alterHostnameConfig(fqdn); err != nil {
-		return err
-	}
-
-	// Update the service to ensure it has -e UYUNI_HOSTNAME
-	if err := adm_podman.UpdateServerSystemdService(); err != nil {
-		return err
+	if err := adm_podman.SetServerHostname(systemd, fqdn); err != nil {
+		// Keep the server running with its previous name
+		return utils.JoinErrors(err, systemd.StartService(podman.ServerService))
 	}
 
 	// Restart the server container: the UYUNI_HOSTNAME change will be picked up by the uyuni-update-config service
@@ -61,38 +52,3 @@ func renameForPodman(_ *types.GlobalFlags, flags *renameFlags, _ *cobra.Command,
 The logs can be found in journalctl -u uyuni-config-update.service output.`))
 	return nil
 }
-
-// alterHostnameConfig changes the UYUNI_HOSTNAME value in the server systemd service or adds it if needed.
-func alterHostnameConfig(fqdn string) error {
-	config, err := readCustomConf()
-	if err != nil {
-		return err
-	}
-
-	// Append Environment=UYUNI_HOSTNAME={{.fqdn}} or replace the value
-	pattern := regexp.MustCompile(`(?m)^Environment=UYUNI_HOSTNAME=.*$`)
-	newConfig := pattern.ReplaceAllString(config, "Environment=UYUNI_HOSTNAME="+fqdn)
-	if config == newConfig {
-		newConfig = fmt.Sprintf("%s\nEnvironment=UYUNI_HOSTNAME=%s\n", config, fqdn)
-	}
-
-	systemdConfPath := podman.GetServiceConfFolder(podman.ServerService)
-	customConf := path.Join(systemdConfPath, podman.CustomConf)
-	if err := os.WriteFile(customConf, []byte(newConfig), 0640); err != nil {
-		return utils.Error(err, L("failed to write custom.conf with the new hostname"))
-	}
-
-	return systemd.ReloadDaemon(false)
-}
-
-func readCustomConf() (config string, err error) {
-	systemdConfPath := podman.GetServiceConfFolder(podman.ServerService)
-	customConf := path.Join(systemdConfPath, podman.CustomConf)
-	out, err := os.ReadFile(customConf)
-	if err != nil {
-		return "", utils.Error(err, L("failed to read the custom.conf file"))
-	}
-
-	config = string(out)
-	return config, nil
-}
@@ -16,8 +16,12 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-func addCAForPodman(_ *types.GlobalFlags, flags *addCAFlags, cmd *cobra.Command, args []string) error {
-	fqdn, err := utils.GetFqdn(args)
+func addCAForPodman(globalFlags *types.GlobalFlags, flags *addCAFlags, cmd *cobra.Command, args []string) error {
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	fqdn, err := host.GetFqdn(args)
 	if err != nil {
 		return err
 	}
@@ -29,18 +33,18 @@ func addCAForPodman(_ *types.GlobalFlags, flags *addCAFlags, cmd *cobra.Command,
 		}
 	}
 
-	image := podman.GetServiceImage(podman.ServerService)
-	tz := adm_podman.GetContainerTimezone()
+	image := podman.GetServiceImage(host, podman.ServerService)
+	tz := adm_podman.GetContainerTimezone(host)
 
-	if err := adm_podman.AddCA(image, &flags.SSL, tz, fqdn); err != nil {
+	if err := adm_podman.AddCA(host, image, &flags.SSL, tz, fqdn); err != nil {
 		return err
 	}
 
-	if err := adm_podman.ApplyNewCertificates(fqdn); err != nil {
+	if err := adm_podman.ApplyNewCertificates(podman.NewSystemdOnHost(host), fqdn); err != nil {
 		return err
 	}
 
-	if fingerprint, err := adm_podman.NewCAFingerprint(); err != nil {
+	if fingerprint, err := adm_podman.NewCAFingerprint(host); err != nil {
 		log.Warn().Err(err).Msg(L("Could not read the new CA fingerprint"))
 	} else {
 		log.Info().Msgf(L("The new root CA (SHA-256 fingerprint %s) has been added to the trusted bundle."),
@@ -17,56 +17,67 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-func rotateForPodman(_ *types.GlobalFlags, flags *rotateFlags, cmd *cobra.Command, args []string) error {
-	fqdn, err := utils.GetFqdn(args)
+func rotateForPodman(globalFlags *types.GlobalFlags, flags *rotateFlags, cmd *cobra.Command, args []string) error {
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	fqdn, err := host.GetFqdn(args)
 	if err != nil {
 		return err
 	}
 
+	systemd := podman.NewSystemdOnHost(host)
 	if flags.Emergency {
-		return emergencyRotate(flags, cmd, fqdn)
+		return emergencyRotate(systemd, flags, cmd, fqdn)
 	}
-	return plannedRotate(flags, cmd, fqdn)
+	return plannedRotate(systemd, flags, cmd, fqdn)
 }
 
-func plannedRotate(flags *rotateFlags, cmd *cobra.Command, fqdn string) error {
+func plannedRotate(systemd podman.Systemd, flags *rotateFlags, cmd *cobra.Command, fqdn string) error {
 	checkOnly, _ := cmd.Flags().GetBool("check-only")
 
-	fingerprint, err := adm_podman.NewCAFingerprint()
+	fingerprint, err := adm_podman.NewCAFingerprint(systemd.Host())
 	if err != nil {
 		return err
 	}
-	if err := checkClientReadiness(fingerprint, flags.Force); err != nil {
+	if err := checkClientReadiness(systemd.Host(), fingerprint, flags.Force); err != nil {
 		return err
 	}
 	if checkOnly {
 		return nil
 	}
 
-	if err := switchServerCertificate(flags, cmd, fqdn, false); err != nil {
+	if err := switchServerCertificate(systemd.Host(), flags, cmd, fqdn, false); err != nil {
 		return err
 	}
-	return adm_podman.ApplyNewCertificates(fqdn)
+	return adm_podman.ApplyNewCertificates(systemd, fqdn)
 }
 
-func emergencyRotate(flags *rotateFlags, cmd *cobra.Command, fqdn string) error {
+func emergencyRotate(systemd podman.Systemd, flags *rotateFlags, cmd *cobra.Command, fqdn string) error {
 	if checkOnly, _ := cmd.Flags().GetBool("check-only"); checkOnly {
 		log.Warn().Msg(L("--check-only is ignored with --emergency! Proceeding with the emergency rotation"))
 	}
 
-	if err := switchServerCertificate(flags, cmd, fqdn, true); err != nil {
+	if err := switchServerCertificate(systemd.Host(), flags, cmd, fqdn, true); err != nil {
 		return err
 	}
-	return adm_podman.ApplyNewCertificates(fqdn)
+	return adm_podman.ApplyNewCertificates(systemd, fqdn)
 }
 
-func switchServerCertificate(flags *rotateFlags, cmd *cobra.Command, fqdn string, regenerateCA bool) error {
+func switchServerCertificate(
+	host *utils.Host,
+	flags *rotateFlags,
+	cmd *cobra.Command,
+	fqdn string,
+	regenerateCA bool,
+) error {
 	if flags.SSL.Ca.IsThirdParty() {
 		if !flags.SSL.UseProvided() {
 			return errors.New(L("the server certificate, key and root CA need to be all provided"))
 		}
 		log.Info().Msg(L("Installing the provided 3rd party server certificate"))
-		return adm_podman.SetThirdPartyCertificates(&flags.SSL, fqdn)
+		return adm_podman.SetThirdPartyCertificates(host, &flags.SSL, fqdn)
 	}
 
 	utils.AskPasswordIfMissing(&flags.SSL.Password, cmd.Flag("ssl-password").Usage, 0, 0)
@@ -74,23 +85,23 @@ func switchServerCertificate(flags *rotateFlags, cmd *cobra.Command, fqdn string
 		return errors.New(L("the CA key password is required"))
 	}
 
-	image := podman.GetServiceImage(podman.ServerService)
-	tz := adm_podman.GetContainerTimezone()
+	image := podman.GetServiceImage(host, podman.ServerService)
+	tz := adm_podman.GetContainerTimezone(host)
 	if regenerateCA {
 		log.Info().Msg(L("Generating a new CA and server certificate"))
-		return adm_podman.RegenerateCAAndCertificate(image, &flags.SSL, tz, fqdn)
+		return adm_podman.RegenerateCAAndCertificate(host, image, &flags.SSL, tz, fqdn)
 	}
 	log.Info().Msg(L("Generating a new server certificate signed by the new CA"))
-	return adm_podman.RotateServerCertificate(image, &flags.SSL, tz, fqdn)
+	return adm_podman.RotateServerCertificate(host, image, &flags.SSL, tz, fqdn)
 }
 
-func checkClientReadiness(fingerprint string, force bool) error {
+func checkClientReadiness(host *utils.Host, fingerprint string, force bool) error {
 	if force {
 		log.Warn().Msg(L("Skipping the client CA trust check as --force was set"))
 		return nil
 	}
 
-	result, err := adm_podman.CheckClientsCATrust(fingerprint)
+	result, err := adm_podman.CheckClientsCATrust(host, fingerprint)
 	if err != nil {
 		return utils.Error(err, L("failed to verify client readiness, rerun with --force to rotate without checking"))
 	}
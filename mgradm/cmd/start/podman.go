@@ -7,14 +7,20 @@ package start
 import (
 	"github.com/spf13/cobra"
 	"github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
+	shared_podman "github.com/uyuni-project/uyuni-tools/shared/podman"
 	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
 func podmanStart(
-	_ *types.GlobalFlags,
+	globalFlags *types.GlobalFlags,
 	_ *startFlags,
 	_ *cobra.Command,
 	_ []string,
 ) error {
-	return podman.StartServices()
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	return podman.StartServices(shared_podman.NewSystemdOnHost(host))
 }
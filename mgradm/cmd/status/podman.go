@@ -9,6 +9,7 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/mgradm/cmd/backup/schedule"
 	adm_utils "github.com/uyuni-project/uyuni-tools/mgradm/shared/utils"
 	"github.com/uyuni-project/uyuni-tools/shared"
 	"github.com/uyuni-project/uyuni-tools/shared/podman"
@@ -16,43 +17,55 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-var systemd podman.Systemd = podman.NewSystemd()
-
 func podmanStatus(
-	_ *types.GlobalFlags,
+	globalFlags *types.GlobalFlags,
 	_ *statusFlags,
 	_ *cobra.Command,
 	_ []string,
 ) error {
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	systemd := podman.NewSystemdOnHost(host)
+
 	if systemd.HasService(podman.DBService) {
-		_ = utils.RunCmdStdMapping(zerolog.DebugLevel, "systemctl", "status", "--no-pager", podman.DBService)
+		_ = host.RunCmdStdMapping(zerolog.DebugLevel, "systemctl", "status", "--no-pager", podman.DBService)
 	}
 
 	// Show the status and that's it if the service is not running
 	if !systemd.IsServiceRunning(podman.ServerService) {
-		_ = utils.RunCmdStdMapping(zerolog.DebugLevel, "systemctl", "status", "--no-pager", podman.ServerService)
+		_ = host.RunCmdStdMapping(zerolog.DebugLevel, "systemctl", "status", "--no-pager", podman.ServerService)
 	} else {
 		// Run spacewalk-service status in the container
-		cnx := shared.NewConnection("podman", podman.ServerContainerName, "")
+		cnx := shared.NewHostConnection(host, "podman", podman.ServerContainerName, "")
 		_ = adm_utils.ExecCommand(zerolog.InfoLevel, cnx, "spacewalk-service", "status")
 	}
 
 	for i := 0; i < systemd.CurrentReplicaCount(podman.ServerAttestationService); i++ {
 		println() // add an empty line between the previous logs and this one
-		_ = utils.RunCmdStdMapping(
+		_ = host.RunCmdStdMapping(
 			zerolog.DebugLevel, "systemctl", "status", "--no-pager", fmt.Sprintf("%s@%d", podman.ServerAttestationService, i),
 		)
 	}
 
 	for i := 0; i < systemd.CurrentReplicaCount(podman.HubXmlrpcService); i++ {
 		println() // add an empty line between the previous logs and this one
-		_ = utils.RunCmdStdMapping(
+		_ = host.RunCmdStdMapping(
 			zerolog.DebugLevel, "systemctl", "status", "--no-pager", fmt.Sprintf("%s@%d", podman.HubXmlrpcService, i),
 		)
 	}
 
 	if systemd.HasService(podman.TFTPService) {
-		_ = utils.RunCmdStdMapping(zerolog.DebugLevel, "systemctl", "status", "--no-pager", podman.TFTPService)
+		_ = host.RunCmdStdMapping(zerolog.DebugLevel, "systemctl", "status", "--no-pager", podman.TFTPService)
+	}
+
+	// The backups are only managed on the local host
+	if !host.IsRemote() && systemd.HasService(schedule.TimerUnit) {
+		if lastRun, err := schedule.ReadLastRun(); err == nil && lastRun != nil {
+			println() // add an empty line between the previous logs and this one
+			schedule.ReportLastRun(lastRun)
+		}
 	}
 
 	return nil
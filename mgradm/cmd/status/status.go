@@ -40,7 +40,11 @@ func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
 }
 
 func status(globalFlags *types.GlobalFlags, flags *statusFlags, cmd *cobra.Command, args []string) error {
-	if systemd.HasService(podman.ServerService) {
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	if podman.NewSystemdOnHost(host).HasService(podman.ServerService) {
 		return podmanStatus(globalFlags, flags, cmd, args)
 	}
 	return errors.New(L("no installed server detected"))
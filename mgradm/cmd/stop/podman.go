@@ -7,14 +7,20 @@ package stop
 import (
 	"github.com/spf13/cobra"
 	"github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
+	shared_podman "github.com/uyuni-project/uyuni-tools/shared/podman"
 	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
 func podmanStop(
-	_ *types.GlobalFlags,
+	globalFlags *types.GlobalFlags,
 	_ *stopFlags,
 	_ *cobra.Command,
 	_ []string,
 ) error {
-	return podman.StopServices()
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	return podman.StopServices(shared_podman.NewSystemdOnHost(host))
 }
@@ -27,7 +27,7 @@ func ptfForPodman(
 	_ []string,
 ) error {
 	// Login first to be able to search the registry for PTF images
-	hostData, err := podman_shared.InspectHost()
+	hostData, err := podman_shared.InspectHost(nil)
 	if err != nil {
 		return err
 	}
@@ -49,7 +49,7 @@ func ptfForPodman(
 		return err
 	}
 
-	return podman.Upgrade(systemd, authFile,
+	return podman.Upgrade(systemd, authFile, "",
 		dummyDB,
 		dummyReportDB,
 		dummySSL,
@@ -62,6 +62,7 @@ func ptfForPodman(
 		flags.TFTPD,
 		flags.Installation.TZ,
 		flags.Installation.Debug.Java,
+		&flags.UpgradePolicy,
 	)
 }
 
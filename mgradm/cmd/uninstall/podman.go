@@ -13,28 +13,32 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-var systemd podman.Systemd = podman.NewSystemd()
-
 func uninstallForPodman(
-	_ *types.GlobalFlags,
+	globalFlags *types.GlobalFlags,
 	flags *utils.UninstallFlags,
 	_ *cobra.Command,
 	_ []string,
 ) error {
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	systemd := podman.NewSystemdOnHost(host)
+
 	// Get the images from the service configs before they are removed
 	images := []string{
-		podman.GetServiceImage(podman.ServerService),
-		podman.GetServiceImage(podman.ServerAttestationService + "@"),
-		podman.GetServiceImage(podman.HubXmlrpcService + "@"),
-		podman.GetServiceImage(podman.SalineService + "@"),
-		podman.GetServiceImage(podman.DBService),
-		podman.GetServiceImage(podman.TFTPService),
+		podman.GetServiceImage(host, podman.ServerService),
+		podman.GetServiceImage(host, podman.ServerAttestationService+"@"),
+		podman.GetServiceImage(host, podman.HubXmlrpcService+"@"),
+		podman.GetServiceImage(host, podman.SalineService+"@"),
+		podman.GetServiceImage(host, podman.DBService),
+		podman.GetServiceImage(host, podman.TFTPService),
 	}
 
 	// Uninstall the service
 	systemd.UninstallService("uyuni-server", !flags.Force)
 	// Force stop the pod
-	podman.DeleteContainer(podman.ServerContainerName, !flags.Force)
+	podman.DeleteContainer(host, podman.ServerContainerName, !flags.Force)
 
 	systemd.UninstallInstantiatedService(podman.ServerAttestationService, !flags.Force)
 	systemd.UninstallInstantiatedService(podman.HubXmlrpcService, !flags.Force)
@@ -53,7 +57,7 @@ func uninstallForPodman(
 			volumes = append(volumes, volume.Name)
 		}
 		for _, volume := range volumes {
-			if err := podman.DeleteVolume(volume, !flags.Force); err != nil {
+			if err := podman.DeleteVolume(host, volume, !flags.Force); err != nil {
 				log.Warn().Err(err).Msgf(L("Failed to remove volume %s"), volume)
 				allOk = false
 			}
@@ -68,7 +72,7 @@ func uninstallForPodman(
 	if flags.Purge.Images {
 		for _, image := range images {
 			if image != "" {
-				if err := podman.DeleteImage(image, !flags.Force); err != nil {
+				if err := podman.DeleteImage(host, image, !flags.Force); err != nil {
 					return utils.Errorf(err, L("cannot delete image %s"), image)
 				}
 			}
@@ -76,24 +80,24 @@ func uninstallForPodman(
 		log.Info().Msg(L("All images have been removed"))
 	}
 
-	podman.DeleteNetwork(!flags.Force)
+	podman.DeleteNetwork(host, !flags.Force)
 
-	podman.DeleteSecret(podman.ReportDBUserSecret, !flags.Force)
-	podman.DeleteSecret(podman.ReportDBPassSecret, !flags.Force)
-	podman.DeleteSecret(podman.DBUserSecret, !flags.Force)
-	podman.DeleteSecret(podman.DBPassSecret, !flags.Force)
-	podman.DeleteSecret(podman.DBAdminUserSecret, !flags.Force)
-	podman.DeleteSecret(podman.DBAdminPassSecret, !flags.Force)
-	podman.DeleteSecret(podman.DBSSLCertSecret, !flags.Force)
-	podman.DeleteSecret(podman.DBSSLKeySecret, !flags.Force)
-	podman.DeleteSecret(podman.DBCASecret, !flags.Force)
-	podman.DeleteSecret(podman.CASecret, !flags.Force)
-	podman.DeleteSecret(podman.SSLCertSecret, !flags.Force)
-	podman.DeleteSecret(podman.SSLKeySecret, !flags.Force)
-	podman.DeleteSecret(podman.AdminUserSecret, !flags.Force)
-	podman.DeleteSecret(podman.AdminPassSecret, !flags.Force)
+	podman.DeleteSecret(host, podman.ReportDBUserSecret, !flags.Force)
+	podman.DeleteSecret(host, podman.ReportDBPassSecret, !flags.Force)
+	podman.DeleteSecret(host, podman.DBUserSecret, !flags.Force)
+	podman.DeleteSecret(host, podman.DBPassSecret, !flags.Force)
+	podman.DeleteSecret(host, podman.DBAdminUserSecret, !flags.Force)
+	podman.DeleteSecret(host, podman.DBAdminPassSecret, !flags.Force)
+	podman.DeleteSecret(host, podman.DBSSLCertSecret, !flags.Force)
+	podman.DeleteSecret(host, podman.DBSSLKeySecret, !flags.Force)
+	podman.DeleteSecret(host, podman.DBCASecret, !flags.Force)
+	podman.DeleteSecret(host, podman.CASecret, !flags.Force)
+	podman.DeleteSecret(host, podman.SSLCertSecret, !flags.Force)
+	podman.DeleteSecret(host, podman.SSLKeySecret, !flags.Force)
+	podman.DeleteSecret(host, podman.AdminUserSecret, !flags.Force)
+	podman.DeleteSecret(host, podman.AdminPassSecret, !flags.Force)
 
-	err := systemd.ReloadDaemon(!flags.Force)
+	err = systemd.ReloadDaemon(!flags.Force)
 
 	if !flags.Force {
 		log.Warn().Msg(
@@ -21,6 +21,23 @@ func AddUpgradeFlags(cmd *cobra.Command) {
 	adm_utils.AddUpgradeSalineFlag(cmd)
 	_ = utils.AddFlagHelpGroup(cmd, &utils.Group{ID: "tftpd-container", Title: L("TFTPD Flags")})
 	utils.AddTFTPDFlags(cmd, true, "tftpd-container")
+
+	utils.AddUpgradePolicyFlags(cmd)
+
+	cmd.Flags().Bool("plan", false,
+		L("Only print the steps the upgrade would run and the host checks results, "+
+			"without running them or pulling the images"))
+	cmd.Flags().String("output", "text", L("Output format of the upgrade plan. Possible values: text, json"))
+
+	cmd.Flags().Bool("prepare-only", false,
+		L("Only pull and verify the images needed by the upgrade while the server keeps running"))
+
+	cmd.Flags().Bool("transactional", false,
+		L("Save the server state before upgrading and roll back to it if the upgrade fails. "+
+			"The saved state is removed once the upgrade succeeds. Requires --snapshot-pgsql"))
+	cmd.Flags().Bool("snapshot-pgsql", false,
+		L("Save the database volume in the transactional upgrade snapshot. This requires stopping the server. "+
+			"Without it, the upgrade cannot be rolled back once the new server may have migrated the database"))
 }
 
 // AddUpgradeListFlags add upgrade list flags to a command.
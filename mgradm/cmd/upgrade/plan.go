@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// writePlan prints the upgrade plan in the requested format.
+func writePlan(out io.Writer, plan *podman.UpgradePlan, format string) error {
+	if format == "json" {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	noValue := func(value string) string {
+		if value == "" {
+			return L("unknown")
+		}
+		return value
+	}
+
+	fmt.Fprintf(out, L("Upgrade from %[1]s to %[2]s")+"\n", noValue(plan.CurrentRelease), noValue(plan.TargetRelease))
+	fmt.Fprintf(out, L("PostgreSQL version: %[1]s to %[2]s")+"\n",
+		noValue(plan.CurrentPgVersion), noValue(plan.TargetPgVersion))
+
+	fmt.Fprintln(out, "\n"+L("Images:"))
+	for _, image := range plan.Images {
+		size := ""
+		if image.Size > 0 {
+			size = fmt.Sprintf(" (%s)", utils.FormatSize(image.Size))
+		}
+		if image.Missing {
+			size += " " + L("[image not present]")
+		}
+		current := image.Current
+		if current == "" {
+			current = L("not installed")
+		}
+		fmt.Fprintf(out, "  %s: %s -> %s%s\n", image.Service, current, image.Target, size)
+	}
+
+	fmt.Fprintln(out, "\n"+L("Steps:"))
+	for i, step := range plan.Steps {
+		downtime := ""
+		if step.Downtime > 0 {
+			downtime = fmt.Sprintf(" "+L("(downtime: %s)"), time.Duration(step.Downtime)*time.Second)
+		}
+		fmt.Fprintf(out, "  %d. [%s] %s%s\n", i+1, step.ID, step.Description, downtime)
+	}
+
+	if len(plan.Checks) > 0 {
+		fmt.Fprintln(out, "\n"+L("Host checks:"))
+		for _, result := range plan.Checks {
+			fmt.Fprintf(out, "  [%s] %s: %s\n", result.Status, result.Name, result.Message)
+			if result.Hint != "" {
+				fmt.Fprintf(out, "    "+L("hint: %s")+"\n", result.Hint)
+			}
+		}
+	}
+
+	if len(plan.Warnings) > 0 {
+		fmt.Fprintln(out, "\n"+L("Warnings:"))
+		for _, warning := range plan.Warnings {
+			fmt.Fprintf(out, "  - %s\n", warning)
+		}
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, L("Required disk space: %s")+"\n", utils.FormatSize(plan.RequiredDiskSpace))
+	_, err := fmt.Fprintf(out, L("Estimated downtime: %s")+"\n",
+		time.Duration(plan.EstimatedDowntime)*time.Second)
+	return err
+}
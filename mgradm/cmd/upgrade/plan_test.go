@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/check"
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+var testPlan = podman.UpgradePlan{
+	CurrentRelease:   "2025.10",
+	TargetRelease:    "2026.01",
+	CurrentPgVersion: "16",
+	TargetPgVersion:  "17",
+	Images: []podman.ImageChange{
+		{Service: "uyuni-server", Current: "server:2025.10", Target: "server:2026.01", Size: 2048},
+		{Service: "uyuni-saline", Target: "saline:2026.01", Missing: true},
+	},
+	Steps: []podman.UpgradeStep{
+		{ID: "stop", Description: "Stop the uyuni-server services", Downtime: 60},
+		{ID: "saline", Description: "uyuni-saline: regenerate the uyuni-saline service"},
+	},
+	RequiredDiskSpace: 1024 * 1024,
+	EstimatedDowntime: 60,
+	Checks: []check.Result{
+		{Name: "podman", Status: check.StatusPass, Message: "podman 5.2.0"},
+		{Name: "storage", Status: check.StatusFail, Message: "not enough space", Hint: "free some space"},
+	},
+	Warnings: []string{"Image saline:2026.01 is not present and could not be inspected"},
+}
+
+func TestWritePlanText(t *testing.T) {
+	var out bytes.Buffer
+	testutils.AssertNoError(t, "failed to write plan", writePlan(&out, &testPlan, "text"))
+	expected := `Upgrade from 2025.10 to 2026.01
+PostgreSQL version: 16 to 17
+
+Images:
+  uyuni-server: server:2025.10 -> server:2026.01 (2.0 KiB)
+  uyuni-saline: not installed -> saline:2026.01 [image not present]
+
+Steps:
+  1. [stop] Stop the uyuni-server services (downtime: 1m0s)
+  2. [saline] uyuni-saline: regenerate the uyuni-saline service
+
+Host checks:
+  [pass] podman: podman 5.2.0
+  [fail] storage: not enough space
+    hint: free some space
+
+Warnings:
+  - Image saline:2026.01 is not present and could not be inspected
+
+Required disk space: 1.0 MiB
+Estimated downtime: 1m0s
+`
+	testutils.AssertEquals(t, "Unexpected text plan", expected, out.String())
+}
+
+func TestWritePlanJSON(t *testing.T) {
+	var out bytes.Buffer
+	testutils.AssertNoError(t, "failed to write plan", writePlan(&out, &testPlan, "json"))
+	var plan podman.UpgradePlan
+	testutils.AssertNoError(t, "failed to parse JSON plan", json.Unmarshal(out.Bytes(), &plan))
+	testutils.AssertEquals(t, "Unexpected JSON plan", testPlan, plan)
+}
@@ -0,0 +1,358 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// snapshotDir is where the state of the server is saved before a transactional upgrade.
+var snapshotDir = "/var/lib/uyuni-tools/upgrade-snapshot"
+
+const (
+	snapshotFile        = "snapshot.json"
+	snapshotSystemdFile = "systemd.tar"
+	snapshotSecretsFile = "secrets.json"
+)
+
+// pgsqlVolume is the database volume optionally saved in the snapshot.
+var pgsqlVolume = utils.VarPgsqlDataVolumeMount.Name
+
+// getServiceImage and getVolumeMountPoint are function pointers to replace the host in unit tests.
+var getServiceImage = podman.GetServiceImage
+var getVolumeMountPoint = podman.GetVolumeMountPoint
+
+// serviceSnapshot is the state of a service before the upgrade.
+type serviceSnapshot struct {
+	// Name is the name of the service, with a trailing @ for the templated ones.
+	Name    string `json:"name"`
+	Image   string `json:"image,omitempty"`
+	Running bool   `json:"running"`
+	// Replicas is the number of enabled instances of a templated service.
+	Replicas int `json:"replicas,omitempty"`
+}
+
+// isTemplate returns whether the service is a templated one.
+func (s *serviceSnapshot) isTemplate() bool {
+	return strings.HasSuffix(s.Name, "@")
+}
+
+// snapshot describes the state of the server saved before a transactional upgrade.
+type snapshot struct {
+	Time     time.Time         `json:"time"`
+	Services []serviceSnapshot `json:"services"`
+	// Files are the systemd units, drop-ins and environment files saved in the snapshot.
+	Files []string `json:"files"`
+	// Pgsql is true if the database volume has been exported in the snapshot.
+	Pgsql bool `json:"pgsql"`
+	// PgsqlDataOld is true if the database volume already had a _data_old folder before the upgrade.
+	PgsqlDataOld bool `json:"pgsqlDataOld"`
+}
+
+// installedServices returns the installed uyuni services, with a trailing @ for the templated ones.
+func installedServices() []string {
+	services := []string{}
+	for _, service := range utils.UyuniServices {
+		if systemd.HasService(service.Name) {
+			services = append(services, service.Name)
+		} else if systemd.HasService(service.Name + "@") {
+			services = append(services, service.Name+"@")
+		}
+	}
+	return services
+}
+
+// takeSnapshot saves the systemd units, podman secrets and images references of the server.
+// If withPgsql is true, the server is stopped to export the database volume.
+func takeSnapshot(withPgsql bool) (*snapshot, error) {
+	log.Info().Msgf(L("Saving the server state into %s"), snapshotDir)
+	if err := os.RemoveAll(snapshotDir); err != nil {
+		return nil, utils.Errorf(err, L("failed to remove the previous snapshot"))
+	}
+	if err := os.MkdirAll(snapshotDir, 0700); err != nil {
+		return nil, utils.Errorf(err, L("failed to create %s"), snapshotDir)
+	}
+
+	snap := snapshot{Time: time.Now()}
+	for _, name := range installedServices() {
+		service := serviceSnapshot{Name: name, Image: podman.GetServiceImage(nil, name)}
+		if service.isTemplate() {
+			service.Replicas = systemd.CurrentReplicaCount(strings.TrimSuffix(name, "@"))
+			service.Running = service.Replicas > 0
+		} else {
+			service.Running = systemd.IsServiceRunning(name)
+		}
+		snap.Services = append(snap.Services, service)
+		snap.Files = append(snap.Files, backup.SystemdServiceFiles(systemd, name)...)
+	}
+
+	if err := writeSnapshotFiles(snap.Files); err != nil {
+		return nil, utils.Errorf(err, L("failed to save the systemd services"))
+	}
+
+	secrets, _, err := backup.ExportPodmanSecrets(nil)
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to export the podman secrets"))
+	}
+	if err := os.WriteFile(path.Join(snapshotDir, snapshotSecretsFile), secrets, 0600); err != nil {
+		return nil, utils.Errorf(err, L("failed to save the podman secrets"))
+	}
+
+	if mountPoint, err := podman.GetVolumeMountPoint(nil, pgsqlVolume); err == nil {
+		snap.PgsqlDataOld = utils.FileExists(path.Join(mountPoint, "..", "_data_old"))
+	}
+
+	if withPgsql {
+		if err := snapshotPgsql(snap.Services); err != nil {
+			return nil, err
+		}
+		snap.Pgsql = true
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path.Join(snapshotDir, snapshotFile), data, 0600); err != nil {
+		return nil, utils.Errorf(err, L("failed to write %s"), snapshotFile)
+	}
+	return &snap, nil
+}
+
+func writeSnapshotFiles(files []string) error {
+	out, err := os.OpenFile(path.Join(snapshotDir, snapshotSystemdFile), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	tw := tar.NewWriter(out)
+	if err := backup.WriteFilesTar(tw, files); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// snapshotPgsql exports the database volume while the server and database are stopped.
+// The services are started again after the export since the upgrade needs to inspect the running server.
+func snapshotPgsql(services []serviceSnapshot) (err error) {
+	stopped := []string{}
+	defer func() {
+		for i := len(stopped) - 1; i >= 0; i-- {
+			err = utils.JoinErrors(err, systemd.StartService(stopped[i]))
+		}
+	}()
+	for _, service := range services {
+		if service.Running && (service.Name == podman.ServerService || service.Name == podman.DBService) {
+			if err := systemd.StopService(service.Name); err != nil {
+				return utils.Errorf(err, L("cannot stop service"))
+			}
+			stopped = append(stopped, service.Name)
+		}
+	}
+
+	log.Info().Msgf(L("Exporting the %s volume"), pgsqlVolume)
+	if err := podman.ExportVolume(nil, pgsqlVolume, snapshotDir, false); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readSnapshot reads the snapshot saved by the last transactional upgrade.
+func readSnapshot() (*snapshot, error) {
+	data, err := os.ReadFile(path.Join(snapshotDir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.New(L("no upgrade snapshot to roll back to: use --transactional when upgrading"))
+	} else if err != nil {
+		return nil, utils.Errorf(err, L("failed to read %s"), snapshotFile)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, utils.Errorf(err, L("failed to parse %s"), snapshotFile)
+	}
+	return &snap, nil
+}
+
+// removeSnapshot removes the snapshot once it is no longer needed: it contains the podman secrets in clear.
+func removeSnapshot() {
+	if err := os.RemoveAll(snapshotDir); err != nil {
+		log.Warn().Err(err).Msgf(L("Failed to remove the upgrade snapshot, remove %s manually"), snapshotDir)
+	}
+}
+
+// checkRollback returns an error if the database cannot be brought back to the state of the snapshot.
+//
+// Without database snapshot nor data preceding a PostgreSQL major upgrade, the database schema
+// may have already been migrated if the server service uses the new image.
+func checkRollback(snap *snapshot) error {
+	if snap.Pgsql {
+		return nil
+	}
+	if !snap.PgsqlDataOld {
+		if mountPoint, err := getVolumeMountPoint(nil, pgsqlVolume); err == nil &&
+			utils.FileExists(path.Join(mountPoint, "..", "_data_old")) {
+			return nil
+		}
+	}
+	for _, service := range snap.Services {
+		if service.Name != podman.ServerService {
+			continue
+		}
+		if image := getServiceImage(nil, service.Name); image != "" && image != service.Image {
+			return fmt.Errorf(L("the server service already uses the %[1]s image and may have migrated the "+
+				"database schema: rolling back to %[2]s needs a snapshot taken with --snapshot-pgsql. "+
+				"Restore a backup instead"), image, service.Image)
+		}
+	}
+	return nil
+}
+
+// rollback restores the services, secrets, images and optionally the database saved in the snapshot
+// and starts the previous version of the server again.
+func rollback(snap *snapshot, authFile string) error {
+	if err := checkRollback(snap); err != nil {
+		return err
+	}
+	log.Info().Msgf(L("Rolling back the server to its state of %s"), snap.Time.Local().Format(time.DateTime))
+
+	// Stop everything, including the services that may have been added by the upgrade.
+	var hasError error
+	for _, name := range installedServices() {
+		if strings.HasSuffix(name, "@") {
+			hasError = utils.JoinErrors(hasError, systemd.StopInstantiated(strings.TrimSuffix(name, "@")))
+		} else if systemd.IsServiceRunning(name) {
+			hasError = utils.JoinErrors(hasError, systemd.StopService(name))
+		}
+	}
+	if hasError != nil {
+		return utils.Errorf(hasError, L("cannot stop service"))
+	}
+
+	hasError = utils.JoinErrors(
+		restoreSnapshotFiles(snap.Files),
+		restoreSnapshotSecrets(),
+		restoreSnapshotPgsql(snap),
+	)
+
+	for _, service := range snap.Services {
+		if service.Image == "" {
+			continue
+		}
+		// The previous images were already running: their signature is not verified again.
+		if _, err := podman.PrepareImage(nil, authFile, "", service.Image, "IfNotPresent", true); err != nil {
+			hasError = utils.JoinErrors(hasError, utils.Errorf(err, L("cannot prepare image %s"), service.Image))
+		}
+	}
+
+	if err := systemd.ReloadDaemon(false); err != nil {
+		return utils.JoinErrors(hasError, err)
+	}
+	return utils.JoinErrors(hasError, startSnapshotServices(snap.Services))
+}
+
+// restoreSnapshotFiles restores the systemd files and removes the drop-ins added since the snapshot.
+func restoreSnapshotFiles(files []string) error {
+	in, err := os.Open(path.Join(snapshotDir, snapshotSystemdFile))
+	if err != nil {
+		return utils.Errorf(err, L("failed to open the saved systemd services"))
+	}
+	defer in.Close()
+	if err := backup.RestoreFilesTar(tar.NewReader(in), false); err != nil {
+		return utils.Errorf(err, L("failed to restore the systemd services"))
+	}
+
+	for _, dir := range files {
+		if !strings.HasSuffix(dir, ".service.d") {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			file := path.Join(dir, entry.Name())
+			if !utils.Contains(files, file) {
+				log.Debug().Msgf("Removing %s added by the upgrade", file)
+				err = utils.JoinErrors(err, os.Remove(file))
+			}
+		}
+		if err != nil {
+			return utils.Errorf(err, L("failed to clean the configuration of %s"), dir)
+		}
+	}
+	return nil
+}
+
+func restoreSnapshotSecrets() error {
+	data, err := os.ReadFile(path.Join(snapshotDir, snapshotSecretsFile))
+	if err != nil {
+		return utils.Errorf(err, L("failed to read the saved podman secrets"))
+	}
+	return backup.RestorePodmanSecrets(data, true)
+}
+
+// restoreSnapshotPgsql restores the database from the snapshot or the data left by a PostgreSQL major upgrade.
+func restoreSnapshotPgsql(snap *snapshot) error {
+	if snap.Pgsql {
+		log.Info().Msgf(L("Restoring the %s volume"), pgsqlVolume)
+		if err := podman.DeleteVolume(nil, pgsqlVolume, false); err != nil {
+			return err
+		}
+		return podman.ImportVolume(nil, pgsqlVolume, path.Join(snapshotDir, pgsqlVolume+".tar"), false, false)
+	}
+
+	if snap.PgsqlDataOld {
+		return nil
+	}
+	mountPoint, err := podman.GetVolumeMountPoint(nil, pgsqlVolume)
+	if err != nil {
+		return utils.Errorf(err, L("cannot find volume %s"), pgsqlVolume)
+	}
+	oldData := path.Join(mountPoint, "..", "_data_old")
+	if !utils.FileExists(oldData) {
+		return nil
+	}
+	// The PostgreSQL major upgrade moved the previous data aside: put them back in place.
+	log.Info().Msg(L("Restoring the database data preceding the PostgreSQL upgrade"))
+	if err := os.RemoveAll(mountPoint); err != nil {
+		return utils.Errorf(err, L("cannot remove %s"), mountPoint)
+	}
+	if err := os.Rename(oldData, mountPoint); err != nil {
+		return utils.Errorf(err, L("cannot move %s"), oldData)
+	}
+	return nil
+}
+
+// startSnapshotServices starts the services that were running, the database first.
+func startSnapshotServices(services []serviceSnapshot) error {
+	ordered := append([]serviceSnapshot{}, services...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Name == podman.DBService && ordered[j].Name != podman.DBService
+	})
+
+	var hasError error
+	for _, service := range ordered {
+		if service.isTemplate() {
+			hasError = utils.JoinErrors(hasError,
+				systemd.ScaleService(service.Replicas, strings.TrimSuffix(service.Name, "@")))
+		} else if service.Running {
+			hasError = utils.JoinErrors(hasError, systemd.StartService(service.Name))
+		}
+	}
+	return hasError
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package upgrade
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+func TestRestoreSnapshotFiles(t *testing.T) {
+	oldSnapshotDir := snapshotDir
+	t.Cleanup(func() { snapshotDir = oldSnapshotDir })
+	snapshotDir = t.TempDir()
+
+	servicesDir := t.TempDir()
+	unit := path.Join(servicesDir, "uyuni-server.service")
+	dropInDir := unit + ".d"
+	dropIn := path.Join(dropInDir, "custom.conf")
+	testutils.AssertNoError(t, "failed to create drop-in dir", os.MkdirAll(dropInDir, 0755))
+	testutils.WriteFile(t, unit, "old unit")
+	testutils.WriteFile(t, dropIn, "old drop-in")
+
+	files := []string{unit, dropInDir, dropIn}
+	testutils.AssertNoError(t, "failed to save the files", writeSnapshotFiles(files))
+
+	// Mimic an upgrade changing the unit and adding a drop-in
+	testutils.WriteFile(t, unit, "new unit")
+	added := path.Join(dropInDir, "generated.conf")
+	testutils.WriteFile(t, added, "new drop-in")
+
+	testutils.AssertNoError(t, "failed to restore the files", restoreSnapshotFiles(files))
+	content, err := os.ReadFile(unit)
+	testutils.AssertNoError(t, "unit should be restored", err)
+	testutils.AssertEquals(t, "Unexpected unit content", "old unit", string(content))
+	_, err = os.Stat(added)
+	testutils.AssertTrue(t, "Drop-in added by the upgrade should be removed", os.IsNotExist(err))
+}
+
+func TestReadSnapshotMissing(t *testing.T) {
+	oldSnapshotDir := snapshotDir
+	t.Cleanup(func() { snapshotDir = oldSnapshotDir })
+	snapshotDir = t.TempDir()
+
+	_, err := readSnapshot()
+	testutils.AssertError(t, "no upgrade snapshot", err)
+}
+
+func TestStartSnapshotServices(t *testing.T) {
+	oldSystemd := systemd
+	t.Cleanup(func() { systemd = oldSystemd })
+	driver := testutils.FakeSystemdDriver{
+		Installed: []string{podman.ServerService, podman.DBService, podman.SalineService + "@"},
+		Enabled:   []string{podman.ServerService, podman.DBService},
+	}
+	systemd = podman.NewSystemdWithDriver(&driver)
+
+	services := []serviceSnapshot{
+		{Name: podman.ServerService, Running: true},
+		{Name: podman.DBService, Running: true},
+		{Name: podman.SalineService + "@", Replicas: 1, Running: true},
+		{Name: podman.TFTPService},
+	}
+	testutils.AssertNoError(t, "failed to start services", startSnapshotServices(services))
+	testutils.AssertEquals(t, "Unexpected running services",
+		[]string{podman.DBService, podman.ServerService, podman.SalineService + "@0"}, driver.Running)
+}
+
+func TestCheckRollback(t *testing.T) {
+	t.Cleanup(func() {
+		getServiceImage = podman.GetServiceImage
+		getVolumeMountPoint = podman.GetVolumeMountPoint
+	})
+	volumeDir := t.TempDir()
+	mountPoint := path.Join(volumeDir, "_data")
+	getVolumeMountPoint = func(_ *utils.Host, _ string) (string, error) { return mountPoint, nil }
+	getServiceImage = func(_ *utils.Host, _ string) string { return "server:2026.01" }
+
+	snap := &snapshot{Services: []serviceSnapshot{{Name: podman.ServerService, Image: "server:2025.10"}}}
+	testutils.AssertError(t, "may have migrated the database schema", checkRollback(snap))
+
+	// The data preceding the PostgreSQL major upgrade can be restored
+	testutils.AssertNoError(t, "failed to create old data", os.MkdirAll(path.Join(volumeDir, "_data_old"), 0700))
+	testutils.AssertNoError(t, "rollback to the old data should be allowed", checkRollback(snap))
+
+	// Those old data were already there before the upgrade
+	snap.PgsqlDataOld = true
+	testutils.AssertError(t, "may have migrated the database schema", checkRollback(snap))
+
+	snap.Pgsql = true
+	testutils.AssertNoError(t, "rollback with a database snapshot should be allowed", checkRollback(snap))
+
+	// The upgrade failed before regenerating the server service
+	snap.Pgsql = false
+	getServiceImage = func(_ *utils.Host, _ string) string { return "server:2025.10" }
+	testutils.AssertNoError(t, "rollback before the server change should be allowed", checkRollback(snap))
+}
+
+func TestRemoveSnapshot(t *testing.T) {
+	oldSnapshotDir := snapshotDir
+	t.Cleanup(func() { snapshotDir = oldSnapshotDir })
+	snapshotDir = path.Join(t.TempDir(), "upgrade-snapshot")
+	testutils.AssertNoError(t, "failed to create snapshot dir", os.MkdirAll(snapshotDir, 0700))
+	testutils.WriteFile(t, path.Join(snapshotDir, snapshotSecretsFile), "[]")
+
+	removeSnapshot()
+	_, err := os.Stat(snapshotDir)
+	testutils.AssertTrue(t, "Snapshot should be removed", os.IsNotExist(err))
+}
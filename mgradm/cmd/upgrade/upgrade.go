@@ -16,6 +16,20 @@ import (
 type podmanUpgradeFlags struct {
 	cmd_utils.ServerFlags `mapstructure:",squash"`
 	Podman                podman.PodmanFlags
+	SkipChecks            bool
+	Plan                  bool
+	Output                string
+	Transactional         bool
+	Snapshot              struct {
+		Pgsql bool
+	}
+	PrepareOnly bool
+}
+
+type rollbackFlags struct {
+	Image types.ImageFlags `mapstructure:",squash"`
+	SCC   types.SCCCredentials
+	Clean bool
 }
 
 func newCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[podmanUpgradeFlags]) *cobra.Command {
@@ -43,11 +57,15 @@ func newCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[podmanUpgradeF
 	}
 	AddUpgradeFlags(cmd)
 	cmd_utils.AddDebugFlags(cmd)
+	cmd_utils.AddSkipChecksFlag(cmd)
 	podman.AddPodmanArgFlag(cmd)
 	return cmd
 }
 
-func newListCmd(globalFlags *types.GlobalFlags, run func(*podmanUpgradeFlags) error) *cobra.Command {
+func newListCmd(
+	globalFlags *types.GlobalFlags,
+	run func(*types.GlobalFlags, *podmanUpgradeFlags) error,
+) *cobra.Command {
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: L("List available tags for an image"),
@@ -64,7 +82,7 @@ func newListCmd(globalFlags *types.GlobalFlags, run func(*podmanUpgradeFlags) er
 			if err := viper.Unmarshal(&flags); err != nil {
 				return utils.Errorf(err, L("failed to unmarshall configuration"))
 			}
-			if err := run(&flags); err != nil {
+			if err := run(globalFlags, &flags); err != nil {
 				return err
 			}
 			return nil
@@ -74,8 +92,12 @@ func newListCmd(globalFlags *types.GlobalFlags, run func(*podmanUpgradeFlags) er
 	return listCmd
 }
 
-func listTags(flags *podmanUpgradeFlags) error {
-	hostData, err := podman.InspectHost()
+func listTags(globalFlags *types.GlobalFlags, flags *podmanUpgradeFlags) error {
+	host, err := utils.NewHost(globalFlags.Host)
+	if err != nil {
+		return err
+	}
+	hostData, err := podman.InspectHost(host)
 	if err != nil {
 		return err
 	}
@@ -86,7 +108,35 @@ func listTags(flags *podmanUpgradeFlags) error {
 	}
 	defer cleaner()
 
-	return podman.ShowAvailableTag(flags.Image.Registry.Host, flags.Image, authFile)
+	return podman.ShowAvailableTag(host, flags.Image.Registry.Host, flags.Image, authFile)
+}
+
+func newRollbackCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[rollbackFlags]) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: L("Roll back to the state saved by a failed transactional upgrade"),
+		Long: L(`Roll back to the state saved by a failed transactional upgrade
+
+The snapshot of a transactional upgrade is removed once the upgrade or its rollback succeeds:
+this command is meant to complete a rollback that failed after fixing the issue.
+Use --clean to discard the snapshot instead: it contains the podman secrets in clear text.
+
+The systemd services, podman secrets and images of the server are restored and the previous version is started.
+The database is restored from the snapshot if it was taken with --snapshot-pgsql.
+Otherwise the data preceding a PostgreSQL major upgrade are put back in place:
+any change made to the database since the upgrade is lost.
+The rollback is refused if the new server may have already migrated the database schema
+and there is no database data to restore.`),
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var flags rollbackFlags
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+	utils.AddRegistryFlag(cmd)
+	cmd_utils.AddSCCFlag(cmd)
+	cmd.Flags().Bool("clean", false, L("Remove the upgrade snapshot without rolling back"))
+	return cmd
 }
 
 // NewCommand to upgrade a podman server.
@@ -94,5 +144,6 @@ func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
 	cmd := newCmd(globalFlags, upgradePodman)
 
 	cmd.AddCommand(newListCmd(globalFlags, listTags))
+	cmd.AddCommand(newRollbackCmd(globalFlags, rollbackPodman))
 	return cmd
 }
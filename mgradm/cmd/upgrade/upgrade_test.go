@@ -16,11 +16,20 @@ import (
 func TestParamsParsing(t *testing.T) {
 	args := flagstests.ServerFlagsTestArgs()
 	args = append(args, flagstests.PodmanFlagsTestArgs...)
+	args = append(args, "--plan", "--output", "json", "--transactional", "--snapshot-pgsql", "--skipchecks",
+		"--prepare-only", "--force")
 
 	// Test function asserting that the args are properly parsed
 	tester := func(_ *types.GlobalFlags, flags *podmanUpgradeFlags,
 		_ *cobra.Command, _ []string,
 	) error {
+		testutils.AssertTrue(t, "Error parsing --plan", flags.Plan)
+		testutils.AssertEquals(t, "Error parsing --output", "json", flags.Output)
+		testutils.AssertTrue(t, "Error parsing --transactional", flags.Transactional)
+		testutils.AssertTrue(t, "Error parsing --snapshot-pgsql", flags.Snapshot.Pgsql)
+		testutils.AssertTrue(t, "Error parsing --skipchecks", flags.SkipChecks)
+		testutils.AssertTrue(t, "Error parsing --prepare-only", flags.PrepareOnly)
+		testutils.AssertTrue(t, "Error parsing --force", flags.UpgradePolicy.Force)
 		flagstests.AssertPodmanInstallFlags(t, &flags.Podman)
 		flagstests.AssertServerFlags(t, &flags.ServerFlags)
 		return nil
@@ -44,7 +53,7 @@ func TestListParamsParsing(t *testing.T) {
 	args = append(args, flagstests.SCCFlagTestArgs...)
 
 	// Test function asserting that the args are properly parsed
-	tester := func(flags *podmanUpgradeFlags) error {
+	tester := func(_ *types.GlobalFlags, flags *podmanUpgradeFlags) error {
 		flagstests.AssertImageFlag(t, &flags.Image)
 		flagstests.AssertSCCFlag(t, &flags.Installation.SCC)
 		return nil
@@ -60,3 +69,32 @@ func TestListParamsParsing(t *testing.T) {
 		t.Errorf("command failed with error: %s", err)
 	}
 }
+
+func TestRollbackParamsParsing(t *testing.T) {
+	args := []string{"--registry", "myOldRegistry", "--clean"}
+	args = append(args, flagstests.RegistryImageFlagsTestArgs...)
+	args = append(args, flagstests.SCCFlagTestArgs...)
+
+	tester := func(_ *types.GlobalFlags, flags *rollbackFlags, _ *cobra.Command, _ []string) error {
+		flagstests.AssertRegistryFlag(t, &flags.Image.Registry)
+		flagstests.AssertSCCFlag(t, &flags.SCC)
+		testutils.AssertTrue(t, "Error parsing --clean", flags.Clean)
+		return nil
+	}
+
+	globalFlags := types.GlobalFlags{}
+	cmd := newRollbackCmd(&globalFlags, tester)
+
+	testutils.AssertHasAllFlags(t, cmd, args)
+
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("command failed with error: %s", err)
+	}
+}
+
+func TestTransactionalNeedsPgsqlSnapshot(t *testing.T) {
+	flags := podmanUpgradeFlags{Output: "text", Transactional: true}
+	err := upgradePodman(&types.GlobalFlags{}, &flags, nil, nil)
+	testutils.AssertError(t, "--transactional requires --snapshot-pgsql", err)
+}
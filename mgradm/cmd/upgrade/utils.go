@@ -6,36 +6,160 @@ package upgrade
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"os/exec"
 
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/check"
 	"github.com/uyuni-project/uyuni-tools/mgradm/shared/podman"
 	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
 	shared_podman "github.com/uyuni-project/uyuni-tools/shared/podman"
 	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
+// systemd manages the local services saved in the snapshots: transactional upgrades are local only.
 var systemd shared_podman.Systemd = shared_podman.NewSystemd()
 
-func upgradePodman(_ *types.GlobalFlags, flags *podmanUpgradeFlags, cmd *cobra.Command, _ []string) error {
-	hostData, err := shared_podman.InspectHost()
+func upgradePodman(globalFlags *types.GlobalFlags, flags *podmanUpgradeFlags, cmd *cobra.Command, _ []string) error {
+	if flags.Output != "text" && flags.Output != "json" {
+		return fmt.Errorf(L("unsupported output format %s: possible values are text and json"), flags.Output)
+	}
+	if flags.Plan && flags.PrepareOnly {
+		return errors.New(L("--plan and --prepare-only cannot be used together"))
+	}
+	// Without the database, the upgrade can hardly be rolled back once the new server has started.
+	if flags.Transactional && !flags.Snapshot.Pgsql {
+		return errors.New(L("--transactional requires --snapshot-pgsql to be able to roll back the database"))
+	}
+	host, err := utils.NewHost(globalFlags.Host)
 	if err != nil {
 		return err
 	}
+	// The snapshot needs direct access to the files of the server host.
+	if flags.Transactional && host.IsRemote() {
+		return errors.New(L("transactional upgrades are not supported on a remote host"))
+	}
+	serverSystemd := shared_podman.NewSystemdOnHost(host)
 
-	authFile, cleaner, err := shared_podman.PodmanLogin(hostData, flags.Image.Registry, flags.Installation.SCC)
+	hostData, err := shared_podman.InspectHost(host)
 	if err != nil {
 		return err
 	}
+
+	// The plan is a preview: report the setup and check failures in it rather than aborting.
+	planWarnings := []string{}
+	authFile, cleaner, err := shared_podman.PodmanLogin(hostData, flags.Image.Registry, flags.Installation.SCC)
+	if err != nil {
+		if !flags.Plan {
+			return err
+		}
+		planWarnings = append(planWarnings, utils.Error(err, L("registry login failed")).Error())
+		cleaner = func() {}
+	}
 	defer cleaner()
 
-	flags.Installation.CheckUpgradeParameters(cmd, "podman")
+	signaturePolicy, cleanPolicy, err := shared_podman.SetupSignatureVerification(host, &flags.Signatures)
+	if err != nil {
+		if !flags.Plan {
+			return err
+		}
+		planWarnings = append(planWarnings, utils.Error(err, L("signature verification setup failed")).Error())
+		cleanPolicy = func() {}
+	}
+	defer cleanPolicy()
+
+	flags.Installation.CheckUpgradeParameters(cmd, "podman", serverSystemd)
 	if _, err := exec.LookPath("podman"); err != nil {
 		return errors.New(L("install podman before running this command"))
 	}
 
+	var checkResults []check.Result
+	if !flags.SkipChecks {
+		checkContext := &check.Context{
+			Operation: check.Upgrade,
+			Host:      host,
+			FQDN:      check.Fqdn(host, nil),
+		}
+		if !flags.Plan {
+			if err := check.RunAndLog(checkContext); err != nil {
+				return err
+			}
+		} else {
+			checkResults = check.Run(checkContext)
+			if err := check.Failed(checkResults); err != nil {
+				planWarnings = append(planWarnings,
+					utils.Error(err, L("the upgrade will refuse to run without --skipchecks")).Error())
+			}
+		}
+	}
+
+	if flags.Plan {
+		plan, err := podman.PlanUpgrade(
+			serverSystemd, authFile,
+			flags.Image,
+			flags.DBUpgradeImage,
+			flags.Coco,
+			flags.HubXmlrpc,
+			flags.Saline,
+			flags.Pgsql,
+			flags.TFTPD,
+			&flags.UpgradePolicy,
+		)
+		if err != nil {
+			return err
+		}
+		plan.Checks = checkResults
+		plan.Warnings = append(planWarnings, plan.Warnings...)
+		return writePlan(os.Stdout, plan, flags.Output)
+	}
+
+	if flags.PrepareOnly {
+		staged, err := podman.PrepareUpgrade(
+			serverSystemd, authFile, signaturePolicy,
+			flags.Image,
+			flags.DBUpgradeImage,
+			flags.Coco,
+			flags.HubXmlrpc,
+			flags.Saline,
+			flags.Pgsql,
+			flags.TFTPD,
+			&flags.UpgradePolicy,
+		)
+		if err != nil {
+			return utils.Error(err, L("failed to prepare the upgrade"))
+		}
+		log.Info().Msgf(L("%d images are ready: run the upgrade with the same parameters to switch over"),
+			len(staged.Images))
+		return nil
+	}
+
+	if flags.Transactional {
+		snap, err := takeSnapshot(flags.Snapshot.Pgsql)
+		if err != nil {
+			return utils.Error(err, L("failed to save the server state before upgrading"))
+		}
+		if err := upgrade(serverSystemd, authFile, signaturePolicy, flags); err != nil {
+			log.Error().Err(err).Msg(L("Upgrade failed, rolling back to the previous version"))
+			if errRollback := rollback(snap, authFile); errRollback != nil {
+				return utils.JoinErrors(err,
+					utils.Error(errRollback, L("rollback failed, fix the issue and run mgradm upgrade rollback")))
+			}
+			removeSnapshot()
+			return utils.Error(err, L("upgrade failed, the previous version has been restored"))
+		}
+		removeSnapshot()
+		return nil
+	}
+
+	return upgrade(serverSystemd, authFile, signaturePolicy, flags)
+}
+
+func upgrade(systemd shared_podman.Systemd, authFile string, signaturePolicy string, flags *podmanUpgradeFlags) error {
 	return podman.Upgrade(
-		systemd, authFile,
+		systemd, authFile, signaturePolicy,
 		flags.Installation.DB,
 		flags.Installation.ReportDB,
 		flags.Installation.SSL,
@@ -48,5 +172,41 @@ func upgradePodman(_ *types.GlobalFlags, flags *podmanUpgradeFlags, cmd *cobra.C
 		flags.TFTPD,
 		flags.Installation.TZ,
 		flags.Installation.Debug.Java,
+		&flags.UpgradePolicy,
 	)
 }
+
+func rollbackPodman(globalFlags *types.GlobalFlags, flags *rollbackFlags, _ *cobra.Command, _ []string) error {
+	if globalFlags.Host != "" {
+		return errors.New(L("rolling back an upgrade is not supported on a remote host"))
+	}
+	if flags.Clean {
+		if err := os.RemoveAll(snapshotDir); err != nil {
+			return utils.Errorf(err, L("failed to remove %s"), snapshotDir)
+		}
+		log.Info().Msg(L("Upgrade snapshot removed"))
+		return nil
+	}
+	snap, err := readSnapshot()
+	if err != nil {
+		return err
+	}
+
+	hostData, err := shared_podman.InspectHost(nil)
+	if err != nil {
+		return err
+	}
+
+	authFile, cleaner, err := shared_podman.PodmanLogin(hostData, flags.Image.Registry, flags.SCC)
+	if err != nil {
+		return err
+	}
+	defer cleaner()
+
+	if err := rollback(snap, authFile); err != nil {
+		return utils.Error(err, L("failed to roll back the upgrade"))
+	}
+	removeSnapshot()
+	log.Info().Msg(L("Server rolled back to its previous version"))
+	return nil
+}
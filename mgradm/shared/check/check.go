@@ -0,0 +1,191 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Operation is the server operation the checks are run before.
+type Operation string
+
+const (
+	Install Operation = "install"
+	Upgrade Operation = "upgrade"
+)
+
+// Statuses of the check results.
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Context describes the operation to the checks.
+type Context struct {
+	Operation Operation
+	// Host is the machine where the server runs, nil for the local one.
+	Host *utils.Host
+	// FQDN is the fully qualified domain name of the server.
+	FQDN string
+	// Ports are the ports the server will expose on the host.
+	Ports []types.PortMap
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// Hint explains how to fix the warning or failure.
+	Hint string `json:"hint,omitempty"`
+}
+
+// Check is a host check run before an operation.
+type Check struct {
+	Name string
+	// Operations lists the operations the check applies to.
+	Operations []Operation
+	Run        func(ctx *Context) Result
+}
+
+var checks = []Check{
+	{Name: "podman", Operations: []Operation{Install, Upgrade}, Run: checkPodman},
+	{Name: "server", Operations: []Operation{Install, Upgrade}, Run: checkServer},
+	{Name: "ports", Operations: []Operation{Install}, Run: checkPorts},
+	{Name: "storage", Operations: []Operation{Install, Upgrade}, Run: checkStorage},
+	{Name: "fqdn", Operations: []Operation{Install, Upgrade}, Run: checkFqdn},
+	{Name: "selinux", Operations: []Operation{Install, Upgrade}, Run: checkSELinux},
+	{Name: "timesync", Operations: []Operation{Install, Upgrade}, Run: checkTimeSync},
+	{Name: "ipv6", Operations: []Operation{Install, Upgrade}, Run: checkIPv6},
+}
+
+// Register adds a check to run before the operations it applies to.
+func Register(check Check) {
+	checks = append(checks, check)
+}
+
+// Run runs the checks applying to the operation of the context.
+func Run(ctx *Context) []Result {
+	results := []Result{}
+	for _, check := range checks {
+		if !appliesTo(check.Operations, ctx.Operation) {
+			continue
+		}
+		log.Debug().Msgf("Running %s check", check.Name)
+		result := check.Run(ctx)
+		result.Name = check.Name
+		results = append(results, result)
+	}
+	return results
+}
+
+func appliesTo(operations []Operation, operation Operation) bool {
+	for _, op := range operations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// Failed returns an error if one of the checks failed.
+func Failed(results []Result) error {
+	failed := 0
+	for _, result := range results {
+		if result.Status == StatusFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf(L("%d host checks failed"), failed)
+	}
+	return nil
+}
+
+// Log shows the check results in the logs, with their hints.
+func Log(results []Result) {
+	for _, result := range results {
+		switch result.Status {
+		case StatusPass:
+			log.Info().Msgf(L("[pass] %[1]s: %[2]s"), result.Name, result.Message)
+			continue
+		case StatusWarn:
+			log.Warn().Msgf(L("[warn] %[1]s: %[2]s"), result.Name, result.Message)
+		default:
+			log.Error().Msgf(L("[fail] %[1]s: %[2]s"), result.Name, result.Message)
+		}
+		if result.Hint != "" {
+			log.Info().Msgf(L("       hint: %s"), result.Hint)
+		}
+	}
+}
+
+// Write writes the check results as JSON.
+func Write(out io.Writer, results []Result) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// RunAndLog runs the checks of the operation, logs their results and fails if one of them failed.
+func RunAndLog(ctx *Context) error {
+	results := Run(ctx)
+	Log(results)
+	if err := Failed(results); err != nil {
+		return utils.Error(err, L("fix the host issues or run again with --skipchecks"))
+	}
+	return nil
+}
+
+func pass(message string) Result {
+	return Result{Status: StatusPass, Message: message}
+}
+
+func warn(message string, hint string) Result {
+	return Result{Status: StatusWarn, Message: message, Hint: hint}
+}
+
+func fail(message string, hint string) Result {
+	return Result{Status: StatusFail, Message: message, Hint: hint}
+}
+
+// ServerPorts returns the ports the server exposes on the host.
+func ServerPorts(debug bool, tftp bool, hubXmlrpc bool) []types.PortMap {
+	ports := utils.GetServerPorts(debug)
+	ports = append(ports, utils.TCPPodmanPorts...)
+	if tftp {
+		ports = append(ports, utils.TftpPorts...)
+	}
+	if hubXmlrpc {
+		ports = append(ports, utils.HubXmlrpcPorts...)
+	}
+	return ports
+}
+
+// Fqdn returns the FQDN passed as argument or the host one, without validating it.
+func Fqdn(host *utils.Host, args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+	out, err := runCmdOutput(host, zerolog.DebugLevel, "hostname", "-f")
+	if err != nil {
+		log.Warn().Err(err).Msg(L("failed to compute server FQDN"))
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// fakeCommands returns a runCmdOutput replacement returning the output of the known commands.
+func fakeCommands(outputs map[string]string) func(*utils.Host, zerolog.Level, string, ...string) ([]byte, error) {
+	return func(_ *utils.Host, _ zerolog.Level, command string, args ...string) ([]byte, error) {
+		key := strings.Join(append([]string{command}, args...), " ")
+		for prefix, out := range outputs {
+			if strings.HasPrefix(key, prefix) {
+				return []byte(out), nil
+			}
+		}
+		return nil, errors.New("command not found")
+	}
+}
+
+func TestCheckPodman(t *testing.T) {
+	type testCase struct {
+		version  string
+		backend  string
+		expected string
+	}
+
+	testCases := []testCase{
+		{"4.9.5", "netavark", StatusPass},
+		{"5.2.2", "netavark", StatusPass},
+		{"4.4.1", "netavark", StatusFail},
+		{"3.4.4", "netavark", StatusFail},
+		{"4.9.5", "cni", StatusWarn},
+		{"", "", StatusFail},
+	}
+
+	for i, test := range testCases {
+		outputs := map[string]string{}
+		if test.version != "" {
+			outputs["podman version"] = test.version + "\n"
+			outputs["podman info"] = test.backend + "\n"
+		}
+		runCmdOutput = fakeCommands(outputs)
+		result := checkPodman(&Context{Operation: Install})
+		testutils.AssertEquals(t, fmt.Sprintf("case %d: unexpected status", i), test.expected, result.Status)
+	}
+}
+
+func TestCheckServer(t *testing.T) {
+	type testCase struct {
+		operation Operation
+		installed bool
+		expected  string
+	}
+
+	testCases := []testCase{
+		{Install, false, StatusPass},
+		{Install, true, StatusFail},
+		{Upgrade, true, StatusPass},
+		{Upgrade, false, StatusFail},
+	}
+
+	for _, test := range testCases {
+		driver := testutils.FakeSystemdDriver{}
+		if test.installed {
+			driver.Installed = []string{podman.ServerService}
+		}
+		newSystemd = func(_ *utils.Host) podman.Systemd { return podman.NewSystemdWithDriver(&driver) }
+		result := checkServer(&Context{Operation: test.operation})
+		testutils.AssertEquals(t, "unexpected status for "+string(test.operation), test.expected, result.Status)
+	}
+}
+
+func TestCheckPorts(t *testing.T) {
+	ports := []types.PortMap{
+		{Exposed: 443},
+		{Exposed: 4505},
+		{Exposed: 69, Protocol: "udp"},
+	}
+	isPortFree = func(_ *utils.Host, port types.PortMap) bool {
+		return port.Exposed != 4505 && port.Exposed != 69
+	}
+	result := checkPorts(&Context{Operation: Install, Ports: ports})
+	testutils.AssertEquals(t, "unexpected status", StatusFail, result.Status)
+	testutils.AssertEquals(t, "unexpected message", "ports already in use: 4505/tcp, 69/udp", result.Message)
+
+	isPortFree = func(_ *utils.Host, _ types.PortMap) bool { return true }
+	result = checkPorts(&Context{Operation: Install, Ports: ports})
+	testutils.AssertEquals(t, "unexpected status", StatusPass, result.Status)
+}
+
+func TestCheckFqdn(t *testing.T) {
+	lookupHost = func(_ *utils.Host, host string) ([]string, error) {
+		if host == "srv.fq.dn" || host == "other.fq.dn" {
+			return []string{"192.168.1.2"}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	lookupAddr = func(_ *utils.Host, _ string) ([]string, error) {
+		return []string{"srv.fq.dn."}, nil
+	}
+	defer func() {
+		lookupHost = resolveHost
+		lookupAddr = resolveAddr
+	}()
+
+	expected := map[string]string{
+		"srv.fq.dn":     StatusPass,
+		"other.fq.dn":   StatusWarn,
+		"missing.fq.dn": StatusFail,
+		"srv":           StatusFail,
+	}
+	for fqdn, status := range expected {
+		result := checkFqdn(&Context{Operation: Install, FQDN: fqdn})
+		testutils.AssertEquals(t, "unexpected status for "+fqdn, status, result.Status)
+	}
+}
+
+func TestCheckFqdnRemote(t *testing.T) {
+	runCmdOutput = fakeCommands(map[string]string{
+		"getent ahosts srv.fq.dn": "192.168.1.2     STREAM srv.fq.dn\n192.168.1.2     DGRAM\n" +
+			"192.168.1.2     RAW\n",
+		"getent hosts 192.168.1.2": "192.168.1.2     srv.fq.dn srv\n",
+	})
+	defer func() { runCmdOutput = (*utils.Host).RunCmdOutput }()
+
+	addresses, err := resolveHost(&utils.Host{}, "srv.fq.dn")
+	testutils.AssertNoError(t, "failed to resolve the host", err)
+	testutils.AssertEquals(t, "unexpected addresses", []string{"192.168.1.2"}, addresses)
+
+	result := checkFqdn(&Context{Operation: Install, Host: &utils.Host{}, FQDN: "srv.fq.dn"})
+	testutils.AssertEquals(t, "unexpected status", StatusPass, result.Status)
+	result = checkFqdn(&Context{Operation: Install, Host: &utils.Host{}, FQDN: "missing.fq.dn"})
+	testutils.AssertEquals(t, "unexpected status", StatusFail, result.Status)
+}
+
+func TestCheckSELinux(t *testing.T) {
+	expected := map[string]string{
+		"Enforcing":  StatusPass,
+		"Permissive": StatusWarn,
+		"Disabled":   StatusPass,
+	}
+	for mode, status := range expected {
+		runCmdOutput = fakeCommands(map[string]string{
+			"getenforce":               mode + "\n",
+			"rpm -q container-selinux": "container-selinux-2.229.0",
+		})
+		result := checkSELinux(&Context{Operation: Install})
+		testutils.AssertEquals(t, "unexpected status for "+mode, status, result.Status)
+	}
+}
+
+func TestCheckStorage(t *testing.T) {
+	runCmdOutput = fakeCommands(map[string]string{"podman system info": "/"})
+	volumeSize = func(_ *utils.Host, _ string) (int64, error) { return 10 * gib, nil }
+
+	type testCase struct {
+		operation Operation
+		free      uint64
+		expected  string
+	}
+
+	testCases := []testCase{
+		{Install, 200 * gib, StatusPass},
+		{Install, 50 * gib, StatusWarn},
+		{Install, 10 * gib, StatusFail},
+		{Upgrade, 50 * gib, StatusPass},
+		{Upgrade, 25 * gib, StatusFail},
+	}
+
+	for _, test := range testCases {
+		freeSpace = func(_ *utils.Host, _ string) (uint64, error) { return test.free, nil }
+		result := checkStorage(&Context{Operation: test.operation})
+		testutils.AssertEquals(t, "unexpected status for "+result.Message, test.expected, result.Status)
+	}
+}
+
+func TestRun(t *testing.T) {
+	saved := checks
+	defer func() { checks = saved }()
+
+	checks = []Check{}
+	Register(Check{Name: "always", Operations: []Operation{Install, Upgrade}, Run: func(_ *Context) Result {
+		return pass("ok")
+	}})
+	Register(Check{Name: "install", Operations: []Operation{Install}, Run: func(_ *Context) Result {
+		return fail("ko", "fix it")
+	}})
+	Register(Check{Name: "upgrade", Operations: []Operation{Upgrade}, Run: func(_ *Context) Result {
+		return warn("maybe", "look at it")
+	}})
+
+	results := Run(&Context{Operation: Upgrade})
+	testutils.AssertEquals(t, "unexpected number of results", 2, len(results))
+	testutils.AssertEquals(t, "result name not set", "upgrade", results[1].Name)
+	testutils.AssertNoError(t, "warnings should not fail", Failed(results))
+
+	results = Run(&Context{Operation: Install})
+	testutils.AssertError(t, "1 host checks failed", Failed(results))
+	testutils.AssertError(t, "--skipchecks", RunAndLog(&Context{Operation: Install}))
+
+	var out bytes.Buffer
+	testutils.AssertNoError(t, "failed to write results", Write(&out, results))
+	var written []Result
+	testutils.AssertNoError(t, "invalid JSON", json.Unmarshal(out.Bytes(), &written))
+	testutils.AssertEquals(t, "unexpected JSON results", "fix it", written[1].Hint)
+}
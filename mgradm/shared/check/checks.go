@@ -0,0 +1,302 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package check
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+	"golang.org/x/sys/unix"
+)
+
+// Minimum podman version, matching the package requirements.
+const (
+	minPodmanMajor = 4
+	minPodmanMinor = 5
+)
+
+// Storage sizes in bytes for the podman volumes.
+const (
+	gib = 1024 * 1024 * 1024
+	// minStorage is the space needed for the images and the data growth during the operation.
+	minStorage = 20 * gib
+	// recommendedStorage is the space recommended for a new server.
+	recommendedStorage = 100 * gib
+)
+
+// Functions pointers to mock the host in unit tests.
+var (
+	runCmdOutput     = (*utils.Host).RunCmdOutput
+	lookupHost       = resolveHost
+	lookupAddr       = resolveAddr
+	isPortFree       = portFree
+	freeSpace        = diskFreeSpace
+	volumeSize       = podman.GetVolumeSize
+	hostHasIpv6      = podman.IsIpv6Enabled
+	networkHasIpv6   = podman.HasIpv6Enabled
+	isNetworkPresent = podman.IsNetworkPresent
+	newSystemd       = podman.NewSystemdOnHost
+)
+
+func checkPodman(ctx *Context) Result {
+	out, err := runCmdOutput(ctx.Host, zerolog.DebugLevel, "podman", "version", "--format", "{{.Client.Version}}")
+	if err != nil {
+		return fail(L("podman is not installed or not working"),
+			fmt.Sprintf(L("Install podman %d.%d or later"), minPodmanMajor, minPodmanMinor))
+	}
+	version := strings.TrimSpace(string(out))
+	var major, minor int
+	if _, err := fmt.Sscanf(version, "%d.%d", &major, &minor); err != nil {
+		return warn(fmt.Sprintf(L("cannot parse podman version %s"), version), "")
+	}
+	if major < minPodmanMajor || major == minPodmanMajor && minor < minPodmanMinor {
+		return fail(fmt.Sprintf(L("podman %s is too old"), version),
+			fmt.Sprintf(L("Install podman %d.%d or later"), minPodmanMajor, minPodmanMinor))
+	}
+
+	out, err = runCmdOutput(ctx.Host, zerolog.DebugLevel, "podman", "info", "--format", "{{.Host.NetworkBackend}}")
+	if backend := strings.TrimSpace(string(out)); err == nil && backend != "netavark" {
+		return warn(fmt.Sprintf(L("podman %[1]s uses the %[2]s network backend instead of netavark"), version, backend),
+			L("Install netavark and aardvark-dns and reset the podman network configuration"))
+	}
+	return pass(fmt.Sprintf(L("podman %s is installed"), version))
+}
+
+func checkServer(ctx *Context) Result {
+	installed := newSystemd(ctx.Host).HasService(podman.ServerService)
+	if ctx.Operation == Install && installed {
+		return fail(L("a server is already installed"), L("Uninstall the server first or use the upgrade command"))
+	}
+	if ctx.Operation == Upgrade && !installed {
+		return fail(L("no server is installed"), L("Use the install command"))
+	}
+	if installed {
+		return pass(L("a server is installed"))
+	}
+	return pass(L("no server is installed"))
+}
+
+func checkPorts(ctx *Context) Result {
+	busy := []string{}
+	for _, port := range ctx.Ports {
+		if !isPortFree(ctx.Host, port) {
+			busy = append(busy, portName(port))
+		}
+	}
+	if len(busy) > 0 {
+		return fail(fmt.Sprintf(L("ports already in use: %s"), strings.Join(busy, ", ")),
+			L("Stop the services using these ports: ss -tulpn lists them"))
+	}
+	return pass(fmt.Sprintf(L("the %d server ports are free"), len(ctx.Ports)))
+}
+
+func portName(port types.PortMap) string {
+	protocol := port.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	return fmt.Sprintf("%d/%s", port.Exposed, protocol)
+}
+
+// portFree returns whether a port can be listened to on the host.
+func portFree(host *utils.Host, port types.PortMap) bool {
+	if host.IsRemote() {
+		ssFlags := "-Hlnt"
+		if port.Protocol == "udp" {
+			ssFlags = "-Hlnu"
+		}
+		out, err := runCmdOutput(host, zerolog.DebugLevel, "ss", ssFlags, fmt.Sprintf("sport = :%d", port.Exposed))
+		return err == nil && len(strings.TrimSpace(string(out))) == 0
+	}
+	address := fmt.Sprintf(":%d", port.Exposed)
+	if port.Protocol == "udp" {
+		conn, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+func checkStorage(ctx *Context) Result {
+	out, err := runCmdOutput(ctx.Host, zerolog.DebugLevel, "podman", "system", "info", "--format={{ .Store.VolumePath }}")
+	if err != nil {
+		return warn(L("cannot find the podman volumes storage"), "")
+	}
+	dir := strings.TrimSpace(string(out))
+	// The volumes folder may not exist before the installation
+	for dir != "/" && !ctx.Host.FileExists(dir) {
+		dir = path.Dir(dir)
+	}
+	free, err := freeSpace(ctx.Host, dir)
+	if err != nil {
+		return warn(fmt.Sprintf(L("cannot compute the free space in %s"), dir), "")
+	}
+
+	required := uint64(minStorage)
+	if ctx.Operation == Upgrade {
+		// A PostgreSQL major upgrade copies the database
+		if size, err := volumeSize(ctx.Host, utils.VarPgsqlDataVolumeMount.Name); err == nil {
+			required += uint64(size)
+		}
+	}
+	hint := fmt.Sprintf(L("Free some space or add storage to %s"), dir)
+	if free < required {
+		return fail(fmt.Sprintf(L("only %[1]s free in %[2]s, %[3]s needed"),
+			utils.FormatSize(int64(free)), dir, utils.FormatSize(int64(required))), hint)
+	}
+	if ctx.Operation == Install && free < recommendedStorage {
+		return warn(fmt.Sprintf(L("only %[1]s free in %[2]s, %[3]s recommended"),
+			utils.FormatSize(int64(free)), dir, utils.FormatSize(int64(recommendedStorage))), hint)
+	}
+	return pass(fmt.Sprintf(L("%[1]s free in %[2]s"), utils.FormatSize(int64(free)), dir))
+}
+
+func diskFreeSpace(host *utils.Host, dir string) (uint64, error) {
+	if host.IsRemote() {
+		out, err := runCmdOutput(host, zerolog.DebugLevel, "df", "--output=avail", "-B1", dir)
+		if err != nil {
+			return 0, err
+		}
+		lines := strings.Fields(string(out))
+		if len(lines) == 0 {
+			return 0, fmt.Errorf(L("unexpected df output: %s"), out)
+		}
+		return strconv.ParseUint(lines[len(lines)-1], 10, 64)
+	}
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
+
+// resolveHost returns the addresses of a host name as resolved by the host.
+func resolveHost(host *utils.Host, name string) ([]string, error) {
+	if !host.IsRemote() {
+		return net.LookupHost(name)
+	}
+	out, err := runCmdOutput(host, zerolog.DebugLevel, "getent", "ahosts", name)
+	if err != nil {
+		return nil, err
+	}
+	// Each address is listed for every socket type
+	addresses := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && !utils.Contains(addresses, fields[0]) {
+			addresses = append(addresses, fields[0])
+		}
+	}
+	return addresses, nil
+}
+
+// resolveAddr returns the names of an address as resolved by the host.
+func resolveAddr(host *utils.Host, address string) ([]string, error) {
+	if !host.IsRemote() {
+		return net.LookupAddr(address)
+	}
+	out, err := runCmdOutput(host, zerolog.DebugLevel, "getent", "hosts", address)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 {
+			names = append(names, fields[1:]...)
+		}
+	}
+	return names, nil
+}
+
+func checkFqdn(ctx *Context) Result {
+	hint := L("Add the FQDN and the server addresses in the DNS or /etc/hosts")
+	if !utils.IsWellFormedFQDN(ctx.FQDN) {
+		return fail(fmt.Sprintf(L("%s is not a valid FQDN"), ctx.FQDN),
+			L("Set a fully qualified host name with hostnamectl or pass the FQDN as argument"))
+	}
+	addresses, err := lookupHost(ctx.Host, ctx.FQDN)
+	if err != nil || len(addresses) == 0 {
+		return fail(fmt.Sprintf(L("cannot resolve %s"), ctx.FQDN), hint)
+	}
+	for _, address := range addresses {
+		names, err := lookupAddr(ctx.Host, address)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			if strings.EqualFold(strings.TrimSuffix(name, "."), ctx.FQDN) {
+				return pass(fmt.Sprintf(L("%[1]s resolves to %[2]s and back"), ctx.FQDN, address))
+			}
+		}
+	}
+	return warn(fmt.Sprintf(L("none of the addresses of %[1]s resolves back to it: %[2]s"),
+		ctx.FQDN, strings.Join(addresses, ", ")), hint)
+}
+
+func checkSELinux(ctx *Context) Result {
+	out, err := runCmdOutput(ctx.Host, zerolog.DebugLevel, "getenforce")
+	if err != nil {
+		return pass(L("SELinux is not available"))
+	}
+	mode := strings.TrimSpace(string(out))
+	switch mode {
+	case "Enforcing":
+		if ctx.Host.IsInstalled("rpm") {
+			if _, err := runCmdOutput(ctx.Host, zerolog.DebugLevel, "rpm", "-q", "container-selinux"); err != nil {
+				return fail(L("SELinux is enforcing but the container-selinux policy is not installed"),
+					L("Install the container-selinux package"))
+			}
+		}
+	case "Permissive":
+		return warn(L("SELinux is in permissive mode"), L("Set SELinux in enforcing mode to confine the containers"))
+	}
+	return pass(fmt.Sprintf(L("SELinux is %s"), strings.ToLower(mode)))
+}
+
+func checkTimeSync(ctx *Context) Result {
+	out, err := runCmdOutput(
+		ctx.Host, zerolog.DebugLevel, "timedatectl", "show", "--property", "NTPSynchronized", "--value",
+	)
+	hint := L("Enable a time synchronization service like chronyd")
+	if err != nil {
+		return warn(L("cannot check the time synchronization"), hint)
+	}
+	if strings.TrimSpace(string(out)) != "yes" {
+		return warn(L("the system clock is not synchronized"), hint)
+	}
+	return pass(L("the system clock is synchronized"))
+}
+
+func checkIPv6(ctx *Context) Result {
+	if !hostHasIpv6(ctx.Host) {
+		return pass(L("IPv6 is disabled on the host"))
+	}
+	if isNetworkPresent(ctx.Host, podman.UyuniNetwork) && !networkHasIpv6(ctx.Host, podman.UyuniNetwork) {
+		hint := fmt.Sprintf(L("The %s network will be recreated with IPv6"), podman.UyuniNetwork)
+		if ctx.Operation == Upgrade {
+			hint = fmt.Sprintf(L("Stop the server and remove the %s podman network to recreate it with IPv6"),
+				podman.UyuniNetwork)
+		}
+		return warn(fmt.Sprintf(L("the %s podman network has no IPv6 while the host has IPv6 enabled"),
+			podman.UyuniNetwork), hint)
+	}
+	return pass(L("IPv6 is enabled"))
+}
@@ -20,6 +20,7 @@ import (
 func Upgrade(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	cocoFlags adm_utils.CocoFlags,
 	baseImage types.ImageFlags,
 	db types.DBFlags,
@@ -29,14 +30,14 @@ func Upgrade(
 		// Don't touch the coco service in ptf if not already present.
 	} else {
 		if err := podman.CreateCredentialsSecrets(
-			podman.DBUserSecret, db.User,
+			systemd.Host(), podman.DBUserSecret, db.User,
 			podman.DBPassSecret, db.Password,
 		); err != nil {
 			return err
 		}
 
 		if err := writeCocoServiceFiles(
-			systemd, authFile, cocoFlags, baseImage, db,
+			systemd, authFile, signaturePolicy, cocoFlags, baseImage, db,
 		); err != nil {
 			return err
 		}
@@ -52,6 +53,7 @@ func Upgrade(
 func writeCocoServiceFiles(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	cocoFlags adm_utils.CocoFlags,
 	baseImage types.ImageFlags,
 	db types.DBFlags,
@@ -80,7 +82,9 @@ func writeCocoServiceFiles(
 
 	pullEnabled := (cocoFlags.Replicas > 0 && cocoFlags.IsChanged) || (currentReplicas > 0 && !cocoFlags.IsChanged)
 
-	preparedImage, err := podman.PrepareImage(authFile, cocoImage, baseImage.PullPolicy, pullEnabled)
+	preparedImage, err := podman.PrepareImage(
+		systemd.Host(), authFile, signaturePolicy, cocoImage, baseImage.PullPolicy, pullEnabled,
+	)
 	if err != nil {
 		return err
 	}
@@ -94,7 +98,7 @@ func writeCocoServiceFiles(
 
 	log.Info().Msg(L("Setting up confidential computing attestation service"))
 
-	if err := utils.WriteTemplateToFile(attestationData,
+	if err := systemd.Host().WriteTemplateToFile(attestationData,
 		podman.GetServicePath(podman.ServerAttestationService+"@"), 0555, true); err != nil {
 		return utils.Errorf(err, L("failed to generate systemd service unit file"))
 	}
@@ -104,7 +108,7 @@ Environment=database_connection=jdbc:postgresql://%s:%d/%s
 `, preparedImage, db.Host, db.Port, db.Name)
 
 	if err := podman.GenerateSystemdConfFile(
-		podman.ServerAttestationService+"@", podman.GeneratedConf, environment, true,
+		systemd.Host(), podman.ServerAttestationService+"@", podman.GeneratedConf, environment, true,
 	); err != nil {
 		return utils.Errorf(err, L("cannot generate systemd conf file"))
 	}
@@ -119,12 +123,13 @@ Environment=database_connection=jdbc:postgresql://%s:%d/%s
 func SetupCocoContainer(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	coco adm_utils.CocoFlags,
 	baseImage types.ImageFlags,
 	db types.DBFlags,
 ) error {
 	if err := writeCocoServiceFiles(
-		systemd, authFile, coco, baseImage, db,
+		systemd, authFile, signaturePolicy, coco, baseImage, db,
 	); err != nil {
 		return err
 	}
@@ -22,6 +22,7 @@ import (
 func SetupHubXmlrpc(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	baseImage types.ImageFlags,
 	hubXmlrpcFlags cmd_utils.HubXmlrpcFlags,
 ) error {
@@ -44,7 +45,9 @@ func SetupHubXmlrpc(
 		return utils.Errorf(err, L("failed to compute image URL"))
 	}
 
-	preparedImage, err := podman.PrepareImage(authFile, hubXmlrpcImage, baseImage.PullPolicy, pullEnabled)
+	preparedImage, err := podman.PrepareImage(
+		systemd.Host(), authFile, signaturePolicy, hubXmlrpcImage, baseImage.PullPolicy, pullEnabled,
+	)
 	if err != nil {
 		return err
 	}
@@ -79,6 +82,7 @@ func EnableHubXmlrpc(systemd podman.Systemd, replicas int) error {
 func Upgrade(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	baseImage types.ImageFlags,
 	hubXmlrpcFlags cmd_utils.HubXmlrpcFlags,
 ) error {
@@ -86,7 +90,7 @@ func Upgrade(
 		// Don't touch the hub service in ptf if not already present.
 		log.Info().Msg(L("Not altering the hub XML-RPC API service"))
 	} else {
-		if err := SetupHubXmlrpc(systemd, authFile, baseImage, hubXmlrpcFlags); err != nil {
+		if err := SetupHubXmlrpc(systemd, authFile, signaturePolicy, baseImage, hubXmlrpcFlags); err != nil {
 			return err
 		}
 
@@ -113,7 +117,7 @@ func generateHubXmlrpcSystemdService(systemd podman.Systemd, image string, serve
 		Network:    podman.UyuniNetwork,
 		ServerHost: serverHost,
 	}
-	if err := utils.WriteTemplateToFile(
+	if err := systemd.Host().WriteTemplateToFile(
 		hubXmlrpcData, podman.GetServicePath(podman.HubXmlrpcService+"@"), 0555, true,
 	); err != nil {
 		return utils.Errorf(err, L("failed to generate systemd service unit file"))
@@ -121,7 +125,7 @@ func generateHubXmlrpcSystemdService(systemd podman.Systemd, image string, serve
 
 	environment := fmt.Sprintf("Environment=UYUNI_HUB_XMLRPC_IMAGE=%s", image)
 	if err := podman.GenerateSystemdConfFile(
-		podman.HubXmlrpcService+"@", podman.GeneratedConf, environment, true,
+		systemd.Host(), podman.HubXmlrpcService+"@", podman.GeneratedConf, environment, true,
 	); err != nil {
 		return utils.Errorf(err, L("cannot generate systemd conf file"))
 	}
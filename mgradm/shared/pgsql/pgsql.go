@@ -19,7 +19,9 @@ import (
 const BackupVolumeConfigName = "generated-backup-volume.conf"
 
 func PreparePgsqlImage(
+	host *utils.Host,
 	authFile string,
+	signaturePolicy string,
 	pgsqlFlags *types.PgsqlFlags,
 	globalImageFlags *types.ImageFlags,
 ) (string, error) {
@@ -30,7 +32,9 @@ func PreparePgsqlImage(
 		return "", utils.Error(err, L("failed to compute image URL"))
 	}
 
-	preparedImage, err := podman.PrepareImage(authFile, pgsqlImage, globalImageFlags.PullPolicy, true)
+	preparedImage, err := podman.PrepareImage(
+		host, authFile, signaturePolicy, pgsqlImage, globalImageFlags.PullPolicy, true,
+	)
 	if err != nil {
 		return "", err
 	}
@@ -52,7 +56,7 @@ func SetupPgsql(
 	if err := systemd.StartService(podman.DBService); err != nil {
 		return err
 	}
-	cnx := shared.NewConnection("podman", podman.DBContainerName, "")
+	cnx := shared.NewHostConnection(systemd.Host(), "podman", podman.DBContainerName, "")
 	if err := cnx.WaitForHealthcheck(); err != nil {
 		return utils.Errorf(err, L("%s fails healtcheck"), podman.DBContainerName)
 	}
@@ -81,7 +85,7 @@ func Upgrade(
 		return err
 	}
 
-	cnx := shared.NewConnection("podman", podman.DBContainerName, "")
+	cnx := shared.NewHostConnection(systemd.Host(), "podman", podman.DBContainerName, "")
 	if err := cnx.WaitForHealthcheck(); err != nil {
 		return utils.Errorf(err, L("%s fails healtcheck"), podman.DBContainerName)
 	}
@@ -112,7 +116,7 @@ func GeneratePgsqlSystemdService(
 		ReportUser:      podman.ReportDBUserSecret,
 		ReportPassword:  podman.ReportDBPassSecret,
 	}
-	if err := utils.WriteTemplateToFile(
+	if err := systemd.Host().WriteTemplateToFile(
 		pgsqlData, podman.GetServicePath(podman.DBService), 0555, true,
 	); err != nil {
 		return utils.Error(err, L("failed to generate systemd service unit file"))
@@ -120,7 +124,9 @@ func GeneratePgsqlSystemdService(
 
 	environment := fmt.Sprintf("Environment=UYUNI_IMAGE=%s\n", image)
 
-	if err := podman.GenerateSystemdConfFile(podman.DBService, podman.GeneratedConf, environment, true); err != nil {
+	if err := podman.GenerateSystemdConfFile(
+		systemd.Host(), podman.DBService, podman.GeneratedConf, environment, true,
+	); err != nil {
 		return utils.Error(err, L("cannot generate systemd configuration file"))
 	}
 
@@ -137,7 +143,9 @@ func BackupVolumeConfig() string {
 // Generates database service configuration for backup volume.
 func GenerateBackupVolumeConfig(systemd podman.Systemd) error {
 	data := BackupVolumeConfig()
-	if err := podman.GenerateSystemdConfFile(podman.DBService, BackupVolumeConfigName, data, true); err != nil {
+	if err := podman.GenerateSystemdConfFile(
+		systemd.Host(), podman.DBService, BackupVolumeConfigName, data, true,
+	); err != nil {
 		return utils.Error(err, L("cannot generate systemd configuration file"))
 	}
 	return systemd.ReloadDaemon(false)
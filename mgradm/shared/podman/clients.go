@@ -20,8 +20,8 @@ import (
 )
 
 // NewCAFingerprint returns the SHA-256 fingerprint of the CA added by 'mgradm ssl addca'.
-func NewCAFingerprint() (string, error) {
-	bundle, err := shared_podman.GetSecret(shared_podman.CASecret)
+func NewCAFingerprint(host *utils.Host) (string, error) {
+	bundle, err := shared_podman.GetSecret(host, shared_podman.CASecret)
 	if err != nil {
 		return "", utils.Error(err, L("failed to read the CA bundle secret"))
 	}
@@ -59,9 +59,9 @@ const (
 
 // CheckClientsCATrust checks over Salt if the registered minions already trust the CA with
 // the given SHA-256 fingerprint.
-func CheckClientsCATrust(fingerprint string) (ClientCheckResult, error) {
+func CheckClientsCATrust(host *utils.Host, fingerprint string) (ClientCheckResult, error) {
 	var result ClientCheckResult
-	cnx := shared.NewConnection("podman", shared_podman.ServerContainerName, "")
+	cnx := shared.NewHostConnection(host, "podman", shared_podman.ServerContainerName, "")
 
 	log.Info().Msg(L("Checking whether the clients trust the new CA over Salt; this can take a moment…"))
 
@@ -0,0 +1,368 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package podman
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/uyuni-tools/mgradm/shared/check"
+	adm_utils "github.com/uyuni-project/uyuni-tools/mgradm/shared/utils"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Rough durations used to estimate the downtime of an upgrade.
+const (
+	stopDuration      = time.Minute
+	configureDuration = 2 * time.Minute
+	startDuration     = 10 * time.Minute
+	// pgsqlUpgradeRate is the number of bytes per second the PostgreSQL upgrade is assumed to copy.
+	pgsqlUpgradeRate = 50 * 1024 * 1024
+)
+
+// UpgradeStep is an operation run by the server upgrade.
+type UpgradeStep struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	// Downtime is the estimated time in seconds the step keeps the server down.
+	Downtime int64 `json:"downtime"`
+}
+
+// ImageChange describes the image change of a service.
+type ImageChange struct {
+	Service string `json:"service"`
+	Current string `json:"current"`
+	Target  string `json:"target"`
+	// Size is the size of the target image in bytes, if it could be inspected.
+	Size int64 `json:"size,omitempty"`
+	// Missing is true if the target image is not present locally and needs to be pulled.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// UpgradePlan describes what an upgrade will do.
+type UpgradePlan struct {
+	CurrentRelease   string        `json:"currentRelease"`
+	TargetRelease    string        `json:"targetRelease"`
+	CurrentPgVersion string        `json:"currentPgVersion"`
+	TargetPgVersion  string        `json:"targetPgVersion"`
+	Images           []ImageChange `json:"images"`
+	Steps            []UpgradeStep `json:"steps"`
+	// RequiredDiskSpace is the disk space in bytes needed by the upgrade besides the images.
+	RequiredDiskSpace int64 `json:"requiredDiskSpace"`
+	// EstimatedDowntime is a rough estimate in seconds of the server downtime.
+	EstimatedDowntime int64 `json:"estimatedDowntime"`
+	// Checks are the results of the host checks run before the upgrade.
+	Checks []check.Result `json:"checks,omitempty"`
+	// Warnings lists what could not be determined without changing the host.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (p *UpgradePlan) addStep(id string, downtime time.Duration, description string) {
+	p.Steps = append(p.Steps, UpgradeStep{ID: id, Description: description, Downtime: int64(downtime.Seconds())})
+	p.EstimatedDowntime += int64(downtime.Seconds())
+}
+
+// upgradePlanData holds what has been gathered on the host to build the upgrade plan.
+type upgradePlanData struct {
+	inspected     *utils.InspectData
+	serverImage   string
+	pgsqlImage    string
+	pgsqlDataSize int64
+	hasTFTP       bool
+}
+
+// PlanUpgrade computes the steps that Upgrade would run without running them.
+//
+// Nothing is pulled, run or changed: the target images are only inspected if they are present locally
+// or in the registry using skopeo.
+func PlanUpgrade(
+	systemd podman.Systemd,
+	authFile string,
+	image types.ImageFlags,
+	upgradeImage types.ImageFlags,
+	cocoFlags adm_utils.CocoFlags,
+	hubXmlrpcFlags adm_utils.HubXmlrpcFlags,
+	salineFlags adm_utils.SalineFlags,
+	pgsqlFlags types.PgsqlFlags,
+	tftpdFlags adm_utils.TFTPDFlags,
+	upgradePolicy *types.UpgradePolicyFlags,
+) (*UpgradePlan, error) {
+	host := systemd.Host()
+	serverImage, pgsqlImage, err := podman.ComputeServerImages(host, image, pgsqlFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []string{}
+	inspected := &utils.InspectData{}
+	// Reading the running server doesn't change it
+	running := systemd.IsServiceRunning(podman.ServerService)
+	if running {
+		runningData, err := podman.RunningContainerInspect[utils.ContainerInspectData](host, utils.NewContainerInspector())
+		if err != nil {
+			return nil, utils.Error(err, L("failed to inspect the running server"))
+		}
+		inspected.ContainerInspectData = *runningData
+	} else {
+		warnings = append(warnings, L("The server is not running: its current release is unknown"))
+	}
+
+	serverImageMetadata := getPlanImageMetadata(host, serverImage, authFile, &warnings)
+	pgsqlImageMetadata := getPlanImageMetadata(host, pgsqlImage, authFile, &warnings)
+	if pgsqlImageMetadata != nil {
+		inspected.DBInspectData.PgVersion = pgsqlImageMetadata.GetEnv("PG_MAJOR")
+	}
+	labeled := setTargetRelease(inspected, serverImage, serverImageMetadata)
+	if running {
+		if err := adm_utils.SanityCheck(inspected, upgradePolicy); err != nil {
+			if labeled {
+				return nil, err
+			}
+			// The release guessed from the image tag may be wrong
+			warnings = append(warnings, fmt.Sprintf(L("The upgrade may be refused: %s"), err))
+		}
+	}
+
+	data := upgradePlanData{
+		inspected:   inspected,
+		serverImage: serverImage,
+		pgsqlImage:  pgsqlImage,
+		hasTFTP:     systemd.ServiceIsEnabled(podman.TFTPService),
+	}
+	if !data.hasTFTP && systemd.HasService(podman.ServerService) {
+		data.hasTFTP = serverExposesTFTP(systemd)
+	}
+	if data.pgsqlDataSize, err = podman.GetVolumeSize(host, utils.VarPgsqlDataVolumeMount.Name); err != nil {
+		log.Warn().Err(err).Msgf(L("Failed to compute the size of the %s volume"), utils.VarPgsqlDataVolumeMount.Name)
+	}
+
+	plan, err := newUpgradePlan(systemd, &data, image, upgradeImage, cocoFlags, hubXmlrpcFlags, salineFlags, tftpdFlags)
+	if err != nil {
+		return nil, err
+	}
+	plan.Warnings = append(warnings, plan.Warnings...)
+
+	plan.Images = []ImageChange{
+		newImageChange(podman.ServerService, podman.GetServiceImage(host, podman.ServerService), serverImage,
+			serverImageMetadata),
+		newImageChange(podman.DBService, podman.GetServiceImage(host, podman.DBService), pgsqlImage,
+			pgsqlImageMetadata),
+	}
+	components := []struct {
+		service string
+		image   types.ImageFlags
+	}{
+		{podman.ServerAttestationService, cocoFlags.Image},
+		{podman.HubXmlrpcService, hubXmlrpcFlags.Image},
+		{podman.SalineService, salineFlags.Image},
+		{podman.TFTPService, tftpdFlags.Image},
+	}
+	for _, component := range components {
+		if component.image.Name == "" {
+			continue
+		}
+		currentImage := ""
+		if systemd.HasService(component.service) {
+			currentImage = podman.GetServiceImage(host, component.service)
+		} else if systemd.HasService(component.service + "@") {
+			currentImage = podman.GetServiceImage(host, component.service+"@")
+		}
+		targetImage, err := componentImage(image, component.image)
+		if err != nil {
+			return nil, err
+		}
+		metadata := getPlanImageMetadata(host, targetImage, authFile, &plan.Warnings)
+		plan.Images = append(plan.Images, newImageChange(component.service, currentImage, targetImage, metadata))
+	}
+	return plan, nil
+}
+
+// getPlanImageMetadata returns the metadata of an image, adding a warning if it cannot be read.
+func getPlanImageMetadata(
+	host *utils.Host,
+	image string,
+	authFile string,
+	warnings *[]string,
+) *podman.ImageMetadata {
+	metadata, err := getImageMetadata(host, image, authFile)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to read the metadata of %s", image)
+		*warnings = append(*warnings, fmt.Sprintf(L("Image %s is not present and could not be inspected"), image))
+		return nil
+	}
+	return metadata
+}
+
+// getImageMetadata is a function pointer to replace the image inspection in unit tests.
+var getImageMetadata = podman.GetImageMetadata
+
+// setTargetRelease sets the release of the server image from its version label or its tag.
+// The product is assumed to remain the same.
+//
+// Returns true if the release was read from the label.
+func setTargetRelease(inspected *utils.InspectData, image string, metadata *podman.ImageMetadata) bool {
+	release := ""
+	if metadata != nil {
+		release = metadata.Labels[podman.ImageVersionLabel]
+	}
+	labeled := release != ""
+	if !labeled {
+		release = utils.ImageTag(image)
+	}
+	if inspected.ContainerInspectData.SuseManagerRelease != "" {
+		inspected.ServerInspectData.SuseManagerRelease = release
+	} else {
+		inspected.ServerInspectData.UyuniRelease = release
+	}
+	return labeled
+}
+
+// newImageChange describes the image change of a service, with the size of the target image if available.
+func newImageChange(service string, current string, target string, metadata *podman.ImageMetadata) ImageChange {
+	change := ImageChange{Service: service, Current: current, Target: target, Missing: true}
+	if metadata != nil {
+		change.Size = metadata.Size
+		change.Missing = !metadata.Local
+	}
+	return change
+}
+
+// componentImage computes the image of a component the same way its upgrade does.
+func componentImage(baseImage types.ImageFlags, image types.ImageFlags) (string, error) {
+	tag := utils.DefaultTag
+	if image.Tag != "" {
+		tag = image.Tag
+	} else if baseImage.Tag != "" {
+		tag = baseImage.Tag
+	}
+	imageURL, err := utils.ComputeImage(baseImage.Registry.Host, tag, image)
+	if err != nil {
+		return "", utils.Errorf(err, L("failed to compute image URL"))
+	}
+	return imageURL, nil
+}
+
+// newUpgradePlan lists the steps Upgrade takes with the gathered data.
+// This needs to be kept in sync with Upgrade.
+func newUpgradePlan(
+	systemd podman.Systemd,
+	data *upgradePlanData,
+	image types.ImageFlags,
+	upgradeImage types.ImageFlags,
+	cocoFlags adm_utils.CocoFlags,
+	hubXmlrpcFlags adm_utils.HubXmlrpcFlags,
+	salineFlags adm_utils.SalineFlags,
+	tftpdFlags adm_utils.TFTPDFlags,
+) (*UpgradePlan, error) {
+	inspected := data.inspected
+	plan := UpgradePlan{
+		CurrentRelease:   inspected.ContainerInspectData.UyuniRelease,
+		TargetRelease:    inspected.ServerInspectData.UyuniRelease,
+		CurrentPgVersion: inspected.ContainerInspectData.PgVersion,
+		TargetPgVersion:  inspected.DBInspectData.PgVersion,
+	}
+	if plan.CurrentRelease == "" {
+		plan.CurrentRelease = inspected.ContainerInspectData.SuseManagerRelease
+		plan.TargetRelease = inspected.ServerInspectData.SuseManagerRelease
+	}
+
+	if !strings.HasPrefix(image.Registry.Host, "registry.suse.com") {
+		plan.addStep("registry-auth", 0, L("Refresh the cloud provider registry credentials"))
+	}
+	plan.addStep("network", 0, fmt.Sprintf(L("Set up the %s podman network"), podman.UyuniNetwork))
+	plan.addStep("images", 0,
+		fmt.Sprintf(L("Prepare the server image %[1]s and database image %[2]s"), data.serverImage, data.pgsqlImage))
+
+	stopped := []string{}
+	for _, service := range []string{podman.ServerService, podman.DBService} {
+		if systemd.HasService(service) {
+			stopped = append(stopped, service)
+		}
+	}
+	if len(stopped) > 0 {
+		plan.addStep("stop", stopDuration, fmt.Sprintf(L("Stop the %s services"), strings.Join(stopped, ", ")))
+	}
+
+	oldPgVersion, _ := strconv.Atoi(plan.CurrentPgVersion)
+	newPgVersion, _ := strconv.Atoi(plan.TargetPgVersion)
+	if oldPgVersion == 0 || newPgVersion == 0 {
+		// The data may still need to be upgraded, but there is no way to know it
+		plan.Warnings = append(plan.Warnings, L("The PostgreSQL data upgrade could not be planned"))
+	} else if newPgVersion > oldPgVersion {
+		upgradeImageURL, err := utils.ComputeImage(image.Registry.Host, image.Tag, upgradeImage)
+		if err != nil {
+			return nil, utils.Errorf(err, L("failed to compute image URL"))
+		}
+		// The data are copied from the _data_old folder into the new _data one.
+		plan.RequiredDiskSpace += data.pgsqlDataSize
+		duration := configureDuration + time.Duration(data.pgsqlDataSize/pgsqlUpgradeRate)*time.Second
+		plan.addStep("pgsql-upgrade", duration,
+			fmt.Sprintf(L("Upgrade PostgreSQL data from version %[1]d to %[2]d using image %[3]s"),
+				oldPgVersion, newPgVersion, upgradeImageURL))
+	} else if newPgVersion < oldPgVersion {
+		return nil, fmt.Errorf(L("trying to downgrade PostgreSQL from %[1]d to %[2]d"), oldPgVersion, newPgVersion)
+	}
+
+	if inspected.DBHost == "localhost" || inspected.ReportDBHost == "localhost" {
+		plan.addStep("ssl", 0, L("Prepare the SSL certificates of the split PostgreSQL container"))
+	}
+	plan.addStep("db-config", configureDuration,
+		L("Move the database settings to the PostgreSQL container and create the credentials secrets"))
+	plan.addStep("db-service", 0,
+		fmt.Sprintf(L("Regenerate the %[1]s service with image %[2]s"), podman.DBService, data.pgsqlImage))
+	plan.addStep("server-service", 0,
+		fmt.Sprintf(L("Regenerate the %[1]s service and environment with image %[2]s"),
+			podman.ServerService, data.serverImage))
+	plan.addStep("start", startDuration, L("Start the server and wait for it to be healthy"))
+
+	addComponentStep(&plan, "coco", systemd, podman.ServerAttestationService,
+		cocoFlags.Image.Name != "", cocoFlags.IsChanged, cocoFlags.Replicas)
+	addComponentStep(&plan, "hub-xmlrpc", systemd, podman.HubXmlrpcService,
+		hubXmlrpcFlags.Image.Name != "", hubXmlrpcFlags.IsChanged, hubXmlrpcFlags.Replicas)
+	addComponentStep(&plan, "saline", systemd, podman.SalineService,
+		salineFlags.Image.Name != "", salineFlags.IsChanged, salineFlags.Replicas)
+
+	if tftpdFlags.Image.Name != "" {
+		description := fmt.Sprintf(L("Regenerate the %s service"), podman.TFTPService)
+		if data.hasTFTP {
+			description = fmt.Sprintf(L("Regenerate and restart the %s service"), podman.TFTPService)
+		}
+		plan.addStep("tftp", 0, description)
+	}
+
+	return &plan, nil
+}
+
+// addComponentStep adds the step upgrading an instantiated service.
+func addComponentStep(
+	plan *UpgradePlan,
+	id string,
+	systemd podman.Systemd,
+	service string,
+	hasImage bool,
+	isChanged bool,
+	replicas int,
+) {
+	currentReplicas := systemd.CurrentReplicaCount(service)
+	actions := []string{}
+	if hasImage {
+		actions = append(actions, fmt.Sprintf(L("regenerate the %s service"), service))
+	}
+	if isChanged {
+		actions = append(actions, fmt.Sprintf(L("scale from %[1]d to %[2]d replicas"), currentReplicas, replicas))
+	} else if currentReplicas > 0 {
+		actions = append(actions, fmt.Sprintf(L("restart the %d running instances"), currentReplicas))
+	}
+	if len(actions) == 0 {
+		return
+	}
+	plan.addStep(id, 0, fmt.Sprintf("%s: %s", service, strings.Join(actions, ", ")))
+}
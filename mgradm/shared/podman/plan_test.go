@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package podman
+
+import (
+	"testing"
+
+	adm_utils "github.com/uyuni-project/uyuni-tools/mgradm/shared/utils"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+func planSteps(plan *UpgradePlan) []string {
+	ids := []string{}
+	for _, step := range plan.Steps {
+		ids = append(ids, step.ID)
+	}
+	return ids
+}
+
+func TestNewUpgradePlan(t *testing.T) {
+	systemd := podman.NewSystemdWithDriver(&testutils.FakeSystemdDriver{
+		Installed: []string{podman.ServerService, podman.DBService, podman.SalineService + "@"},
+		Enabled:   []string{podman.SalineService + "@0"},
+	})
+	data := upgradePlanData{
+		inspected: &utils.InspectData{
+			ServerInspectData: utils.ServerInspectData{UyuniRelease: "2026.01"},
+			DBInspectData:     utils.DBInspectData{PgVersion: "17"},
+			ContainerInspectData: utils.ContainerInspectData{
+				UyuniRelease: "2025.10",
+				PgVersion:    "16",
+				DBHost:       "localhost",
+			},
+		},
+		serverImage:   "registry.suse.com/uyuni/server:2026.01",
+		pgsqlImage:    "registry.suse.com/uyuni/server-postgresql:2026.01",
+		pgsqlDataSize: 100 * pgsqlUpgradeRate,
+	}
+	image := types.ImageFlags{Registry: types.Registry{Host: "registry.suse.com"}, Tag: "2026.01"}
+	upgradeImage := types.ImageFlags{Name: "uyuni/server-migration-14-16"}
+	coco := adm_utils.CocoFlags{Replicas: 1, IsChanged: true}
+	coco.Image.Name = "uyuni/server-attestation"
+
+	plan, err := newUpgradePlan(systemd, &data, image, upgradeImage, coco,
+		adm_utils.HubXmlrpcFlags{}, adm_utils.SalineFlags{}, adm_utils.TFTPDFlags{})
+	testutils.AssertNoError(t, "failed to build the plan", err)
+
+	testutils.AssertEquals(t, "Unexpected steps",
+		[]string{"network", "images", "stop", "pgsql-upgrade", "ssl", "db-config", "db-service", "server-service",
+			"start", "coco", "saline"},
+		planSteps(plan))
+	testutils.AssertEquals(t, "Unexpected current release", "2025.10", plan.CurrentRelease)
+	testutils.AssertEquals(t, "Unexpected disk space", int64(100*pgsqlUpgradeRate), plan.RequiredDiskSpace)
+	// stop, PostgreSQL upgrade with its copy time, database configuration and start
+	testutils.AssertEquals(t, "Unexpected downtime", int64(60+120+100+120+600), plan.EstimatedDowntime)
+	testutils.AssertEquals(t, "Unexpected saline step",
+		"uyuni-saline: restart the 1 running instances", plan.Steps[len(plan.Steps)-1].Description)
+}
+
+func TestNewUpgradePlanSamePgVersion(t *testing.T) {
+	systemd := podman.NewSystemdWithDriver(&testutils.FakeSystemdDriver{})
+	data := upgradePlanData{
+		inspected: &utils.InspectData{
+			DBInspectData:        utils.DBInspectData{PgVersion: "16"},
+			ContainerInspectData: utils.ContainerInspectData{PgVersion: "16", DBHost: "db"},
+		},
+		pgsqlDataSize: 1024,
+	}
+	tftpd := adm_utils.TFTPDFlags{}
+	tftpd.Image.Name = "uyuni/server-tftpd"
+
+	plan, err := newUpgradePlan(systemd, &data, types.ImageFlags{}, types.ImageFlags{}, adm_utils.CocoFlags{},
+		adm_utils.HubXmlrpcFlags{}, adm_utils.SalineFlags{}, tftpd)
+	testutils.AssertNoError(t, "failed to build the plan", err)
+	testutils.AssertEquals(t, "Unexpected steps",
+		[]string{"registry-auth", "network", "images", "db-config", "db-service", "server-service", "start", "tftp"},
+		planSteps(plan))
+	testutils.AssertEquals(t, "No disk space should be needed", int64(0), plan.RequiredDiskSpace)
+}
+
+func TestNewUpgradePlanPgDowngrade(t *testing.T) {
+	systemd := podman.NewSystemdWithDriver(&testutils.FakeSystemdDriver{})
+	data := upgradePlanData{
+		inspected: &utils.InspectData{
+			DBInspectData:        utils.DBInspectData{PgVersion: "16"},
+			ContainerInspectData: utils.ContainerInspectData{PgVersion: "17"},
+		},
+	}
+	_, err := newUpgradePlan(systemd, &data, types.ImageFlags{}, types.ImageFlags{}, adm_utils.CocoFlags{},
+		adm_utils.HubXmlrpcFlags{}, adm_utils.SalineFlags{}, adm_utils.TFTPDFlags{})
+	testutils.AssertError(t, "downgrade PostgreSQL from 17 to 16", err)
+}
+
+func TestSetTargetRelease(t *testing.T) {
+	metadata := &podman.ImageMetadata{
+		Labels: map[string]string{podman.ImageVersionLabel: "5.1.2"},
+		Env:    []string{"PATH=/usr/bin", "PG_MAJOR=17"},
+		Local:  true,
+	}
+	inspected := &utils.InspectData{
+		ContainerInspectData: utils.ContainerInspectData{SuseManagerRelease: "5.1.0"},
+	}
+	testutils.AssertTrue(t, "Release should come from the label",
+		setTargetRelease(inspected, "registry.suse.com/suse/multi-linux-manager/5.1/x86_64/server:5.1", metadata))
+	testutils.AssertEquals(t, "Unexpected release", "5.1.2", inspected.ServerInspectData.SuseManagerRelease)
+	testutils.AssertEquals(t, "Unexpected PostgreSQL version", "17", metadata.GetEnv("PG_MAJOR"))
+
+	inspected = &utils.InspectData{}
+	testutils.AssertTrue(t, "Release should come from the tag",
+		!setTargetRelease(inspected, "registry.example.com:5000/uyuni/server:2026.01", nil))
+	testutils.AssertEquals(t, "Unexpected release", "2026.01", inspected.ServerInspectData.UyuniRelease)
+}
+
+func TestNewImageChange(t *testing.T) {
+	change := newImageChange(podman.ServerService, "server:2025.10", "server:2026.01",
+		&podman.ImageMetadata{Size: 2048, Local: true})
+	testutils.AssertEquals(t, "Unexpected local image change",
+		ImageChange{Service: podman.ServerService, Current: "server:2025.10", Target: "server:2026.01", Size: 2048},
+		change)
+
+	change = newImageChange(podman.ServerService, "", "server:2026.01", &podman.ImageMetadata{Size: 1024})
+	testutils.AssertTrue(t, "Image only in the registry should be missing", change.Missing)
+	testutils.AssertEquals(t, "Unexpected remote size", int64(1024), change.Size)
+
+	change = newImageChange(podman.ServerService, "", "server:2026.01", nil)
+	testutils.AssertTrue(t, "Image not inspected should be missing", change.Missing)
+}
@@ -5,9 +5,9 @@
 package podman
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -31,13 +31,11 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-var systemd podman.Systemd = podman.NewSystemd()
-
 // GetContainerTimezone returns the timezone of the running server container,
 // falling back to the systemd configuration and finally the host timezone when it cannot be determined.
-func GetContainerTimezone() string {
+func GetContainerTimezone(host *utils.Host) string {
 	// If the container is running, read the TZ environment variable from it.
-	cnx := shared.NewConnection("podman", podman.ServerContainerName, "")
+	cnx := shared.NewHostConnection(host, "podman", podman.ServerContainerName, "")
 	if out, err := cnx.Exec("sh", "-c", "echo \"$TZ\""); err == nil {
 		if tz := strings.TrimSpace(string(out)); tz != "" {
 			return tz
@@ -46,19 +44,19 @@ func GetContainerTimezone() string {
 
 	// Otherwise read it from the server.env environment file referenced by the systemd service.
 	log.Debug().Msg("Failed to get the timezone from the container, looking for it in the server environment file")
-	if tz := timezoneFromEnvironmentFile(); tz != "" {
+	if tz := timezoneFromEnvironmentFile(host); tz != "" {
 		return tz
 	}
 
 	log.Debug().Msg("Failed to get the timezone from the configuration, getting the host one")
-	return utils.GetLocalTimezone()
+	return host.GetTimezone()
 }
 
 // timezoneFromEnvironmentFile returns the value of the TZ variable set in the server.env environment
 // file, or an empty string when it cannot be found.
-func timezoneFromEnvironmentFile() string {
+func timezoneFromEnvironmentFile(host *utils.Host) string {
 	envFile := path.Join(podman.GetServiceConfFolder(podman.ServerService), podman.ServerEnvironmentFile)
-	data, err := os.ReadFile(envFile)
+	data, err := host.ReadFile(envFile)
 	if err != nil {
 		log.Debug().Err(err).Msgf("Failed to read the %s environment file", envFile)
 		return ""
@@ -74,7 +72,7 @@ func timezoneFromEnvironmentFile() string {
 
 // ApplyNewCertificates restarts the server and database containers so that they pick up the new
 // SSL certificates from the podman secrets, then re-adds the CA to the host trusted certificates.
-func ApplyNewCertificates(fqdn string) error {
+func ApplyNewCertificates(systemd podman.Systemd, fqdn string) error {
 	log.Info().Msg(L("Restarting the services to apply the new certificates"))
 	if systemd.HasService(podman.DBService) {
 		if err := systemd.RestartService(podman.DBService); err != nil {
@@ -85,7 +83,7 @@ func ApplyNewCertificates(fqdn string) error {
 		return err
 	}
 
-	cnx := shared.NewConnection("podman", podman.ServerContainerName, "")
+	cnx := shared.NewHostConnection(systemd.Host(), "podman", podman.ServerContainerName, "")
 	if err := cnx.WaitForContainer(); err != nil {
 		return utils.Error(err, L("cannot wait for the server container to start"))
 	}
@@ -122,9 +120,11 @@ func getExposedPorts(debug bool) []types.PortMap {
 }
 
 // GenerateServerEnvironmentFile generates envvars used for initial installation.
-func GenerateServerEnvironmentFile(flags adm_utils.InstallationFlags, fqdn string, hasMirror bool) error {
+func GenerateServerEnvironmentFile(
+	host *utils.Host, flags adm_utils.InstallationFlags, fqdn string, hasMirror bool,
+) error {
 	confDir := podman.GetServiceConfFolder(podman.ServerService)
-	if err := os.MkdirAll(confDir, 0755); err != nil {
+	if err := host.MkdirAll(confDir, 0755); err != nil {
 		return utils.Errorf(err, L("failed to create %s folder"), confDir)
 	}
 	envfile := filepath.Join(confDir, podman.ServerEnvironmentFile)
@@ -141,7 +141,7 @@ func GenerateServerEnvironmentFile(flags adm_utils.InstallationFlags, fqdn strin
 		Admin:     &flags.Admin,
 		HasMirror: hasMirror,
 	}
-	if err := utils.WriteTemplateToFile(data, envfile, 0400, true); err != nil {
+	if err := host.WriteTemplateToFile(data, envfile, 0400, true); err != nil {
 		return utils.Errorf(err, L("failed to generate server environment file"))
 	}
 
@@ -150,14 +150,14 @@ func GenerateServerEnvironmentFile(flags adm_utils.InstallationFlags, fqdn strin
 
 // Generate new server environmentfile with only things useful for upgrade scenario.
 // Currently only debug. Needs changes on uyuni container side too.
-func GenerateUpgradeServerEnvironmentFile(debug bool) error {
+func GenerateUpgradeServerEnvironmentFile(host *utils.Host, debug bool) error {
 	confDir := podman.GetServiceConfFolder(podman.ServerService)
 	envfile := filepath.Join(confDir, podman.ServerEnvironmentFile)
 	data := templates.PodmanServiceEnvironmentTemplateData{
 		Debug: debug,
 	}
 
-	if err := utils.WriteTemplateToFile(data, envfile, 0400, true); err != nil {
+	if err := host.WriteTemplateToFile(data, envfile, 0400, true); err != nil {
 		return utils.Errorf(err, L("failed to generate server environment file"))
 	}
 
@@ -165,7 +165,7 @@ func GenerateUpgradeServerEnvironmentFile(debug bool) error {
 }
 
 // GenerateServerSystemdService creates the server systemd service file.
-func GenerateServerSystemdService(mirrorPath string, debug bool) error {
+func GenerateServerSystemdService(host *utils.Host, mirrorPath string, debug bool) error {
 	args := podman.GetCommonParams()
 
 	volumes := utils.ServerVolumeMounts
@@ -174,7 +174,7 @@ func GenerateServerSystemdService(mirrorPath string, debug bool) error {
 	}
 
 	ports := getExposedPorts(debug)
-	if _, err := exec.LookPath("csp-billing-adapter"); err == nil {
+	if host.IsInstalled("csp-billing-adapter") {
 		ports = append(ports, utils.NewPortMap(18888))
 		args = append(args, "-e ISPAYG=1")
 	}
@@ -195,27 +195,27 @@ func GenerateServerSystemdService(mirrorPath string, debug bool) error {
 		DBCaPath:      ssl.DBCAContainerPath,
 		ServerEnvFile: podman.GetServiceConfPath("uyuni-server", podman.ServerEnvironmentFile),
 	}
-	if podman.HasSecret(podman.SCCUserSecret) {
+	if podman.HasSecret(host, podman.SCCUserSecret) {
 		data.SCCUserSecret = podman.SCCUserSecret
 		data.SCCPassSecret = podman.SCCPassSecret
 	}
 
-	if podman.HasSecret(podman.AdminUserSecret) {
+	if podman.HasSecret(host, podman.AdminUserSecret) {
 		data.AdminUserSecret = podman.AdminUserSecret
 		data.AdminPassSecret = podman.AdminPassSecret
 	}
 
-	if podman.HasSecret(podman.DBUserSecret) {
+	if podman.HasSecret(host, podman.DBUserSecret) {
 		data.DBUserSecret = podman.DBUserSecret
 		data.DBPassSecret = podman.DBPassSecret
 	}
 
-	if podman.HasSecret(podman.ReportDBUserSecret) {
+	if podman.HasSecret(host, podman.ReportDBUserSecret) {
 		data.ReportDBUserSecret = podman.ReportDBUserSecret
 		data.ReportDBPassSecret = podman.ReportDBPassSecret
 	}
 
-	if err := utils.WriteTemplateToFile(data, podman.GetServicePath("uyuni-server"), 0444, true); err != nil {
+	if err := host.WriteTemplateToFile(data, podman.GetServicePath("uyuni-server"), 0444, true); err != nil {
 		return utils.Errorf(err, L("failed to generate systemd service unit file"))
 	}
 
@@ -230,13 +230,15 @@ func GenerateSystemdService(
 	podmanArgs []string,
 	mirrorPath string,
 ) error {
-	err := podman.SetupNetwork(false)
+	host := systemd.Host()
+	err := podman.SetupNetwork(host, false)
 	if err != nil {
 		return utils.Errorf(err, L("cannot setup network"))
 	}
 
 	// Add the SCC and admin credentials as secrets
 	if err := podman.CreateCredentialsSecrets(
+		host,
 		podman.AdminUserSecret, flags.Admin.Login, podman.AdminPassSecret, flags.Admin.Password,
 	); err != nil {
 		return err
@@ -244,6 +246,7 @@ func GenerateSystemdService(
 
 	if flags.SCC.User != "" {
 		if err := podman.CreateCredentialsSecrets(
+			host,
 			podman.SCCUserSecret, flags.SCC.User, podman.SCCPassSecret, flags.SCC.Password,
 		); err != nil {
 			return err
@@ -251,19 +254,19 @@ func GenerateSystemdService(
 	}
 
 	log.Info().Msg(L("Enabling system service"))
-	if err := GenerateServerSystemdService(mirrorPath, flags.Debug.Java); err != nil {
+	if err := GenerateServerSystemdService(host, mirrorPath, flags.Debug.Java); err != nil {
 		return err
 	}
 
-	if err := podman.GenerateSystemdConfFile(podman.ServerService, podman.GeneratedConf,
+	if err := podman.GenerateSystemdConfFile(host, podman.ServerService, podman.GeneratedConf,
 		"Environment=UYUNI_IMAGE="+image, true,
 	); err != nil {
 		return utils.Errorf(err, L("cannot generate systemd conf file"))
 	}
 
 	config := fmt.Sprintf("Environment=\"PODMAN_EXTRA_ARGS=%s\"", strings.Join(podmanArgs, " "))
-	if !utils.FileExists(podman.GetServiceConfPath(podman.ServerService, podman.CustomConf)) {
-		if err := podman.GenerateSystemdConfFile(podman.ServerService, podman.CustomConf, config, false); err != nil {
+	if !host.FileExists(podman.GetServiceConfPath(podman.ServerService, podman.CustomConf)) {
+		if err := podman.GenerateSystemdConfFile(host, podman.ServerService, podman.CustomConf, config, false); err != nil {
 			return utils.Errorf(err, L("cannot generate systemd user configuration file"))
 		}
 	}
@@ -271,6 +274,7 @@ func GenerateSystemdService(
 }
 
 func RunSSLMigration(
+	host *utils.Host,
 	preparedImage string,
 	sshAuthSocket string,
 	sshConfigPath string,
@@ -278,6 +282,10 @@ func RunSSLMigration(
 	sourceFqdn string,
 	user string,
 ) (*utils.InspectResult, error) {
+	// The SSH agent socket and configuration bind mounted in the container are on the local machine
+	if host.IsRemote() {
+		return nil, errors.New(L("migrating the SSL certificates is not supported on a remote host"))
+	}
 	scriptDir, cleaner, err := utils.TempDir()
 	defer cleaner()
 	if err != nil {
@@ -313,13 +321,13 @@ func RunSSLMigration(
 	}
 
 	log.Info().Msgf(L("Migrating SSL certificates from the source server %s"), sourceFqdn)
-	if err := podman.RunContainer("uyuni-ssl-migration", preparedImage, utils.SSLMigrationVolumeMounts, extraArgs,
+	if err := podman.RunContainer(host, "uyuni-ssl-migration", preparedImage, utils.SSLMigrationVolumeMounts, extraArgs,
 		[]string{"bash", "-e", "-c", script}); err != nil {
 		return nil, utils.Errorf(err, L("cannot run uyuni SSL migration container"))
 	}
 
 	// now that everything is migrated, we need to fix SELinux permission
-	if err := restoreSELinuxContext(utils.SSLMigrationVolumeMounts); err != nil {
+	if err := restoreSELinuxContext(host, utils.SSLMigrationVolumeMounts); err != nil {
 		return nil, err
 	}
 
@@ -338,14 +346,14 @@ func RunSSLMigration(
 	return extractedData, nil
 }
 
-func restoreSELinuxContext(volumes []types.VolumeMount) error {
-	if utils.IsInstalled("restorecon") {
+func restoreSELinuxContext(host *utils.Host, volumes []types.VolumeMount) error {
+	if host.IsInstalled("restorecon") {
 		for _, volumeMount := range volumes {
-			mountPoint, err := GetMountPoint(volumeMount.Name)
+			mountPoint, err := GetMountPoint(host, volumeMount.Name)
 			if err != nil {
 				return utils.Errorf(err, L("cannot inspect volume %s"), volumeMount)
 			}
-			if err := utils.RunCmdStdMapping(zerolog.DebugLevel, "restorecon", "-F", "-r", "-v", mountPoint); err != nil {
+			if err := host.RunCmdStdMapping(zerolog.DebugLevel, "restorecon", "-F", "-r", "-v", mountPoint); err != nil {
 				return utils.Errorf(err, L("cannot restore %s SELinux permissions"), mountPoint)
 			}
 		}
@@ -358,7 +366,9 @@ var runContainer = podman.RunContainer
 
 // RunPgsqlVersionUpgrade perform a PostgreSQL major upgrade.
 func RunPgsqlVersionUpgrade(
+	host *utils.Host,
 	authFile string,
+	signaturePolicy string,
 	image types.ImageFlags,
 	upgradeImage types.ImageFlags,
 	volumeMounts []types.VolumeMount,
@@ -369,18 +379,18 @@ func RunPgsqlVersionUpgrade(
 		"--tmpfs", "/tmp:rw,mode=1777",
 	}
 
-	if podman.HasSecret(podman.DBCASecret) {
+	if podman.HasSecret(host, podman.DBCASecret) {
 		extraArgs = append(extraArgs,
 			"--secret", fmt.Sprintf("%s,type=mount,target=%s", podman.DBCASecret, ssl.DBCAContainerPath),
 		)
 	}
 
-	if podman.HasSecret(podman.DBSSLKeySecret) {
+	if podman.HasSecret(host, podman.DBSSLKeySecret) {
 		extraArgs = append(extraArgs,
 			"--secret", fmt.Sprintf("%s,type=mount,uid=999,mode=0400,target=%s", podman.DBSSLKeySecret, ssl.DBCertKeyPath),
 		)
 	}
-	if podman.HasSecret(podman.DBSSLCertSecret) {
+	if podman.HasSecret(host, podman.DBSSLCertSecret) {
 		extraArgs = append(extraArgs,
 			"--secret", fmt.Sprintf("%s,type=mount,target=%s", podman.DBSSLCertSecret, ssl.DBCertPath),
 		)
@@ -391,14 +401,14 @@ func RunPgsqlVersionUpgrade(
 		return utils.Errorf(err, L("failed to compute image URL"))
 	}
 
-	preparedImage, err := prepareImage(authFile, upgradeImageURL, image.PullPolicy, true)
+	preparedImage, err := prepareImage(host, authFile, signaturePolicy, upgradeImageURL, image.PullPolicy, true)
 	if err != nil {
 		return err
 	}
 
 	log.Info().Msgf(L("Using database upgrade image %s"), preparedImage)
 
-	return runContainer(pgsqlVersionUpgradeContainer, preparedImage, volumeMounts, extraArgs,
+	return runContainer(host, pgsqlVersionUpgradeContainer, preparedImage, volumeMounts, extraArgs,
 		[]string{})
 }
 
@@ -406,6 +416,7 @@ func RunPgsqlVersionUpgrade(
 func Upgrade(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	db types.DBFlags,
 	reportdb types.DBFlags,
 	ssl adm_utils.InstallSSLFlags,
@@ -418,32 +429,39 @@ func Upgrade(
 	tftpdFlags adm_utils.TFTPDFlags,
 	tz string,
 	debug bool,
+	upgradePolicy *types.UpgradePolicyFlags,
 ) error {
+	host := systemd.Host()
 	// Calling cloudguestregistryauth only makes sense if using the cloud provider registry.
 	// This check assumes users won't use custom registries that are not the cloud provider one on a cloud image.
 	if !strings.HasPrefix(image.Registry.Host, "registry.suse.com") {
-		if err := CallCloudGuestRegistryAuth(); err != nil {
+		if err := CallCloudGuestRegistryAuth(host); err != nil {
 			return err
 		}
 	}
 
+	// Don't pull again the images staged by mgradm upgrade --prepare-only
+	image = useStagedImages(host, image, pgsqlFlags)
+
 	// Prepare Uyuni network, migration container needs to run in the same network as resulting image
-	err := podman.SetupNetwork(false)
+	err := podman.SetupNetwork(host, false)
 	if err != nil {
 		return utils.Errorf(err, L("cannot setup network"))
 	}
 
-	fqdn, err := utils.GetFqdn([]string{})
+	fqdn, err := host.GetFqdn([]string{})
 	if err != nil {
 		return err
 	}
 
-	preparedServerImage, preparedPgsqlImage, err := podman.PrepareImages(authFile, image, pgsqlFlags)
+	preparedServerImage, preparedPgsqlImage, err := podman.PrepareImages(
+		host, authFile, signaturePolicy, image, pgsqlFlags,
+	)
 	if err != nil {
 		return utils.Errorf(err, L("cannot prepare images"))
 	}
 
-	inspectedValues, err := prepareHost(preparedServerImage, preparedPgsqlImage)
+	inspectedValues, err := prepareHost(host, preparedServerImage, preparedPgsqlImage, upgradePolicy)
 	if err != nil {
 		return err
 	}
@@ -475,7 +493,7 @@ func Upgrade(
 	if newPgVersion > oldPgVersion {
 		log.Info().Msgf(L("Initiating PostgreSQL upgrade from version %[1]d to %[2]d"), oldPgVersion, newPgVersion)
 
-		pgsqlMountpoint, err := podman.GetVolumeMountPoint(utils.VarPgsqlDataVolumeMount.Name)
+		pgsqlMountpoint, err := podman.GetVolumeMountPoint(host, utils.VarPgsqlDataVolumeMount.Name)
 		if err != nil {
 			return utils.Errorf(err, L("cannot find volume %s"), utils.VarPgsqlDataVolumeMount.Name)
 		}
@@ -491,7 +509,7 @@ func Upgrade(
 
 		backupPath := path.Join(pgsqlMountpoint, "..", "_data_old")
 
-		if err := utils.RunCmdStdMapping(zerolog.DebugLevel, "mv", targetPath, backupPath); err != nil {
+		if err := host.RunCmdStdMapping(zerolog.DebugLevel, "mv", targetPath, backupPath); err != nil {
 			return utils.Errorf(err, L("cannot move %s"), targetPath)
 		}
 
@@ -507,12 +525,14 @@ func Upgrade(
 			})
 		}
 
-		if err := utils.RunCmdStdMapping(zerolog.DebugLevel, "mkdir", "-p", targetPath); err != nil {
+		if err := host.RunCmdStdMapping(zerolog.DebugLevel, "mkdir", "-p", targetPath); err != nil {
 			return utils.Errorf(err, L("cannot mkdir %s"), targetPath)
 		}
 
 		log.Warn().Msg(L("Data will be copied during this process. Please ensure sufficient disk space is available."))
-		if err := RunPgsqlVersionUpgrade(authFile, image, upgradeImage, upgradeVolumeMounts); err != nil {
+		if err := RunPgsqlVersionUpgrade(
+			host, authFile, signaturePolicy, image, upgradeImage, upgradeVolumeMounts,
+		); err != nil {
 			return utils.Errorf(err, L("cannot run PostgreSQL version upgrade script"))
 		}
 	} else if newPgVersion == oldPgVersion {
@@ -528,7 +548,7 @@ func Upgrade(
 		inspectedValues.ReportDBHost == "localhost" {
 		log.Info().Msgf(L("Configuring split PostgreSQL container"))
 
-		if err := PrepareSSLCertificates(preparedServerImage, &ssl, tz, fqdn); err != nil {
+		if err := PrepareSSLCertificates(host, preparedServerImage, &ssl, tz, fqdn); err != nil {
 			return err
 		}
 	}
@@ -542,16 +562,16 @@ func Upgrade(
 		return err
 	}
 
-	if err := podman.CleanSystemdConfFile(podman.ServerService); err != nil {
+	if err := podman.CleanSystemdConfFile(host, podman.ServerService); err != nil {
 		return err
 	}
 
 	// Regenerate environment file. 00-mgrSetup will be skipped, but debug and other values can be changed
-	if err := GenerateUpgradeServerEnvironmentFile(debug); err != nil {
+	if err := GenerateUpgradeServerEnvironmentFile(host, debug); err != nil {
 		return err
 	}
 
-	if err := podman.GenerateSystemdConfFile(podman.ServerService, podman.GeneratedConf,
+	if err := podman.GenerateSystemdConfFile(host, podman.ServerService, podman.GeneratedConf,
 		"Environment=UYUNI_IMAGE="+preparedServerImage, true,
 	); err != nil {
 		return err
@@ -561,7 +581,7 @@ func Upgrade(
 		return err
 	}
 
-	if err := UpdateServerSystemdService(); err != nil {
+	if err := UpdateServerSystemdService(host); err != nil {
 		return err
 	}
 
@@ -571,7 +591,7 @@ func Upgrade(
 
 	log.Info().Msg(L("Waiting for server to start. This include service setup operations and can take a very long time."))
 	log.Info().Msg(L("Use `journalctl -f -u uyuni-server` for tracking progress"))
-	cnx := shared.NewConnection("podman", podman.ServerContainerName, "")
+	cnx := shared.NewHostConnection(host, "podman", podman.ServerContainerName, "")
 	if err := systemd.StartService(podman.ServerService); err != nil {
 		return utils.Error(err, L("cannot start service"))
 	}
@@ -579,6 +599,7 @@ func Upgrade(
 	if err := cnx.WaitForHealthcheck(); err != nil {
 		return utils.Error(err, L("cannot wait for system start"))
 	}
+	removeStagedImages(host)
 
 	inspectedDB := types.DBFlags{
 		Name:     inspectedValues.DBName,
@@ -589,10 +610,10 @@ func Upgrade(
 	}
 
 	return utils.JoinErrors(
-		coco.Upgrade(systemd, authFile, cocoFlags, image, inspectedDB),
-		hub.Upgrade(systemd, authFile, image, hubXmlrpcFlags),
-		saline.Upgrade(systemd, authFile, image, salineFlags, utils.GetLocalTimezone()),
-		tftp.Upgrade(systemd, authFile, image, tftpdFlags, fqdn, hasTFTP),
+		coco.Upgrade(systemd, authFile, signaturePolicy, cocoFlags, image, inspectedDB),
+		hub.Upgrade(systemd, authFile, signaturePolicy, image, hubXmlrpcFlags),
+		saline.Upgrade(systemd, authFile, signaturePolicy, image, salineFlags, host.GetTimezone()),
+		tftp.Upgrade(systemd, authFile, signaturePolicy, image, tftpdFlags, fqdn, hasTFTP),
 		systemd.ReloadDaemon(false),
 	)
 }
@@ -621,7 +642,7 @@ func WaitForSystemStart(
 	return cnx.WaitForHealthcheck()
 }
 
-var runCmdOutput = utils.RunCmdOutput
+var runCmdOutput = (*utils.Host).RunCmdOutput
 
 func hasDebugPorts(definition []byte) bool {
 	return regexp.MustCompile(`-p 8003:8003`).Match(definition)
@@ -638,17 +659,17 @@ func getMirrorPath(definition []byte) string {
 }
 
 // UpdateServerSystemdService refreshes the server systemd service file.
-func UpdateServerSystemdService() error {
-	out, err := runCmdOutput(zerolog.DebugLevel, "systemctl", "cat", podman.ServerService)
+func UpdateServerSystemdService(host *utils.Host) error {
+	out, err := runCmdOutput(host, zerolog.DebugLevel, "systemctl", "cat", podman.ServerService)
 	if err != nil {
 		return utils.Errorf(err, "failed to get %s systemd service definition", podman.ServerService)
 	}
 
-	return GenerateServerSystemdService(getMirrorPath(out), hasDebugPorts(out))
+	return GenerateServerSystemdService(host, getMirrorPath(out), hasDebugPorts(out))
 }
 
 // RunSplitContainerSettings migrate to separate postgres container.
-func RunSplitContainerSettings(serverImage string, dbHost string, reportDBHost string) error {
+func RunSplitContainerSettings(host *utils.Host, serverImage string, dbHost string, reportDBHost string) error {
 	data := templates.SplitContainerSettingsScriptTemplateData{
 		DBHost:       dbHost,
 		ReportDBHost: reportDBHost,
@@ -662,17 +683,16 @@ func RunSplitContainerSettings(serverImage string, dbHost string, reportDBHost s
 	podmanArgs := []string{
 		"--security-opt", "label=disable",
 	}
-	return podman.RunContainer("uyuni-db-migrate", serverImage, utils.DatabaseMigrationVolumeMounts, podmanArgs,
+	return podman.RunContainer(host, "uyuni-db-migrate", serverImage, utils.DatabaseMigrationVolumeMounts, podmanArgs,
 		[]string{"bash", "-e", "-c", scriptBuilder.String()})
 }
 
 // CallCloudGuestRegistryAuth calls cloudguestregistryauth if it is available.
-func CallCloudGuestRegistryAuth() error {
+func CallCloudGuestRegistryAuth(host *utils.Host) error {
 	cloudguestregistryauth := "cloudguestregistryauth"
 
-	path, err := exec.LookPath(cloudguestregistryauth)
-	if err == nil {
-		if err := utils.RunCmdStdMapping(zerolog.DebugLevel, path); err != nil && isPAYG() {
+	if host.IsInstalled(cloudguestregistryauth) {
+		if err := host.RunCmdStdMapping(zerolog.DebugLevel, cloudguestregistryauth); err != nil && isPAYG(host) {
 			// Not being registered against the cloud registry is  not an error on BYOS.
 			return err
 		} else if err != nil {
@@ -683,19 +703,19 @@ func CallCloudGuestRegistryAuth() error {
 	return nil
 }
 
-func isPAYG() bool {
+func isPAYG(host *utils.Host) bool {
 	flavorCheckPath := "/usr/bin/instance-flavor-check"
-	if utils.FileExists(flavorCheckPath) {
-		out, _ := utils.RunCmdOutput(zerolog.DebugLevel, flavorCheckPath)
+	if host.FileExists(flavorCheckPath) {
+		out, _ := host.RunCmdOutput(zerolog.DebugLevel, flavorCheckPath)
 		return strings.TrimSpace(string(out)) == "PAYG"
 	}
 	return false
 }
 
 // GetMountPoint return folder where a given volume is mounted.
-func GetMountPoint(volumeName string) (string, error) {
+func GetMountPoint(host *utils.Host, volumeName string) (string, error) {
 	args := []string{"volume", "inspect", "--format", "{{.Mountpoint}}", volumeName}
-	mountPoint, err := utils.RunCmdOutput(zerolog.DebugLevel, "podman", args...)
+	mountPoint, err := host.RunCmdOutput(zerolog.DebugLevel, "podman", args...)
 	if err != nil {
 		return "", err
 	}
@@ -733,15 +753,17 @@ func GetSSHPaths() (string, string) {
 }
 
 func prepareHost(
+	host *utils.Host,
 	preparedServerImage string,
 	preparedPgsqlImage string,
+	upgradePolicy *types.UpgradePolicyFlags,
 ) (*utils.InspectData, error) {
-	inspectedValues, err := podman.Inspect(preparedServerImage, preparedPgsqlImage)
+	inspectedValues, err := podman.Inspect(host, preparedServerImage, preparedPgsqlImage)
 	if err != nil {
 		return nil, utils.Errorf(err, L("cannot inspect podman values"))
 	}
 
-	return inspectedValues, adm_utils.SanityCheck(inspectedValues)
+	return inspectedValues, adm_utils.SanityCheck(inspectedValues, upgradePolicy)
 }
 
 func configureDBContainer(
@@ -751,12 +773,14 @@ func configureDBContainer(
 	db types.DBFlags,
 	reportdb types.DBFlags,
 ) error {
-	if err := RunSplitContainerSettings(serverImage, "db", "reportdb"); err != nil {
+	host := systemd.Host()
+	if err := RunSplitContainerSettings(host, serverImage, "db", "reportdb"); err != nil {
 		return utils.Errorf(err, L("PostgreSQL migration failure"))
 	}
 
 	// Create all the database credentials secrets
 	if err := podman.CreateCredentialsSecretsIfMissing(
+		host,
 		podman.DBUserSecret, db.User,
 		podman.DBPassSecret, db.Password,
 	); err != nil {
@@ -764,6 +788,7 @@ func configureDBContainer(
 	}
 
 	if err := podman.CreateCredentialsSecretsIfMissing(
+		host,
 		podman.ReportDBUserSecret, reportdb.User,
 		podman.ReportDBPassSecret, reportdb.Password,
 	); err != nil {
@@ -771,9 +796,10 @@ func configureDBContainer(
 	}
 
 	if db.IsLocal() {
-		if !podman.HasSecret(podman.DBAdminUserSecret) && !podman.HasSecret(podman.DBAdminPassSecret) {
+		if !podman.HasSecret(host, podman.DBAdminUserSecret) && !podman.HasSecret(host, podman.DBAdminPassSecret) {
 			// The admin password is not needed for external databases
 			if err := podman.CreateCredentialsSecrets(
+				host,
 				podman.DBAdminUserSecret, db.Admin.User,
 				podman.DBAdminPassSecret, db.Admin.Password,
 			); err != nil {
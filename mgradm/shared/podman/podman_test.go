@@ -10,6 +10,7 @@ import (
 
 	"github.com/uyuni-project/uyuni-tools/shared/testutils"
 	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
 func TestHasDebugPorts(t *testing.T) {
@@ -112,18 +113,24 @@ func TestRunPgsqlVersionUpgrade(t *testing.T) {
 	}
 
 	expectedAuthfile := "authfile to pass"
+	expectedPolicy := "policy to pass"
 	for i, testCase := range cases {
-		prepareImage = func(authFile string, image string, pullPolicy string, _ bool) (string, error) {
+		prepareImage = func(
+			_ *utils.Host, authFile string, signaturePolicy string, image string, pullPolicy string, _ bool,
+		) (string, error) {
 			// test that the image computation
 			testutils.AssertEquals(t, "auth file not passed down", expectedAuthfile, authFile)
+			testutils.AssertEquals(t, "signature policy not passed down", expectedPolicy, signaturePolicy)
 			testutils.AssertEquals(t, fmt.Sprintf("case %d: wrong image", i), testCase.expectedImage, image)
 			testutils.AssertEquals(t, fmt.Sprintf("case %d: wrong pull policy", i), testCase.image.PullPolicy, pullPolicy)
 			return image, nil
 		}
-		runContainer = func(_ string, image string, _ []types.VolumeMount, _ []string, _ []string) error {
+		runContainer = func(_ *utils.Host, _ string, image string, _ []types.VolumeMount, _ []string, _ []string) error {
 			testutils.AssertEquals(t, fmt.Sprintf("case %d: wrong image used for container", i), testCase.expectedImage, image)
 			return nil
 		}
-		_ = RunPgsqlVersionUpgrade(expectedAuthfile, testCase.image, testCase.upgradeImage, []types.VolumeMount{})
+		_ = RunPgsqlVersionUpgrade(
+			nil, expectedAuthfile, expectedPolicy, testCase.image, testCase.upgradeImage, []types.VolumeMount{},
+		)
 	}
 }
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package podman
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/rs/zerolog/log"
+	adm_utils "github.com/uyuni-project/uyuni-tools/mgradm/shared/utils"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+var customConfHostnameRegexp = regexp.MustCompile(`(?m)^Environment=UYUNI_HOSTNAME=.*$`)
+var environmentHostnameRegexp = regexp.MustCompile(`(?m)^UYUNI_HOSTNAME=.*$`)
+
+// PrepareServerRename prepares the SSL certificates matching the new FQDN of the server.
+// The existing certificates are reused if they match, otherwise they are regenerated.
+func PrepareServerRename(host *utils.Host, sslFlags *adm_utils.InstallSSLFlags, fqdn string) error {
+	image := podman.GetServiceImage(host, podman.ServerService)
+	tz := GetContainerTimezone(host)
+	log.Info().Msg(L("Preparing SSL certificates to match the new hostname"))
+	return PrepareSSLCertificates(host, image, sslFlags, tz, fqdn)
+}
+
+// SetServerHostname sets the FQDN in the server systemd configuration and environment file.
+// The server service needs to be stopped and is not restarted: starting it again completes the rename.
+//
+// The configuration files are restored to their previous content if any change fails.
+func SetServerHostname(systemd podman.Systemd, fqdn string) (err error) {
+	log.Info().Msgf(L("Changing the UYUNI_HOSTNAME to %s"), fqdn)
+	host := systemd.Host()
+	customConf := podman.GetServiceConfPath(podman.ServerService, podman.CustomConf)
+	envFile := podman.GetServiceConfPath(podman.ServerService, podman.ServerEnvironmentFile)
+
+	// The files are restored with their original mode: the environment file is only readable by root
+	type original struct {
+		content []byte
+		mode    os.FileMode
+	}
+	originals := map[string]original{}
+	defer func() {
+		if err == nil {
+			return
+		}
+		for file, saved := range originals {
+			if errRestore := host.WriteFile(file, saved.content, saved.mode); errRestore != nil {
+				log.Error().Err(errRestore).Msgf(L("failed to restore %s"), file)
+			}
+		}
+		if errReload := systemd.ReloadDaemon(false); errReload != nil {
+			log.Error().Err(errReload).Msg(L("failed to reload the systemd daemon"))
+		}
+	}()
+
+	config, err := host.ReadFile(customConf)
+	if err != nil {
+		return utils.Error(err, L("failed to read the custom.conf file"))
+	}
+	mode, err := host.FileMode(customConf)
+	if err != nil {
+		return utils.Error(err, L("failed to read the custom.conf file"))
+	}
+	originals[customConf] = original{config, mode}
+	if err := host.WriteFile(customConf, []byte(setHostnameInCustomConf(string(config), fqdn)), mode); err != nil {
+		return utils.Error(err, L("failed to write custom.conf with the new hostname"))
+	}
+
+	// The environment file may not set the hostname for servers installed with old versions
+	if env, errRead := host.ReadFile(envFile); errRead == nil {
+		mode, err := host.FileMode(envFile)
+		if err != nil {
+			return utils.Errorf(err, L("failed to read %s"), envFile)
+		}
+		originals[envFile] = original{env, mode}
+		if err := host.WriteFile(envFile, []byte(setHostnameInEnvironment(string(env), fqdn)), mode); err != nil {
+			return utils.Errorf(err, L("failed to write %s with the new hostname"), envFile)
+		}
+	}
+
+	if err := systemd.ReloadDaemon(false); err != nil {
+		return err
+	}
+
+	// Update the service to ensure it has -e UYUNI_HOSTNAME
+	return UpdateServerSystemdService(host)
+}
+
+// setHostnameInCustomConf changes the UYUNI_HOSTNAME value in the server systemd configuration or adds it.
+func setHostnameInCustomConf(config string, fqdn string) string {
+	if !customConfHostnameRegexp.MatchString(config) {
+		return fmt.Sprintf("%s\nEnvironment=UYUNI_HOSTNAME=%s\n", config, fqdn)
+	}
+	return customConfHostnameRegexp.ReplaceAllString(config, "Environment=UYUNI_HOSTNAME="+fqdn)
+}
+
+// setHostnameInEnvironment changes the UYUNI_HOSTNAME value in the server environment file if it is set.
+func setHostnameInEnvironment(env string, fqdn string) string {
+	return environmentHostnameRegexp.ReplaceAllString(env, "UYUNI_HOSTNAME="+fqdn)
+}
@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package podman
+
+import (
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestSetHostnameInCustomConf(t *testing.T) {
+	config := "[Service]\nEnvironment=UYUNI_IMAGE=server:latest\nEnvironment=UYUNI_HOSTNAME=old.example.com\n"
+	testutils.AssertEquals(t, "The hostname should be replaced",
+		"[Service]\nEnvironment=UYUNI_IMAGE=server:latest\nEnvironment=UYUNI_HOSTNAME=new.example.com\n",
+		setHostnameInCustomConf(config, "new.example.com"))
+
+	config = "[Service]\nEnvironment=UYUNI_IMAGE=server:latest"
+	testutils.AssertEquals(t, "The hostname should be added",
+		"[Service]\nEnvironment=UYUNI_IMAGE=server:latest\nEnvironment=UYUNI_HOSTNAME=new.example.com\n",
+		setHostnameInCustomConf(config, "new.example.com"))
+}
+
+func TestSetHostnameInEnvironment(t *testing.T) {
+	env := "# uyuni-server environment, generated by mgradm\nTZ=Europe/Berlin\nUYUNI_HOSTNAME=old.example.com\n"
+	testutils.AssertEquals(t, "The hostname should be replaced",
+		"# uyuni-server environment, generated by mgradm\nTZ=Europe/Berlin\nUYUNI_HOSTNAME=new.example.com\n",
+		setHostnameInEnvironment(env, "new.example.com"))
+
+	env = "# uyuni-server environment, generated by mgradm\nDEBUG_JAVA=true"
+	testutils.AssertEquals(t, "The environment without hostname should not change",
+		env, setHostnameInEnvironment(env, "new.example.com"))
+}
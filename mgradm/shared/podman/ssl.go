@@ -21,24 +21,13 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-func prepareThirdPartyCertificate(
-	caChain *types.CaChain,
-	pair *types.SSLPair,
-	caSecretName string,
-	certSecretName string,
-	keySecretName string,
-	fqdns ...string,
-) error {
-	tempDir, cleaner, err := utils.TempDir()
-	if err != nil {
-		return err
-	}
-	defer cleaner()
-
+// checkThirdPartyCertificate validates a provided certificate against the FQDNs.
+// The ordered certificate and the root CA are written in dir as server.crt and ca.crt.
+func checkThirdPartyCertificate(dir string, caChain *types.CaChain, pair *types.SSLPair, fqdns ...string) error {
 	// OrderCas checks the chain of certificates to report problems early
 	// We also sort the certificates of the chain in a single blob for Apache and PostgreSQL
-	var orderedCert, rootCA []byte
-	if orderedCert, rootCA, err = ssl.OrderCas(caChain, pair); err != nil {
+	orderedCert, rootCA, err := ssl.OrderCas(caChain, pair)
+	if err != nil {
 		return err
 	}
 
@@ -48,12 +37,12 @@ func prepareThirdPartyCertificate(
 	}
 
 	// Write the ordered cert and Root CA to temp files
-	caPath := path.Join(tempDir, "ca.crt")
+	caPath := path.Join(dir, "ca.crt")
 	if err = os.WriteFile(caPath, rootCA, 0600); err != nil {
 		return err
 	}
 
-	serverCertPath := path.Join(tempDir, "server.crt")
+	serverCertPath := path.Join(dir, "server.crt")
 	if err = os.WriteFile(serverCertPath, orderedCert, 0600); err != nil {
 		return err
 	}
@@ -62,23 +51,41 @@ func prepareThirdPartyCertificate(
 	for _, fqdn := range fqdns {
 		errors = append(errors, ssl.VerifyHostname(caPath, serverCertPath, fqdn))
 	}
+	return utils.JoinErrors(errors...)
+}
 
-	err = utils.JoinErrors(errors...)
+func prepareThirdPartyCertificate(
+	host *utils.Host,
+	caChain *types.CaChain,
+	pair *types.SSLPair,
+	caSecretName string,
+	certSecretName string,
+	keySecretName string,
+	fqdns ...string,
+) error {
+	tempDir, cleaner, err := utils.TempDir()
 	if err != nil {
 		return err
 	}
+	defer cleaner()
+
+	if err := checkThirdPartyCertificate(tempDir, caChain, pair, fqdns...); err != nil {
+		return err
+	}
 
 	// Create secrets for CA
 	return shared_podman.CreateTLSSecrets(
+		host,
 		caSecretName, path.Join(tempDir, "ca.crt"),
 		certSecretName, path.Join(tempDir, "server.crt"),
 		keySecretName, pair.Key,
 	)
 }
 
-var newRunner = utils.NewRunner
+var newRunner = (*utils.Host).NewRunner
 
 func prepareThirdPartyCertificates(
+	host *utils.Host,
 	sslFlags *adm_utils.InstallSSLFlags, fqdn string,
 ) (serverCertReady bool, dbCertReady bool, err error) {
 	var errs []error
@@ -86,7 +93,7 @@ func prepareThirdPartyCertificates(
 	// Check if we have been provided certificates as parameters
 	if sslFlags.UseProvided() {
 		log.Info().Msg(L("Using provided 3rd party server certificates"))
-		if err := prepareThirdPartyCertificate(&sslFlags.Ca, &sslFlags.Server,
+		if err := prepareThirdPartyCertificate(host, &sslFlags.Ca, &sslFlags.Server,
 			shared_podman.CASecret, shared_podman.SSLCertSecret, shared_podman.SSLKeySecret, fqdn,
 		); err != nil {
 			errs = append(errs, err)
@@ -97,6 +104,7 @@ func prepareThirdPartyCertificates(
 	if sslFlags.UseProvidedDB() {
 		log.Info().Msg(L("Using provided 3rd party database certificates"))
 		if err := prepareThirdPartyCertificate(
+			host,
 			&sslFlags.DB.CA, &sslFlags.DB.SSLPair, shared_podman.DBCASecret,
 			shared_podman.DBSSLCertSecret, shared_podman.DBSSLKeySecret, fqdn,
 		); err != nil {
@@ -109,10 +117,33 @@ func prepareThirdPartyCertificates(
 	return
 }
 
+// CheckThirdPartyCertificates validates the provided 3rd party certificates against the FQDN
+// without storing them.
+func CheckThirdPartyCertificates(sslFlags *adm_utils.InstallSSLFlags, fqdn string) error {
+	tempDir, cleaner, err := utils.TempDir()
+	if err != nil {
+		return err
+	}
+	defer cleaner()
+
+	var errs []error
+	if sslFlags.UseProvided() {
+		if err := checkThirdPartyCertificate(tempDir, &sslFlags.Ca, &sslFlags.Server, fqdn); err != nil {
+			errs = append(errs, utils.Error(err, L("invalid server certificate")))
+		}
+	}
+	if sslFlags.UseProvidedDB() {
+		if err := checkThirdPartyCertificate(tempDir, &sslFlags.DB.CA, &sslFlags.DB.SSLPair, fqdn); err != nil {
+			errs = append(errs, utils.Error(err, L("invalid database certificate")))
+		}
+	}
+	return utils.JoinErrors(errs...)
+}
+
 // SetThirdPartyCertificates validates the provided 3rd party certificates and stores them in the
 // podman secrets, replacing any existing server and/or database certificate secrets.
-func SetThirdPartyCertificates(sslFlags *adm_utils.InstallSSLFlags, fqdn string) error {
-	serverCertReady, dbCertReady, err := prepareThirdPartyCertificates(sslFlags, fqdn)
+func SetThirdPartyCertificates(host *utils.Host, sslFlags *adm_utils.InstallSSLFlags, fqdn string) error {
+	serverCertReady, dbCertReady, err := prepareThirdPartyCertificates(host, sslFlags, fqdn)
 	if err != nil {
 		return utils.Errorf(err, L("Failed to create secrets from the provided SSL arguments"))
 	}
@@ -124,7 +155,7 @@ func SetThirdPartyCertificates(sslFlags *adm_utils.InstallSSLFlags, fqdn string)
 
 // AddCA generates or imports a new root CA and appends it to the existing
 // server and database CA secrets without changing the served certificate.
-func AddCA(image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn string) error {
+func AddCA(host *utils.Host, image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn string) error {
 	tempDir, cleaner, err := utils.TempDir()
 	if err != nil {
 		return err
@@ -157,13 +188,13 @@ func AddCA(image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn st
 			"HOSTNAME":     fqdn,
 		}
 		// The script writes the generated CA to /ssl/ca.crt (i.e. tempDir/ca.crt).
-		if err := runSSLContainer(sslGenCAScript, tempDir, image, tz, env); err != nil {
+		if err := runSSLContainer(host, sslGenCAScript, tempDir, image, tz, env); err != nil {
 			return utils.Error(err, L("Failed to generate the new SSL CA. Please check the input parameters."))
 		}
 	}
 
 	// Append the new CA to the existing server CA bundle.
-	if err := appendCAToSecret(shared_podman.CASecret, newCAPath, tempDir); err != nil {
+	if err := appendCAToSecret(host, shared_podman.CASecret, newCAPath, tempDir); err != nil {
 		return err
 	}
 
@@ -181,20 +212,20 @@ func AddCA(image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn st
 			return err
 		}
 	}
-	return appendCAToSecret(shared_podman.DBCASecret, dbCAPath, tempDir)
+	return appendCAToSecret(host, shared_podman.DBCASecret, dbCAPath, tempDir)
 }
 
 // appendCAToSecret appends the CA at newCAPath to the bundle of the given secret,
 // creating or overwriting it. It is a no-op if the secret already contains the new CA.
-func appendCAToSecret(secretName string, newCAPath string, tempDir string) error {
+func appendCAToSecret(host *utils.Host, secretName string, newCAPath string, tempDir string) error {
 	newCA, err := os.ReadFile(newCAPath)
 	if err != nil {
 		return err
 	}
 
 	var bundle []byte
-	if shared_podman.HasSecret(secretName) {
-		existing, err := shared_podman.GetSecret(secretName)
+	if shared_podman.HasSecret(host, secretName) {
+		existing, err := shared_podman.GetSecret(host, secretName)
 		if err != nil {
 			return err
 		}
@@ -215,18 +246,22 @@ func appendCAToSecret(secretName string, newCAPath string, tempDir string) error
 	if err := os.WriteFile(bundlePath, bundle, 0600); err != nil {
 		return err
 	}
-	return shared_podman.CreateCASecrets(secretName, bundlePath)
+	return shared_podman.CreateCASecrets(host, secretName, bundlePath)
 }
 
 // RotateServerCertificate promotes the CA staged by AddCA to be the active one, issues a new
 // certificate signed by it, and resets the certificate secrets to that single CA.
-func RotateServerCertificate(image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn string) error {
-	return issueServerCertificate(sslRotateServerScript, image, sslFlags, tz, fqdn)
+func RotateServerCertificate(
+	host *utils.Host, image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn string,
+) error {
+	return issueServerCertificate(host, sslRotateServerScript, image, sslFlags, tz, fqdn)
 }
 
 // RegenerateCAAndCertificate forces the generation of a new self-signed CA and a matching
 // server (and database) certificate, replacing the CA secrets with only the new CA.
-func RegenerateCAAndCertificate(image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn string) error {
+func RegenerateCAAndCertificate(
+	host *utils.Host, image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn string,
+) error {
 	if sslFlags.Password == "" {
 		return errors.New(L("Cannot generate a new CA without a CA password. Please check input options"))
 	}
@@ -247,21 +282,23 @@ func RegenerateCAAndCertificate(image string, sslFlags *adm_utils.InstallSSLFlag
 		"CERT_PASS":    sslFlags.Password,
 		"HOSTNAME":     fqdn,
 	}
-	if err := runSSLContainer(sslGenCAScript, tempDir, image, tz, env); err != nil {
+	if err := runSSLContainer(host, sslGenCAScript, tempDir, image, tz, env); err != nil {
 		return utils.Error(err, L("Failed to generate the new SSL CA. Please check the input parameters."))
 	}
 
-	return RotateServerCertificate(image, sslFlags, tz, fqdn)
+	return RotateServerCertificate(host, image, sslFlags, tz, fqdn)
 }
 
 // PrepareSSLCertificates prepares SSL environment for the server and database.
 // If 3rd party certificates are provided, it uses them, else new certificates are generated.
 // This function is called in both new installation and upgrade scenarios.
-func PrepareSSLCertificates(image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn string) error {
+func PrepareSSLCertificates(
+	host *utils.Host, image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn string,
+) error {
 	var serverCertReady bool
 	var dbCertReady bool
 
-	serverCertReady, dbCertReady, err := prepareThirdPartyCertificates(sslFlags, fqdn)
+	serverCertReady, dbCertReady, err := prepareThirdPartyCertificates(host, sslFlags, fqdn)
 	if err != nil {
 		return utils.Errorf(err, L("Failed to create secrets from the provided SSL arguments"))
 	}
@@ -269,7 +306,7 @@ func PrepareSSLCertificates(image string, sslFlags *adm_utils.InstallSSLFlags, t
 	// Do we have secrets or certificates from volumes to reuse?
 	if !serverCertReady {
 		// Check if this is an upgrade scenario and there is existing CA and cert/key pair
-		serverCertReady, err = reuseExistingCertificates(image, fqdn, false)
+		serverCertReady, err = reuseExistingCertificates(host, image, fqdn, false)
 	}
 	if serverCertReady && err != nil {
 		// we found certificates, but there was trouble loading it
@@ -278,7 +315,7 @@ func PrepareSSLCertificates(image string, sslFlags *adm_utils.InstallSSLFlags, t
 
 	if !dbCertReady {
 		// Check if this is an upgrade scenario and there is existing CA and cert/key pair
-		dbCertReady, err = reuseExistingCertificates(image, fqdn, true)
+		dbCertReady, err = reuseExistingCertificates(host, image, fqdn, true)
 	}
 	if dbCertReady && err != nil {
 		// we found certificates, but there was trouble loading it
@@ -295,6 +332,7 @@ func PrepareSSLCertificates(image string, sslFlags *adm_utils.InstallSSLFlags, t
 	if serverCertReady {
 		// Do we have generated certificates files?
 		_, err = shared_podman.ReadFromContainer(
+			host,
 			"ca-key-reader", image, []types.VolumeMount{utils.RootVolumeMount}, []string{},
 			"/root/ssl-build/RHN-ORG-PRIVATE-SSL-KEY",
 		)
@@ -303,16 +341,16 @@ func PrepareSSLCertificates(image string, sslFlags *adm_utils.InstallSSLFlags, t
 				L("Cannot generate certificates as the SSL CA key cannot be found. Please set up third-party certificates."))
 		}
 
-		if err := validateCA(image, sslFlags, tz); err != nil {
+		if err := validateCA(host, image, sslFlags, tz); err != nil {
 			return err
 		}
 	}
 
 	// Generate them all in order to have the same expiration date on both.
-	return issueServerCertificate(sslSetupServerScript, image, sslFlags, tz, fqdn)
+	return issueServerCertificate(host, sslSetupServerScript, image, sslFlags, tz, fqdn)
 }
 
-func validateCA(image string, sslFlags *adm_utils.InstallSSLFlags, tz string) error {
+func validateCA(host *utils.Host, image string, sslFlags *adm_utils.InstallSSLFlags, tz string) error {
 	log.Info().Msg(L("Verifying the CA key password…"))
 	tempDir, cleaner, err := utils.TempDir()
 	if err != nil {
@@ -323,13 +361,15 @@ func validateCA(image string, sslFlags *adm_utils.InstallSSLFlags, tz string) er
 		"CERT_PASS": sslFlags.Password,
 	}
 
-	if err := runSSLContainer(sslValidateCA, tempDir, image, tz, env); err != nil {
+	if err := runSSLContainer(host, sslValidateCA, tempDir, image, tz, env); err != nil {
 		return errors.New(L("Failed to verify the CA key password!"))
 	}
 	return nil
 }
 
-func reuseExistingCertificates(image string, fqdn string, isDatabaseCheck bool) (reused bool, err error) {
+func reuseExistingCertificates(
+	host *utils.Host, image string, fqdn string, isDatabaseCheck bool,
+) (reused bool, err error) {
 	// Write the ordered cert and Root CA to temp files
 	tempDir, cleaner, err := utils.TempDir()
 	if err != nil {
@@ -338,7 +378,7 @@ func reuseExistingCertificates(image string, fqdn string, isDatabaseCheck bool)
 	defer cleaner()
 
 	// Upgrading from 5.1+ with all certificates as secrets
-	if reuseExistingCertificatesFromSecrets(isDatabaseCheck) {
+	if reuseExistingCertificatesFromSecrets(host, isDatabaseCheck) {
 		secretName := shared_podman.SSLCertSecret
 		caSecretName := shared_podman.CASecret
 		fqdns := []string{fqdn}
@@ -348,7 +388,7 @@ func reuseExistingCertificates(image string, fqdn string, isDatabaseCheck bool)
 			caSecretName = shared_podman.DBCASecret
 			msg = L("Reusing the existing database certificate secrets")
 		}
-		reused = isFQDNMatchingCertificateSecret(secretName, caSecretName, fqdns...)
+		reused = isFQDNMatchingCertificateSecret(host, secretName, caSecretName, fqdns...)
 		if reused {
 			log.Info().Msg(msg)
 		}
@@ -356,11 +396,11 @@ func reuseExistingCertificates(image string, fqdn string, isDatabaseCheck bool)
 	}
 
 	// Upgrading from 5.0- with all certs in files
-	return reuseExistingCertificatesFromMounts(image, tempDir, fqdn, isDatabaseCheck)
+	return reuseExistingCertificatesFromMounts(host, image, tempDir, fqdn, isDatabaseCheck)
 }
 
-func isFQDNMatchingCertificateSecret(secretName string, caSecretName string, fqdns ...string) bool {
-	cert, err := shared_podman.GetSecret(secretName)
+func isFQDNMatchingCertificateSecret(host *utils.Host, secretName string, caSecretName string, fqdns ...string) bool {
+	cert, err := shared_podman.GetSecret(host, secretName)
 	if err != nil {
 		log.Error().Err(err).Send()
 		return false
@@ -375,7 +415,7 @@ func isFQDNMatchingCertificateSecret(secretName string, caSecretName string, fqd
 	caPath := path.Join(tmpDir, "ca.crt")
 	certPath := path.Join(tmpDir, "toverify.crt")
 
-	caCert, err := shared_podman.GetSecret(caSecretName)
+	caCert, err := shared_podman.GetSecret(host, caSecretName)
 	if err != nil {
 		log.Error().Err(err).Send()
 		return false
@@ -413,16 +453,17 @@ func isFQDNMatchingCertificate(fqdn string, certPath string, caPath string) bool
 	return err == nil
 }
 
-func reuseExistingCertificatesFromSecrets(isDatabaseCheck bool) bool {
+func reuseExistingCertificatesFromSecrets(host *utils.Host, isDatabaseCheck bool) bool {
 	if isDatabaseCheck {
-		return shared_podman.HasSecret(shared_podman.DBCASecret) &&
-			shared_podman.HasSecret(shared_podman.DBSSLCertSecret)
+		return shared_podman.HasSecret(host, shared_podman.DBCASecret) &&
+			shared_podman.HasSecret(host, shared_podman.DBSSLCertSecret)
 	}
-	return shared_podman.HasSecret(shared_podman.CASecret) &&
-		shared_podman.HasSecret(shared_podman.SSLCertSecret)
+	return shared_podman.HasSecret(host, shared_podman.CASecret) &&
+		shared_podman.HasSecret(host, shared_podman.SSLCertSecret)
 }
 
 func reuseExistingCertificatesFromMounts(
+	host *utils.Host,
 	image string,
 	tempDir string,
 	fqdn string,
@@ -450,7 +491,7 @@ func reuseExistingCertificatesFromMounts(
 	const containerName = "uyuni-read-certs"
 
 	// Check if we have existing CA
-	rootCA, err := shared_podman.ReadFromContainer(containerName, image, utils.SSLMigrationVolumeMounts, nil,
+	rootCA, err := shared_podman.ReadFromContainer(host, containerName, image, utils.SSLMigrationVolumeMounts, nil,
 		caCheckPath)
 	if err != nil {
 		log.Info().Msgf(L("CA file %s not found."), caCheckPath)
@@ -462,7 +503,7 @@ func reuseExistingCertificatesFromMounts(
 	}
 
 	// Check for server certificate
-	cert, err := shared_podman.ReadFromContainer(containerName, image, utils.SSLMigrationVolumeMounts, nil,
+	cert, err := shared_podman.ReadFromContainer(host, containerName, image, utils.SSLMigrationVolumeMounts, nil,
 		crtCheckPath)
 	if err != nil {
 		log.Info().Msgf(L("Certificate file %s not found."), crtCheckPath)
@@ -479,7 +520,7 @@ func reuseExistingCertificatesFromMounts(
 	}
 
 	// Check for server certificate key
-	keyData, err := shared_podman.ReadFromContainer(containerName, image, utils.SSLMigrationVolumeMounts, nil,
+	keyData, err := shared_podman.ReadFromContainer(host, containerName, image, utils.SSLMigrationVolumeMounts, nil,
 		keyCheckPath)
 	if err != nil {
 		log.Warn().Msgf(L("Certificate key file %s not found."), keyCheckPath)
@@ -492,19 +533,28 @@ func reuseExistingCertificatesFromMounts(
 	log.Info().Msg(msg)
 	if isDatabaseCheck {
 		return true, shared_podman.CreateTLSSecrets(
+			host,
 			shared_podman.DBCASecret, caPath,
 			shared_podman.DBSSLCertSecret, serverCert,
 			shared_podman.DBSSLKeySecret, serverKey,
 		)
 	}
 	return true, shared_podman.CreateTLSSecrets(
+		host,
 		shared_podman.CASecret, caPath,
 		shared_podman.SSLCertSecret, serverCert,
 		shared_podman.SSLKeySecret, serverKey,
 	)
 }
 
-func runSSLContainer(script string, workdir string, image string, tz string, env map[string]string) error {
+func runSSLContainer(
+	host *utils.Host, script string, workdir string, image string, tz string, env map[string]string,
+) error {
+	hostDir, syncWorkdir, err := host.WorkDir(workdir)
+	if err != nil {
+		return err
+	}
+
 	envNames := []string{}
 	envValues := []string{}
 	for key, value := range env {
@@ -519,7 +569,7 @@ func runSSLContainer(script string, workdir string, image string, tz string, env
 		"--network", shared_podman.UyuniNetwork,
 		"-e", "TZ=" + tz,
 		"-v", utils.RootVolumeMount.Name + ":" + utils.RootVolumeMount.MountPath,
-		"-v", workdir + ":/ssl:z", // Bind mount for the generated certificates
+		"-v", hostDir + ":/ssl:z", // Bind mount for the generated certificates
 	}
 	command = append(command, envNames...)
 	command = append(command, image)
@@ -527,14 +577,15 @@ func runSSLContainer(script string, workdir string, image string, tz string, env
 	// Fail fast with `-e`.
 	command = append(command, "/usr/bin/sh", "-e", "-c", script)
 
-	_, err := newRunner("podman", command...).Env(envValues).StdMapping().Exec()
+	_, err = newRunner(host, "podman", command...).Env(envValues).StdMapping().Exec()
 
-	return err
+	return utils.JoinErrors(err, syncWorkdir())
 }
 
 // issueServerCertificate runs the given script in the SSL container and stores
 // the resulting CA, server certificate and key in the server and database secrets.
 func issueServerCertificate(
+	host *utils.Host,
 	script string, image string, sslFlags *adm_utils.InstallSSLFlags, tz string, fqdn string,
 ) error {
 	// This generally should not happen, otherwise we would ask for CA password in parameters check.
@@ -562,7 +613,7 @@ func issueServerCertificate(
 		"CERT_PASS":    sslFlags.Password,
 		"HOSTNAME":     fqdn,
 	}
-	if err := runSSLContainer(script, tempDir, image, tz, env); err != nil {
+	if err := runSSLContainer(host, script, tempDir, image, tz, env); err != nil {
 		return utils.Error(err, L("Failed to generate server SSL certificate. Please check the input parameters."))
 	}
 
@@ -570,6 +621,7 @@ func issueServerCertificate(
 
 	// Create secret for the server key and certificate
 	if err := shared_podman.CreateTLSSecrets(
+		host,
 		shared_podman.CASecret, path.Join(tempDir, "ca.crt"),
 		shared_podman.SSLCertSecret, path.Join(tempDir, "server.crt"),
 		shared_podman.SSLKeySecret, path.Join(tempDir, "server.key"),
@@ -579,6 +631,7 @@ func issueServerCertificate(
 
 	// Create secret for the database key and certificate
 	return shared_podman.CreateTLSSecrets(
+		host,
 		shared_podman.DBCASecret, path.Join(tempDir, "ca.crt"),
 		shared_podman.DBSSLCertSecret, path.Join(tempDir, "server.crt"),
 		shared_podman.DBSSLKeySecret, path.Join(tempDir, "server.key"),
@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package podman
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	adm_utils "github.com/uyuni-project/uyuni-tools/mgradm/shared/utils"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// stagedImagesFile lists the images pulled by PrepareUpgrade on the server host.
+var stagedImagesFile = "/var/lib/uyuni-tools/upgrade-staged.json"
+
+// StagedImages describes the images pulled ahead of an upgrade.
+type StagedImages struct {
+	Time time.Time `json:"time"`
+	// Server and Pgsql are the requested images, used to find out if the upgrade targets the staged version.
+	Server string `json:"server"`
+	Pgsql  string `json:"pgsql"`
+	// Images are all the pulled images, including the components and database upgrade ones.
+	Images []string `json:"images"`
+}
+
+// PrepareUpgrade pulls and verifies the images needed by Upgrade while the server keeps running.
+//
+// The staged images are recorded for the next Upgrade to the same images not to pull them again.
+func PrepareUpgrade(
+	systemd podman.Systemd,
+	authFile string,
+	signaturePolicy string,
+	image types.ImageFlags,
+	upgradeImage types.ImageFlags,
+	cocoFlags adm_utils.CocoFlags,
+	hubXmlrpcFlags adm_utils.HubXmlrpcFlags,
+	salineFlags adm_utils.SalineFlags,
+	pgsqlFlags types.PgsqlFlags,
+	tftpdFlags adm_utils.TFTPDFlags,
+	upgradePolicy *types.UpgradePolicyFlags,
+) (*StagedImages, error) {
+	host := systemd.Host()
+	if !strings.HasPrefix(image.Registry.Host, "registry.suse.com") {
+		if err := CallCloudGuestRegistryAuth(host); err != nil {
+			return nil, err
+		}
+	}
+
+	serverImage, pgsqlImage, err := podman.ComputeServerImages(host, image, pgsqlFlags)
+	if err != nil {
+		return nil, err
+	}
+	staged := StagedImages{Time: time.Now(), Server: serverImage, Pgsql: pgsqlImage}
+
+	preparedServerImage, err := prepareImage(host, authFile, signaturePolicy, serverImage, image.PullPolicy, true)
+	if err != nil {
+		return nil, utils.Errorf(err, L("cannot prepare image %s"), serverImage)
+	}
+	preparedPgsqlImage, err := prepareImage(host, authFile, signaturePolicy, pgsqlImage, image.PullPolicy, true)
+	if err != nil {
+		return nil, utils.Errorf(err, L("cannot prepare image %s"), pgsqlImage)
+	}
+	staged.Images = append(staged.Images, preparedServerImage, preparedPgsqlImage)
+
+	inspectedValues, err := prepareHost(host, preparedServerImage, preparedPgsqlImage, upgradePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	oldPgVersion, _ := strconv.Atoi(inspectedValues.ContainerInspectData.PgVersion)
+	newPgVersion, _ := strconv.Atoi(inspectedValues.DBInspectData.PgVersion)
+	if newPgVersion < oldPgVersion {
+		return nil, fmt.Errorf(L("trying to downgrade PostgreSQL from %[1]d to %[2]d"), oldPgVersion, newPgVersion)
+	}
+
+	images, err := upgradeImages(systemd, image, upgradeImage, newPgVersion > oldPgVersion, signaturePolicy != "",
+		cocoFlags, hubXmlrpcFlags, salineFlags, tftpdFlags)
+	if err != nil {
+		return nil, err
+	}
+	for _, imageURL := range images {
+		preparedImage, err := prepareImage(host, authFile, signaturePolicy, imageURL, image.PullPolicy, true)
+		if err != nil {
+			return nil, utils.Errorf(err, L("cannot prepare image %s"), imageURL)
+		}
+		staged.Images = append(staged.Images, preparedImage)
+	}
+
+	if err := writeStagedImages(host, &staged); err != nil {
+		return nil, err
+	}
+	return &staged, nil
+}
+
+// upgradeImages computes the images besides the server and database ones that Upgrade will pull.
+// This needs to be kept in sync with Upgrade: when verifying the signatures, the images of the disabled
+// components are pulled too.
+func upgradeImages(
+	systemd podman.Systemd,
+	image types.ImageFlags,
+	upgradeImage types.ImageFlags,
+	pgsqlUpgrade bool,
+	verify bool,
+	cocoFlags adm_utils.CocoFlags,
+	hubXmlrpcFlags adm_utils.HubXmlrpcFlags,
+	salineFlags adm_utils.SalineFlags,
+	tftpdFlags adm_utils.TFTPDFlags,
+) ([]string, error) {
+	images := []string{}
+	if pgsqlUpgrade {
+		upgradeImageURL, err := utils.ComputeImage(image.Registry.Host, image.Tag, upgradeImage)
+		if err != nil {
+			return nil, utils.Errorf(err, L("failed to compute image URL"))
+		}
+		images = append(images, upgradeImageURL)
+	}
+
+	hasTFTP := systemd.ServiceIsEnabled(podman.TFTPService)
+	if !hasTFTP && systemd.HasService(podman.ServerService) {
+		hasTFTP = serverExposesTFTP(systemd)
+	}
+
+	components := []struct {
+		image   types.ImageFlags
+		enabled bool
+	}{
+		{cocoFlags.Image, isComponentEnabled(systemd, podman.ServerAttestationService,
+			cocoFlags.IsChanged, cocoFlags.Replicas)},
+		{hubXmlrpcFlags.Image, isComponentEnabled(systemd, podman.HubXmlrpcService,
+			hubXmlrpcFlags.IsChanged, hubXmlrpcFlags.Replicas)},
+		{salineFlags.Image, isComponentEnabled(systemd, podman.SalineService,
+			salineFlags.IsChanged, salineFlags.Replicas)},
+		{tftpdFlags.Image, hasTFTP && !tftpdFlags.IsChanged || tftpdFlags.IsChanged && tftpdFlags.Enable},
+	}
+	for _, component := range components {
+		if component.image.Name == "" || !component.enabled && !verify {
+			continue
+		}
+		imageURL, err := componentImage(image, component.image)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, imageURL)
+	}
+	return images, nil
+}
+
+// isComponentEnabled returns whether an instantiated service will be running after the upgrade.
+func isComponentEnabled(systemd podman.Systemd, service string, isChanged bool, replicas int) bool {
+	if isChanged {
+		return replicas > 0
+	}
+	return systemd.CurrentReplicaCount(service) > 0
+}
+
+func writeStagedImages(host *utils.Host, staged *StagedImages) error {
+	data, err := json.MarshalIndent(staged, "", "  ")
+	if err != nil {
+		return utils.Error(err, L("failed to serialize the staged images"))
+	}
+	if err := host.MkdirAll(path.Dir(stagedImagesFile), 0700); err != nil {
+		return utils.Errorf(err, L("failed to create folder %s"), path.Dir(stagedImagesFile))
+	}
+	if err := host.WriteFile(stagedImagesFile, data, 0600); err != nil {
+		return utils.Errorf(err, L("failed to write %s"), stagedImagesFile)
+	}
+	return nil
+}
+
+// readStagedImages returns the images staged by PrepareUpgrade or nil if there are none.
+func readStagedImages(host *utils.Host) (*StagedImages, error) {
+	if !host.FileExists(stagedImagesFile) {
+		return nil, nil
+	}
+	data, err := host.ReadFile(stagedImagesFile)
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to read %s"), stagedImagesFile)
+	}
+	var staged StagedImages
+	if err := json.Unmarshal(data, &staged); err != nil {
+		return nil, utils.Errorf(err, L("invalid staged images file %s"), stagedImagesFile)
+	}
+	return &staged, nil
+}
+
+// useStagedImages changes the pull policy for the upgrade to use the images staged by PrepareUpgrade.
+//
+// The staged images are only used if they have been prepared for the same server and database images.
+// The images missing on the host are still pulled.
+func useStagedImages(host *utils.Host, image types.ImageFlags, pgsqlFlags types.PgsqlFlags) types.ImageFlags {
+	staged, err := readStagedImages(host)
+	if err != nil {
+		log.Warn().Err(err).Msg(L("Ignoring the staged images"))
+		return image
+	}
+	if staged == nil || strings.ToLower(image.PullPolicy) != "always" {
+		return image
+	}
+
+	serverImage, pgsqlImage, err := podman.ComputeServerImages(host, image, pgsqlFlags)
+	if err != nil || staged.Server != serverImage || staged.Pgsql != pgsqlImage {
+		log.Info().Msg(L("The staged images do not match the upgrade target, pulling the images again"))
+		return image
+	}
+
+	log.Info().Msgf(L("Using the images staged on %s"), staged.Time.Format(time.RFC3339))
+	image.PullPolicy = "IfNotPresent"
+	return image
+}
+
+// removeStagedImages forgets about the staged images once the upgrade used them.
+func removeStagedImages(host *utils.Host) {
+	if !host.FileExists(stagedImagesFile) {
+		return
+	}
+	if err := host.Remove(stagedImagesFile); err != nil {
+		log.Warn().Err(err).Msgf(L("Failed to remove %s"), stagedImagesFile)
+	}
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package podman
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	adm_utils "github.com/uyuni-project/uyuni-tools/mgradm/shared/utils"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+func TestUpgradeImages(t *testing.T) {
+	systemd := podman.NewSystemdWithDriver(&testutils.FakeSystemdDriver{
+		Installed: []string{podman.SalineService + "@", podman.TFTPService},
+		Enabled:   []string{podman.SalineService + "@0", podman.TFTPService},
+	})
+	image := types.ImageFlags{Registry: types.Registry{Host: "registry.opensuse.org"}, Tag: "2026.10"}
+	upgradeImage := types.ImageFlags{Name: "uyuni/server-migration-16-17"}
+
+	coco := adm_utils.CocoFlags{}
+	coco.Image.Name = "uyuni/server-attestation"
+	hub := adm_utils.HubXmlrpcFlags{Replicas: 1, IsChanged: true}
+	hub.Image.Name = "uyuni/server-hub-xmlrpc-api"
+	saline := adm_utils.SalineFlags{}
+	saline.Image.Name = "uyuni/server-saline"
+	tftpd := adm_utils.TFTPDFlags{}
+	tftpd.Image.Name = "uyuni/server-tftpd"
+
+	images, err := upgradeImages(systemd, image, upgradeImage, true, false, coco, hub, saline, tftpd)
+	testutils.AssertNoError(t, "failed to compute the images", err)
+	// coco has no running instance and is not requested
+	testutils.AssertEquals(t, "Unexpected images", []string{
+		"registry.opensuse.org/uyuni/server-migration-16-17:2026.10",
+		"registry.opensuse.org/uyuni/server-hub-xmlrpc-api:2026.10",
+		"registry.opensuse.org/uyuni/server-saline:2026.10",
+		"registry.opensuse.org/uyuni/server-tftpd:2026.10",
+	}, images)
+
+	// No PostgreSQL upgrade and the TFTP server is disabled
+	tftpd.IsChanged = true
+	images, err = upgradeImages(systemd, image, upgradeImage, false, false, coco, hub, saline, tftpd)
+	testutils.AssertNoError(t, "failed to compute the images", err)
+	testutils.AssertEquals(t, "Unexpected images", []string{
+		"registry.opensuse.org/uyuni/server-hub-xmlrpc-api:2026.10",
+		"registry.opensuse.org/uyuni/server-saline:2026.10",
+	}, images)
+
+	// The disabled components are verified too
+	images, err = upgradeImages(systemd, image, upgradeImage, false, true, coco, hub, saline, tftpd)
+	testutils.AssertNoError(t, "failed to compute the images", err)
+	testutils.AssertEquals(t, "Unexpected images", []string{
+		"registry.opensuse.org/uyuni/server-attestation:2026.10",
+		"registry.opensuse.org/uyuni/server-hub-xmlrpc-api:2026.10",
+		"registry.opensuse.org/uyuni/server-saline:2026.10",
+		"registry.opensuse.org/uyuni/server-tftpd:2026.10",
+	}, images)
+}
+
+func TestUseStagedImages(t *testing.T) {
+	stagedImagesFile = path.Join(t.TempDir(), "staged.json")
+	defer func() { stagedImagesFile = "/var/lib/uyuni-tools/upgrade-staged.json" }()
+
+	image := types.ImageFlags{
+		Name:       "uyuni/server",
+		Tag:        "2026.10",
+		Registry:   types.Registry{Host: "registry.opensuse.org"},
+		PullPolicy: "Always",
+	}
+	pgsql := types.PgsqlFlags{Image: types.ImageFlags{Name: "uyuni/server-postgresql"}}
+
+	// Nothing staged
+	testutils.AssertEquals(t, "Pull policy should not change", "Always", useStagedImages(nil, image, pgsql).PullPolicy)
+
+	testutils.AssertNoError(t, "failed to write the staged images", writeStagedImages(nil, &StagedImages{
+		Time:   time.Now(),
+		Server: "registry.opensuse.org/uyuni/server:2026.10",
+		Pgsql:  "registry.opensuse.org/uyuni/server-postgresql:2026.10",
+		Images: []string{
+			"registry.opensuse.org/uyuni/server:2026.10",
+			"registry.opensuse.org/uyuni/server-postgresql:2026.10",
+		},
+	}))
+	testutils.AssertEquals(t, "Staged images should be used", "IfNotPresent",
+		useStagedImages(nil, image, pgsql).PullPolicy)
+
+	// Staged for another version
+	otherImage := image
+	otherImage.Tag = "2027.01"
+	testutils.AssertEquals(t, "Staged images should not be used for another version", "Always",
+		useStagedImages(nil, otherImage, pgsql).PullPolicy)
+
+	// Don't override a pull policy set by the user
+	image.PullPolicy = "Never"
+	testutils.AssertEquals(t, "Pull policy should not change", "Never", useStagedImages(nil, image, pgsql).PullPolicy)
+
+	removeStagedImages(nil)
+	testutils.AssertTrue(t, "Staged images file should be removed", !utils.FileExists(stagedImagesFile))
+}
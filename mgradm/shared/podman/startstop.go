@@ -9,7 +9,7 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-func StartServices() error {
+func StartServices(systemd podman.Systemd) error {
 	var dbErr error
 	if systemd.HasService(podman.DBService) {
 		dbErr = systemd.StartService(podman.DBService)
@@ -33,7 +33,7 @@ func StartServices() error {
 	return errs
 }
 
-func StopServices() error {
+func StopServices(systemd podman.Systemd) error {
 	errs := utils.JoinErrors(
 		systemd.StopInstantiated(podman.ServerAttestationService),
 		systemd.StopInstantiated(podman.HubXmlrpcService),
@@ -87,9 +87,9 @@ func TestStartServices(t *testing.T) {
 			StartServiceErrors: testCase.startErrors,
 		}
 
-		systemd = podman.NewSystemdWithDriver(&driver)
+		systemd := podman.NewSystemdWithDriver(&driver)
 
-		err := StartServices()
+		err := StartServices(systemd)
 
 		prefix := fmt.Sprintf("case %d - ", i+1)
 		for _, service := range testCase.expectedStarted {
@@ -174,9 +174,9 @@ func TestStopServices(t *testing.T) {
 			StopServiceErrors: testCase.stopErrors,
 		}
 
-		systemd = podman.NewSystemdWithDriver(&driver)
+		systemd := podman.NewSystemdWithDriver(&driver)
 
-		err := StopServices()
+		err := StopServices(systemd)
 
 		prefix := fmt.Sprintf("case %d - ", i+1)
 		for _, service := range testCase.expectedStarted {
@@ -21,12 +21,13 @@ import (
 func Upgrade(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	baseImage types.ImageFlags,
 	salineFlags adm_utils.SalineFlags,
 	tz string,
 ) error {
 	if err := writeSalineServiceFiles(
-		systemd, authFile, baseImage, salineFlags, tz,
+		systemd, authFile, signaturePolicy, baseImage, salineFlags, tz,
 	); err != nil {
 		return err
 	}
@@ -40,6 +41,7 @@ func Upgrade(
 func writeSalineServiceFiles(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	baseImage types.ImageFlags,
 	salineFlags adm_utils.SalineFlags,
 	tz string,
@@ -74,7 +76,9 @@ func writeSalineServiceFiles(
 
 	pullEnabled := salineFlags.Replicas > 0 && salineFlags.IsChanged
 
-	preparedImage, err := podman.PrepareImage(authFile, salineImage, baseImage.PullPolicy, pullEnabled)
+	preparedImage, err := podman.PrepareImage(
+		systemd.Host(), authFile, signaturePolicy, salineImage, baseImage.PullPolicy, pullEnabled,
+	)
 	if err != nil {
 		return err
 	}
@@ -87,7 +91,7 @@ func writeSalineServiceFiles(
 
 	log.Info().Msg(L("Setting up Saline service"))
 
-	if err := utils.WriteTemplateToFile(salineData,
+	if err := systemd.Host().WriteTemplateToFile(salineData,
 		podman.GetServicePath(podman.SalineService+"@"), 0555, true); err != nil {
 		return utils.Error(err, L("failed to generate systemd service unit file"))
 	}
@@ -95,7 +99,7 @@ func writeSalineServiceFiles(
 	environment := fmt.Sprintf(`Environment=UYUNI_SALINE_IMAGE=%s`, preparedImage)
 
 	if err := podman.GenerateSystemdConfFile(
-		podman.SalineService+"@", podman.GeneratedConf, environment, true,
+		systemd.Host(), podman.SalineService+"@", podman.GeneratedConf, environment, true,
 	); err != nil {
 		return utils.Error(err, L("cannot generate systemd conf file"))
 	}
@@ -103,7 +107,7 @@ func writeSalineServiceFiles(
 	config := fmt.Sprintf(`Environment=TZ=%s
 `, strings.TrimSpace(tz))
 
-	if err := podman.GenerateSystemdConfFile(podman.SalineService+"@", podman.CustomConf,
+	if err := podman.GenerateSystemdConfFile(systemd.Host(), podman.SalineService+"@", podman.CustomConf,
 		config, false); err != nil {
 		return utils.Error(err, L("cannot generate systemd user configuration file"))
 	}
@@ -118,12 +122,13 @@ func writeSalineServiceFiles(
 func SetupSalineContainer(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	baseImage types.ImageFlags,
 	salineFlags adm_utils.SalineFlags,
 	tz string,
 ) error {
 	if err := writeSalineServiceFiles(systemd,
-		authFile, baseImage, salineFlags, tz); err != nil {
+		authFile, signaturePolicy, baseImage, salineFlags, tz); err != nil {
 		return err
 	}
 	return EnableSaline(systemd, salineFlags.Replicas)
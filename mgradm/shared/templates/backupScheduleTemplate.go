@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package templates
+
+import (
+	"io"
+	"text/template"
+)
+
+const backupScheduleServiceTemplate = `# uyuni-backup.service, generated by mgradm
+# Use an uyuni-backup.service.d/custom.conf file to override
+
+[Unit]
+Description=Uyuni scheduled backup
+Wants=network-online.target
+After=network-online.target uyuni-server.service
+
+[Service]
+Type=oneshot
+ExecStart={{ .Executable }} backup create --timestamped $UYUNI_BACKUP_CREATE_ARGS ${UYUNI_BACKUP_LOCATION}
+{{- if .Prune }}
+ExecStartPost={{ .Executable }} backup prune $UYUNI_BACKUP_PRUNE_ARGS ${UYUNI_BACKUP_LOCATION}
+{{- end }}
+ExecStopPost={{ .Executable }} backup schedule record
+`
+
+const backupScheduleTimerTemplate = `# uyuni-backup.timer, generated by mgradm
+
+[Unit]
+Description=Uyuni scheduled backup timer
+
+[Timer]
+OnCalendar={{ .Calendar }}
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`
+
+// BackupScheduleServiceTemplateData represents information used to create the scheduled backup service.
+type BackupScheduleServiceTemplateData struct {
+	// Executable is the path to the mgradm command.
+	Executable string
+	// Prune is true if the old backups have to be removed after a successful backup.
+	Prune bool
+}
+
+// Render will create the scheduled backup systemd service file.
+func (data BackupScheduleServiceTemplateData) Render(wr io.Writer) error {
+	t := template.Must(template.New("service").Parse(backupScheduleServiceTemplate))
+	return t.Execute(wr, data)
+}
+
+// BackupScheduleTimerTemplateData represents information used to create the scheduled backup timer.
+type BackupScheduleTimerTemplateData struct {
+	// Calendar is the systemd calendar event expression defining when to run the backup.
+	Calendar string
+}
+
+// Render will create the scheduled backup systemd timer file.
+func (data BackupScheduleTimerTemplateData) Render(wr io.Writer) error {
+	t := template.Must(template.New("timer").Parse(backupScheduleTimerTemplate))
+	return t.Execute(wr, data)
+}
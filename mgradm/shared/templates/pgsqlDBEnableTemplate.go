@@ -17,8 +17,10 @@ if [[ ! -d {{ .BackupDir }} ]]; then
 	exit 1
 fi
 
+{{- if .Clean }}
 echo "Removing old backup files..."
 find "{{ .BackupDir }}" -mindepth 1 -delete
+{{- end }}
 
 echo "Adjusting ownership..."
 chown postgres:postgres {{ .BackupDir }}
@@ -26,13 +28,17 @@ chmod u=rwx,og= {{ .BackupDir }}
 
 # Inspired by the original smdba
 # https://github.com/SUSE/smdba/blob/master/src/smdba/postgresqlgate.py#L853C110-L853C120
-echo "Performing initial base backup..."
-su postgres -c '/usr/bin/pg_basebackup -U postgres -D {{ .BackupDir }} -Ft -z -c fast -X fetch -v'
+echo "Performing base backup..."
+su postgres -c '/usr/bin/pg_basebackup -U postgres -D {{ .BaseBackupDir }} -Ft -z -c fast -X fetch -v'
 `
 
 // FinalizePostgresTemplateData represents information used to create PostgreSQL migration script.
 type EnablePostgresTemplateData struct {
 	BackupDir string
+	// BaseBackupDir is the directory to create the base backup in.
+	BaseBackupDir string
+	// Clean is true to remove all the previous backup files.
+	Clean bool
 }
 
 // Render will create script for finalizing PostgreSQL upgrade.
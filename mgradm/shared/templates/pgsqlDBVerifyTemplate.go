@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package templates
+
+import (
+	"io"
+	"text/template"
+)
+
+// Appended to the restore script to recover a throwaway copy of the database.
+// The later settings override the ones of the restored configuration.
+const postgresVerifyScriptTemplate = `
+echo "Configuring the isolated recovery..."
+cat >>"{{ .Datadir }}/postgresql.conf" <<EOF
+
+# Throwaway database used to verify the backup
+restore_command = {{ .RestoreCommand }}
+recovery_target_time = ''
+recovery_target_lsn = ''
+recovery_target_name = ''
+recovery_target_action = 'promote'
+archive_mode = off
+listen_addresses = ''
+ssl = off
+EOF
+`
+
+// VerifyPostgresTemplateData represents information used to configure a throwaway database recovery.
+type VerifyPostgresTemplateData struct {
+	Datadir        string
+	RestoreCommand string
+}
+
+// Render will create the script configuring the throwaway database recovery.
+func (data VerifyPostgresTemplateData) Render(wr io.Writer) error {
+	t := template.Must(template.New("script").Parse(postgresVerifyScriptTemplate))
+	return t.Execute(wr, data)
+}
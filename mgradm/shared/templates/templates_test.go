@@ -311,6 +311,62 @@ REPORT_DB_PORT=5432
 ORGANIZATION=My Example.com
 ADMIN_FIRSTNAME=Test
 ADMIN_LASTNAME=Admin`,
+		},
+		{
+			name:     "BackupScheduleServiceTemplateData",
+			template: BackupScheduleServiceTemplateData{Executable: "/usr/bin/mgradm", Prune: true},
+			expected: `# uyuni-backup.service, generated by mgradm
+# Use an uyuni-backup.service.d/custom.conf file to override
+
+[Unit]
+Description=Uyuni scheduled backup
+Wants=network-online.target
+After=network-online.target uyuni-server.service
+
+[Service]
+Type=oneshot
+ExecStart=/usr/bin/mgradm backup create --timestamped $UYUNI_BACKUP_CREATE_ARGS ${UYUNI_BACKUP_LOCATION}
+ExecStartPost=/usr/bin/mgradm backup prune $UYUNI_BACKUP_PRUNE_ARGS ${UYUNI_BACKUP_LOCATION}
+ExecStopPost=/usr/bin/mgradm backup schedule record
+`,
+		},
+		{
+			name:     "BackupScheduleTimerTemplateData",
+			template: BackupScheduleTimerTemplateData{Calendar: "*-*-* 02:00:00"},
+			expected: `# uyuni-backup.timer, generated by mgradm
+
+[Unit]
+Description=Uyuni scheduled backup timer
+
+[Timer]
+OnCalendar=*-*-* 02:00:00
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`,
+		},
+		{
+			name: "VerifyPostgresTemplateData",
+			template: VerifyPostgresTemplateData{
+				Datadir:        "/var/lib/pgsql/data",
+				RestoreCommand: "'/usr/bin/cp /var/lib/pgsql/backup/%f %p'",
+			},
+			expected: `
+echo "Configuring the isolated recovery..."
+cat >>"/var/lib/pgsql/data/postgresql.conf" <<EOF
+
+# Throwaway database used to verify the backup
+restore_command = '/usr/bin/cp /var/lib/pgsql/backup/%f %p'
+recovery_target_time = ''
+recovery_target_lsn = ''
+recovery_target_name = ''
+recovery_target_action = 'promote'
+archive_mode = off
+listen_addresses = ''
+ssl = off
+EOF
+`,
 		},
 		{
 			name:     "ServerEnvironmentEmptyData",
@@ -21,6 +21,7 @@ import (
 func SetupTFTPContainer(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	baseImage types.ImageFlags,
 	tftpFlags adm_utils.TFTPDFlags,
 	fqdn string,
@@ -41,7 +42,9 @@ func SetupTFTPContainer(
 		return utils.Errorf(err, L("failed to compute image URL"))
 	}
 
-	preparedImage, err := podman.PrepareImage(authFile, tftpImage, baseImage.PullPolicy, enable)
+	preparedImage, err := podman.PrepareImage(
+		systemd.Host(), authFile, signaturePolicy, tftpImage, baseImage.PullPolicy, enable,
+	)
 	if err != nil {
 		return err
 	}
@@ -62,6 +65,7 @@ func SetupTFTPContainer(
 func Upgrade(
 	systemd podman.Systemd,
 	authFile string,
+	signaturePolicy string,
 	baseImage types.ImageFlags,
 	tftpFlags adm_utils.TFTPDFlags,
 	fqdn string,
@@ -72,7 +76,7 @@ func Upgrade(
 		return nil
 	}
 
-	if err := SetupTFTPContainer(systemd, authFile, baseImage, tftpFlags, fqdn, hasTFTP); err != nil {
+	if err := SetupTFTPContainer(systemd, authFile, signaturePolicy, baseImage, tftpFlags, fqdn, hasTFTP); err != nil {
 		return err
 	}
 
@@ -89,7 +93,7 @@ func generateTFTPSystemdService(systemd podman.Systemd, image string, fqdn strin
 		Network:    podman.UyuniNetwork,
 		ServerFQDN: fqdn,
 	}
-	if err := utils.WriteTemplateToFile(
+	if err := systemd.Host().WriteTemplateToFile(
 		tftpData, podman.GetServicePath(podman.TFTPService), 0555, true,
 	); err != nil {
 		return utils.Errorf(err, L("failed to generate systemd service unit file"))
@@ -97,7 +101,7 @@ func generateTFTPSystemdService(systemd podman.Systemd, image string, fqdn strin
 
 	environment := fmt.Sprintf("Environment=UYUNI_TFTPD_IMAGE=%s", image)
 	if err := podman.GenerateSystemdConfFile(
-		podman.TFTPService, podman.GeneratedConf, environment, true,
+		systemd.Host(), podman.TFTPService, podman.GeneratedConf, environment, true,
 	); err != nil {
 		return utils.Errorf(err, L("cannot generate systemd conf file"))
 	}
@@ -214,6 +214,7 @@ func AddPgsqlFlags(cmd *cobra.Command) {
 // AddServerFlags add flags common to install, upgrade and migration.
 func AddServerFlags(cmd *cobra.Command) {
 	AddImageFlag(cmd)
+	utils.AddSignatureFlags(cmd)
 	AddSCCFlag(cmd)
 	AddPgsqlFlags(cmd)
 	AddDBFlags(cmd)
@@ -229,3 +230,8 @@ func AddServerFlags(cmd *cobra.Command) {
 func AddDebugFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool("debug-java", false, L("Enable tomcat and taskomatic remote debugging"))
 }
+
+// AddSkipChecksFlag adds the flag to skip the host checks run before installing or upgrading.
+func AddSkipChecksFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("skipchecks", false, L("Do not check the host before running the command"))
+}
@@ -6,14 +6,13 @@ package utils
 
 import (
 	"errors"
-	"fmt"
-	"os/exec"
 	"strings"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/uyuni-project/uyuni-tools/shared"
 	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
@@ -41,64 +40,40 @@ func ExecCommand(logLevel zerolog.Level, cnx *shared.Connection, args ...string)
 
 	commandArgs = append(commandArgs, "sh", "-c", strings.Join(args, " "))
 
-	runCmd := exec.Command(command, commandArgs...)
+	runCmd := cnx.Host().Command(command, commandArgs...)
 	logger := log.Logger.Level(logLevel)
 	runCmd.Stdout = logger
 	runCmd.Stderr = logger
 	return runCmd.Run()
 }
 
-// SanityCheck verifies if an upgrade can be run.
-func SanityCheck(inspectedValues *utils.InspectData) error {
+// SanityCheck verifies if an upgrade can be run and follows a supported upgrade path.
+func SanityCheck(inspectedValues *utils.InspectData, policy *types.UpgradePolicyFlags) error {
 	// Skip the uyuni / SUSE Manager release checks if the runningValues is nil.
 	if inspectedValues == nil {
 		return nil
 	}
 
-	isUyuni := inspectedValues.ContainerInspectData.UyuniRelease != ""
-	isUyuniImage := inspectedValues.ServerInspectData.UyuniRelease != ""
-	isSumaImage := inspectedValues.ServerInspectData.SuseManagerRelease != ""
-
-	if isUyuni && isSumaImage {
-		return fmt.Errorf(
-			L("currently SUSE Manager %s is installed, instead the image is Uyuni. Upgrade is not supported"),
-			inspectedValues.ContainerInspectData.SuseManagerRelease,
-		)
-	}
-
-	if !isUyuni && isUyuniImage {
-		return fmt.Errorf(
-			L("currently Uyuni %s is installed, instead the image is SUSE Manager. Upgrade is not supported"),
-			inspectedValues.ContainerInspectData.UyuniRelease,
-		)
+	current := utils.Release{Product: utils.UyuniProduct, Version: inspectedValues.ContainerInspectData.UyuniRelease}
+	if current.Version == "" {
+		current = utils.Release{
+			Product: utils.SuseManagerProduct,
+			Version: inspectedValues.ContainerInspectData.SuseManagerRelease,
+		}
 	}
+	log.Debug().Msgf("Current release is %s", current)
 
-	if isUyuni {
-		currentUyuniRelease := inspectedValues.ContainerInspectData.UyuniRelease
-		log.Debug().Msgf("Current release is %s", string(currentUyuniRelease))
-		if !isUyuniImage {
-			return errors.New(L("cannot fetch release from server image"))
-		}
-		log.Debug().Msgf("Server image release is %s", inspectedValues.ServerInspectData.UyuniRelease)
-		if utils.CompareVersion(inspectedValues.ServerInspectData.UyuniRelease, string(currentUyuniRelease)) < 0 {
-			return fmt.Errorf(
-				L("cannot downgrade from version %[1]s to %[2]s"),
-				string(currentUyuniRelease), inspectedValues.ServerInspectData.UyuniRelease,
-			)
-		}
-	} else {
-		currentSuseManagerRelease := inspectedValues.ContainerInspectData.SuseManagerRelease
-		log.Debug().Msgf("Current release is %s", currentSuseManagerRelease)
-		if !isSumaImage {
-			return errors.New(L("cannot fetch release from server image"))
-		}
-		log.Debug().Msgf("Server image release is %s", inspectedValues.ServerInspectData.SuseManagerRelease)
-		if utils.CompareVersion(inspectedValues.ServerInspectData.SuseManagerRelease, currentSuseManagerRelease) < 0 {
-			return fmt.Errorf(
-				L("cannot downgrade from version %[1]s to %[2]s"),
-				currentSuseManagerRelease, inspectedValues.ServerInspectData.SuseManagerRelease,
-			)
+	target := utils.Release{Product: utils.UyuniProduct, Version: inspectedValues.ServerInspectData.UyuniRelease}
+	if target.Version == "" {
+		target = utils.Release{
+			Product: utils.SuseManagerProduct,
+			Version: inspectedValues.ServerInspectData.SuseManagerRelease,
 		}
 	}
-	return nil
+	if target.Version == "" {
+		return errors.New(L("cannot fetch release from server image"))
+	}
+	log.Debug().Msgf("Server image release is %s", target)
+
+	return utils.CheckUpgradePath(policy, current, target)
 }
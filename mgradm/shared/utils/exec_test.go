@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
@@ -36,11 +37,19 @@ func TestSanityCheck(t *testing.T) {
 		},
 		{
 			"2024.07", "", "16", "", "5.1.0", "17",
-			"Upgrade is not supported",
+			"upgrade is not supported",
 		},
 		{
 			"", "5.1.0", "17", "2024.07", "", "16",
-			"Upgrade is not supported",
+			"upgrade is not supported",
+		},
+		{
+			"", "4.3.14", "14", "", "5.1.0", "17",
+			"upgrade to SUSE Manager 5.0 first",
+		},
+		{
+			"2024.07", "", "16", "", "", "17",
+			"cannot fetch release from server image",
 		},
 	}
 
@@ -61,8 +70,7 @@ func TestSanityCheck(t *testing.T) {
 			},
 		}
 
-		// Ensure SanityCheck signature accepts (*ContainerInspectData, *InspectData)
-		err := SanityCheck(&newValues)
+		err := SanityCheck(&newValues, &types.UpgradePolicyFlags{})
 
 		if test.errorPart != "" {
 			if err != nil {
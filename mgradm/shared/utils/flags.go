@@ -32,6 +32,8 @@ type ServerFlags struct {
 	Pgsql          types.PgsqlFlags
 	TFTPD          TFTPDFlags
 	Debug          DebugFlags
+	Signatures     types.SignatureFlags
+	UpgradePolicy  types.UpgradePolicyFlags `mapstructure:",squash"`
 }
 
 // MigrationFlags contains the parameters that are used only for migration.
@@ -57,13 +59,11 @@ type InstallationFlags struct {
 	Organization string
 }
 
-var systemd podman.Systemd = podman.NewSystemd()
-
 // CheckUpgradeParameters verifies the consistency of the parameters for upgrade and migrate commands.
-func (flags *InstallationFlags) CheckUpgradeParameters(cmd *cobra.Command, command string) {
+func (flags *InstallationFlags) CheckUpgradeParameters(cmd *cobra.Command, command string, systemd podman.Systemd) {
 	flags.setPasswordIfMissing()
 
-	flags.checkUpgradeSSLParameters(cmd, command)
+	flags.checkUpgradeSSLParameters(cmd, command, systemd)
 }
 
 func (flags *InstallationFlags) setPasswordIfMissing() {
@@ -91,7 +91,7 @@ func (flags *InstallationFlags) checkSSLParameters(cmd *cobra.Command, command s
 	}
 }
 
-func (flags *InstallationFlags) checkUpgradeSSLParameters(cmd *cobra.Command, command string) {
+func (flags *InstallationFlags) checkUpgradeSSLParameters(cmd *cobra.Command, command string, systemd podman.Systemd) {
 	// Make sure we have all the required 3rd party flags or none
 	flags.SSL.CheckUpgradeParameters(flags.DB.IsLocal())
 
@@ -102,14 +102,14 @@ func (flags *InstallationFlags) checkUpgradeSSLParameters(cmd *cobra.Command, co
 }
 
 // CheckParameters checks parameters for install command.
-func (flags *InstallationFlags) CheckParameters(cmd *cobra.Command, command string) {
+func (flags *InstallationFlags) CheckParameters(cmd *cobra.Command, command string, host *utils.Host) {
 	flags.setPasswordIfMissing()
 
 	flags.checkSSLParameters(cmd, command)
 
 	// Use the host timezone if the user didn't define one
 	if flags.TZ == "" {
-		flags.TZ = utils.GetLocalTimezone()
+		flags.TZ = host.GetTimezone()
 	}
 
 	utils.AskIfMissing(&flags.Email, cmd.Flag("email").Usage, 1, 128, emailChecker)
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+type createFlags struct {
+	Include struct {
+		Cache bool `mapstructure:"cache"`
+	} `mapstructure:"include"`
+	NoRestart bool `mapstructure:"norestart"`
+	DryRun    bool `mapstructure:"dryrun"`
+	Sign      struct {
+		Key string `mapstructure:"key"`
+	} `mapstructure:"sign"`
+	Unsigned bool `mapstructure:"unsigned"`
+
+	Artifacts backup.ArtifactFlags `mapstructure:",squash"`
+	Target    backup.TargetFlags   `mapstructure:",squash"`
+}
+
+type restoreFlags struct {
+	Restart      bool `mapstructure:"restart"`
+	DryRun       bool `mapstructure:"dryrun"`
+	ForceRestore bool `mapstructure:"force"`
+	SkipVerify   bool `mapstructure:"skipverify"`
+
+	Artifacts backup.ArtifactFlags `mapstructure:",squash"`
+	Target    backup.TargetFlags   `mapstructure:",squash"`
+}
+
+// addTargetFlags adds the flags needed to access the remote backup locations.
+func addTargetFlags(cmd *cobra.Command) {
+	cmd.Flags().String("s3-endpoint", "",
+		L("URL of the S3-compatible storage. Defaults to the AWS_ENDPOINT_URL environment variable or AWS S3"))
+	cmd.Flags().String("s3-region", "", L("Region of the S3 storage. Defaults to the AWS_REGION environment variable"))
+	cmd.Flags().Int("s3-partsize", 0, L("Size in MiB of the parts of the S3 multipart uploads. Default is 64"))
+	cmd.Flags().String("ssh-identity", "", L("Private key file to use to connect to the SFTP backup location"))
+}
+
+func newCreateCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[createFlags]) *cobra.Command {
+	var flags createFlags
+
+	createCmd := &cobra.Command{
+		Use:   "create output-location",
+		Args:  cobra.ExactArgs(1),
+		Short: L("Create backup"),
+		Long: L(`Create backup of the already configured proxy
+
+The backup contains the proxy configuration directory, the podman secrets holding the
+certificates and system ID, the systemd services with their configuration and the tuning files.
+The squid cache volume can be added with --include-cache: the proxy is then stopped during the backup.
+
+The output location can be a local directory, an sftp://[user@]host[:port]/path URL
+or an s3://bucket/prefix URL.`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+
+	createCmd.Flags().Bool("include-cache", false, L("Also backup the squid cache volume"))
+	createCmd.Flags().Bool("norestart", false, L("Do not restart the proxy after backup is done"))
+	createCmd.Flags().Bool("dryrun", false, L("Print expected actions, but no action is done"))
+	createCmd.Flags().String("compress", backup.CompressionNone,
+		L("Compress the backup files. Possible values: none, gzip, zstd"))
+	createCmd.Flags().StringSlice("encrypt-recipient", []string{},
+		L("Encrypt the backup files for the GPG key of the recipient. Can be repeated"))
+	createCmd.Flags().String("passphrase-file", "",
+		L("Encrypt the backup files with the passphrase contained in the file"))
+	createCmd.Flags().String("sign-key", "",
+		L("GPG key to sign the backup manifest with. Required unless --unsigned is used"))
+	createCmd.Flags().Bool("unsigned", false,
+		L("Do not sign the backup manifest. Tampering with the backup will not be detected"))
+	addTargetFlags(createCmd)
+
+	return createCmd
+}
+
+func newRestoreCmd(globalFlags *types.GlobalFlags, run utils.CommandFunc[restoreFlags]) *cobra.Command {
+	var flags restoreFlags
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore location",
+		Args:  cobra.ExactArgs(1),
+		Short: L("Restore backup from the location"),
+		Long: L(`Restore backup of the previously configured proxy from a specified location
+
+The location can be a local directory, an sftp://[user@]host[:port]/path URL
+or an s3://bucket/prefix URL.`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return utils.CommandHelper(globalFlags, cmd, args, &flags, nil, run)
+		},
+	}
+
+	restoreCmd.Flags().Bool("restart", false, L("Start the proxy after restore is done"))
+	restoreCmd.Flags().Bool("dryrun", false, L("Print expected actions, but no action is done"))
+	restoreCmd.Flags().Bool("force", false, L("Force overwrite of existing items"))
+	restoreCmd.Flags().Bool("skipverify", false, L("Skip verification of the backup files"))
+	restoreCmd.Flags().String("passphrase-file", "",
+		L("Decrypt the backup files with the passphrase contained in the file"))
+	addTargetFlags(restoreCmd)
+
+	return restoreCmd
+}
+
+// NewCommand command for proxy backup management.
+func NewCommand(globalFlags *types.GlobalFlags) *cobra.Command {
+	backupCmd := &cobra.Command{
+		Use:     "backup",
+		GroupID: "tool",
+		Short:   L("Backup solution"),
+		Long:    L("Tools for local proxy backup management"),
+	}
+	backupCmd.AddCommand(newCreateCmd(globalFlags, doBackup))
+	backupCmd.AddCommand(newRestoreCmd(globalFlags, doRestore))
+	return backupCmd
+}
+
+// Backup helper to catch errors with unified error message.
+func doBackup(
+	global *types.GlobalFlags,
+	flags *createFlags,
+	cmd *cobra.Command,
+	args []string,
+) error {
+	err := create(global, flags, cmd, args)
+	var backupError *backup.BackupError
+	if errors.As(err, &backupError) {
+		// l10n-ignore
+		log.Error().Msgf("%s", backupError.Err.Error())
+		if backupError.Abort && backupError.DataRemains {
+			return fmt.Errorf(L("Backup aborted, partially backed up files remains in '%s'"), args[0])
+		}
+		if !backupError.Abort {
+			return errors.New(L("Proxy configuration was backed up successfully, but errors were present"))
+		}
+	}
+	return err
+}
+
+// Restore helper to catch errors with unified error message.
+func doRestore(
+	global *types.GlobalFlags,
+	flags *restoreFlags,
+	cmd *cobra.Command,
+	args []string,
+) error {
+	err := restore(global, flags, cmd, args)
+	var backupError *backup.BackupError
+	if errors.As(err, &backupError) {
+		log.Warn().Err(backupError).Msgf(L("Encountered problems:"))
+		if backupError.Abort && backupError.DataRemains {
+			return errors.New(L("Restore aborted with partially restored files. Resolve the error and try again"))
+		}
+		if !backupError.Abort {
+			return errors.New(L("Proxy configuration was restored successfully, but with warnings"))
+		}
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+)
+
+func TestCreateParamsParsing(t *testing.T) {
+	args := []string{
+		"--include-cache",
+		"--norestart",
+		"--dryrun",
+		"--compress", "zstd",
+		"--sign-key", "backup@example.com",
+		"--unsigned",
+		"--encrypt-recipient", "backup@example.com",
+		"--passphrase-file", "/root/passphrase",
+		"--s3-endpoint", "https://s3.example.com",
+		"--s3-region", "eu-west-1",
+		"--s3-partsize", "16",
+		"--ssh-identity", "/root/.ssh/id_backup",
+		"/backup",
+	}
+
+	tester := func(_ *types.GlobalFlags, flags *createFlags, _ *cobra.Command, args []string) error {
+		testutils.AssertTrue(t, "Error parsing --include-cache", flags.Include.Cache)
+		testutils.AssertTrue(t, "Error parsing --norestart", flags.NoRestart)
+		testutils.AssertTrue(t, "Error parsing --dryrun", flags.DryRun)
+		testutils.AssertEquals(t, "Error parsing --compress", "zstd", flags.Artifacts.Compress)
+		testutils.AssertEquals(t, "Error parsing --sign-key", "backup@example.com", flags.Sign.Key)
+		testutils.AssertTrue(t, "Error parsing --unsigned", flags.Unsigned)
+		testutils.AssertEquals(t, "Error parsing --encrypt-recipient",
+			[]string{"backup@example.com"}, flags.Artifacts.Encrypt.Recipient)
+		testutils.AssertEquals(t, "Error parsing --passphrase-file", "/root/passphrase", flags.Artifacts.Passphrase.File)
+		testutils.AssertEquals(t, "Error parsing --s3-endpoint", "https://s3.example.com", flags.Target.S3.Endpoint)
+		testutils.AssertEquals(t, "Error parsing --s3-region", "eu-west-1", flags.Target.S3.Region)
+		testutils.AssertEquals(t, "Error parsing --s3-partsize", 16, flags.Target.S3.PartSize)
+		testutils.AssertEquals(t, "Error parsing --ssh-identity", "/root/.ssh/id_backup", flags.Target.SSH.Identity)
+		testutils.AssertEquals(t, "Wrong location", "/backup", args[0])
+		return nil
+	}
+
+	globalFlags := types.GlobalFlags{}
+	cmd := newCreateCmd(&globalFlags, tester)
+
+	testutils.AssertHasAllFlags(t, cmd, args)
+
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("command failed with error: %s", err)
+	}
+}
+
+func TestRestoreParamsParsing(t *testing.T) {
+	args := []string{
+		"--restart",
+		"--dryrun",
+		"--force",
+		"--skipverify",
+		"--passphrase-file", "/root/passphrase",
+		"--s3-endpoint", "https://s3.example.com",
+		"--s3-region", "eu-west-1",
+		"--s3-partsize", "16",
+		"--ssh-identity", "/root/.ssh/id_backup",
+		"/backup",
+	}
+
+	tester := func(_ *types.GlobalFlags, flags *restoreFlags, _ *cobra.Command, args []string) error {
+		testutils.AssertTrue(t, "Error parsing --restart", flags.Restart)
+		testutils.AssertTrue(t, "Error parsing --dryrun", flags.DryRun)
+		testutils.AssertTrue(t, "Error parsing --force", flags.ForceRestore)
+		testutils.AssertTrue(t, "Error parsing --skipverify", flags.SkipVerify)
+		testutils.AssertEquals(t, "Error parsing --passphrase-file", "/root/passphrase", flags.Artifacts.Passphrase.File)
+		testutils.AssertEquals(t, "Error parsing --s3-endpoint", "https://s3.example.com", flags.Target.S3.Endpoint)
+		testutils.AssertEquals(t, "Error parsing --ssh-identity", "/root/.ssh/id_backup", flags.Target.SSH.Identity)
+		testutils.AssertEquals(t, "Wrong location", "/backup", args[0])
+		return nil
+	}
+
+	globalFlags := types.GlobalFlags{}
+	cmd := newRestoreCmd(&globalFlags, tester)
+
+	testutils.AssertHasAllFlags(t, cmd, args)
+
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("command failed with error: %s", err)
+	}
+}
+
+func TestTuningFilePaths(t *testing.T) {
+	customConf := `[Service]
+Environment=HTTPD_EXTRA_CONF=-v/root/tuning/apache.conf:/etc/apache2/conf.d/apache_tuning.conf:ro
+Environment=OTHER=value
+`
+	testutils.AssertEquals(t, "Unexpected tuning files", []string{"/root/tuning/apache.conf"},
+		tuningFilePaths(customConf))
+}
+
+func TestProxyServices(t *testing.T) {
+	oldSystemd := systemd
+	t.Cleanup(func() { systemd = oldSystemd })
+	systemd = podman.NewSystemdWithDriver(&testutils.FakeSystemdDriver{
+		Installed: []string{podman.ProxyService, "uyuni-proxy-httpd", "uyuni-proxy-squid"},
+	})
+
+	testutils.AssertEquals(t, "Unexpected services",
+		[]string{podman.ProxyService, "uyuni-proxy-httpd", "uyuni-proxy-squid"}, proxyServices())
+}
+
+func TestBackupAndRestoreConfigFiles(t *testing.T) {
+	oldConfigDir := proxyConfigDir
+	t.Cleanup(func() { proxyConfigDir = oldConfigDir })
+	proxyConfigDir = path.Join(t.TempDir(), "proxy")
+	testutils.AssertNoError(t, "failed to create config dir", os.MkdirAll(proxyConfigDir, 0755))
+	configFile := path.Join(proxyConfigDir, "config.yaml")
+	testutils.WriteFile(t, configFile, "server: server.example.com\n")
+
+	files, err := gatherConfigFiles(nil)
+	testutils.AssertNoError(t, "failed to gather config files", err)
+	testutils.AssertEquals(t, "Unexpected config files", []string{proxyConfigDir, configFile}, files)
+
+	location := t.TempDir()
+	target, err := backup.NewTarget(location, &backup.TargetFlags{})
+	testutils.AssertNoError(t, "failed to create target", err)
+	manifest := backup.NewManifest(&backup.ArtifactFlags{})
+	err = backupFiles(target, manifest, backup.ArtifactConfig, configBackupFile, files, &createFlags{}, false)
+	testutils.AssertNoError(t, "failed to backup config files", err)
+	testutils.AssertEquals(t, "Artifact should be in the manifest", 1, len(manifest.Artifacts))
+	testutils.AssertEquals(t, "Unexpected artifact kind", backup.ArtifactConfig, manifest.Artifacts[0].Kind)
+
+	testutils.AssertNoError(t, "failed to remove config dir", os.RemoveAll(proxyConfigDir))
+	testutils.AssertNoError(t, "failed to restore config files",
+		restoreFiles(target, configBackupFile, &restoreFlags{}))
+	content, err := os.ReadFile(configFile)
+	testutils.AssertNoError(t, "config.yaml should be restored", err)
+	testutils.AssertEquals(t, "Unexpected content", "server: server.example.com\n", string(content))
+}
@@ -0,0 +1,340 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	proxy_podman "github.com/uyuni-project/uyuni-tools/mgrpxy/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// configBackupFile is the name of the tarball with the proxy configuration and tuning files.
+const configBackupFile = "proxyConfig.tar"
+
+var systemd podman.Systemd = podman.NewSystemd()
+
+// proxyConfigDir is the directory containing the proxy configuration and the default tuning files.
+var proxyConfigDir = "/etc/uyuni/proxy"
+
+// proxySecrets are the podman secrets used by the proxy containers.
+var proxySecrets = []string{
+	podman.CASecret,
+	podman.ProxySSLCertSecret,
+	podman.ProxySSLKeySecret,
+	proxy_podman.SystemIDSecret,
+}
+
+// squidCacheVolume is the only volume worth backing up: the other ones are synchronized from the server.
+var squidCacheVolume = utils.ProxySquidVolumes[0].Name
+
+// tuningFileRegexp matches the tuning files mounted by the services custom configuration.
+var tuningFileRegexp = regexp.MustCompile(`_EXTRA_CONF=-v([^:]+):`)
+
+func create(
+	_ *types.GlobalFlags,
+	flags *createFlags,
+	_ *cobra.Command,
+	args []string,
+) error {
+	dryRun := flags.DryRun
+	outputDirectory := args[0]
+	printCreateIntro(outputDirectory, flags)
+
+	target, err := backup.NewTarget(outputDirectory, &flags.Target)
+	if err != nil {
+		return backup.AbortError(err, false)
+	}
+	defer target.Close()
+
+	if err := createSanityChecks(target, &flags.Artifacts); err != nil {
+		return backup.AbortError(err, false)
+	}
+	if err := backup.CheckSignKey(flags.Sign.Key, flags.Unsigned); err != nil {
+		return backup.AbortError(err, false)
+	}
+
+	// Remote targets don't need to be prepared, the files are streamed to them
+	if localDir, isLocal := target.LocalPath(""); isLocal && !dryRun {
+		if err := os.MkdirAll(localDir, 0700); err != nil {
+			return backup.AbortError(fmt.Errorf(L("unable to create target output directory: %w"), err), false)
+		}
+	}
+
+	services := proxyServices()
+	manifest := backup.NewManifest(&flags.Artifacts)
+
+	// The configuration and secrets are needed to run the proxy again, abort if they can't be saved
+	configFiles, err := gatherConfigFiles(services)
+	if err == nil {
+		err = backupFiles(target, manifest, backup.ArtifactConfig, configBackupFile, configFiles, flags, dryRun)
+	}
+	if err == nil {
+		err = backupSecrets(target, manifest, &flags.Artifacts, dryRun)
+	}
+	if err != nil {
+		err = backup.AbortError(err, true)
+		manifest.SetStatus(err)
+		if errManifest := writeManifest(target, manifest, flags.Sign.Key, dryRun); errManifest != nil {
+			log.Warn().Err(errManifest).Msg(L("Failed to record the aborted backup"))
+		}
+		return err
+	}
+
+	systemdFiles := []string{}
+	for _, service := range services {
+		systemdFiles = append(systemdFiles, backup.SystemdServiceFiles(systemd, service)...)
+	}
+	hasError := backupFiles(target, manifest, backup.ArtifactSystemd, backup.SystemdConfBackupFile, systemdFiles,
+		flags, dryRun)
+	if hasError != nil {
+		log.Warn().Err(hasError).Msg(L("Systemd services and configuration was not backed up"))
+	}
+
+	if flags.Include.Cache {
+		hasError = utils.JoinErrors(hasError, backupCache(target, manifest, flags, dryRun))
+	}
+
+	// the manifest is needed to verify the backup without restoring it
+	manifest.SetStatus(hasError)
+	hasError = utils.JoinErrors(hasError, writeManifest(target, manifest, flags.Sign.Key, dryRun))
+
+	log.Info().Msgf(L("Backup finished into %s"), outputDirectory)
+	return backup.ReportError(hasError)
+}
+
+func printCreateIntro(outputDir string, flags *createFlags) {
+	log.Debug().Msg("Creating proxy backup with options:")
+	log.Debug().Msgf("output directory: %s", outputDir)
+	log.Debug().Msgf("dry run: %t", flags.DryRun)
+	log.Debug().Msgf("include cache: %t", flags.Include.Cache)
+	log.Debug().Msgf("skip restart: %t", flags.NoRestart)
+	log.Debug().Msgf("compression: %s", flags.Artifacts.Compress)
+	log.Debug().Msgf("encrypted: %t", flags.Artifacts.IsEncrypted())
+}
+
+func createSanityChecks(target backup.Target, flags *backup.ArtifactFlags) error {
+	if err := backup.SanityChecks(); err != nil {
+		return err
+	}
+
+	if err := flags.CheckArtifactTools(); err != nil {
+		return err
+	}
+
+	empty, err := backup.IsEmpty(target)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return fmt.Errorf(L("output directory %s already exists and is not empty"), target)
+	}
+
+	if !systemd.HasService(podman.ProxyService) {
+		return errors.New(L("proxy is not installed"))
+	}
+	return nil
+}
+
+// proxyServices returns the installed proxy pod and containers services.
+func proxyServices() []string {
+	services := []string{}
+	for _, service := range append([]string{podman.ProxyService}, podman.ProxyContainerNames...) {
+		if systemd.HasService(service) {
+			services = append(services, service)
+		} else {
+			log.Debug().Msgf("No service found for %s, skipping", service)
+		}
+	}
+	return services
+}
+
+// gatherConfigFiles lists the content of the proxy configuration directory and the tuning files.
+func gatherConfigFiles(services []string) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(proxyConfigDir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to list the files of %s"), proxyConfigDir)
+	}
+
+	for _, tuningFile := range tuningFiles(services) {
+		if !utils.Contains(files, tuningFile) {
+			files = append(files, tuningFile)
+		}
+	}
+	return files, nil
+}
+
+// tuningFiles returns the tuning files used by the services.
+// The files may have been set anywhere on the host using the tuning flags at installation time.
+func tuningFiles(services []string) []string {
+	files := []string{}
+	for _, service := range services {
+		customConf := podman.GetServiceConfPath(service, podman.CustomConf)
+		content, err := os.ReadFile(customConf)
+		if err != nil {
+			continue
+		}
+		for _, file := range tuningFilePaths(string(content)) {
+			if _, err := os.Stat(file); err != nil {
+				log.Warn().Err(err).Msgf(L("Tuning file %[1]s of %[2]s service will not be backed up"), file, service)
+				continue
+			}
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// tuningFilePaths returns the paths of the tuning files mounted in a service custom configuration.
+func tuningFilePaths(customConf string) []string {
+	files := []string{}
+	for _, match := range tuningFileRegexp.FindAllStringSubmatch(customConf, -1) {
+		files = append(files, match[1])
+	}
+	return files
+}
+
+// backupFiles writes the files into a tarball and registers it in the manifest.
+func backupFiles(
+	target backup.Target,
+	manifest *backup.Manifest,
+	kind string,
+	name string,
+	files []string,
+	flags *createFlags,
+	dryRun bool,
+) (err error) {
+	if dryRun {
+		log.Info().Msgf(L("Would backup %[1]s into %[2]s"), files, name)
+		return nil
+	}
+	log.Info().Msgf(L("Backing up %s"), name)
+	log.Debug().Msgf("Backed up files: %s", strings.Join(files, ", "))
+	out, err := backup.CreateArtifact(target, name, &flags.Artifacts)
+	if err != nil {
+		return utils.Errorf(err, L("failed to create %s"), name)
+	}
+	defer func() {
+		err = out.Finish(err)
+		if err == nil {
+			manifest.AddArtifact(kind, name, out.Artifact(), files)
+		}
+	}()
+
+	tw := tar.NewWriter(out)
+	defer tw.Close()
+	return backup.WriteFilesTar(tw, files)
+}
+
+// backupSecrets writes the proxy podman secrets into a tarball and registers it in the manifest.
+func backupSecrets(
+	target backup.Target,
+	manifest *backup.Manifest,
+	flags *backup.ArtifactFlags,
+	dryRun bool,
+) (err error) {
+	if dryRun {
+		log.Info().Msgf(L("Would backup podman secrets %s"), strings.Join(proxySecrets, ", "))
+		return nil
+	}
+	log.Info().Msg(L("Backing up podman secrets"))
+	secrets, names, err := backup.ExportPodmanSecrets(func(name string) bool {
+		return utils.Contains(proxySecrets, name)
+	})
+	if err != nil {
+		return utils.Error(err, L("failed to export the podman secrets"))
+	}
+
+	out, err := backup.CreateArtifact(target, backup.PodmanConfBackupFile, flags)
+	if err != nil {
+		return fmt.Errorf(L("failed to create podman backup tarball: %w"), err)
+	}
+	defer func() {
+		err = out.Finish(err)
+		if err == nil {
+			manifest.AddArtifact(backup.ArtifactPodman, backup.PodmanConfBackupFile, out.Artifact(), names)
+		}
+	}()
+
+	tw := tar.NewWriter(out)
+	defer tw.Close()
+	header := &tar.Header{
+		Name: backup.SecretBackupFile,
+		Mode: 0600,
+		Size: int64(len(secrets)),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = tw.Write(secrets)
+	return err
+}
+
+// backupCache exports the squid cache volume, stopping the proxy while exporting it.
+func backupCache(target backup.Target, manifest *backup.Manifest, flags *createFlags, dryRun bool) (err error) {
+	if !podman.IsVolumePresent(nil, squidCacheVolume) {
+		log.Warn().Msgf(L("Volume %s not found, not backing it up"), squidCacheVolume)
+		return nil
+	}
+
+	if !dryRun && systemd.IsServiceRunning(podman.ProxyService) {
+		log.Info().Msg(L("Stopping proxy service"))
+		if err := systemd.StopService(podman.ProxyService); err != nil {
+			return err
+		}
+		if !flags.NoRestart {
+			defer func() {
+				log.Info().Msg(L("Restarting proxy service"))
+				err = utils.JoinErrors(err, systemd.StartService(podman.ProxyService))
+			}()
+		}
+	}
+
+	log.Info().Msgf(L("Backing up %s volume"), squidCacheVolume)
+	outputFile := path.Join(backup.VolumesSubdir, squidCacheVolume+".tar")
+	exportCommand := []string{"podman", "volume", "export", squidCacheVolume}
+	artifact, err := backup.ExportArtifact(target, outputFile, &flags.Artifacts, dryRun, exportCommand...)
+	if err != nil {
+		return utils.Errorf(err, L("Failed to export volume %s"), squidCacheVolume)
+	}
+	if !dryRun {
+		manifest.AddArtifact(backup.ArtifactVolume, squidCacheVolume, artifact, nil)
+	}
+	return nil
+}
+
+func writeManifest(target backup.Target, manifest *backup.Manifest, signKey string, dryRun bool) error {
+	if dryRun {
+		log.Info().Msgf(L("Would write backup manifest to %s"), backup.JoinLocation(target.String(), backup.ManifestFile))
+		return nil
+	}
+	if err := manifest.Write(target, signKey); err != nil {
+		log.Warn().Err(err).Msg(L("Backup manifest was not written, the backup cannot be verified"))
+		return err
+	}
+	return nil
+}
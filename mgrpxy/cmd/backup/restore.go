@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	proxy_podman "github.com/uyuni-project/uyuni-tools/mgrpxy/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/backup"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+func restore(
+	_ *types.GlobalFlags,
+	flags *restoreFlags,
+	_ *cobra.Command,
+	args []string,
+) error {
+	inputDirectory := args[0]
+	printRestoreIntro(inputDirectory, flags)
+	dryRun := flags.DryRun
+
+	target, err := backup.NewTarget(inputDirectory, &flags.Target)
+	if err != nil {
+		return backup.AbortError(err, false)
+	}
+	defer target.Close()
+
+	if err := restoreSanityChecks(target, flags); err != nil {
+		return backup.AbortError(err, false)
+	}
+
+	if !dryRun && systemd.IsServiceRunning(podman.ProxyService) {
+		log.Info().Msg(L("Stopping proxy service"))
+		if err := systemd.StopService(podman.ProxyService); err != nil {
+			return backup.AbortError(err, false)
+		}
+	}
+
+	// The proxy can't work without its configuration and secrets
+	if err := restoreFiles(target, configBackupFile, flags); err != nil {
+		return backup.AbortError(err, true)
+	}
+	if err := restoreSecrets(target, flags); err != nil {
+		return backup.AbortError(err, true)
+	}
+
+	hasError := restoreFiles(target, backup.SystemdConfBackupFile, flags)
+	if hasError != nil {
+		log.Warn().Err(hasError).Msg(L("Systemd services were not restored, run mgrpxy install to generate them"))
+	}
+	hasError = utils.JoinErrors(hasError, systemd.ReloadDaemon(dryRun))
+	hasError = utils.JoinErrors(hasError, restoreCache(target, flags))
+
+	if flags.Restart && !dryRun {
+		log.Info().Msg(L("Starting proxy service"))
+		hasError = utils.JoinErrors(hasError, proxy_podman.StartPod(systemd))
+	}
+
+	return backup.ReportError(hasError)
+}
+
+func printRestoreIntro(dir string, flags *restoreFlags) {
+	log.Debug().Msg("Restoring proxy backup with options:")
+	log.Debug().Msgf("input directory: %s", dir)
+	log.Debug().Msgf("dry run: %t", flags.DryRun)
+	log.Debug().Msgf("force: %t", flags.ForceRestore)
+	log.Debug().Msgf("restart: %t", flags.Restart)
+	log.Debug().Msgf("skip verify: %t", flags.SkipVerify)
+}
+
+func restoreSanityChecks(target backup.Target, flags *restoreFlags) error {
+	if err := backup.SanityChecks(); err != nil {
+		return err
+	}
+
+	if err := flags.Artifacts.CheckArtifactTools(); err != nil {
+		return err
+	}
+
+	empty, err := backup.IsEmpty(target)
+	if err != nil {
+		return err
+	}
+	if empty {
+		return fmt.Errorf(L("input directory %s does not exists"), target)
+	}
+
+	if systemd.HasService(podman.ProxyService) {
+		if !flags.ForceRestore {
+			return errors.New(L("proxy is already installed. Use force to overwrite"))
+		}
+		log.Warn().Msg(L("Restoring over already installed proxy"))
+	}
+	return nil
+}
+
+// openBackupFile opens the artifact with the name from the backup, verifying its checksum unless skipped.
+func openBackupFile(target backup.Target, directory string, name string, flags *restoreFlags) (io.ReadCloser, error) {
+	file := backup.FindArtifact(target, directory, name)
+	if file == "" {
+		return nil, fmt.Errorf(L("%s not found in the backup"), name)
+	}
+	checksum, err := artifactChecksum(target, file, flags)
+	if err != nil {
+		return nil, err
+	}
+	return backup.OpenArtifact(target, file, &flags.Artifacts, checksum)
+}
+
+// artifactChecksum returns the checksum to verify the artifact against, or an empty string if skipped.
+func artifactChecksum(target backup.Target, file string, flags *restoreFlags) (string, error) {
+	if flags.SkipVerify {
+		return "", nil
+	}
+	return backup.ReadChecksum(target, file)
+}
+
+// restoreFiles writes back the files of a tarball created by backupFiles.
+func restoreFiles(target backup.Target, name string, flags *restoreFlags) (hasError error) {
+	log.Info().Msgf(L("Restoring %s"), name)
+	backupFile, err := openBackupFile(target, "", name, flags)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		hasError = utils.JoinErrors(hasError, backupFile.Close())
+	}()
+	return backup.RestoreFilesTar(tar.NewReader(backupFile), flags.DryRun)
+}
+
+// restoreSecrets creates the podman secrets from the backup.
+func restoreSecrets(target backup.Target, flags *restoreFlags) (hasError error) {
+	backupFile, err := openBackupFile(target, "", backup.PodmanConfBackupFile, flags)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		hasError = utils.JoinErrors(hasError, backupFile.Close())
+	}()
+
+	tr := tar.NewReader(backupFile)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if header.Name != backup.SecretBackupFile {
+			log.Warn().Msgf(L("Ignoring unexpected file in the podman backup %s"), header.Name)
+			continue
+		}
+		if flags.DryRun {
+			log.Info().Msg(L("Would restore podman secrets"))
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return utils.Error(err, L("failed to read the backed up podman secrets"))
+		}
+		hasError = utils.JoinErrors(hasError, backup.RestorePodmanSecrets(data, flags.ForceRestore))
+	}
+	return hasError
+}
+
+// restoreCache imports the squid cache volume if it is in the backup.
+func restoreCache(target backup.Target, flags *restoreFlags) error {
+	file := backup.FindArtifact(target, backup.VolumesSubdir, squidCacheVolume+".tar")
+	if file == "" {
+		log.Debug().Msg("No squid cache in the backup")
+		return nil
+	}
+	if flags.DryRun {
+		log.Info().Msgf(L("Would restore volume %[1]s from %[2]s"), squidCacheVolume,
+			backup.JoinLocation(target.String(), file))
+		return nil
+	}
+	if podman.IsVolumePresent(nil, squidCacheVolume) && !flags.ForceRestore {
+		return fmt.Errorf(L("Not restoring existing volume %s unless forced"), squidCacheVolume)
+	}
+
+	checksum, err := artifactChecksum(target, file, flags)
+	if err != nil {
+		return err
+	}
+	targetPath, err := podman.PrepareVolumeImport(nil, squidCacheVolume)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf(L("Restoring %s volume"), squidCacheVolume)
+
+	// Extract next to the volume until the streamed artifact is verified
+	extractPath := targetPath
+	if checksum != "" {
+		if extractPath, err = backup.PrepareStagingDir(targetPath); err != nil {
+			return err
+		}
+		defer os.RemoveAll(extractPath)
+	}
+	importCommand := []string{"tar", "xf", "-", "-C", extractPath}
+	if err := backup.ImportArtifact(target, file, &flags.Artifacts, checksum, false, importCommand...); err != nil {
+		return utils.Errorf(err, L("Failed to import volume %s"), squidCacheVolume)
+	}
+	if extractPath != targetPath {
+		if err := backup.MoveStagedFiles(extractPath, targetPath); err != nil {
+			return utils.Errorf(err, L("Failed to import volume %s"), squidCacheVolume)
+		}
+	}
+	podman.RestoreVolumeContext(nil, targetPath)
+	return nil
+}
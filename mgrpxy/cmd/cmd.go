@@ -11,6 +11,7 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/uyuni-project/uyuni-tools/mgrpxy/cmd/backup"
 	"github.com/uyuni-project/uyuni-tools/mgrpxy/cmd/cache"
 	"github.com/uyuni-project/uyuni-tools/mgrpxy/cmd/install"
 	"github.com/uyuni-project/uyuni-tools/mgrpxy/cmd/logs"
@@ -79,6 +80,7 @@ func NewUyuniproxyCommand() (*cobra.Command, error) {
 	rootCmd.AddCommand(restart.NewCommand(globalFlags))
 	rootCmd.AddCommand(upgrade.NewCommand(globalFlags))
 	rootCmd.AddCommand(logs.NewCommand(globalFlags))
+	rootCmd.AddCommand(backup.NewCommand(globalFlags))
 
 	if supportCommand := support.NewCommand(globalFlags); supportCommand != nil {
 		rootCmd.AddCommand(supportCommand)
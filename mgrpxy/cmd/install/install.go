@@ -53,6 +53,7 @@ NOTE: for now installing on a remote podman is not supported!
 	utils.AddSCCFlag(cmd)
 	utils.AddImageFlags(cmd)
 	shared_podman.AddPodmanArgFlag(cmd)
+	shared_utils.AddSignatureFlags(cmd)
 
 	return cmd
 }
@@ -21,12 +21,14 @@ func TestParamsParsing(t *testing.T) {
 	}
 	args = append(args, flagstests.ImageProxyFlagsTestArgs...)
 	args = append(args, flagstests.PodmanFlagsTestArgs...)
+	args = append(args, flagstests.SignatureFlagsTestArgs...)
 	args = append(args, flagstests.SCCFlagTestArgs...)
 
 	// Test function asserting that the args are properly parsed
 	tester := func(_ *types.GlobalFlags, flags *podman.PodmanProxyFlags, _ *cobra.Command, _ []string) error {
 		flagstests.AssertProxyImageFlags(t, &flags.ProxyImageFlags)
 		flagstests.AssertPodmanInstallFlags(t, &flags.Podman)
+		flagstests.AssertSignatureFlags(t, &flags.Signatures)
 		flagstests.AssertSCCFlag(t, &flags.SCC)
 		return nil
 	}
@@ -39,13 +39,13 @@ func installForPodman(
 		return err
 	}
 
-	hostData, err := shared_podman.InspectHost()
+	hostData, err := shared_podman.InspectHost(nil)
 	if err != nil {
 		return err
 	}
 
 	// If we previously created systemid secret, remove it
-	shared_podman.DeleteSecret(podman.SystemIDSecret, false)
+	shared_podman.DeleteSecret(nil, podman.SystemIDSecret, false)
 
 	// Check if we are a salt minion registered to SMLM and if so, try to get up to date systemid
 	if hostData.HasSaltMinion {
@@ -60,33 +60,39 @@ func installForPodman(
 	}
 	defer cleaner()
 
-	httpdImage, err := podman.GetContainerImage(authFile, &flags.ProxyImageFlags, "httpd")
+	signaturePolicy, cleanPolicy, err := shared_podman.SetupSignatureVerification(nil, &flags.Signatures)
 	if err != nil {
 		return err
 	}
-	saltBrokerImage, err := podman.GetContainerImage(authFile, &flags.ProxyImageFlags, "salt-broker")
+	defer cleanPolicy()
+
+	httpdImage, err := podman.GetContainerImage(authFile, signaturePolicy, &flags.ProxyImageFlags, "httpd")
+	if err != nil {
+		return err
+	}
+	saltBrokerImage, err := podman.GetContainerImage(authFile, signaturePolicy, &flags.ProxyImageFlags, "salt-broker")
 	if err != nil {
 		return err
 	}
-	squidImage, err := podman.GetContainerImage(authFile, &flags.ProxyImageFlags, "squid")
+	squidImage, err := podman.GetContainerImage(authFile, signaturePolicy, &flags.ProxyImageFlags, "squid")
 	if err != nil {
 		return err
 	}
-	sshImage, err := podman.GetContainerImage(authFile, &flags.ProxyImageFlags, "ssh")
+	sshImage, err := podman.GetContainerImage(authFile, signaturePolicy, &flags.ProxyImageFlags, "ssh")
 	if err != nil {
 		return err
 	}
-	tftpdImage, err := podman.GetContainerImage(authFile, &flags.ProxyImageFlags, "tftpd")
+	tftpdImage, err := podman.GetContainerImage(authFile, signaturePolicy, &flags.ProxyImageFlags, "tftpd")
 	if err != nil {
 		return err
 	}
 
-	err = shared_podman.SetupNetwork(true)
+	err = shared_podman.SetupNetwork(nil, true)
 	if err != nil {
 		return shared_utils.Errorf(err, L("cannot setup network"))
 	}
 
-	ipv6Enabled := shared_podman.HasIpv6Enabled(shared_podman.UyuniNetwork)
+	ipv6Enabled := shared_podman.HasIpv6Enabled(nil, shared_podman.UyuniNetwork)
 
 	log.Info().Msg(L("Generating systemd services"))
 	httpProxyConfig := podman.GetHTTPProxyConfig()
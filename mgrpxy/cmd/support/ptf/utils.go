@@ -30,7 +30,7 @@ func ptfForPodman(
 		return errors.New(L("install podman before running this command"))
 	}
 
-	hostData, err := podman_shared.InspectHost()
+	hostData, err := podman_shared.InspectHost(nil)
 	if err != nil {
 		return err
 	}
@@ -55,7 +55,7 @@ func ptfForPodman(
 	sshImage := getImage(authFile, flags.UpgradeFlags.SSH.Name, pullPolicy)
 	tftpdImage := getImage(authFile, flags.UpgradeFlags.Tftpd.Name, pullPolicy)
 
-	ipv6Enabled := podman_shared.HasIpv6Enabled(podman_shared.UyuniNetwork)
+	ipv6Enabled := podman_shared.HasIpv6Enabled(nil, podman_shared.UyuniNetwork)
 
 	log.Info().Msg(L("Generating systemd services"))
 	httpProxyConfig := podman.GetHTTPProxyConfig()
@@ -74,7 +74,7 @@ func getImage(authFile string, image string, policy string) string {
 	var newImage string
 	var err error
 	if image != "" {
-		newImage, err = podman_shared.PrepareImage(authFile, image, policy, true)
+		newImage, err = podman_shared.PrepareImage(nil, authFile, "", image, policy, true)
 		if err != nil {
 			log.Warn().Msgf(L("cannot find %s image: it will no be upgraded"), image)
 		}
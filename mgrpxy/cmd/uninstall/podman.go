@@ -28,11 +28,11 @@ func uninstallForPodman(
 
 	// Get the images from the service configs before they are removed
 	images := []string{
-		podman.GetServiceImage("uyuni-proxy-httpd"),
-		podman.GetServiceImage("uyuni-proxy-salt-broker"),
-		podman.GetServiceImage("uyuni-proxy-squid"),
-		podman.GetServiceImage("uyuni-proxy-ssh"),
-		podman.GetServiceImage("uyuni-proxy-tftpd"),
+		podman.GetServiceImage(nil, "uyuni-proxy-httpd"),
+		podman.GetServiceImage(nil, "uyuni-proxy-salt-broker"),
+		podman.GetServiceImage(nil, "uyuni-proxy-squid"),
+		podman.GetServiceImage(nil, "uyuni-proxy-ssh"),
+		podman.GetServiceImage(nil, "uyuni-proxy-tftpd"),
 	}
 
 	// Uninstall the service
@@ -45,7 +45,7 @@ func uninstallForPodman(
 
 	// Force stop the pod
 	for _, containerName := range podman.ProxyContainerNames {
-		podman.DeleteContainer(containerName, dryRun)
+		podman.DeleteContainer(nil, containerName, dryRun)
 	}
 
 	// Remove the volumes
@@ -64,7 +64,7 @@ func uninstallForPodman(
 
 		// Delete each volume
 		for _, volume := range volumes {
-			if err := podman.DeleteVolume(volume, dryRun); err != nil {
+			if err := podman.DeleteVolume(nil, volume, dryRun); err != nil {
 				return utils.Errorf(err, L("cannot delete volume %s"), volume)
 			}
 		}
@@ -80,7 +80,7 @@ func uninstallForPodman(
 	if flags.Purge.Images {
 		for _, image := range images {
 			if image != "" {
-				if err := podman.DeleteImage(image, !flags.Force); err != nil {
+				if err := podman.DeleteImage(nil, image, !flags.Force); err != nil {
 					return utils.Errorf(err, L("cannot delete image %s"), image)
 				}
 			}
@@ -88,12 +88,12 @@ func uninstallForPodman(
 		log.Info().Msg(L("All images have been removed"))
 	}
 
-	podman.DeleteNetwork(dryRun)
+	podman.DeleteNetwork(nil, dryRun)
 
-	podman.DeleteSecret(pxy_podman.SystemIDSecret, dryRun)
-	podman.DeleteSecret(podman.CASecret, dryRun)
-	podman.DeleteSecret(podman.ProxySSLCertSecret, dryRun)
-	podman.DeleteSecret(podman.ProxySSLKeySecret, dryRun)
+	podman.DeleteSecret(nil, pxy_podman.SystemIDSecret, dryRun)
+	podman.DeleteSecret(nil, podman.CASecret, dryRun)
+	podman.DeleteSecret(nil, podman.ProxySSLCertSecret, dryRun)
+	podman.DeleteSecret(nil, podman.ProxySSLKeySecret, dryRun)
 
 	err := systemd.ReloadDaemon(dryRun)
 
@@ -45,6 +45,8 @@ NOTE: for now upgrading on a remote podman is not supported!
 	utils.AddSCCFlag(podmanCmd)
 	utils.AddImageFlags(podmanCmd)
 	shared_podman.AddPodmanArgFlag(podmanCmd)
+	shared_utils.AddSignatureFlags(podmanCmd)
+	shared_utils.AddUpgradePolicyFlags(podmanCmd)
 
 	return podmanCmd
 }
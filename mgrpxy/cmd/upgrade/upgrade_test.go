@@ -20,6 +20,8 @@ func TestParamsParsing(t *testing.T) {
 	args = append(args, flagstests.SCCFlagTestArgs...)
 	args = append(args, flagstests.ImageProxyFlagsTestArgs...)
 	args = append(args, flagstests.PodmanFlagsTestArgs...)
+	args = append(args, flagstests.SignatureFlagsTestArgs...)
+	args = append(args, "--force")
 
 	// Test function asserting that the args are properly parsed
 	tester := func(_ *types.GlobalFlags, flags *podman.PodmanProxyFlags,
@@ -27,7 +29,9 @@ func TestParamsParsing(t *testing.T) {
 	) error {
 		flagstests.AssertSCCFlag(t, &flags.SCC)
 		flagstests.AssertPodmanInstallFlags(t, &flags.Podman)
+		flagstests.AssertSignatureFlags(t, &flags.Signatures)
 		flagstests.AssertProxyImageFlags(t, &flags.ProxyImageFlags)
+		testutils.AssertTrue(t, "Error parsing --force", flags.UpgradePolicy.Force)
 		return nil
 	}
 
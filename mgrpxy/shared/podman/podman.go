@@ -51,6 +51,8 @@ var createSecret = podman.CreateSecret
 type PodmanProxyFlags struct {
 	utils.ProxyImageFlags `mapstructure:",squash"`
 	Podman                podman.PodmanFlags
+	Signatures            types.SignatureFlags
+	UpgradePolicy         types.UpgradePolicyFlags `mapstructure:",squash"`
 }
 
 // GenerateSystemdService generates all the systemd files required by proxy.
@@ -85,16 +87,16 @@ func GenerateSystemdService(
 			Volumes:       shared_utils.ProxyHttpdVolumes,
 			HTTPProxyFile: httpProxyConfig,
 		}
-		if podman.HasSecret(SystemIDSecret) {
+		if podman.HasSecret(nil, SystemIDSecret) {
 			dataHttpd.SystemIDSecret = SystemIDSecret
 		}
-		if podman.HasSecret(podman.CASecret) {
+		if podman.HasSecret(nil, podman.CASecret) {
 			dataHttpd.CaSecret = podman.CASecret
 		}
-		if podman.HasSecret(podman.ProxySSLCertSecret) {
+		if podman.HasSecret(nil, podman.ProxySSLCertSecret) {
 			dataHttpd.CertSecret = podman.ProxySSLCertSecret
 		}
-		if podman.HasSecret(podman.ProxySSLKeySecret) {
+		if podman.HasSecret(nil, podman.ProxySSLKeySecret) {
 			dataHttpd.KeySecret = podman.ProxySSLKeySecret
 		}
 
@@ -170,7 +172,7 @@ func GenerateSystemdService(
 			Volumes:       shared_utils.ProxyTftpdVolumes,
 			HTTPProxyFile: httpProxyConfig,
 		}
-		if podman.HasSecret(podman.CASecret) {
+		if podman.HasSecret(nil, podman.CASecret) {
 			dataTftpd.CaSecret = podman.CASecret
 		}
 		if err := systemdGenerator(dataTftpd, "tftpd", tftpdImage, ""); err != nil {
@@ -207,6 +209,7 @@ func generateSystemdFile(template shared_utils.Template, service string, image s
 	if image != "" {
 		configBody := fmt.Sprintf("Environment=UYUNI_IMAGE=%s", image)
 		if err := podman.GenerateSystemdConfFile(
+			nil,
 			"uyuni-proxy-"+service, podman.GeneratedConf, configBody, true,
 		); err != nil {
 			return shared_utils.Errorf(err, L("cannot generate systemd conf file"))
@@ -214,7 +217,7 @@ func generateSystemdFile(template shared_utils.Template, service string, image s
 	}
 
 	if config != "" {
-		if err := podman.GenerateSystemdConfFile("uyuni-proxy-"+service, podman.CustomConf, config, false); err != nil {
+		if err := podman.GenerateSystemdConfFile(nil, "uyuni-proxy-"+service, podman.CustomConf, config, false); err != nil {
 			return shared_utils.Errorf(err, L("cannot generate systemd conf user configuration file"))
 		}
 	}
@@ -233,10 +236,14 @@ func GetHTTPProxyConfig() string {
 }
 
 // GetContainerImage returns a proxy image URL.
-func GetContainerImage(authFile string, flags *utils.ProxyImageFlags, name string) (string, error) {
+//
+// If a signature policy is passed, the image is refused if its signature is not valid.
+func GetContainerImage(
+	authFile string, signaturePolicy string, flags *utils.ProxyImageFlags, name string,
+) (string, error) {
 	image := flags.GetContainerImage(name)
 
-	preparedImage, err := podman.PrepareImage(authFile, image, flags.PullPolicy, true)
+	preparedImage, err := podman.PrepareImage(nil, authFile, signaturePolicy, image, flags.PullPolicy, true)
 	if err != nil {
 		return "", err
 	}
@@ -320,56 +327,76 @@ func Upgrade(
 	if _, err := exec.LookPath("podman"); err != nil {
 		return errors.New(L("install podman before running this command"))
 	}
-	if err := systemd.StopService(podman.ProxyService); err != nil {
+	hostData, err := podman.InspectHost(nil)
+	if err != nil {
 		return err
 	}
 
-	if err := ExtractSecrets(); err != nil {
+	authFile, cleaner, err := podman.PodmanLogin(hostData, flags.Registry, flags.SCC)
+	if err != nil {
 		return err
 	}
+	defer cleaner()
 
-	hostData, err := podman.InspectHost()
+	signaturePolicy, cleanPolicy, err := podman.SetupSignatureVerification(nil, &flags.Signatures)
 	if err != nil {
 		return err
 	}
+	defer cleanPolicy()
+
+	if err := checkServerRelease(flags, authFile); err != nil {
+		return err
+	}
+	if err := systemd.StopService(podman.ProxyService); err != nil {
+		return err
+	}
+
+	if err := ExtractSecrets(); err != nil {
+		return err
+	}
 
 	// Check if we are a salt minion registered to SMLM and if so, try to get up to date systemid
 	if hostData.HasSaltMinion {
 		// If we previously created systemid secret, remove it
-		podman.DeleteSecret(SystemIDSecret, false)
+		podman.DeleteSecret(nil, SystemIDSecret, false)
 		if err := GetSystemID(); err != nil {
 			log.Warn().Err(err).Msg(L("Unable to fetch up to date systemid, using one from the provided configuration file"))
 		}
 	}
 
-	authFile, cleaner, err := podman.PodmanLogin(hostData, flags.Registry, flags.SCC)
-	if err != nil {
-		return err
+	// A missing image is not upgraded, but an image with an invalid signature stops the upgrade
+	getUpgradeImage := func(name string) (string, error) {
+		image, err := GetContainerImage(authFile, signaturePolicy, &flags.ProxyImageFlags, name)
+		if err != nil {
+			if signaturePolicy != "" {
+				return "", err
+			}
+			log.Warn().Msgf(L("cannot find %s image: it will no be upgraded"), name)
+		}
+		return image, nil
 	}
-	defer cleaner()
-
-	httpdImage, err := GetContainerImage(authFile, &flags.ProxyImageFlags, "httpd")
+	httpdImage, err := getUpgradeImage("httpd")
 	if err != nil {
-		log.Warn().Msgf(L("cannot find httpd image: it will no be upgraded"))
+		return err
 	}
-	saltBrokerImage, err := GetContainerImage(authFile, &flags.ProxyImageFlags, "salt-broker")
+	saltBrokerImage, err := getUpgradeImage("salt-broker")
 	if err != nil {
-		log.Warn().Msgf(L("cannot find salt-broker image: it will no be upgraded"))
+		return err
 	}
-	squidImage, err := GetContainerImage(authFile, &flags.ProxyImageFlags, "squid")
+	squidImage, err := getUpgradeImage("squid")
 	if err != nil {
-		log.Warn().Msgf(L("cannot find squid image: it will no be upgraded"))
+		return err
 	}
-	sshImage, err := GetContainerImage(authFile, &flags.ProxyImageFlags, "ssh")
+	sshImage, err := getUpgradeImage("ssh")
 	if err != nil {
-		log.Warn().Msgf(L("cannot find ssh image: it will no be upgraded"))
+		return err
 	}
-	tftpdImage, err := GetContainerImage(authFile, &flags.ProxyImageFlags, "tftpd")
+	tftpdImage, err := getUpgradeImage("tftpd")
 	if err != nil {
-		log.Warn().Msgf(L("cannot find tftpd image: it will no be upgraded"))
+		return err
 	}
 
-	ipv6Enabled := podman.HasIpv6Enabled(podman.UyuniNetwork)
+	ipv6Enabled := podman.HasIpv6Enabled(nil, podman.UyuniNetwork)
 
 	log.Info().Msg(L("Generating systemd services"))
 	httpProxyConfig := GetHTTPProxyConfig()
@@ -470,7 +497,7 @@ func GetSystemID() error {
 	}
 	log.Trace().Msgf("SystemID: %s", systemid)
 
-	return createSecret(SystemIDSecret, systemid)
+	return createSecret(nil, SystemIDSecret, systemid)
 }
 
 var proxyConfigDir = "/etc/uyuni/proxy"
@@ -560,7 +587,7 @@ func saveYaml(filePath string, config map[string]interface{}) error {
 
 func extractKeyToSecret(m map[string]interface{}, key string, secretName string) (bool, error) {
 	if val, ok := m[key].(string); ok && val != "" {
-		if err := createSecret(secretName, val); err != nil {
+		if err := createSecret(nil, secretName, val); err != nil {
 			return false, shared_utils.Errorf(err, L("failed to create %s secret"), secretName)
 		}
 		delete(m, key)
@@ -227,7 +227,7 @@ func TestExtractSecrets(t *testing.T) {
 
 	secrets := make(map[string]string)
 	oldCreateSecret := createSecret
-	createSecret = func(name string, value string) error {
+	createSecret = func(_ *utils.Host, name string, value string) error {
 		secrets[name] = value
 		return nil
 	}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package podman
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uyuni-project/uyuni-tools/shared/api"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	shared_utils "github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Function pointers to mock podman and the server API in unit tests.
+var (
+	getServerVersion = fetchServerVersion
+	getImageMetadata = podman.GetImageMetadata
+)
+
+// fetchServerVersion asks the server of the proxy configuration for its version.
+func fetchServerVersion() (string, error) {
+	config, err := loadYaml(path.Join(proxyConfigDir, "config.yaml"))
+	if err != nil {
+		return "", err
+	}
+	server, _ := config["server"].(string)
+	if server == "" {
+		return "", errors.New(L("no server defined in the proxy configuration"))
+	}
+
+	tempDir, cleaner, err := shared_utils.TempDir()
+	if err != nil {
+		return "", err
+	}
+	defer cleaner()
+	caPath, err := writeServerCA(config, path.Join(tempDir, "ca.crt"))
+	if err != nil {
+		return "", err
+	}
+
+	client, err := api.Init(&api.ConnectionDetails{Server: server, CApath: caPath})
+	if err != nil {
+		return "", err
+	}
+	res, err := api.Get[string](client, "api/systemVersion")
+	if err != nil {
+		return "", shared_utils.Errorf(err, L("failed to get the version of server %s"), server)
+	}
+	if !res.Success {
+		return "", errors.New(res.Message)
+	}
+	return res.Result, nil
+}
+
+// writeServerCA writes the CA of the server to caPath to trust the server certificate.
+//
+// The CA is read from the podman secret or from the configuration if not extracted yet.
+// Returns an empty path if there is no CA.
+func writeServerCA(config map[string]interface{}, caPath string) (string, error) {
+	caCert, _ := config["ca_crt"].(string)
+	if podman.HasSecret(nil, podman.CASecret) {
+		var err error
+		if caCert, err = podman.GetSecret(nil, podman.CASecret); err != nil {
+			return "", err
+		}
+	}
+	if caCert == "" {
+		return "", nil
+	}
+	if err := os.WriteFile(caPath, []byte(caCert), 0600); err != nil {
+		return "", shared_utils.Errorf(err, L("failed to write %s"), caPath)
+	}
+	return caPath, nil
+}
+
+// proxyRelease returns the release of the proxy image from its version label, or from its tag.
+func proxyRelease(image string, authFile string) (shared_utils.Release, bool) {
+	version := ""
+	if metadata, err := getImageMetadata(nil, image, authFile); err != nil {
+		log.Debug().Err(err).Msgf("Cannot read the labels of image %s", image)
+	} else {
+		version = metadata.Labels[podman.ImageVersionLabel]
+	}
+	if version == "" {
+		version = shared_utils.ImageTag(image)
+	}
+	return shared_utils.ReleaseFromVersion(version)
+}
+
+// checkServerRelease verifies that the proxy release to upgrade to can be used with its server.
+//
+// The check is skipped with a warning if the proxy image has no version, like with the latest tag.
+// Failing to find the server version is an error that --force bypasses.
+func checkServerRelease(flags *PodmanProxyFlags, authFile string) error {
+	image := flags.GetContainerImage("httpd")
+	proxy, ok := proxyRelease(image, authFile)
+	if !ok {
+		log.Warn().Msgf(L("Cannot find the version of the %s image: not checking if the server supports it"), image)
+		return nil
+	}
+
+	serverVersion, err := getServerVersion()
+	if err != nil {
+		return shared_utils.ApplyUpgradePolicy(&flags.UpgradePolicy,
+			shared_utils.Error(err, L("cannot check the proxy version against the server one")))
+	}
+	server, ok := shared_utils.ReleaseFromVersion(serverVersion)
+	if !ok {
+		return shared_utils.ApplyUpgradePolicy(&flags.UpgradePolicy,
+			fmt.Errorf(L("cannot check the proxy version against the server one %s"), serverVersion))
+	}
+	log.Debug().Msgf("Upgrading the proxy to %[1]s with server %[2]s", proxy, server)
+
+	return shared_utils.CheckProxyRelease(&flags.UpgradePolicy, server, proxy)
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package podman
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/mgrpxy/shared/utils"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	shared_utils "github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+func TestCheckServerRelease(t *testing.T) {
+	defer func() {
+		getServerVersion = fetchServerVersion
+		getImageMetadata = podman.GetImageMetadata
+	}()
+	getImageMetadata = func(_ *shared_utils.Host, image string, _ string) (*podman.ImageMetadata, error) {
+		return nil, fmt.Errorf("image %s is not present", image)
+	}
+
+	newFlags := func(tag string) *PodmanProxyFlags {
+		return &PodmanProxyFlags{
+			ProxyImageFlags: utils.ProxyImageFlags{
+				Registry: types.Registry{Host: "registry.suse.com"},
+				Tag:      tag,
+				Httpd:    types.ImageFlags{Name: "suse/multi-linux-manager/5.1/x86_64/proxy-httpd"},
+			},
+		}
+	}
+
+	getServerVersion = func() (string, error) { return "5.1.2", nil }
+	testutils.AssertNoError(t, "same version should be accepted", checkServerRelease(newFlags("5.1.2"), ""))
+	testutils.AssertError(t, "newer than the SUSE Manager 5.1.2 server", checkServerRelease(newFlags("5.2.0"), ""))
+
+	flags := newFlags("5.2.0")
+	flags.UpgradePolicy.Force = true
+	testutils.AssertNoError(t, "forced check should pass", checkServerRelease(flags, ""))
+
+	// Images without version are not checked
+	testutils.AssertNoError(t, "latest image should not be checked", checkServerRelease(newFlags("latest"), ""))
+
+	// The version label is preferred over the tag
+	getImageMetadata = func(_ *shared_utils.Host, _ string, _ string) (*podman.ImageMetadata, error) {
+		return &podman.ImageMetadata{Labels: map[string]string{podman.ImageVersionLabel: "5.2.0"}}, nil
+	}
+	testutils.AssertError(t, "newer than the SUSE Manager 5.1.2 server", checkServerRelease(newFlags("latest"), ""))
+
+	getServerVersion = func() (string, error) { return "", errors.New("server unreachable") }
+	testutils.AssertError(t, "server unreachable", checkServerRelease(newFlags("5.2.0"), ""))
+	getServerVersion = func() (string, error) { return "unknown", nil }
+	testutils.AssertError(t, "against the server one unknown", checkServerRelease(newFlags("5.2.0"), ""))
+
+	flags = newFlags("5.2.0")
+	flags.UpgradePolicy.Force = true
+	testutils.AssertNoError(t, "forced check should pass without server version", checkServerRelease(flags, ""))
+}
+
+func TestFetchServerVersionNoServer(t *testing.T) {
+	oldProxyConfigDir := proxyConfigDir
+	proxyConfigDir = t.TempDir()
+	defer func() { proxyConfigDir = oldProxyConfigDir }()
+
+	testutils.WriteFile(t, path.Join(proxyConfigDir, "config.yaml"), "proxy_fqdn: proxy.example.com\n")
+	_, err := fetchServerVersion()
+	testutils.AssertError(t, "no server defined in the proxy configuration", err)
+}
+
+func TestWriteServerCA(t *testing.T) {
+	caPath := path.Join(t.TempDir(), "ca.crt")
+	written, err := writeServerCA(map[string]interface{}{"ca_crt": "CA_CERT_CONTENT"}, caPath)
+	testutils.AssertNoError(t, "failed to write the CA", err)
+	testutils.AssertEquals(t, "Unexpected CA path", caPath, written)
+	testutils.AssertEquals(t, "Unexpected CA content", "CA_CERT_CONTENT", testutils.ReadFile(t, caPath))
+}
@@ -0,0 +1,640 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// Supported compression algorithms of the backup artifacts.
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// ChecksumSuffix is the suffix of the files containing the checksum of a backup artifact.
+const ChecksumSuffix = ".sha256sum"
+
+const tarSuffix = ".tar"
+const gzipSuffix = ".gz"
+const zstdSuffix = ".zst"
+const gpgSuffix = ".gpg"
+
+// ArtifactFlags describes how the backup artifacts are compressed and encrypted.
+type ArtifactFlags struct {
+	Compress string `mapstructure:"compress"`
+	Encrypt  struct {
+		Recipient []string `mapstructure:"recipient"`
+	} `mapstructure:"encrypt"`
+	Passphrase struct {
+		File string `mapstructure:"file"`
+	} `mapstructure:"passphrase"`
+}
+
+// IsEncrypted returns true if the artifacts are to be encrypted.
+func (f *ArtifactFlags) IsEncrypted() bool {
+	return len(f.Encrypt.Recipient) > 0 || f.Passphrase.File != ""
+}
+
+// Suffix returns the suffix to append to the artifact file names.
+func (f *ArtifactFlags) Suffix() string {
+	suffix := ""
+	switch f.Compress {
+	case CompressionGzip:
+		suffix = gzipSuffix
+	case CompressionZstd:
+		suffix = zstdSuffix
+	}
+	if f.IsEncrypted() {
+		suffix += gpgSuffix
+	}
+	return suffix
+}
+
+// CheckArtifactTools verifies the flags values and that the needed tools are installed.
+func (f *ArtifactFlags) CheckArtifactTools() error {
+	switch f.Compress {
+	case "", CompressionNone:
+	case CompressionGzip, CompressionZstd:
+		if !utils.IsInstalled(f.Compress) {
+			return fmt.Errorf(L("install %s before running this command"), f.Compress)
+		}
+	default:
+		return fmt.Errorf(L("unsupported compression: %s"), f.Compress)
+	}
+
+	if f.Passphrase.File != "" && !utils.FileExists(f.Passphrase.File) {
+		return fmt.Errorf(L("passphrase file %s does not exist"), f.Passphrase.File)
+	}
+	if f.IsEncrypted() && !utils.IsInstalled("gpg") {
+		return errors.New(L("install gpg before running this command"))
+	}
+	return nil
+}
+
+// encodeCommands returns the commands to pipe the artifact through to compress and encrypt it.
+func (f *ArtifactFlags) encodeCommands() [][]string {
+	commands := [][]string{}
+	switch f.Compress {
+	case CompressionGzip:
+		commands = append(commands, []string{"gzip", "-c"})
+	case CompressionZstd:
+		commands = append(commands, []string{"zstd", "-q", "-c", "-T0"})
+	}
+
+	if f.IsEncrypted() {
+		gpgCommand := []string{"gpg", "--batch", "--yes", "--quiet", "--output", "-"}
+		if len(f.Encrypt.Recipient) > 0 {
+			gpgCommand = append(gpgCommand, "--encrypt", "--trust-model", "always")
+			for _, recipient := range f.Encrypt.Recipient {
+				gpgCommand = append(gpgCommand, "--recipient", recipient)
+			}
+		}
+		if f.Passphrase.File != "" {
+			gpgCommand = append(gpgCommand, "--symmetric", "--cipher-algo", "AES256",
+				"--pinentry-mode", "loopback", "--passphrase-file", f.Passphrase.File)
+		}
+		commands = append(commands, gpgCommand)
+	}
+	return commands
+}
+
+// decodeCommands returns the commands to pipe an artifact through to decrypt and decompress it.
+// The commands are computed from the suffixes of the artifact file name.
+func (f *ArtifactFlags) decodeCommands(file string) [][]string {
+	commands := [][]string{}
+	name := file
+	for {
+		switch {
+		case strings.HasSuffix(name, gpgSuffix):
+			gpgCommand := []string{"gpg", "--batch", "--quiet", "--decrypt"}
+			if f.Passphrase.File != "" {
+				gpgCommand = append(gpgCommand, "--pinentry-mode", "loopback", "--passphrase-file", f.Passphrase.File)
+			}
+			commands = append(commands, gpgCommand)
+			name = strings.TrimSuffix(name, gpgSuffix)
+		case strings.HasSuffix(name, zstdSuffix):
+			commands = append(commands, []string{"zstd", "-q", "-d", "-c"})
+			name = strings.TrimSuffix(name, zstdSuffix)
+		case strings.HasSuffix(name, gzipSuffix):
+			commands = append(commands, []string{"gzip", "-d", "-c"})
+			name = strings.TrimSuffix(name, gzipSuffix)
+		default:
+			return commands
+		}
+	}
+}
+
+// TrimArtifactSuffix removes the compression and encryption suffixes from the artifact file name.
+func TrimArtifactSuffix(file string) string {
+	for {
+		trimmed := strings.TrimSuffix(file, gpgSuffix)
+		trimmed = strings.TrimSuffix(trimmed, zstdSuffix)
+		trimmed = strings.TrimSuffix(trimmed, gzipSuffix)
+		if trimmed == file {
+			return file
+		}
+		file = trimmed
+	}
+}
+
+// IsTarArtifact returns true if the file is a possibly compressed or encrypted tarball.
+func IsTarArtifact(file string) bool {
+	return strings.HasSuffix(TrimArtifactSuffix(file), tarSuffix)
+}
+
+// ArtifactBaseName returns the name of the backed up item from the artifact path.
+//
+// For example /backup/volumes/var-pgsql.tar.zst.gpg gives var-pgsql.
+func ArtifactBaseName(file string) string {
+	return strings.TrimSuffix(TrimArtifactSuffix(path.Base(file)), tarSuffix)
+}
+
+// FindArtifact looks for the artifact with the base name in a directory of the target, whatever its suffixes.
+// The path of the artifact relative to the target is returned,
+// or an empty string if no such artifact was found.
+func FindArtifact(target Target, directory string, name string) string {
+	entries, err := target.List(directory)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.IsDir || strings.HasSuffix(entry.Name, ChecksumSuffix) {
+			continue
+		}
+		if TrimArtifactSuffix(entry.Name) == name {
+			return path.Join(directory, entry.Name)
+		}
+	}
+	return ""
+}
+
+// ReadChecksum reads the checksum stored next to an artifact of the target.
+func ReadChecksum(target Target, file string) (string, error) {
+	reader, err := target.Open(file + ChecksumSuffix)
+	if err != nil {
+		return "", utils.Errorf(err, L("Failed to read checksum of the file %[1]s"), file)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", utils.Errorf(err, L("Failed to read checksum of the file %[1]s"), file)
+	}
+	// Split by space to work with checksums created by sha256sum
+	checksum, _, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	if checksum == "" {
+		return "", fmt.Errorf(L("Failed to read checksum of the file %[1]s"), file)
+	}
+	return checksum, nil
+}
+
+// ValidateArtifact checks the integrity of an artifact of the target against its stored checksum.
+func ValidateArtifact(target Target, file string) error {
+	expected, err := ReadChecksum(target, file)
+	if err != nil {
+		return err
+	}
+	checksum, err := computeChecksum(target, file)
+	if err != nil {
+		return err
+	}
+	if checksum != expected {
+		return fmt.Errorf(L("Checksum of %s does not match"), file)
+	}
+	return nil
+}
+
+// computeChecksum reads a file of the target to compute its SHA256 checksum.
+func computeChecksum(target Target, file string) (string, error) {
+	reader, err := target.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	checksum, err := utils.ComputeReaderChecksum(reader)
+	if err != nil {
+		return "", utils.Errorf(err, L("Failed to calculate checksum of the file %s"), file)
+	}
+	return checksum, nil
+}
+
+// pipeline is a chain of commands, each one reading the output of the previous one.
+type pipeline struct {
+	cmds    []*exec.Cmd
+	stderrs []*bytes.Buffer
+	closers []io.Closer
+}
+
+func newPipeline(commands [][]string, input io.Reader, output io.Writer) (*pipeline, error) {
+	p := pipeline{}
+	var previous io.Reader = input
+	for i, command := range commands {
+		cmd := exec.Command(command[0], command[1:]...)
+		stderr := bytes.Buffer{}
+		cmd.Stderr = &stderr
+		cmd.Stdin = previous
+
+		if i == len(commands)-1 {
+			cmd.Stdout = output
+		} else {
+			reader, writer, err := os.Pipe()
+			if err != nil {
+				p.closeAll()
+				return nil, err
+			}
+			cmd.Stdout = writer
+			previous = reader
+			p.closers = append(p.closers, reader, writer)
+		}
+		p.cmds = append(p.cmds, cmd)
+		p.stderrs = append(p.stderrs, &stderr)
+	}
+	return &p, nil
+}
+
+func (p *pipeline) start() error {
+	for _, cmd := range p.cmds {
+		log.Debug().Msgf("Running: %s", strings.Join(cmd.Args, " "))
+		if err := cmd.Start(); err != nil {
+			p.kill()
+			p.closeAll()
+			return err
+		}
+	}
+	// The pipe ends are now owned by the child processes
+	p.closeAll()
+	return nil
+}
+
+func (p *pipeline) wait() error {
+	var hasError error
+	for i, cmd := range p.cmds {
+		if err := cmd.Wait(); err != nil {
+			message := strings.TrimSpace(p.stderrs[i].String())
+			if message != "" {
+				err = errors.New(message)
+			}
+			hasError = utils.JoinErrors(hasError, utils.Errorf(err, L("%s failed"), cmd.Args[0]))
+		}
+	}
+	return hasError
+}
+
+// kill stops all the commands of the pipeline, for instance when the output is not needed anymore.
+func (p *pipeline) kill() {
+	for _, cmd := range p.cmds {
+		if cmd.Process != nil {
+			_ = cmd.Process.Kill()
+		}
+	}
+}
+
+func (p *pipeline) closeAll() {
+	for _, closer := range p.closers {
+		closer.Close()
+	}
+	p.closers = nil
+}
+
+// Artifact describes a file written to a backup target.
+type Artifact struct {
+	// File is the path of the artifact relative to the target.
+	File     string
+	Size     int64
+	Checksum string
+}
+
+// ArtifactWriter compresses and encrypts all what is written to it into a file of a backup target.
+// The checksum of the file is computed while writing, the file is never read back.
+type ArtifactWriter struct {
+	io.Writer
+	target   Target
+	artifact Artifact
+	output   TargetWriter
+	hashed   *utils.HashingWriter
+	input    io.Closer
+	pipeline *pipeline
+}
+
+// Close flushes the data, waits for the encoding commands to finish, closes the file and stores its checksum.
+// The file is discarded if the encoding commands failed.
+func (w *ArtifactWriter) Close() error {
+	if w.pipeline != nil {
+		if err := utils.JoinErrors(w.input.Close(), w.pipeline.wait()); err != nil {
+			return utils.Errorf(utils.JoinErrors(err, w.output.Abort()), L("failed to write %s"), w.artifact.File)
+		}
+	}
+	if err := w.output.Close(); err != nil {
+		return utils.Errorf(err, L("failed to write %s"), w.artifact.File)
+	}
+
+	w.artifact.Size = w.hashed.Size()
+	w.artifact.Checksum = w.hashed.Checksum()
+	checksumFile := w.artifact.File + ChecksumSuffix
+	checksumWriter, err := w.target.Create(checksumFile)
+	if err == nil {
+		_, err = checksumWriter.Write([]byte(w.artifact.Checksum))
+		err = utils.JoinErrors(err, checksumWriter.Close())
+	}
+	if err != nil {
+		return utils.Errorf(err, L("Failed to write checksum of the file %[1]s to the %[2]s"),
+			w.artifact.File, checksumFile)
+	}
+	return nil
+}
+
+// Abort stops the encoding commands and discards the partially written file, without checksum.
+func (w *ArtifactWriter) Abort() error {
+	if w.pipeline != nil {
+		_ = w.input.Close()
+		w.pipeline.kill()
+		_ = w.pipeline.wait()
+	}
+	if err := w.output.Abort(); err != nil {
+		return utils.Errorf(err, L("failed to remove %s"), w.artifact.File)
+	}
+	return nil
+}
+
+// Finish closes the writer if err is nil or aborts it otherwise.
+// Returns err joined with the closing error.
+func (w *ArtifactWriter) Finish(err error) error {
+	if err != nil {
+		return utils.JoinErrors(err, w.Abort())
+	}
+	return w.Close()
+}
+
+// Artifact returns the description of the written file.
+// The size and checksum are only valid after a successful Close.
+func (w *ArtifactWriter) Artifact() Artifact {
+	return w.artifact
+}
+
+// CreateArtifact creates an artifact file in the target, compressing and encrypting it as set in the flags.
+// The suffixes corresponding to compression and encryption are added to the name.
+//
+// The returned writer needs to be closed to ensure all the data are written.
+func CreateArtifact(target Target, name string, flags *ArtifactFlags) (*ArtifactWriter, error) {
+	name += flags.Suffix()
+	output, err := target.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	hashed := utils.NewHashingWriter(output)
+	w := &ArtifactWriter{Writer: hashed, target: target, artifact: Artifact{File: name}, output: output, hashed: hashed}
+
+	commands := flags.encodeCommands()
+	if len(commands) == 0 {
+		return w, nil
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, utils.JoinErrors(err, output.Abort())
+	}
+	p, err := newPipeline(commands, reader, hashed)
+	if err == nil {
+		err = p.start()
+	}
+	reader.Close()
+	if err != nil {
+		writer.Close()
+		return nil, utils.JoinErrors(utils.Errorf(err, L("failed to prepare %s"), name), output.Abort())
+	}
+	w.Writer = writer
+	w.input = writer
+	w.pipeline = p
+	return w, nil
+}
+
+// ExportArtifact writes the output of command to an artifact file of the target and stores its checksum.
+// The output is streamed to the target without being stored on the local disk.
+// If dryRun is set to true, only messages will be logged to explain what would happen.
+func ExportArtifact(
+	target Target,
+	name string,
+	flags *ArtifactFlags,
+	dryRun bool,
+	command ...string,
+) (Artifact, error) {
+	return ExportArtifactWithProgress(target, name, flags, dryRun, nil, command...)
+}
+
+// ExportArtifactWithProgress works like ExportArtifact and also copies the output of command to progress.
+func ExportArtifactWithProgress(
+	target Target,
+	name string,
+	flags *ArtifactFlags,
+	dryRun bool,
+	progress io.Writer,
+	command ...string,
+) (Artifact, error) {
+	if dryRun {
+		log.Info().Msgf(L("Would run %[1]s into %[2]s"), strings.Join(command, " "),
+			JoinLocation(target.String(), name+flags.Suffix()))
+		return Artifact{File: name + flags.Suffix()}, nil
+	}
+
+	writer, err := CreateArtifact(target, name, flags)
+	if err != nil {
+		return Artifact{File: name + flags.Suffix()}, err
+	}
+
+	log.Info().Msgf(L("Run %s"), strings.Join(command, " "))
+	cmd := exec.Command(command[0], command[1:]...)
+	stderr := bytes.Buffer{}
+	cmd.Stdout = writer
+	if progress != nil {
+		cmd.Stdout = io.MultiWriter(writer, progress)
+	}
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if message := strings.TrimSpace(stderr.String()); err != nil && message != "" {
+		err = errors.New(message)
+	}
+	// The size and checksum are only known once closed
+	err = writer.Finish(err)
+	return writer.Artifact(), err
+}
+
+// artifactReader decrypts and decompresses an artifact file.
+type artifactReader struct {
+	io.Reader
+	file     string
+	input    io.Closer
+	hashed   *utils.HashingReader
+	checksum string
+	output   io.Closer
+	pipeline *pipeline
+}
+
+// Close waits for the decoding commands to finish and closes the file.
+// The remaining data are read to let the decoding commands terminate properly
+// and to verify the checksum of the whole file.
+func (r *artifactReader) Close() error {
+	var hasError error
+	if r.pipeline != nil || r.checksum != "" {
+		_, hasError = io.Copy(io.Discard, r.Reader)
+	}
+	if r.pipeline != nil {
+		hasError = utils.JoinErrors(hasError, r.output.Close())
+		hasError = utils.JoinErrors(hasError, r.pipeline.wait())
+	}
+	hasError = utils.JoinErrors(hasError, r.input.Close())
+	if hasError == nil && r.checksum != "" && r.hashed.Checksum() != r.checksum {
+		hasError = fmt.Errorf(L("Checksum of %s does not match"), r.file)
+	}
+	return hasError
+}
+
+// OpenArtifact opens an artifact file of the target, decrypting and decompressing it based on its suffixes.
+// If checksum is not empty, the file content is verified against it when closing the reader.
+//
+// The returned reader needs to be closed to catch the decoding and verification errors.
+func OpenArtifact(target Target, file string, flags *ArtifactFlags, checksum string) (io.ReadCloser, error) {
+	return openArtifact(target, file, flags, checksum, nil)
+}
+
+// openArtifact works like OpenArtifact and also copies the raw content of the file to progress.
+func openArtifact(
+	target Target,
+	file string,
+	flags *ArtifactFlags,
+	checksum string,
+	progress io.Writer,
+) (io.ReadCloser, error) {
+	input, err := target.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	var raw io.Reader = input
+	if progress != nil {
+		raw = io.TeeReader(input, progress)
+	}
+	hashed := utils.NewHashingReader(raw)
+	r := &artifactReader{Reader: hashed, file: file, input: input, hashed: hashed, checksum: checksum}
+
+	commands := flags.decodeCommands(file)
+	if len(commands) == 0 {
+		return r, nil
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		input.Close()
+		return nil, err
+	}
+	p, err := newPipeline(commands, hashed, writer)
+	if err == nil {
+		err = p.start()
+	}
+	writer.Close()
+	if err != nil {
+		reader.Close()
+		input.Close()
+		return nil, utils.Errorf(err, L("failed to decode %s"), file)
+	}
+	r.Reader = reader
+	r.output = reader
+	r.pipeline = p
+	return r, nil
+}
+
+// ImportArtifact feeds the decoded content of the artifact file of the target to the input of command.
+// If checksum is not empty, the artifact content is verified against it.
+// If dryRun is set to true, only messages will be logged to explain what would happen.
+func ImportArtifact(
+	target Target,
+	file string,
+	flags *ArtifactFlags,
+	checksum string,
+	dryRun bool,
+	command ...string,
+) error {
+	return ImportArtifactWithProgress(target, file, flags, checksum, dryRun, nil, command...)
+}
+
+// ImportArtifactWithProgress works like ImportArtifact and also copies the raw content of the file to progress.
+func ImportArtifactWithProgress(
+	target Target,
+	file string,
+	flags *ArtifactFlags,
+	checksum string,
+	dryRun bool,
+	progress io.Writer,
+	command ...string,
+) error {
+	if dryRun {
+		log.Info().Msgf(L("Would run %[1]s from %[2]s"), strings.Join(command, " "),
+			JoinLocation(target.String(), file))
+		return nil
+	}
+	return importArtifact(target, file, flags, checksum, progress, command...)
+}
+
+// StageArtifact decodes the artifact into the output file, verifying its content against checksum if not empty.
+// The output file is removed if the artifact cannot be decoded or verified.
+//
+// This is needed for the commands that cannot undo what they imported if the checksum is wrong.
+func StageArtifact(target Target, file string, flags *ArtifactFlags, checksum string, output string) error {
+	reader, err := OpenArtifact(target, file, flags, checksum)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return utils.JoinErrors(utils.Errorf(err, L("failed to create %s"), output), reader.Close())
+	}
+	_, err = io.Copy(out, reader)
+	if err = utils.JoinErrors(err, reader.Close(), out.Close()); err != nil {
+		return utils.JoinErrors(err, os.Remove(output))
+	}
+	return nil
+}
+
+func importArtifact(
+	target Target,
+	file string,
+	flags *ArtifactFlags,
+	checksum string,
+	progress io.Writer,
+	command ...string,
+) error {
+	reader, err := openArtifact(target, file, flags, checksum, progress)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf(L("Run %s"), strings.Join(command, " "))
+	cmd := exec.Command(command[0], command[1:]...)
+	stderr := bytes.Buffer{}
+	cmd.Stdin = reader
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			err = errors.New(message)
+		}
+		// No need to decode the rest of the artifact
+		if artifact, ok := reader.(*artifactReader); ok && artifact.pipeline != nil {
+			artifact.pipeline.kill()
+		}
+	}
+	return utils.JoinErrors(err, reader.Close())
+}
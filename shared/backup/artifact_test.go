@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+func TestArtifactSuffix(t *testing.T) {
+	data := []struct {
+		compress   string
+		recipients []string
+		passphrase string
+		expected   string
+	}{
+		{"", nil, "", ""},
+		{CompressionNone, nil, "", ""},
+		{CompressionGzip, nil, "", ".gz"},
+		{CompressionZstd, nil, "", ".zst"},
+		{CompressionZstd, []string{"admin@example.com"}, "", ".zst.gpg"},
+		{CompressionNone, nil, "/root/pass", ".gpg"},
+	}
+
+	for i, test := range data {
+		flags := ArtifactFlags{Compress: test.compress}
+		flags.Encrypt.Recipient = test.recipients
+		flags.Passphrase.File = test.passphrase
+		testutils.AssertEquals(t, fmt.Sprintf("Unexpected suffix for case %d", i), test.expected, flags.Suffix())
+	}
+}
+
+func TestArtifactBaseName(t *testing.T) {
+	data := map[string]string{
+		"var-pgsql.tar":                     "var-pgsql",
+		"/backup/volumes/var-pgsql.tar.gz":  "var-pgsql",
+		"/backup/volumes/etc-rhn.tar.zst":   "etc-rhn",
+		"/backup/images/server.tar.zst.gpg": "server",
+		"/backup/images/server.tar.gpg":     "server",
+	}
+
+	for file, expected := range data {
+		testutils.AssertEquals(t, "Unexpected base name for "+file, expected, ArtifactBaseName(file))
+		testutils.AssertTrue(t, file+" should be a tar artifact", IsTarArtifact(file))
+	}
+	testutils.AssertTrue(t, "checksum should not be a tar artifact", !IsTarArtifact("server.tar.gz.sha256sum"))
+}
+
+func TestDecodeCommands(t *testing.T) {
+	flags := ArtifactFlags{}
+	flags.Passphrase.File = "/root/pass"
+
+	commands := flags.decodeCommands("/backup/volumes/var-pgsql.tar.zst.gpg")
+	testutils.AssertEquals(t, "Unexpected number of decode commands", 2, len(commands))
+	testutils.AssertEquals(t, "gpg should decode first", "gpg", commands[0][0])
+	testutils.AssertTrue(t, "passphrase file should be passed to gpg",
+		strings.Contains(strings.Join(commands[0], " "), "--passphrase-file /root/pass"))
+	testutils.AssertEquals(t, "zstd should decode last", "zstd", commands[1][0])
+
+	testutils.AssertEquals(t, "Plain tar should not be decoded", 0, len(flags.decodeCommands("var-pgsql.tar")))
+}
+
+func TestFindArtifact(t *testing.T) {
+	dir := t.TempDir()
+	testutils.WriteFile(t, path.Join(dir, PodmanConfBackupFile+".gz"+ChecksumSuffix), "checksum")
+	testutils.WriteFile(t, path.Join(dir, PodmanConfBackupFile+".gz"), "content")
+
+	target := &localTarget{root: dir}
+	testutils.AssertEquals(t, "Compressed artifact not found",
+		PodmanConfBackupFile+".gz", FindArtifact(target, "", PodmanConfBackupFile))
+	testutils.AssertEquals(t, "Missing artifact should not be found", "", FindArtifact(target, "", SystemdConfBackupFile))
+}
+
+func TestArtifactRoundTrip(t *testing.T) {
+	if !utils.IsInstalled("gzip") || !utils.IsInstalled("gpg") {
+		t.Skip("gzip and gpg are required for this test")
+	}
+	t.Setenv("GNUPGHOME", t.TempDir())
+
+	dir := t.TempDir()
+	passphraseFile := path.Join(dir, "passphrase")
+	testutils.WriteFile(t, passphraseFile, "secret")
+
+	flags := ArtifactFlags{Compress: CompressionGzip}
+	flags.Passphrase.File = passphraseFile
+
+	const content = "some secret content to backup"
+	target := &localTarget{root: path.Join(dir, "backup")}
+	writer, err := CreateArtifact(target, "secrets.tar", &flags)
+	testutils.AssertNoError(t, "failed to create artifact", err)
+	_, err = writer.Write([]byte(content))
+	testutils.AssertNoError(t, "failed to write artifact", err)
+	testutils.AssertNoError(t, "failed to close artifact", writer.Close())
+	artifact := writer.Artifact()
+	testutils.AssertEquals(t, "Unexpected artifact file", "secrets.tar.gz.gpg", artifact.File)
+
+	outputFile := path.Join(dir, "backup", artifact.File)
+	raw, err := os.ReadFile(outputFile)
+	testutils.AssertNoError(t, "failed to read artifact", err)
+	testutils.AssertTrue(t, "artifact should not contain plain data", !strings.Contains(string(raw), content))
+	testutils.AssertEquals(t, "Unexpected artifact size", int64(len(raw)), artifact.Size)
+	testutils.AssertNoError(t, "checksum computed while writing should be valid", utils.ValidateChecksum(outputFile))
+
+	reader, err := OpenArtifact(target, artifact.File, &flags, artifact.Checksum)
+	testutils.AssertNoError(t, "failed to open artifact", err)
+	decoded, err := io.ReadAll(reader)
+	testutils.AssertNoError(t, "failed to read decoded artifact", err)
+	testutils.AssertNoError(t, "failed to close decoded artifact", reader.Close())
+	testutils.AssertEquals(t, "Unexpected decoded content", content, string(decoded))
+}
+
+func TestArtifactAbort(t *testing.T) {
+	if !utils.IsInstalled("gzip") {
+		t.Skip("gzip is required for this test")
+	}
+	for _, flags := range []ArtifactFlags{{}, {Compress: CompressionGzip}} {
+		dir := t.TempDir()
+		target := &localTarget{root: dir}
+		writer, err := CreateArtifact(target, "secrets.tar", &flags)
+		testutils.AssertNoError(t, "failed to create artifact", err)
+		_, err = writer.Write([]byte("truncated content"))
+		testutils.AssertNoError(t, "failed to write artifact", err)
+
+		err = writer.Finish(errors.New("command failed"))
+		testutils.AssertError(t, "command failed", err)
+		entries, err := os.ReadDir(dir)
+		testutils.AssertNoError(t, "failed to list the target", err)
+		testutils.AssertEquals(t, "Aborted artifact and its checksum should not be stored", 0, len(entries))
+	}
+}
+
+func TestStageArtifact(t *testing.T) {
+	dir := t.TempDir()
+	testutils.WriteFile(t, path.Join(dir, "server.tar"), "image content")
+	checksum, err := utils.ComputeChecksum(path.Join(dir, "server.tar"))
+	testutils.AssertNoError(t, "failed to compute checksum", err)
+	target := &localTarget{root: dir}
+
+	staged := path.Join(dir, "staged.tar")
+	err = StageArtifact(target, "server.tar", &ArtifactFlags{}, "0123", staged)
+	testutils.AssertError(t, "does not match", err)
+	testutils.AssertTrue(t, "Unverified staged file should be removed", !utils.FileExists(staged))
+
+	testutils.AssertNoError(t, "failed to stage artifact",
+		StageArtifact(target, "server.tar", &ArtifactFlags{}, checksum, staged))
+	testutils.AssertEquals(t, "Unexpected staged content", "image content", testutils.ReadFile(t, staged))
+}
+
+func TestOpenArtifactChecksumMismatch(t *testing.T) {
+	dir := t.TempDir()
+	testutils.WriteFile(t, path.Join(dir, "secrets.tar"), "content")
+	target := &localTarget{root: dir}
+
+	reader, err := OpenArtifact(target, "secrets.tar", &ArtifactFlags{}, "0123")
+	testutils.AssertNoError(t, "failed to open artifact", err)
+	testutils.AssertError(t, "does not match", reader.Close())
+}
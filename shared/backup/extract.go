@@ -0,0 +1,243 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// WalkTarArtifact decodes a tarball artifact of the target and calls walk for each of its entries.
+// The content reader passed to walk is only valid until it returns.
+// If checksum is not empty, the artifact content is verified against it.
+// The raw content of the artifact is copied to progress if not nil.
+func WalkTarArtifact(
+	target Target,
+	file string,
+	flags *ArtifactFlags,
+	checksum string,
+	progress io.Writer,
+	walk func(header *tar.Header, content io.Reader) error,
+) error {
+	reader, err := openArtifact(target, file, flags, checksum, progress)
+	if err != nil {
+		return err
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return utils.JoinErrors(utils.Errorf(err, L("failed to read %s"), file), reader.Close())
+		}
+		if err := walk(header, tarReader); err != nil {
+			return utils.JoinErrors(err, reader.Close())
+		}
+	}
+	return reader.Close()
+}
+
+// TarEntryName returns the path of a tar entry relative to the archive root, without leading ./ or /.
+// The root entry itself has an empty name.
+func TarEntryName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// MatchesInclude returns true if the tar entry name or one of its parent directories matches one of the patterns.
+// The patterns use the path.Match syntax and are relative to the archive root. No pattern matches everything.
+func MatchesInclude(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	name = TarEntryName(name)
+	for _, pattern := range patterns {
+		pattern = TarEntryName(pattern)
+		for current := name; current != "." && current != ""; current = path.Dir(current) {
+			if matched, _ := path.Match(pattern, current); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExtractTarEntry writes a tar entry in the root directory, keeping its permissions, owner and modification time.
+// Existing files are overwritten.
+func ExtractTarEntry(root string, header *tar.Header, content io.Reader) error {
+	name := TarEntryName(header.Name)
+	if name == "" {
+		return nil
+	}
+	if err := checkNoSymlinkParent(root, name); err != nil {
+		return err
+	}
+	dest := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	mode := header.FileInfo().Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	switch header.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			return err
+		}
+	case tar.TypeReg:
+		if err := removeExisting(dest); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(file, content)
+		if err = utils.JoinErrors(err, file.Close()); err != nil {
+			return utils.Errorf(err, L("failed to write %s"), dest)
+		}
+	case tar.TypeSymlink:
+		if err := removeExisting(dest); err != nil {
+			return err
+		}
+		return utils.JoinErrors(os.Symlink(header.Linkname, dest), os.Lchown(dest, header.Uid, header.Gid))
+	case tar.TypeLink:
+		linkName := TarEntryName(header.Linkname)
+		if err := checkNoSymlinkParent(root, linkName); err != nil {
+			return err
+		}
+		if err := removeExisting(dest); err != nil {
+			return err
+		}
+		return os.Link(filepath.Join(root, linkName), dest)
+	default:
+		log.Debug().Msgf("Skipping %s: unsupported tar entry type %c", name, header.Typeflag)
+		return nil
+	}
+
+	// chown resets the setuid and setgid bits, change the mode after it
+	if err := os.Lchown(dest, header.Uid, header.Gid); err != nil {
+		return err
+	}
+	if err := os.Chmod(dest, mode); err != nil {
+		return err
+	}
+	return os.Chtimes(dest, time.Now(), header.ModTime)
+}
+
+// stagingSuffix is appended to a directory to get its staging one.
+const stagingSuffix = ".partial"
+
+// PrepareStagingDir creates an empty directory next to targetPath to extract files before moving them into it.
+// The staging directory gets the permissions and owner of targetPath for them to be kept when moving the files.
+func PrepareStagingDir(targetPath string) (string, error) {
+	staging := targetPath + stagingSuffix
+	if err := os.RemoveAll(staging); err != nil {
+		return "", utils.Errorf(err, L("failed to remove %s"), staging)
+	}
+	if err := os.Mkdir(staging, 0700); err != nil {
+		return "", utils.Errorf(err, L("failed to create %s directory"), staging)
+	}
+	if err := copyDirMetadata(targetPath, staging); err != nil {
+		return "", utils.JoinErrors(err, os.RemoveAll(staging))
+	}
+	return staging, nil
+}
+
+// MoveStagedFiles moves the files extracted and verified in the staging directory into the target one.
+// Like when extracting a tarball, the existing files are replaced and the other ones are kept.
+// Both directories need to be on the same file system.
+func MoveStagedFiles(staging string, targetPath string) error {
+	return filepath.WalkDir(staging, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(staging, file)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(targetPath, relative)
+		if !entry.IsDir() {
+			return os.Rename(file, dest)
+		}
+
+		info, err := os.Lstat(dest)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err == nil && info.IsDir() {
+			// Merge into the existing directory and take the staged one permissions and owner
+			return copyDirMetadata(file, dest)
+		}
+		if err == nil {
+			if err := os.Remove(dest); err != nil {
+				return err
+			}
+		}
+		if err := os.Rename(file, dest); err != nil {
+			return err
+		}
+		return fs.SkipDir
+	})
+}
+
+func copyDirMetadata(source string, dest string) error {
+	info, err := os.Lstat(source)
+	if err != nil {
+		return err
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		if err := os.Lchown(dest, int(stat.Uid), int(stat.Gid)); err != nil {
+			return err
+		}
+	}
+	if err := os.Chmod(dest, info.Mode()&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+		return err
+	}
+	return os.Chtimes(dest, time.Now(), info.ModTime())
+}
+
+// checkNoSymlinkParent ensures that no parent directory of name in root is a symbolic link.
+// This prevents an archive from writing outside of the root directory.
+func checkNoSymlinkParent(root string, name string) error {
+	current := root
+	parts := strings.Split(name, "/")
+	for _, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf(L("refusing to extract %[1]s: %[2]s is a symbolic link"), name, current)
+		}
+	}
+	return nil
+}
+
+// removeExisting removes a file or an empty directory if it exists.
+func removeExisting(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestMatchesInclude(t *testing.T) {
+	data := []struct {
+		name     string
+		patterns []string
+		expected bool
+	}{
+		{"./rhn.conf", nil, true},
+		{"./rhn.conf", []string{"rhn.conf"}, true},
+		{"rhn.conf", []string{"./rhn.conf"}, true},
+		{"./spacewalk/rhn.conf", []string{"rhn.conf"}, false},
+		{"./salt/states/top.sls", []string{"salt/states"}, true},
+		{"./salt/states/top.sls", []string{"salt/*/top.sls"}, true},
+		{"./salt/pillar/top.sls", []string{"salt/states", "*.conf"}, false},
+		{"./", []string{"rhn.conf"}, false},
+	}
+
+	for _, test := range data {
+		testutils.AssertEquals(t, "Unexpected match for "+test.name, test.expected,
+			MatchesInclude(test.name, test.patterns))
+	}
+}
+
+// writeTestTarball writes a tarball with the entries into the target and returns its path.
+func writeTestTarball(t *testing.T, dir string, name string, headers []*tar.Header, contents []string) string {
+	buffer := bytes.Buffer{}
+	writer := tar.NewWriter(&buffer)
+	for i, header := range headers {
+		testutils.AssertNoError(t, "failed to write tar header", writer.WriteHeader(header))
+		_, err := writer.Write([]byte(contents[i]))
+		testutils.AssertNoError(t, "failed to write tar content", err)
+	}
+	testutils.AssertNoError(t, "failed to close tar", writer.Close())
+	testutils.AssertNoError(t, "failed to create tar directory", os.MkdirAll(path.Join(dir, path.Dir(name)), 0700))
+	testutils.AssertNoError(t, "failed to write tarball", os.WriteFile(path.Join(dir, name), buffer.Bytes(), 0600))
+	return name
+}
+
+func TestWalkAndExtractTarArtifact(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	uid, gid := os.Getuid(), os.Getgid()
+	headers := []*tar.Header{
+		{Name: "./", Typeflag: tar.TypeDir, Mode: 0755, Uid: uid, Gid: gid, ModTime: modTime},
+		{Name: "./rhn.conf", Typeflag: tar.TypeReg, Mode: 0640, Size: 12, Uid: uid, Gid: gid, ModTime: modTime},
+		{Name: "./spacewalk/", Typeflag: tar.TypeDir, Mode: 0755, Uid: uid, Gid: gid, ModTime: modTime},
+		{Name: "./spacewalk/other.conf", Typeflag: tar.TypeReg, Mode: 0644, Size: 5, Uid: uid, Gid: gid,
+			ModTime: modTime},
+	}
+	contents := []string{"", "db_name = x\n", "", "other"}
+	file := writeTestTarball(t, dir, "volumes/etc-rhn.tar", headers, contents)
+
+	target := &localTarget{root: dir}
+	output := path.Join(dir, "output")
+	progress := &bytes.Buffer{}
+	extract := func(header *tar.Header, content io.Reader) error {
+		if !MatchesInclude(header.Name, []string{"rhn.conf"}) {
+			return nil
+		}
+		return ExtractTarEntry(output, header, content)
+	}
+	err := WalkTarArtifact(target, file, &ArtifactFlags{}, "", progress, extract)
+	testutils.AssertNoError(t, "failed to extract", err)
+	testutils.AssertTrue(t, "progress should count the read bytes", progress.Len() > 0)
+
+	info, err := os.Stat(path.Join(output, "rhn.conf"))
+	testutils.AssertNoError(t, "rhn.conf should be extracted", err)
+	testutils.AssertEquals(t, "Unexpected mode", os.FileMode(0640), info.Mode().Perm())
+	testutils.AssertTrue(t, "Unexpected modification time", info.ModTime().Equal(modTime))
+	content, err := os.ReadFile(path.Join(output, "rhn.conf"))
+	testutils.AssertNoError(t, "failed to read rhn.conf", err)
+	testutils.AssertEquals(t, "Unexpected content", "db_name = x\n", string(content))
+
+	_, err = os.Stat(path.Join(output, "spacewalk"))
+	testutils.AssertTrue(t, "Not included files should not be extracted", os.IsNotExist(err))
+}
+
+func TestExtractTarEntryRefusesSymlinkParent(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	testutils.AssertNoError(t, "failed to create symlink", os.Symlink(outside, path.Join(root, "link")))
+
+	header := &tar.Header{Name: "./link/passwd", Typeflag: tar.TypeReg, Mode: 0644, Size: 1}
+	err := ExtractTarEntry(root, header, bytes.NewBufferString("x"))
+	testutils.AssertError(t, "is a symbolic link", err)
+
+	_, err = os.Stat(path.Join(outside, "passwd"))
+	testutils.AssertTrue(t, "No file should be written outside of the root", os.IsNotExist(err))
+}
+
+func TestMoveStagedFiles(t *testing.T) {
+	staging := t.TempDir()
+	targetPath := t.TempDir()
+	for _, dir := range []string{path.Join(targetPath, "spacewalk"), path.Join(staging, "spacewalk"),
+		path.Join(staging, "salt")} {
+		testutils.AssertNoError(t, "failed to create "+dir, os.MkdirAll(dir, 0755))
+	}
+	testutils.WriteFile(t, path.Join(targetPath, "kept.conf"), "kept")
+	testutils.WriteFile(t, path.Join(targetPath, "rhn.conf"), "old")
+	testutils.WriteFile(t, path.Join(targetPath, "spacewalk", "old.conf"), "old")
+	testutils.WriteFile(t, path.Join(staging, "rhn.conf"), "new")
+	testutils.WriteFile(t, path.Join(staging, "spacewalk", "other.conf"), "other")
+	testutils.WriteFile(t, path.Join(staging, "salt", "top.sls"), "top")
+
+	testutils.AssertNoError(t, "failed to move the staged files", MoveStagedFiles(staging, targetPath))
+
+	expected := map[string]string{
+		"kept.conf":            "kept",
+		"rhn.conf":             "new",
+		"spacewalk/old.conf":   "old",
+		"spacewalk/other.conf": "other",
+		"salt/top.sls":         "top",
+	}
+	for name, value := range expected {
+		content, err := os.ReadFile(path.Join(targetPath, name))
+		testutils.AssertNoError(t, "failed to read "+name, err)
+		testutils.AssertEquals(t, "Unexpected content of "+name, value, string(content))
+	}
+}
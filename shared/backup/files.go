@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"archive/tar"
+	"encoding/csv"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// SystemdServiceFiles returns the service file of a systemd service, its drop-in directory and files
+// and its environment file if any.
+func SystemdServiceFiles(systemd podman.Systemd, serviceName string) []string {
+	result := []string{}
+	servicePath, err := systemd.GetServiceProperty(serviceName, podman.FragmentPath)
+	if err != nil {
+		log.Debug().Err(err).Msgf("failed to get the path to the %s service file", serviceName)
+		// Skipping the dropins since we would likely get a similar error.
+		return result
+	}
+	result = append(result, servicePath)
+
+	// Get the drop in files
+	dropIns, err := systemd.GetServiceProperty(serviceName, podman.DropInPaths)
+	if err != nil {
+		log.Debug().Err(err).Msgf("failed to get the path to the %s service configuration files", serviceName)
+	} else {
+		r := csv.NewReader(strings.NewReader(dropIns))
+		r.Comma = ' '
+		dropIns, err := r.Read()
+		if err != nil {
+			log.Debug().Err(err).Msgf("failed to parse the drop-in paths for %s service", serviceName)
+		}
+		if len(dropIns) > 0 {
+			result = append(result, filepath.Dir(dropIns[0]))
+			result = append(result, dropIns[:]...)
+		}
+	}
+
+	// Get the environment file
+	envFile := path.Join(servicePath+".d", podman.ServerEnvironmentFile)
+	if _, err := os.Stat(envFile); err == nil {
+		result = append(result, envFile)
+	}
+	return result
+}
+
+// WriteFilesTar adds the files and directories to the tarball, keeping their absolute path.
+// The content of the directories is not added: list the files to backup too.
+func WriteFilesTar(tw *tar.Writer, files []string) error {
+	for _, fileToBackup := range files {
+		if err := writeFileTar(tw, fileToBackup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFileTar(tw *tar.Writer, fileToBackup string) error {
+	f, err := os.Open(fileToBackup)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fstat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	h, err := tar.FileInfoHeader(fstat, "")
+	if err != nil {
+		return err
+	}
+	// Produced header does not have full path, overwrite it
+	h.Name = fileToBackup
+	if fstat.IsDir() {
+		h.Name += "/"
+	}
+	if err := tw.WriteHeader(h); err != nil {
+		return err
+	}
+	if fstat.IsDir() {
+		return nil
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// RestoreFilesTar writes the files and directories of a tarball created by WriteFilesTar at their path.
+// If dryRun is true, only the files that would be restored are logged.
+func RestoreFilesTar(tr *tar.Reader, dryRun bool) (hasError error) {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			log.Info().Msgf(L("Would restore %s"), header.Name)
+			continue
+		}
+
+		log.Debug().Msgf("Restoring file %s", header.Name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(header.Name, header.FileInfo().Mode()); err != nil {
+				log.Warn().Msgf(L("Unable to create directory %s"), header.Name)
+				hasError = utils.JoinErrors(hasError, err)
+				continue
+			}
+		case tar.TypeReg:
+			if err := restoreFile(header, tr); err != nil {
+				hasError = utils.JoinErrors(hasError, err)
+				continue
+			}
+		default:
+			log.Warn().Msgf(L("Unknown filetype of %s"), header.Name)
+			continue
+		}
+
+		if err := restoreFileAttributes(header.Name, header); err != nil {
+			log.Warn().Err(err).Msgf(L("Unable to restore file details for %s"), header.Name)
+			hasError = utils.JoinErrors(hasError, err)
+		}
+	}
+	return hasError
+}
+
+func restoreFile(header *tar.Header, tr *tar.Reader) error {
+	// Backups may not package directories, so be sure they are present
+	if err := os.MkdirAll(filepath.Dir(header.Name), 0750); err != nil {
+		log.Warn().Msgf(L("Unable to create directories for %s"), header.Name)
+	}
+	fh, err := os.Create(header.Name)
+	if err != nil {
+		log.Warn().Err(err).Msgf(L("Unable to create %s"), header.Name)
+		return err
+	}
+	if _, err := io.Copy(fh, tr); err != nil {
+		log.Warn().Err(err).Msgf(L("Unable to restore content of %s"), header.Name)
+		fh.Close()
+		os.Remove(header.Name)
+		return err
+	}
+	fh.Close()
+	return nil
+}
+
+func restoreFileAttributes(filename string, th *tar.Header) error {
+	var e error
+	e = utils.JoinErrors(e, os.Chmod(filename, th.FileInfo().Mode()))
+	e = utils.JoinErrors(e, os.Chown(filename, th.Uid, th.Gid))
+	e = utils.JoinErrors(e, os.Chtimes(filename, th.AccessTime, th.ModTime))
+	return e
+}
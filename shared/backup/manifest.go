@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// ManifestFile is the name of the file describing the content of a backup.
+const ManifestFile = "manifest.json"
+
+// ManifestSignatureFile is the name of the detached signature of the manifest.
+const ManifestSignatureFile = ManifestFile + ".asc"
+
+// Kinds of the backup artifacts.
+const (
+	ArtifactVolume  = "volume"
+	ArtifactImage   = "image"
+	ArtifactPodman  = "podman"
+	ArtifactSystemd = "systemd"
+	ArtifactConfig  = "config"
+)
+
+// Status of the backups.
+const (
+	// BackupComplete is the status of backups containing all the requested data.
+	BackupComplete = "complete"
+	// BackupPartial is the status of backups with the important data, but missing some optional ones.
+	BackupPartial = "partial"
+	// BackupAborted is the status of backups that failed after starting to write data.
+	BackupAborted = "aborted"
+)
+
+// ManifestArtifact describes one file of the backup.
+type ManifestArtifact struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// File is the path of the artifact relative to the backup directory.
+	File     string `json:"file"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"sha256"`
+	// Content lists the items stored in the artifact like the secrets names or the systemd files.
+	Content []string `json:"content,omitempty"`
+}
+
+// Manifest describes the content of a backup.
+type Manifest struct {
+	Created            time.Time          `json:"created"`
+	Status             string             `json:"status"`
+	ToolVersion        string             `json:"toolVersion"`
+	UyuniRelease       string             `json:"uyuniRelease,omitempty"`
+	SuseManagerRelease string             `json:"suseManagerRelease,omitempty"`
+	Compression        string             `json:"compression,omitempty"`
+	Encrypted          bool               `json:"encrypted"`
+	Artifacts          []ManifestArtifact `json:"artifacts"`
+}
+
+// NewManifest creates a manifest for a backup created now.
+func NewManifest(flags *ArtifactFlags) *Manifest {
+	return &Manifest{
+		Created:     time.Now().UTC(),
+		ToolVersion: utils.Version,
+		Compression: flags.Compress,
+		Encrypted:   flags.IsEncrypted(),
+		Artifacts:   []ManifestArtifact{},
+	}
+}
+
+// IsComplete returns true if the backup contains all the requested data.
+func (m *Manifest) IsComplete() bool {
+	return m.Status == BackupComplete
+}
+
+// SetStatus computes the backup status from the error returned by the backup.
+func (m *Manifest) SetStatus(err error) {
+	var backupError *BackupError
+	switch {
+	case err == nil:
+		m.Status = BackupComplete
+	case errors.As(err, &backupError) && backupError.Abort:
+		m.Status = BackupAborted
+	default:
+		m.Status = BackupPartial
+	}
+}
+
+// ServerVersion returns the version of the backed up server.
+func (m *Manifest) ServerVersion() string {
+	if m.SuseManagerRelease != "" {
+		return m.SuseManagerRelease
+	}
+	return m.UyuniRelease
+}
+
+// AddArtifact registers a backup file in the manifest.
+func (m *Manifest) AddArtifact(kind string, name string, artifact Artifact, content []string) {
+	m.Artifacts = append(m.Artifacts, ManifestArtifact{
+		Kind:     kind,
+		Name:     name,
+		File:     artifact.File,
+		Size:     artifact.Size,
+		Checksum: artifact.Checksum,
+		Content:  content,
+	})
+}
+
+// CheckSignKey ensures the backup manifest can be signed with the key.
+func CheckSignKey(signKey string, unsigned bool) error {
+	return utils.CheckSignKey(signKey, unsigned, L("backup manifest"))
+}
+
+// Write stores the manifest in the backup target and signs it if a key is provided.
+func (m *Manifest) Write(target Target, signKey string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return utils.Error(err, L("failed to encode backup manifest"))
+	}
+	if err := writeTargetFile(target, ManifestFile, data); err != nil {
+		return utils.Errorf(err, L("failed to write %s"), JoinLocation(target.String(), ManifestFile))
+	}
+
+	if signKey == "" {
+		log.Info().Msg(L("Backup manifest is not signed as requested"))
+		return nil
+	}
+
+	// gpg needs local files to work on
+	return withLocalFiles(target, []string{ManifestFile}, func(dir string) error {
+		signaturePath := path.Join(dir, ManifestSignatureFile)
+		if err := utils.SignFile(signKey, path.Join(dir, ManifestFile), signaturePath); err != nil {
+			return utils.Error(err, L("failed to sign the backup manifest"))
+		}
+		if _, local := target.LocalPath(ManifestSignatureFile); local {
+			return nil
+		}
+		signature, err := os.ReadFile(signaturePath)
+		if err != nil {
+			return err
+		}
+		return writeTargetFile(target, ManifestSignatureFile, signature)
+	})
+}
+
+// ReadManifest loads the manifest of a backup.
+func ReadManifest(target Target) (*Manifest, error) {
+	manifestPath := JoinLocation(target.String(), ManifestFile)
+	data, err := readTargetFile(target, ManifestFile)
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to read %s"), manifestPath)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, utils.Errorf(err, L("failed to decode %s"), manifestPath)
+	}
+	return &manifest, nil
+}
+
+// IsManifestSigned returns true if the backup has a manifest signature.
+func IsManifestSigned(target Target) bool {
+	return FileExists(target, ManifestSignatureFile)
+}
+
+// VerifyManifestSignature checks the manifest detached signature using the keys of the GPG keyring.
+func VerifyManifestSignature(target Target) error {
+	if !IsManifestSigned(target) {
+		return errors.New(L("backup manifest is not signed"))
+	}
+	return withLocalFiles(target, []string{ManifestFile, ManifestSignatureFile}, func(dir string) error {
+		if err := utils.VerifyFileSignature(path.Join(dir, ManifestFile), path.Join(dir, ManifestSignatureFile)); err != nil {
+			return utils.Error(err, L("invalid backup manifest signature"))
+		}
+		return nil
+	})
+}
+
+// withLocalFiles calls the callback with a local directory containing the files of the target.
+// Local targets are used directly, the files of remote ones are downloaded to a temporary directory.
+func withLocalFiles(target Target, files []string, callback func(dir string) error) error {
+	if dir, local := target.LocalPath(""); local {
+		return callback(dir)
+	}
+
+	tempDir, cleaner, err := utils.TempDir()
+	if err != nil {
+		return err
+	}
+	defer cleaner()
+	for _, file := range files {
+		data, err := readTargetFile(target, file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path.Join(tempDir, file), data, 0600); err != nil {
+			return err
+		}
+	}
+	return callback(tempDir)
+}
+
+func writeTargetFile(target Target, name string, data []byte) error {
+	writer, err := target.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return utils.JoinErrors(err, writer.Abort())
+	}
+	return writer.Close()
+}
+
+func readTargetFile(target Target, name string) ([]byte, error) {
+	reader, err := target.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	return data, utils.JoinErrors(err, reader.Close())
+}
+
+// CheckSize checks that the artifact file exists and has the size written in the manifest.
+func (a *ManifestArtifact) CheckSize(target Target) error {
+	entries, err := target.List(path.Dir(a.File))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir || entry.Name != path.Base(a.File) {
+			continue
+		}
+		if entry.Size != a.Size {
+			return fmt.Errorf(L("%[1]s size is %[2]d instead of %[3]d"), a.File, entry.Size, a.Size)
+		}
+		return nil
+	}
+	return fmt.Errorf(L("%s is missing"), a.File)
+}
+
+// Verify checks that the artifact file matches its manifest description.
+func (a *ManifestArtifact) Verify(target Target) error {
+	if err := a.CheckSize(target); err != nil {
+		return err
+	}
+	checksum, err := computeChecksum(target, a.File)
+	if err != nil {
+		return err
+	}
+	if checksum != a.Checksum {
+		return fmt.Errorf(L("Checksum of %s does not match"), a.File)
+	}
+	return nil
+}
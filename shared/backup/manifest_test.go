@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	backupDir := t.TempDir()
+	target := &localTarget{root: backupDir}
+	writer, err := CreateArtifact(target, path.Join(VolumesSubdir, "etc-rhn.tar"), &ArtifactFlags{})
+	testutils.AssertNoError(t, "failed to create artifact", err)
+	_, err = writer.Write([]byte("volume content"))
+	testutils.AssertNoError(t, "failed to write artifact", err)
+	testutils.AssertNoError(t, "failed to close artifact", writer.Close())
+	volumeFile := path.Join(backupDir, VolumesSubdir, "etc-rhn.tar")
+
+	flags := ArtifactFlags{Compress: CompressionGzip}
+	manifest := NewManifest(&flags)
+	manifest.UyuniRelease = "2026.07"
+	manifest.AddArtifact(ArtifactVolume, "etc-rhn", writer.Artifact(), nil)
+	testutils.AssertNoError(t, "failed to write manifest", manifest.Write(target, ""))
+
+	read, err := ReadManifest(target)
+	testutils.AssertNoError(t, "failed to read manifest", err)
+	testutils.AssertEquals(t, "Unexpected server version", "2026.07", read.ServerVersion())
+	testutils.AssertEquals(t, "Unexpected compression", CompressionGzip, read.Compression)
+	testutils.AssertEquals(t, "Unexpected number of artifacts", 1, len(read.Artifacts))
+	testutils.AssertEquals(t, "Artifact path should be relative",
+		path.Join(VolumesSubdir, "etc-rhn.tar"), read.Artifacts[0].File)
+	testutils.AssertEquals(t, "Unexpected artifact size", int64(len("volume content")), read.Artifacts[0].Size)
+	testutils.AssertTrue(t, "Manifest should not be signed", !IsManifestSigned(target))
+
+	testutils.AssertNoError(t, "valid artifact failed verification", read.Artifacts[0].Verify(target))
+
+	// Same size, different content
+	testutils.WriteFile(t, volumeFile, "VOLUME content")
+	testutils.AssertError(t, "does not match", read.Artifacts[0].Verify(target))
+
+	testutils.AssertNoError(t, "failed to remove artifact", os.Remove(volumeFile))
+	testutils.AssertError(t, "is missing", read.Artifacts[0].Verify(target))
+}
+
+func TestCheckSignKey(t *testing.T) {
+	testutils.AssertError(t, "a GPG key is needed to sign the backup manifest", CheckSignKey("", false))
+	testutils.AssertError(t, "cannot be used together", CheckSignKey("backup@example.com", true))
+	testutils.AssertNoError(t, "explicitly unsigned backups should be accepted", CheckSignKey("", true))
+}
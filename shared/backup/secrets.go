@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/podman"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+var runCmdOutput = utils.RunCmdOutput
+var runCmdInput = utils.RunCmdInput
+
+// podmanSecretsFile is where the podman file driver stores the secrets values.
+var podmanSecretsFile = "/var/lib/containers/storage/secrets/filedriver/secretsdata.json"
+
+// ExportPodmanSecrets returns the JSON encoded podman secrets and their names.
+// Only the secrets accepted by the filter are exported, or all of them if filter is nil.
+func ExportPodmanSecrets(filter func(name string) bool) ([]byte, []string, error) {
+	secretListCommand := []string{"podman", "secret", "ls", "--format", "{{range .}}{{.Name}}:{{.ID}},{{end}}"}
+	output, err := runCmdOutput(zerolog.DebugLevel, secretListCommand[0], secretListCommand[1:]...)
+	if err != nil {
+		log.Warn().Err(err).Msg(L("Failed to export secrets data"))
+		return nil, nil, err
+	}
+
+	type SecretMap struct {
+		Name string
+		ID   string
+	}
+	secretMappings := []SecretMap{}
+	for _, v := range strings.Split(string(output), ",") {
+		tmp := strings.SplitN(v, ":", 2)
+		// Ignore different length, usually last emptry string
+		if len(tmp) == 2 && (filter == nil || filter(tmp[0])) {
+			secretMappings = append(secretMappings, SecretMap{Name: tmp[0], ID: tmp[1]})
+		}
+	}
+
+	// load secretFile as json
+	output, err = os.ReadFile(podmanSecretsFile)
+	if err != nil {
+		log.Warn().Err(err).Msg(L("Failed to read secrets data"))
+		return nil, nil, err
+	}
+
+	var podmanSecrets map[string]string
+	if err := json.Unmarshal(output, &podmanSecrets); err != nil {
+		log.Warn().Err(err).Msg(L("Unable to decode podman secrets"))
+		return nil, nil, err
+	}
+
+	// store id -> secret file in tar ball location
+	backupSecretMap := []BackupSecretMap{}
+	secretNames := []string{}
+	for _, secretMap := range secretMappings {
+		for secretID, secretValue := range podmanSecrets {
+			if secretMap.ID == secretID {
+				backupSecretMap = append(backupSecretMap, BackupSecretMap{Name: secretMap.Name, Secret: secretValue})
+				secretNames = append(secretNames, secretMap.Name)
+			}
+		}
+	}
+	output, err = json.Marshal(backupSecretMap)
+	if err != nil {
+		log.Warn().Err(err).Msg(L("Unable to encode secrets backup"))
+		return nil, nil, err
+	}
+	return output, secretNames, nil
+}
+
+// ParseSecretsData decodes the secrets exported by ExportPodmanSecrets.
+func ParseSecretsData(data []byte) ([]BackupSecretMap, error) {
+	secrets := []BackupSecretMap{}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		log.Warn().Err(err).Msg(L("Unable to decode podman secrets"))
+		return nil, err
+	}
+
+	decodedSecrets := make([]BackupSecretMap, len(secrets))
+	for i, v := range secrets {
+		decoded, err := base64.StdEncoding.DecodeString(v.Secret)
+		if err != nil {
+			log.Warn().Msgf(L("Unable to decode secret %s, using as is"), v.Name)
+		} else {
+			decodedSecrets[i] = BackupSecretMap{
+				Name:   v.Name,
+				Secret: string(decoded[:]),
+			}
+		}
+	}
+	return decodedSecrets, nil
+}
+
+// RestorePodmanSecrets creates the podman secrets exported by ExportPodmanSecrets.
+// The existing secrets are only replaced if force is true.
+func RestorePodmanSecrets(data []byte, force bool) error {
+	secrets, err := ParseSecretsData(data)
+	if err != nil {
+		log.Warn().Msg(L("Failed to decode backed up podman secrets, no secrets were restored"))
+		return err
+	}
+
+	var hasError error
+	log.Info().Msg(L("Restoring podman secrets"))
+	for _, v := range secrets {
+		command := []string{"podman", "secret", "create"}
+		if podman.IsSecretPresent(nil, v.Name) {
+			if !force {
+				log.Error().Msgf(L("Podman secret %s is already present, not restoring unless forced"), v.Name)
+				continue
+			}
+			command = append(command, "--replace")
+		}
+		command = append(command, v.Name, "-")
+		if err := runCmdInput(command[0], v.Secret, command[1:]...); err != nil {
+			log.Error().Msg(L("Unable to create podman secret"))
+			hasError = utils.JoinErrors(hasError, err)
+		}
+	}
+	return hasError
+}
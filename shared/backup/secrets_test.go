@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"encoding/base64"
+	"path"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestExportPodmanSecrets(t *testing.T) {
+	oldRunCmdOutput := runCmdOutput
+	oldSecretsFile := podmanSecretsFile
+	t.Cleanup(func() {
+		runCmdOutput = oldRunCmdOutput
+		podmanSecretsFile = oldSecretsFile
+	})
+	runCmdOutput = func(_ zerolog.Level, _ string, _ ...string) ([]byte, error) {
+		return []byte("uyuni-ca:id1,uyuni-db-pass:id2,"), nil
+	}
+	podmanSecretsFile = path.Join(t.TempDir(), "secretsdata.json")
+	encoded := base64.StdEncoding.EncodeToString([]byte("CA"))
+	testutils.WriteFile(t, podmanSecretsFile, `{"id1": "`+encoded+`", "id2": "cGFzcw=="}`)
+
+	data, names, err := ExportPodmanSecrets(func(name string) bool { return name == "uyuni-ca" })
+	testutils.AssertNoError(t, "failed to export secrets", err)
+	testutils.AssertEquals(t, "Unexpected secret names", []string{"uyuni-ca"}, names)
+
+	secrets, err := ParseSecretsData(data)
+	testutils.AssertNoError(t, "failed to parse secrets", err)
+	testutils.AssertEquals(t, "Unexpected secrets", []BackupSecretMap{{Name: "uyuni-ca", Secret: "CA"}}, secrets)
+}
@@ -0,0 +1,622 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// SFTP version 3 packet types, as described in draft-ietf-secsh-filexfer-02.
+const (
+	sftpInit    = 1
+	sftpVersion = 2
+	sftpOpen    = 3
+	sftpClose   = 4
+	sftpRead    = 5
+	sftpWrite   = 6
+	sftpOpendir = 11
+	sftpReaddir = 12
+	sftpRemove  = 13
+	sftpMkdir   = 14
+	sftpRmdir   = 15
+	sftpStat    = 17
+	sftpStatus  = 101
+	sftpHandle  = 102
+	sftpData    = 103
+	sftpName    = 104
+	sftpAttrs   = 105
+)
+
+// SFTP status codes.
+const (
+	sftpStatusOK         = 0
+	sftpStatusEOF        = 1
+	sftpStatusNoSuchFile = 2
+)
+
+// SFTP open flags.
+const (
+	sftpOpenRead   = 0x01
+	sftpOpenWrite  = 0x02
+	sftpOpenCreate = 0x08
+	sftpOpenTrunc  = 0x10
+)
+
+// SFTP file attributes flags.
+const (
+	sftpAttrSize        = 0x00000001
+	sftpAttrUIDGID      = 0x00000002
+	sftpAttrPermissions = 0x00000004
+	sftpAttrACModTime   = 0x00000008
+	sftpAttrExtended    = 0x80000000
+)
+
+// sftpChunkSize is the size of the data read or written by request.
+// All servers have to support packets of 34000 bytes.
+const sftpChunkSize = 32768
+
+// sftpMaxInflight is the number of read or write requests sent before waiting for their responses.
+const sftpMaxInflight = 16
+
+// sftpMaxPacketSize protects from allocating huge buffers for a broken server.
+const sftpMaxPacketSize = 256 * 1024
+
+// sftpStatusError is a failure reported by the SFTP server.
+type sftpStatusError struct {
+	code    uint32
+	message string
+}
+
+func (e *sftpStatusError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf(L("SFTP error %d"), e.code)
+	}
+	return e.message
+}
+
+// Is allows to check a missing file using errors.Is(err, fs.ErrNotExist).
+func (e *sftpStatusError) Is(target error) bool {
+	return target == fs.ErrNotExist && e.code == sftpStatusNoSuchFile
+}
+
+// sftpPacket is a response of the SFTP server, without its request identifier.
+type sftpPacket struct {
+	kind byte
+	data *sftpDecoder
+}
+
+// sftpDecoder reads the fields of an SFTP packet, recording the first error.
+type sftpDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *sftpDecoder) readUint32() uint32 {
+	if len(d.data) < 4 {
+		d.err = errors.New(L("truncated SFTP packet"))
+		return 0
+	}
+	value := binary.BigEndian.Uint32(d.data)
+	d.data = d.data[4:]
+	return value
+}
+
+func (d *sftpDecoder) readUint64() uint64 {
+	return uint64(d.readUint32())<<32 | uint64(d.readUint32())
+}
+
+func (d *sftpDecoder) readString() []byte {
+	length := d.readUint32()
+	if uint32(len(d.data)) < length {
+		d.err = errors.New(L("truncated SFTP packet"))
+		return nil
+	}
+	value := d.data[:length]
+	d.data = d.data[length:]
+	return value
+}
+
+// sftpFileAttrs are the attributes of a remote file needed by the backup targets.
+type sftpFileAttrs struct {
+	size uint64
+	mode uint32
+}
+
+func (a *sftpFileAttrs) isDir() bool {
+	return a.mode&0170000 == 0040000
+}
+
+func (d *sftpDecoder) readAttrs() sftpFileAttrs {
+	attrs := sftpFileAttrs{}
+	flags := d.readUint32()
+	if flags&sftpAttrSize != 0 {
+		attrs.size = d.readUint64()
+	}
+	if flags&sftpAttrUIDGID != 0 {
+		d.readUint64()
+	}
+	if flags&sftpAttrPermissions != 0 {
+		attrs.mode = d.readUint32()
+	}
+	if flags&sftpAttrACModTime != 0 {
+		d.readUint64()
+	}
+	if flags&sftpAttrExtended != 0 {
+		count := d.readUint32()
+		for i := uint32(0); i < count && d.err == nil; i++ {
+			d.readString()
+			d.readString()
+		}
+	}
+	return attrs
+}
+
+func appendUint32(data []byte, value uint32) []byte {
+	return binary.BigEndian.AppendUint32(data, value)
+}
+
+func appendUint64(data []byte, value uint64) []byte {
+	return binary.BigEndian.AppendUint64(data, value)
+}
+
+func appendString(data []byte, value []byte) []byte {
+	return append(appendUint32(data, uint32(len(value))), value...)
+}
+
+// sftpSession talks the SFTP protocol with a server through its input and output.
+//
+// Several requests can be sent before reading their responses.
+// A session is safe for concurrent use: the responses read while waiting for another one are kept for later.
+type sftpSession struct {
+	mutex   sync.Mutex
+	input   io.WriteCloser
+	output  *bufio.Reader
+	closer  func() error
+	nextID  uint32
+	pending map[uint32]sftpPacket
+}
+
+// newSftpSession negotiates the protocol version with the server.
+// closer is called to wait for the server to stop once its input is closed.
+func newSftpSession(input io.WriteCloser, output io.Reader, closer func() error) (*sftpSession, error) {
+	s := &sftpSession{
+		input:   input,
+		output:  bufio.NewReaderSize(output, sftpChunkSize+1024),
+		closer:  closer,
+		pending: map[uint32]sftpPacket{},
+	}
+	// The init packet has no request identifier, but a version at the same place
+	if err := s.writePacket(sftpInit, appendUint32(nil, 3)); err != nil {
+		return nil, utils.JoinErrors(err, s.Close())
+	}
+	kind, _, err := s.readPacket()
+	if err == nil && kind != sftpVersion {
+		err = errors.New(L("unexpected SFTP server response"))
+	}
+	if err != nil {
+		return nil, utils.JoinErrors(utils.Error(err, L("failed to start the SFTP session")), s.Close())
+	}
+	return s, nil
+}
+
+// Close ends the session and waits for the server to stop.
+func (s *sftpSession) Close() error {
+	return utils.JoinErrors(s.input.Close(), s.closer())
+}
+
+func (s *sftpSession) writePacket(kind byte, data []byte) error {
+	packet := appendUint32(make([]byte, 0, len(data)+5), uint32(len(data)+1))
+	packet = append(packet, kind)
+	packet = append(packet, data...)
+	_, err := s.input.Write(packet)
+	return err
+}
+
+func (s *sftpSession) readPacket() (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(s.output, header); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header)
+	if length < 1 || length > sftpMaxPacketSize {
+		return 0, nil, fmt.Errorf(L("invalid SFTP packet length: %d"), length)
+	}
+	data := make([]byte, length-1)
+	if _, err := io.ReadFull(s.output, data); err != nil {
+		return 0, nil, err
+	}
+	return header[4], data, nil
+}
+
+// send writes a request and returns its identifier to read the response.
+func (s *sftpSession) send(kind byte, payload []byte) (uint32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextID++
+	id := s.nextID
+	data := appendUint32(make([]byte, 0, len(payload)+4), id)
+	return id, s.writePacket(kind, append(data, payload...))
+}
+
+// receive reads the response of a request, keeping the responses to the other requests for later.
+func (s *sftpSession) receive(id uint32) (sftpPacket, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for {
+		if packet, found := s.pending[id]; found {
+			delete(s.pending, id)
+			return packet, nil
+		}
+		kind, data, err := s.readPacket()
+		if err != nil {
+			return sftpPacket{}, err
+		}
+		decoder := &sftpDecoder{data: data}
+		responseID := decoder.readUint32()
+		if decoder.err != nil {
+			return sftpPacket{}, decoder.err
+		}
+		s.pending[responseID] = sftpPacket{kind: kind, data: decoder}
+	}
+}
+
+func (s *sftpSession) request(kind byte, payload []byte) (sftpPacket, error) {
+	id, err := s.send(kind, payload)
+	if err != nil {
+		return sftpPacket{}, err
+	}
+	return s.receive(id)
+}
+
+// statusError returns the error of a status response or an error if the response is not the expected kind.
+func statusError(packet sftpPacket, expected byte) error {
+	if packet.kind == expected && expected != sftpStatus {
+		return nil
+	}
+	if packet.kind != sftpStatus {
+		return fmt.Errorf(L("unexpected SFTP response type %d"), packet.kind)
+	}
+	code := packet.data.readUint32()
+	message := packet.data.readString()
+	if packet.data.err != nil {
+		return packet.data.err
+	}
+	if code != sftpStatusOK {
+		return &sftpStatusError{code: code, message: string(message)}
+	}
+	if expected != sftpStatus {
+		return fmt.Errorf(L("unexpected SFTP response type %d"), packet.kind)
+	}
+	return nil
+}
+
+// requestStatus sends a request only returning a status.
+func (s *sftpSession) requestStatus(kind byte, payload []byte) error {
+	packet, err := s.request(kind, payload)
+	if err != nil {
+		return err
+	}
+	return statusError(packet, sftpStatus)
+}
+
+// openHandle sends a request returning a file or directory handle.
+func (s *sftpSession) openHandle(kind byte, payload []byte) ([]byte, error) {
+	packet, err := s.request(kind, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := statusError(packet, sftpHandle); err != nil {
+		return nil, err
+	}
+	handle := packet.data.readString()
+	return handle, packet.data.err
+}
+
+func (s *sftpSession) open(file string, flags uint32, perm uint32) ([]byte, error) {
+	payload := appendString(nil, []byte(file))
+	payload = appendUint32(payload, flags)
+	payload = appendUint32(payload, sftpAttrPermissions)
+	payload = appendUint32(payload, perm)
+	return s.openHandle(sftpOpen, payload)
+}
+
+func (s *sftpSession) closeHandle(handle []byte) error {
+	return s.requestStatus(sftpClose, appendString(nil, handle))
+}
+
+func (s *sftpSession) stat(file string) (sftpFileAttrs, error) {
+	packet, err := s.request(sftpStat, appendString(nil, []byte(file)))
+	if err != nil {
+		return sftpFileAttrs{}, err
+	}
+	if err := statusError(packet, sftpAttrs); err != nil {
+		return sftpFileAttrs{}, err
+	}
+	attrs := packet.data.readAttrs()
+	return attrs, packet.data.err
+}
+
+func (s *sftpSession) remove(file string) error {
+	return s.requestStatus(sftpRemove, appendString(nil, []byte(file)))
+}
+
+func (s *sftpSession) rmdir(dir string) error {
+	return s.requestStatus(sftpRmdir, appendString(nil, []byte(dir)))
+}
+
+// mkdirAll creates a directory and its missing parents.
+func (s *sftpSession) mkdirAll(dir string) error {
+	if dir == "." || dir == "/" || dir == "" {
+		return nil
+	}
+	attrs, err := s.stat(dir)
+	if err == nil {
+		if !attrs.isDir() {
+			return fmt.Errorf(L("%s is not a directory"), dir)
+		}
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := s.mkdirAll(path.Dir(dir)); err != nil {
+		return err
+	}
+	payload := appendString(nil, []byte(dir))
+	payload = appendUint32(payload, sftpAttrPermissions)
+	payload = appendUint32(payload, 0700)
+	return s.requestStatus(sftpMkdir, payload)
+}
+
+// sftpDirEntry is a file of a remote directory.
+type sftpDirEntry struct {
+	name  string
+	attrs sftpFileAttrs
+}
+
+// readDir lists the content of a remote directory, without the . and .. entries.
+func (s *sftpSession) readDir(dir string) ([]sftpDirEntry, error) {
+	handle, err := s.openHandle(sftpOpendir, appendString(nil, []byte(dir)))
+	if err != nil {
+		return nil, err
+	}
+	entries := []sftpDirEntry{}
+	for {
+		packet, err := s.request(sftpReaddir, appendString(nil, handle))
+		if err == nil {
+			err = statusError(packet, sftpName)
+		}
+		var statusErr *sftpStatusError
+		if errors.As(err, &statusErr) && statusErr.code == sftpStatusEOF {
+			break
+		}
+		if err != nil {
+			return nil, utils.JoinErrors(err, s.closeHandle(handle))
+		}
+		count := packet.data.readUint32()
+		for i := uint32(0); i < count && packet.data.err == nil; i++ {
+			name := string(packet.data.readString())
+			// Skip the long name, only meant to be displayed
+			packet.data.readString()
+			attrs := packet.data.readAttrs()
+			if name != "." && name != ".." {
+				entries = append(entries, sftpDirEntry{name: name, attrs: attrs})
+			}
+		}
+		if packet.data.err != nil {
+			return nil, utils.JoinErrors(packet.data.err, s.closeHandle(handle))
+		}
+	}
+	return entries, s.closeHandle(handle)
+}
+
+// removeAll removes a remote directory and all its content.
+func (s *sftpSession) removeAll(dir string) error {
+	entries, err := s.readDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		file := path.Join(dir, entry.name)
+		if entry.attrs.isDir() {
+			err = s.removeAll(file)
+		} else {
+			err = s.remove(file)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return s.rmdir(dir)
+}
+
+// sftpFileWriter writes a remote file, sending several chunks before waiting for their acknowledgment.
+type sftpFileWriter struct {
+	session *sftpSession
+	file    string
+	handle  []byte
+	offset  uint64
+	buffer  []byte
+	pending []uint32
+	err     error
+}
+
+func (w *sftpFileWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	written := 0
+	for len(p) > 0 {
+		n := sftpChunkSize - len(w.buffer)
+		if n > len(p) {
+			n = len(p)
+		}
+		w.buffer = append(w.buffer, p[:n]...)
+		p = p[n:]
+		written += n
+		if len(w.buffer) == sftpChunkSize {
+			if w.err = w.flush(); w.err != nil {
+				return written, w.err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flush sends the buffered data and waits for the oldest writes if too many are not acknowledged yet.
+func (w *sftpFileWriter) flush() error {
+	if len(w.buffer) == 0 {
+		return nil
+	}
+	payload := appendString(nil, w.handle)
+	payload = appendUint64(payload, w.offset)
+	payload = appendString(payload, w.buffer)
+	id, err := w.session.send(sftpWrite, payload)
+	if err != nil {
+		return err
+	}
+	w.offset += uint64(len(w.buffer))
+	w.buffer = w.buffer[:0]
+	w.pending = append(w.pending, id)
+	if len(w.pending) >= sftpMaxInflight {
+		return w.wait(1)
+	}
+	return nil
+}
+
+// wait checks the responses to the oldest count write requests.
+func (w *sftpFileWriter) wait(count int) error {
+	for ; count > 0; count-- {
+		packet, err := w.session.receive(w.pending[0])
+		w.pending = w.pending[1:]
+		if err == nil {
+			err = statusError(packet, sftpStatus)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close writes the remaining data and closes the file.
+// The file is only completely written if no error is returned.
+func (w *sftpFileWriter) Close() error {
+	err := w.err
+	if err == nil {
+		err = w.flush()
+	}
+	if err == nil {
+		err = w.wait(len(w.pending))
+	}
+	w.err = errors.New(L("writing to a closed SFTP file"))
+	return utils.JoinErrors(err, w.session.closeHandle(w.handle))
+}
+
+// Abort closes and removes the partially written file.
+func (w *sftpFileWriter) Abort() error {
+	w.err = errors.New(L("writing to an aborted SFTP file"))
+	// Read the pending responses not to leave them in the session
+	for _, id := range w.pending {
+		_, _ = w.session.receive(id)
+	}
+	w.pending = nil
+	return utils.JoinErrors(w.session.closeHandle(w.handle), w.session.remove(w.file))
+}
+
+// sftpReadRequest is a read request waiting for its response.
+type sftpReadRequest struct {
+	id     uint32
+	offset uint64
+}
+
+// sftpFileReader reads a remote file, requesting the next chunks before they are needed.
+type sftpFileReader struct {
+	session *sftpSession
+	handle  []byte
+	// offset is the position of the next chunk to request
+	offset  uint64
+	pending []sftpReadRequest
+	buffer  []byte
+	eof     bool
+}
+
+func (r *sftpFileReader) Read(p []byte) (int, error) {
+	for len(r.buffer) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		for len(r.pending) < sftpMaxInflight {
+			payload := appendString(nil, r.handle)
+			payload = appendUint64(payload, r.offset)
+			payload = appendUint32(payload, sftpChunkSize)
+			id, err := r.session.send(sftpRead, payload)
+			if err != nil {
+				return 0, err
+			}
+			r.pending = append(r.pending, sftpReadRequest{id: id, offset: r.offset})
+			r.offset += sftpChunkSize
+		}
+
+		request := r.pending[0]
+		r.pending = r.pending[1:]
+		packet, err := r.session.receive(request.id)
+		if err == nil {
+			err = statusError(packet, sftpData)
+		}
+		var statusErr *sftpStatusError
+		if errors.As(err, &statusErr) && statusErr.code == sftpStatusEOF {
+			r.eof = true
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		data := packet.data.readString()
+		if packet.data.err != nil {
+			return 0, packet.data.err
+		}
+		r.buffer = data
+		if len(data) < sftpChunkSize {
+			// Short read: the next requests have the wrong offsets, request again from the end of the data
+			if err := r.drain(); err != nil {
+				return 0, err
+			}
+			r.offset = request.offset + uint64(len(data))
+		}
+	}
+	n := copy(p, r.buffer)
+	r.buffer = r.buffer[n:]
+	return n, nil
+}
+
+// drain reads and ignores the responses to the pending read requests.
+func (r *sftpFileReader) drain() error {
+	for _, request := range r.pending {
+		if _, err := r.session.receive(request.id); err != nil {
+			return err
+		}
+	}
+	r.pending = nil
+	return nil
+}
+
+// Close closes the file.
+func (r *sftpFileReader) Close() error {
+	return utils.JoinErrors(r.drain(), r.session.closeHandle(r.handle))
+}
@@ -0,0 +1,305 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// fakeSftpServer serves the files of a local directory using the SFTP protocol.
+//
+// Like internal-sftp in a chroot, the paths are relative to the served directory.
+type fakeSftpServer struct {
+	root    string
+	input   *bufio.Reader
+	output  chan []byte
+	files   map[string]*os.File
+	dirs    map[string][]os.DirEntry
+	handles int
+}
+
+// connectFakeSftp returns a function connecting the SFTP targets to a fake server serving root.
+func connectFakeSftp(root string) func(*sftpTarget) (*sftpSession, error) {
+	return func(_ *sftpTarget) (*sftpSession, error) {
+		clientReader, serverWriter := io.Pipe()
+		serverReader, clientWriter := io.Pipe()
+		server := &fakeSftpServer{
+			root:   root,
+			input:  bufio.NewReader(serverReader),
+			output: make(chan []byte, 2*sftpMaxInflight),
+			files:  map[string]*os.File{},
+			dirs:   map[string][]os.DirEntry{},
+		}
+		// Buffer the responses like the ssh pipes do, the client sends several requests before reading
+		go func() {
+			for packet := range server.output {
+				_, _ = serverWriter.Write(packet)
+			}
+			serverWriter.Close()
+		}()
+		done := make(chan error, 1)
+		go func() {
+			err := server.serve()
+			close(server.output)
+			done <- err
+		}()
+		return newSftpSession(clientWriter, clientReader, func() error { return <-done })
+	}
+}
+
+func (s *fakeSftpServer) serve() error {
+	for {
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(s.input, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		data := make([]byte, binary.BigEndian.Uint32(header)-1)
+		if _, err := io.ReadFull(s.input, data); err != nil {
+			return err
+		}
+		decoder := &sftpDecoder{data: data}
+		id := decoder.readUint32()
+		if header[4] == sftpInit {
+			s.reply(sftpVersion, appendUint32(nil, 3))
+			continue
+		}
+		kind, payload := s.handle(header[4], decoder)
+		s.reply(kind, append(appendUint32(nil, id), payload...))
+	}
+}
+
+func (s *fakeSftpServer) reply(kind byte, data []byte) {
+	packet := appendUint32(nil, uint32(len(data)+1))
+	packet = append(packet, kind)
+	s.output <- append(packet, data...)
+}
+
+func (s *fakeSftpServer) path(name []byte) string {
+	return path.Join(s.root, path.Clean("/"+string(name)))
+}
+
+func (s *fakeSftpServer) newHandle() string {
+	s.handles++
+	return strconv.Itoa(s.handles)
+}
+
+func status(err error) (byte, []byte) {
+	code := uint32(sftpStatusOK)
+	message := ""
+	if errors.Is(err, io.EOF) {
+		code = sftpStatusEOF
+	} else if errors.Is(err, fs.ErrNotExist) {
+		code = sftpStatusNoSuchFile
+		message = err.Error()
+	} else if err != nil {
+		code = 4
+		message = err.Error()
+	}
+	return sftpStatus, appendString(appendUint32(nil, code), []byte(message))
+}
+
+func appendAttrs(data []byte, info os.FileInfo) []byte {
+	mode := uint32(info.Mode().Perm())
+	if info.IsDir() {
+		mode |= 0040000
+	} else {
+		mode |= 0100000
+	}
+	data = appendUint32(data, sftpAttrSize|sftpAttrPermissions)
+	data = appendUint64(data, uint64(info.Size()))
+	return appendUint32(data, mode)
+}
+
+func (s *fakeSftpServer) handle(kind byte, request *sftpDecoder) (byte, []byte) {
+	switch kind {
+	case sftpOpen:
+		name := s.path(request.readString())
+		flags := request.readUint32()
+		osFlags := os.O_RDONLY
+		if flags&sftpOpenWrite != 0 {
+			osFlags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		file, err := os.OpenFile(name, osFlags, 0600)
+		if err != nil {
+			return status(err)
+		}
+		handle := s.newHandle()
+		s.files[handle] = file
+		return sftpHandle, appendString(nil, []byte(handle))
+	case sftpOpendir:
+		entries, err := os.ReadDir(s.path(request.readString()))
+		if err != nil {
+			return status(err)
+		}
+		handle := s.newHandle()
+		s.dirs[handle] = entries
+		return sftpHandle, appendString(nil, []byte(handle))
+	case sftpReaddir:
+		handle := string(request.readString())
+		entries := s.dirs[handle]
+		if len(entries) == 0 {
+			return status(io.EOF)
+		}
+		// Only return one entry at a time to check the client reads all of them
+		s.dirs[handle] = entries[1:]
+		info, err := entries[0].Info()
+		if err != nil {
+			return status(err)
+		}
+		data := appendUint32(nil, 1)
+		data = appendString(data, []byte(entries[0].Name()))
+		data = appendString(data, []byte(entries[0].Name()))
+		return sftpName, appendAttrs(data, info)
+	case sftpRead:
+		file := s.files[string(request.readString())]
+		offset := request.readUint64()
+		// Short reads have to be supported by the client
+		buffer := make([]byte, request.readUint32()/2+1)
+		n, err := file.ReadAt(buffer, int64(offset))
+		if n == 0 {
+			return status(err)
+		}
+		return sftpData, appendString(nil, buffer[:n])
+	case sftpWrite:
+		file := s.files[string(request.readString())]
+		offset := request.readUint64()
+		_, err := file.WriteAt(request.readString(), int64(offset))
+		return status(err)
+	case sftpClose:
+		handle := string(request.readString())
+		if file, found := s.files[handle]; found {
+			delete(s.files, handle)
+			return status(file.Close())
+		}
+		delete(s.dirs, handle)
+		return status(nil)
+	case sftpStat:
+		info, err := os.Stat(s.path(request.readString()))
+		if err != nil {
+			return status(err)
+		}
+		return sftpAttrs, appendAttrs(nil, info)
+	case sftpMkdir:
+		return status(os.Mkdir(s.path(request.readString()), 0700))
+	case sftpRemove, sftpRmdir:
+		return status(os.Remove(s.path(request.readString())))
+	}
+	return status(errors.New("unsupported operation"))
+}
+
+func TestSftpLargeFile(t *testing.T) {
+	dir := t.TempDir()
+	connectSftp = connectFakeSftp(dir)
+	defer func() { connectSftp = (*sftpTarget).connect }()
+
+	target := &sftpTarget{host: "storage.example.com", root: "backup", connection: &sftpConnection{}}
+	content := bytes.Repeat([]byte("0123456789"), sftpChunkSize*sftpMaxInflight/4)
+
+	writer, err := target.Create("volumes/var-spacewalk.tar")
+	testutils.AssertNoError(t, "failed to create file", err)
+	_, err = writer.Write(content)
+	testutils.AssertNoError(t, "failed to write file", err)
+	testutils.AssertNoError(t, "failed to close written file", writer.Close())
+
+	written, err := os.ReadFile(path.Join(dir, "backup/volumes/var-spacewalk.tar"))
+	testutils.AssertNoError(t, "failed to read the written file", err)
+	testutils.AssertTrue(t, "Unexpected written content", bytes.Equal(content, written))
+
+	reader, err := target.Open("volumes/var-spacewalk.tar")
+	testutils.AssertNoError(t, "failed to open file", err)
+	read, err := io.ReadAll(reader)
+	testutils.AssertNoError(t, "failed to read file", err)
+	testutils.AssertNoError(t, "failed to close read file", reader.Close())
+	testutils.AssertTrue(t, "Unexpected read content", bytes.Equal(content, read))
+}
+
+func TestSftpSessionReuse(t *testing.T) {
+	dir := t.TempDir()
+	connections := 0
+	connect := connectFakeSftp(dir)
+	connectSftp = func(target *sftpTarget) (*sftpSession, error) {
+		connections++
+		return connect(target)
+	}
+	defer func() { connectSftp = (*sftpTarget).connect }()
+
+	target := &sftpTarget{host: "storage.example.com", root: "backup", connection: &sftpConnection{}}
+	sub := target.Sub("volumes")
+
+	// Write two files at the same time on the shared session
+	first, err := sub.Create("first.tar")
+	testutils.AssertNoError(t, "failed to create first file", err)
+	second, err := sub.Create("second.tar")
+	testutils.AssertNoError(t, "failed to create second file", err)
+	content := bytes.Repeat([]byte("0123456789"), sftpChunkSize)
+	done := make(chan error)
+	for _, writer := range []io.WriteCloser{first, second} {
+		go func(writer io.WriteCloser) {
+			_, err := writer.Write(content)
+			done <- utils.JoinErrors(err, writer.Close())
+		}(writer)
+	}
+	testutils.AssertNoError(t, "failed to write first file", <-done)
+	testutils.AssertNoError(t, "failed to write second file", <-done)
+
+	entries, err := target.List("volumes")
+	testutils.AssertNoError(t, "failed to list files", err)
+	testutils.AssertEquals(t, "Unexpected number of files", 2, len(entries))
+	size, err := TargetSize(target, "")
+	testutils.AssertNoError(t, "failed to compute size", err)
+	testutils.AssertEquals(t, "Unexpected size", int64(2*len(content)), size)
+	testutils.AssertEquals(t, "The session should be reused", 1, connections)
+
+	testutils.AssertNoError(t, "failed to close the target", target.Close())
+	testutils.AssertNoError(t, "failed to remove files", target.RemoveAll("volumes"))
+	testutils.AssertEquals(t, "A closed target should connect again", 2, connections)
+	testutils.AssertNoError(t, "failed to close the target", target.Close())
+}
+
+func TestSftpAbort(t *testing.T) {
+	dir := t.TempDir()
+	connectSftp = connectFakeSftp(dir)
+	defer func() { connectSftp = (*sftpTarget).connect }()
+
+	target := &sftpTarget{host: "storage.example.com", root: "backup", connection: &sftpConnection{}}
+	writer, err := target.Create("volumes/var-spacewalk.tar")
+	testutils.AssertNoError(t, "failed to create file", err)
+	_, err = writer.Write(bytes.Repeat([]byte("0123456789"), sftpChunkSize))
+	testutils.AssertNoError(t, "failed to write file", err)
+	testutils.AssertNoError(t, "failed to abort file", writer.Abort())
+
+	_, err = os.Stat(path.Join(dir, "backup/volumes/var-spacewalk.tar"))
+	testutils.AssertTrue(t, "Aborted file should be removed", errors.Is(err, fs.ErrNotExist))
+	testutils.AssertNoError(t, "failed to close the target", target.Close())
+}
+
+func TestSftpMissingFile(t *testing.T) {
+	connectSftp = connectFakeSftp(t.TempDir())
+	defer func() { connectSftp = (*sftpTarget).connect }()
+
+	target := &sftpTarget{host: "storage.example.com", root: "backup", connection: &sftpConnection{}}
+	_, err := target.Open("manifest.json")
+	testutils.AssertError(t, "failed to open", err)
+
+	entries, err := target.List("volumes")
+	testutils.AssertNoError(t, "Listing a missing directory should pass", err)
+	testutils.AssertEquals(t, "Unexpected entries in missing directory", 0, len(entries))
+	testutils.AssertNoError(t, "Removing a missing directory should pass", target.RemoveAll("volumes"))
+}
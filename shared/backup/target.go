@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// TargetFlags holds the parameters needed to access the remote backup targets.
+type TargetFlags struct {
+	S3 struct {
+		Endpoint  string `mapstructure:"endpoint"`
+		Region    string `mapstructure:"region"`
+		AccessKey string `mapstructure:"accesskey"`
+		SecretKey string `mapstructure:"secretkey"`
+		// PartSize is the size of the multipart upload parts in MiB.
+		PartSize int `mapstructure:"partsize"`
+	} `mapstructure:"s3"`
+	SSH struct {
+		Identity string `mapstructure:"identity"`
+	} `mapstructure:"ssh"`
+}
+
+// TargetEntry is a file or a directory stored in a backup target.
+type TargetEntry struct {
+	Name  string
+	Size  int64
+	IsDir bool
+}
+
+// Target is a storage where the backups are written to and read from.
+//
+// The names passed to the methods are slash separated paths relative to the root of the target.
+type Target interface {
+	// Create opens a file for writing, creating the parent directories if needed.
+	// The file is only guaranteed to be stored once the writer is closed without error.
+	Create(name string) (TargetWriter, error)
+	// Open opens a file for reading.
+	Open(name string) (io.ReadCloser, error)
+	// List returns the files and directories directly contained in a directory.
+	// A missing directory has no entry.
+	List(dir string) ([]TargetEntry, error)
+	// RemoveAll removes a directory and all its content.
+	RemoveAll(dir string) error
+	// Sub returns a target rooted in a subdirectory.
+	Sub(dir string) Target
+	// LocalPath returns the path of the file on the local file system if the target is local.
+	LocalPath(name string) (string, bool)
+	// String returns the location of the target to show in the messages.
+	String() string
+	// Close releases the connection to the storage, shared with the sub targets.
+	Close() error
+}
+
+// TargetWriter writes a file of a backup target.
+type TargetWriter interface {
+	io.Writer
+	// Close stores the file.
+	Close() error
+	// Abort discards the partially written file instead of storing it.
+	Abort() error
+}
+
+const s3Scheme = "s3"
+const sftpScheme = "sftp"
+
+// NewTarget creates the backup target for a location.
+//
+// The location can be a local directory, an sftp://[user@]host[:port]/path URL
+// or an s3://bucket/prefix URL.
+func NewTarget(location string, flags *TargetFlags) (Target, error) {
+	if !strings.Contains(location, "://") {
+		return &localTarget{root: location}, nil
+	}
+
+	locationURL, err := url.Parse(location)
+	if err != nil {
+		return nil, utils.Errorf(err, L("invalid backup location %s"), location)
+	}
+	switch locationURL.Scheme {
+	case s3Scheme:
+		return newS3Target(locationURL, flags)
+	case sftpScheme:
+		return newSftpTarget(locationURL, flags)
+	}
+	return nil, errors.New(L("unsupported backup location: only local directories, sftp:// and s3:// are supported"))
+}
+
+// JoinLocation appends a name to a backup location, being it a local path or a URL.
+func JoinLocation(location string, name string) string {
+	return strings.TrimSuffix(location, "/") + "/" + name
+}
+
+// FileExists returns true if the file is stored in the target.
+func FileExists(target Target, name string) bool {
+	entries, err := target.List(path.Dir(name))
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.Name == path.Base(name) && !entry.IsDir {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty returns true if the target contains no file.
+func IsEmpty(target Target) (bool, error) {
+	entries, err := target.List("")
+	return len(entries) == 0, err
+}
+
+// TargetSize computes the size of all the files in a directory of the target.
+func TargetSize(target Target, dir string) (int64, error) {
+	entries, err := target.List(dir)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, entry := range entries {
+		if entry.IsDir {
+			dirSize, err := TargetSize(target, path.Join(dir, entry.Name))
+			if err != nil {
+				return 0, err
+			}
+			size += dirSize
+		} else {
+			size += entry.Size
+		}
+	}
+	return size, nil
+}
+
+// localTarget stores the backups in a local directory.
+type localTarget struct {
+	root string
+}
+
+func (t *localTarget) path(name string) string {
+	return path.Join(t.root, name)
+}
+
+func (t *localTarget) Create(name string) (TargetWriter, error) {
+	file := t.path(name)
+	if err := os.MkdirAll(path.Dir(file), 0700); err != nil {
+		return nil, utils.Errorf(err, L("failed to create %s"), path.Dir(file))
+	}
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to create %s"), file)
+	}
+	return &localWriter{File: out}, nil
+}
+
+// localWriter writes a file of a local target.
+type localWriter struct {
+	*os.File
+}
+
+// Abort closes and removes the partially written file.
+func (w *localWriter) Abort() error {
+	return utils.JoinErrors(w.File.Close(), os.Remove(w.Name()))
+}
+
+func (t *localTarget) Open(name string) (io.ReadCloser, error) {
+	return os.Open(t.path(name))
+}
+
+func (t *localTarget) List(dir string) ([]TargetEntry, error) {
+	entries, err := os.ReadDir(t.path(dir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []TargetEntry{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	result := []TargetEntry{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, TargetEntry{Name: entry.Name(), Size: info.Size(), IsDir: entry.IsDir()})
+	}
+	return result, nil
+}
+
+func (t *localTarget) RemoveAll(dir string) error {
+	return os.RemoveAll(t.path(dir))
+}
+
+func (t *localTarget) Sub(dir string) Target {
+	return &localTarget{root: t.path(dir)}
+}
+
+func (t *localTarget) LocalPath(name string) (string, bool) {
+	return t.path(name), true
+}
+
+func (t *localTarget) String() string {
+	return t.root
+}
+
+func (t *localTarget) Close() error {
+	return nil
+}
@@ -0,0 +1,617 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+const s3DefaultRegion = "us-east-1"
+
+// s3DefaultPartSize is the default size of the multipart upload parts in MiB.
+const s3DefaultPartSize = 64
+
+// s3MinPartSize is the minimum size of a multipart upload part in MiB, except for the last one.
+const s3MinPartSize = 5
+
+// s3PartsPerSize is the number of parts uploaded before doubling the part size.
+// S3 limits the number of parts to 10000, growing the parts allows uploading huge volumes.
+const s3PartsPerSize = 1000
+
+// s3IdleTimeout is the time after which a request not sending or receiving any data is aborted.
+// This prevents a stalled connection from blocking a scheduled backup forever.
+const s3IdleTimeout = 2 * time.Minute
+
+// emptyPayloadHash is the SHA256 of an empty request body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// s3Client sends requests signed with the AWS signature version 4 to an S3-compatible storage.
+//
+// Buckets are addressed using the path style as all S3-compatible storages support it.
+type s3Client struct {
+	endpoint  *url.URL
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+	// idleTimeout is the time after which a request without progress is aborted.
+	idleTimeout time.Duration
+}
+
+// newS3HTTPClient creates the HTTP client with timeouts for all the steps of the requests.
+//
+// The whole request duration is not limited as downloading a volume can take hours:
+// the requests are aborted when no data is transferred for the idle timeout instead.
+func newS3HTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+			TLSHandshakeTimeout:   30 * time.Second,
+			ResponseHeaderTimeout: s3IdleTimeout,
+			ExpectContinueTimeout: time.Second,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+}
+
+// s3Error is the error returned by the S3 API.
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func (e *s3Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// do sends a request for an object key of a bucket.
+// The response body needs to be closed by the caller when no error is returned.
+func (c *s3Client) do(method string, bucket string, key string, query url.Values, body []byte) (*http.Response, error) {
+	requestURL := *c.endpoint
+	// Keep the endpoint path for the gateways serving S3 in a sub path
+	requestURL.Path = strings.TrimSuffix(c.endpoint.Path, "/") + "/" + bucket
+	if key != "" {
+		requestURL.Path += "/" + key
+	}
+	requestURL.RawPath = uriEncode(requestURL.Path, false)
+	requestURL.RawQuery = canonicalQuery(query)
+
+	ctx, watchdog := newIdleWatchdog(c.idleTimeout)
+	var requestBody io.Reader = http.NoBody
+	if len(body) > 0 {
+		requestBody = watchdog.reader(bytes.NewReader(body))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL.String(), requestBody)
+	if err != nil {
+		watchdog.stop()
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	payloadHash := emptyPayloadHash
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	c.sign(req, payloadHash)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if cause := context.Cause(ctx); cause != nil {
+			err = cause
+		}
+		watchdog.stop()
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer watchdog.stop()
+		defer resp.Body.Close()
+		return nil, readS3Error(resp)
+	}
+	resp.Body = &watchedBody{ReadCloser: resp.Body, watchdog: watchdog}
+	return resp, nil
+}
+
+// idleWatchdog cancels a request when its body transfer makes no progress for too long.
+type idleWatchdog struct {
+	timer   *time.Timer
+	timeout time.Duration
+	cancel  context.CancelCauseFunc
+}
+
+func newIdleWatchdog(timeout time.Duration) (context.Context, *idleWatchdog) {
+	if timeout <= 0 {
+		timeout = s3IdleTimeout
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	w := &idleWatchdog{timeout: timeout, cancel: cancel}
+	w.timer = time.AfterFunc(timeout, func() {
+		cancel(fmt.Errorf(L("S3 request aborted after %s without progress"), timeout))
+	})
+	return ctx, w
+}
+
+// reader returns a reader postponing the timeout each time data is read.
+func (w *idleWatchdog) reader(reader io.Reader) io.Reader {
+	return &watchedReader{Reader: reader, watchdog: w}
+}
+
+func (w *idleWatchdog) stop() {
+	w.timer.Stop()
+	w.cancel(nil)
+}
+
+type watchedReader struct {
+	io.Reader
+	watchdog *idleWatchdog
+}
+
+func (r *watchedReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.watchdog.timer.Reset(r.watchdog.timeout)
+	return n, err
+}
+
+// watchedBody is a response body postponing the timeout each time data is read and stopping it when closed.
+type watchedBody struct {
+	io.ReadCloser
+	watchdog *idleWatchdog
+}
+
+func (b *watchedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.watchdog.timer.Reset(b.watchdog.timeout)
+	return n, err
+}
+
+func (b *watchedBody) Close() error {
+	defer b.watchdog.stop()
+	return b.ReadCloser.Close()
+}
+
+// doXML sends a request and decodes the XML response into result.
+func (c *s3Client) doXML(method string, bucket string, key string, query url.Values, body []byte, result any) error {
+	resp, err := c.do(method, bucket, key, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	// Some operations like CompleteMultipartUpload can fail with a 200 status code
+	var apiError s3Error
+	if xml.Unmarshal(data, &apiError) == nil {
+		return &apiError
+	}
+	if result == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, result)
+}
+
+func readS3Error(resp *http.Response) error {
+	data, _ := io.ReadAll(resp.Body)
+	var apiError s3Error
+	if err := xml.Unmarshal(data, &apiError); err == nil {
+		return &apiError
+	}
+	return fmt.Errorf(L("S3 request failed with status %s"), resp.Status)
+}
+
+// sign adds the AWS signature version 4 authorization header to the request.
+func (c *s3Client) sign(req *http.Request, payloadHash string) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "range" || name == "content-type" || name == "content-md5" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := strings.Builder{}
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, c.region)
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode encodes a value as expected by the AWS signature.
+func uriEncode(value string, encodeSlash bool) string {
+	encoded := strings.Builder{}
+	for _, b := range []byte(value) {
+		switch {
+		case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
+
+// canonicalQuery encodes the query parameters sorted by name as expected by the AWS signature.
+func canonicalQuery(query url.Values) string {
+	params := []string{}
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// s3Target stores the backups in a bucket of an S3-compatible storage.
+type s3Target struct {
+	client   *s3Client
+	bucket   string
+	prefix   string
+	partSize int
+}
+
+func newS3Target(location *url.URL, flags *TargetFlags) (*s3Target, error) {
+	bucket := location.Host
+	if bucket == "" {
+		return nil, fmt.Errorf(L("missing bucket in backup location %s"), location.Redacted())
+	}
+
+	region := firstNotEmpty(flags.S3.Region, os.Getenv("AWS_REGION"), s3DefaultRegion)
+	endpoint := firstNotEmpty(flags.S3.Endpoint, os.Getenv("AWS_ENDPOINT_URL"),
+		fmt.Sprintf("https://s3.%s.amazonaws.com", region))
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || endpointURL.Host == "" {
+		return nil, fmt.Errorf(L("invalid S3 endpoint: %s"), endpoint)
+	}
+
+	accessKey := firstNotEmpty(flags.S3.AccessKey, os.Getenv("AWS_ACCESS_KEY_ID"))
+	secretKey := firstNotEmpty(flags.S3.SecretKey, os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New(L("S3 credentials are missing: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY"))
+	}
+
+	partSize := flags.S3.PartSize
+	if partSize == 0 {
+		partSize = s3DefaultPartSize
+	} else if partSize < s3MinPartSize {
+		return nil, fmt.Errorf(L("S3 part size needs to be at least %d MiB"), s3MinPartSize)
+	}
+
+	return &s3Target{
+		client: &s3Client{
+			endpoint:    &url.URL{Scheme: endpointURL.Scheme, Host: endpointURL.Host, Path: endpointURL.Path},
+			region:      region,
+			accessKey:   accessKey,
+			secretKey:   secretKey,
+			client:      newS3HTTPClient(),
+			now:         time.Now,
+			idleTimeout: s3IdleTimeout,
+		},
+		bucket:   bucket,
+		prefix:   strings.Trim(location.Path, "/"),
+		partSize: partSize * 1024 * 1024,
+	}, nil
+}
+
+func firstNotEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func (t *s3Target) key(name string) string {
+	return strings.Trim(path.Join(t.prefix, name), "/")
+}
+
+func (t *s3Target) Create(name string) (TargetWriter, error) {
+	return &s3Writer{target: t, key: t.key(name), partSize: t.partSize}, nil
+}
+
+func (t *s3Target) Open(name string) (io.ReadCloser, error) {
+	resp, err := t.client.do(http.MethodGet, t.bucket, t.key(name), nil, nil)
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to open %s"), t.location(name))
+	}
+	return resp.Body, nil
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// list calls the callback for each page of the objects starting with the prefix.
+// If a delimiter is passed, the objects in the sub directories are grouped in common prefixes.
+func (t *s3Target) list(prefix string, delimiter string, callback func(*s3ListResult) error) error {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		var result s3ListResult
+		if err := t.client.doXML(http.MethodGet, t.bucket, "", query, nil, &result); err != nil {
+			return err
+		}
+		if err := callback(&result); err != nil {
+			return err
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (t *s3Target) dirPrefix(dir string) string {
+	prefix := t.key(dir)
+	if prefix != "" {
+		prefix += "/"
+	}
+	return prefix
+}
+
+func (t *s3Target) List(dir string) ([]TargetEntry, error) {
+	prefix := t.dirPrefix(dir)
+	entries := []TargetEntry{}
+	err := t.list(prefix, "/", func(result *s3ListResult) error {
+		for _, object := range result.Contents {
+			name := strings.TrimPrefix(object.Key, prefix)
+			// Ignore the directory markers created by some tools
+			if name != "" {
+				entries = append(entries, TargetEntry{Name: name, Size: object.Size})
+			}
+		}
+		for _, common := range result.CommonPrefixes {
+			name := strings.TrimSuffix(strings.TrimPrefix(common.Prefix, prefix), "/")
+			entries = append(entries, TargetEntry{Name: name, IsDir: true})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to list %s"), t.location(dir))
+	}
+	return entries, nil
+}
+
+func (t *s3Target) RemoveAll(dir string) error {
+	err := t.list(t.dirPrefix(dir), "", func(result *s3ListResult) error {
+		for _, object := range result.Contents {
+			resp, err := t.client.do(http.MethodDelete, t.bucket, object.Key, nil, nil)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+		}
+		return nil
+	})
+	if err != nil {
+		return utils.Errorf(err, L("failed to remove %s"), t.location(dir))
+	}
+	return nil
+}
+
+func (t *s3Target) Sub(dir string) Target {
+	sub := *t
+	sub.prefix = t.key(dir)
+	return &sub
+}
+
+func (t *s3Target) LocalPath(_ string) (string, bool) {
+	return "", false
+}
+
+func (t *s3Target) location(name string) string {
+	return fmt.Sprintf("%s://%s/%s", s3Scheme, t.bucket, t.key(name))
+}
+
+func (t *s3Target) String() string {
+	return t.location("")
+}
+
+func (t *s3Target) Close() error {
+	return nil
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// s3Writer uploads an object, using a multipart upload if it is bigger than a part.
+//
+// Only one part is kept in memory at a time.
+type s3Writer struct {
+	target   *s3Target
+	key      string
+	partSize int
+	buffer   bytes.Buffer
+	uploadID string
+	parts    []s3CompletedPart
+	err      error
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.buffer.Write(p)
+	for w.buffer.Len() >= w.partSize {
+		if w.err = w.uploadPart(w.buffer.Next(w.partSize)); w.err != nil {
+			w.abort()
+			return 0, w.err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *s3Writer) uploadPart(data []byte) error {
+	if w.uploadID == "" {
+		var result struct {
+			UploadID string `xml:"UploadId"`
+		}
+		err := w.target.client.doXML(http.MethodPost, w.target.bucket, w.key, url.Values{"uploads": {""}}, nil, &result)
+		if err != nil {
+			return utils.Errorf(err, L("failed to start the upload of %s"), w.key)
+		}
+		w.uploadID = result.UploadID
+	}
+
+	partNumber := len(w.parts) + 1
+	log.Debug().Msgf("Uploading part %d of %s", partNumber, w.key)
+	query := url.Values{"partNumber": {fmt.Sprint(partNumber)}, "uploadId": {w.uploadID}}
+	resp, err := w.target.client.do(http.MethodPut, w.target.bucket, w.key, query, data)
+	if err != nil {
+		return utils.Errorf(err, L("failed to upload part %[1]d of %[2]s"), partNumber, w.key)
+	}
+	resp.Body.Close()
+	w.parts = append(w.parts, s3CompletedPart{PartNumber: partNumber, ETag: resp.Header.Get("ETag")})
+
+	if len(w.parts)%s3PartsPerSize == 0 {
+		w.partSize *= 2
+	}
+	return nil
+}
+
+func (w *s3Writer) abort() {
+	if w.uploadID == "" {
+		return
+	}
+	resp, err := w.target.client.do(http.MethodDelete, w.target.bucket, w.key, url.Values{"uploadId": {w.uploadID}}, nil)
+	w.uploadID = ""
+	if err != nil {
+		log.Warn().Err(err).Msgf(L("Failed to abort the upload of %s"), w.key)
+		return
+	}
+	resp.Body.Close()
+}
+
+// Abort cancels the upload: the uploaded parts are deleted and nothing is stored.
+func (w *s3Writer) Abort() error {
+	w.err = errors.New(L("writing to an aborted S3 object"))
+	w.buffer.Reset()
+	w.abort()
+	return nil
+}
+
+// Close uploads the remaining data and completes the upload.
+func (w *s3Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	// Prevent further writes
+	w.err = errors.New(L("writing to a closed S3 object"))
+
+	if w.uploadID == "" {
+		resp, err := w.target.client.do(http.MethodPut, w.target.bucket, w.key, nil, w.buffer.Bytes())
+		if err != nil {
+			return utils.Errorf(err, L("failed to upload %s"), w.key)
+		}
+		return resp.Body.Close()
+	}
+
+	if w.buffer.Len() > 0 {
+		if err := w.uploadPart(w.buffer.Bytes()); err != nil {
+			w.abort()
+			return err
+		}
+	}
+
+	completion := struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: w.parts}
+	body, err := xml.Marshal(completion)
+	if err != nil {
+		w.abort()
+		return err
+	}
+	query := url.Values{"uploadId": {w.uploadID}}
+	if err := w.target.client.doXML(http.MethodPost, w.target.bucket, w.key, query, body, nil); err != nil {
+		w.abort()
+		return utils.Errorf(err, L("failed to complete the upload of %s"), w.key)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// sftpTarget stores the backups on a remote host using the SFTP subsystem of the ssh client.
+//
+// Only the SFTP protocol is used, the accounts restricted to internal-sftp or chrooted are supported.
+// The ssh configuration, agent and known hosts of the user running the command are used.
+type sftpTarget struct {
+	host     string
+	port     string
+	identity string
+	root     string
+	// connection is shared with the sub targets.
+	connection *sftpConnection
+}
+
+// sftpConnection is the SFTP session of a target, started on first use and reused by the next ones.
+type sftpConnection struct {
+	mutex   sync.Mutex
+	session *sftpSession
+}
+
+// connectSftp is a function pointer to replace the ssh connection in unit tests.
+var connectSftp = (*sftpTarget).connect
+
+func newSftpTarget(location *url.URL, flags *TargetFlags) (*sftpTarget, error) {
+	if location.Hostname() == "" {
+		return nil, fmt.Errorf(L("missing host in backup location %s"), location.Redacted())
+	}
+	if !utils.IsInstalled("ssh") {
+		return nil, errors.New(L("install ssh before running this command"))
+	}
+	host := location.Hostname()
+	if location.User != nil {
+		host = location.User.Username() + "@" + host
+	}
+	root := location.Path
+	if root == "" {
+		root = "."
+	}
+	return &sftpTarget{
+		host:       host,
+		port:       location.Port(),
+		identity:   flags.SSH.Identity,
+		root:       root,
+		connection: &sftpConnection{},
+	}, nil
+}
+
+func (t *sftpTarget) path(name string) string {
+	return path.Join(t.root, name)
+}
+
+// connect starts an SFTP session with the remote host.
+func (t *sftpTarget) connect() (*sftpSession, error) {
+	args := []string{"-o", "BatchMode=yes"}
+	if t.port != "" {
+		args = append(args, "-p", t.port)
+	}
+	if t.identity != "" {
+		args = append(args, "-i", t.identity)
+	}
+	args = append(args, "-s", t.host, "sftp")
+	log.Debug().Msgf("Running: ssh %s", strings.Join(args, " "))
+	cmd := exec.Command("ssh", args...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	input, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, utils.Errorf(err, L("failed to connect to %s"), t.String())
+	}
+
+	session, err := newSftpSession(input, output, func() error {
+		if err := cmd.Wait(); err != nil {
+			if message := strings.TrimSpace(stderr.String()); message != "" {
+				return errors.New(message)
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to connect to %s"), t.String())
+	}
+	return session, nil
+}
+
+// session returns the SFTP session of the target, connecting to the remote host on first use.
+func (t *sftpTarget) session() (*sftpSession, error) {
+	t.connection.mutex.Lock()
+	defer t.connection.mutex.Unlock()
+	if t.connection.session == nil {
+		session, err := connectSftp(t)
+		if err != nil {
+			return nil, err
+		}
+		t.connection.session = session
+	}
+	return t.connection.session, nil
+}
+
+// Close ends the SFTP session shared by the target and its sub targets.
+func (t *sftpTarget) Close() error {
+	t.connection.mutex.Lock()
+	defer t.connection.mutex.Unlock()
+	if t.connection.session == nil {
+		return nil
+	}
+	err := t.connection.session.Close()
+	t.connection.session = nil
+	return err
+}
+
+func (t *sftpTarget) Create(name string) (TargetWriter, error) {
+	session, err := t.session()
+	if err != nil {
+		return nil, err
+	}
+	file := t.path(name)
+	if err := session.mkdirAll(path.Dir(file)); err != nil {
+		return nil, utils.Errorf(err, L("failed to create %s"), t.location(path.Dir(name)))
+	}
+	handle, err := session.open(file, sftpOpenWrite|sftpOpenCreate|sftpOpenTrunc, 0600)
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to create %s"), t.location(name))
+	}
+	return &sftpFileWriter{session: session, file: file, handle: handle}, nil
+}
+
+func (t *sftpTarget) Open(name string) (io.ReadCloser, error) {
+	session, err := t.session()
+	if err != nil {
+		return nil, err
+	}
+	handle, err := session.open(t.path(name), sftpOpenRead, 0)
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to open %s"), t.location(name))
+	}
+	return &sftpFileReader{session: session, handle: handle}, nil
+}
+
+func (t *sftpTarget) List(dir string) ([]TargetEntry, error) {
+	session, err := t.session()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := session.readDir(t.path(dir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []TargetEntry{}, nil
+	}
+	if err != nil {
+		return nil, utils.Errorf(err, L("failed to list %s"), t.location(dir))
+	}
+	entries := []TargetEntry{}
+	for _, file := range files {
+		entries = append(entries, TargetEntry{Name: file.name, Size: int64(file.attrs.size), IsDir: file.attrs.isDir()})
+	}
+	return entries, nil
+}
+
+func (t *sftpTarget) RemoveAll(dir string) error {
+	session, err := t.session()
+	if err != nil {
+		return err
+	}
+
+	if err := session.removeAll(t.path(dir)); err != nil {
+		return utils.Errorf(err, L("failed to remove %s"), t.location(dir))
+	}
+	return nil
+}
+
+func (t *sftpTarget) Sub(dir string) Target {
+	sub := *t
+	sub.root = t.path(dir)
+	return &sub
+}
+
+func (t *sftpTarget) LocalPath(_ string) (string, bool) {
+	return "", false
+}
+
+func (t *sftpTarget) location(name string) string {
+	host := t.host
+	if t.port != "" {
+		host += ":" + t.port
+	}
+	return fmt.Sprintf("%s://%s%s", sftpScheme, host, path.Join("/", t.path(name)))
+}
+
+func (t *sftpTarget) String() string {
+	return t.location("")
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestNewTarget(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "minioadmin")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "minioadmin")
+	flags := TargetFlags{}
+	flags.S3.Endpoint = "http://localhost:9000"
+
+	data := []struct {
+		location string
+		expected string
+	}{
+		{"/var/backups", "/var/backups"},
+		{"backups", "backups"},
+		{"s3://bucket/uyuni/", "s3://bucket/uyuni"},
+		{"sftp://backup@storage.example.com:2222/srv/backups", "sftp://backup@storage.example.com:2222/srv/backups"},
+	}
+
+	for i, test := range data {
+		target, err := NewTarget(test.location, &flags)
+		testutils.AssertNoError(t, fmt.Sprintf("failed to create target for case %d", i), err)
+		testutils.AssertEquals(t, fmt.Sprintf("Unexpected target for case %d", i), test.expected, target.String())
+	}
+
+	_, err := NewTarget("ftp://storage.example.com/backups", &flags)
+	testutils.AssertError(t, "unsupported backup location", err)
+}
+
+func TestLocalTarget(t *testing.T) {
+	target, err := NewTarget(t.TempDir(), &TargetFlags{})
+	testutils.AssertNoError(t, "failed to create target", err)
+
+	empty, err := IsEmpty(target)
+	testutils.AssertNoError(t, "failed to check empty target", err)
+	testutils.AssertTrue(t, "New target should be empty", empty)
+
+	testutils.AssertNoError(t, "failed to write file", writeTargetFile(target, "volumes/etc-rhn.tar", []byte("volume")))
+	testutils.AssertNoError(t, "failed to write file", writeTargetFile(target, ManifestFile, []byte("{}")))
+
+	size, err := TargetSize(target, "")
+	testutils.AssertNoError(t, "failed to compute size", err)
+	testutils.AssertEquals(t, "Unexpected size", int64(8), size)
+	testutils.AssertTrue(t, "File in subdirectory should exist", FileExists(target, "volumes/etc-rhn.tar"))
+
+	entries, err := target.List("missing")
+	testutils.AssertNoError(t, "missing directory should have no entry", err)
+	testutils.AssertEquals(t, "Unexpected entries in missing directory", 0, len(entries))
+}
+
+func TestSftpTarget(t *testing.T) {
+	// Fake ssh only to pass the installation check, the SFTP server is faked
+	binDir := t.TempDir()
+	if err := os.WriteFile(path.Join(binDir, "ssh"), []byte("#!/bin/sh\nexit 1\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+	connectSftp = connectFakeSftp(t.TempDir())
+	defer func() { connectSftp = (*sftpTarget).connect }()
+
+	target, err := NewTarget("sftp://backup@storage.example.com/srv/backups", &TargetFlags{})
+	testutils.AssertNoError(t, "failed to create target", err)
+
+	writer, err := CreateArtifact(target, "volumes/etc-rhn.tar", &ArtifactFlags{})
+	testutils.AssertNoError(t, "failed to create artifact", err)
+	_, err = writer.Write([]byte("volume"))
+	testutils.AssertNoError(t, "failed to write artifact", err)
+	testutils.AssertNoError(t, "failed to close artifact", writer.Close())
+
+	entries, err := target.List(VolumesSubdir)
+	testutils.AssertNoError(t, "failed to list", err)
+	testutils.AssertEquals(t, "Unexpected entries", 2, len(entries))
+	testutils.AssertNoError(t, "failed to validate artifact", ValidateArtifact(target, "volumes/etc-rhn.tar"))
+
+	testutils.AssertNoError(t, "failed to remove", target.RemoveAll(VolumesSubdir))
+	empty, err := IsEmpty(target)
+	testutils.AssertNoError(t, "failed to check empty target", err)
+	testutils.AssertTrue(t, "Target should be empty after removal", empty)
+}
+
+func TestJoinLocation(t *testing.T) {
+	testutils.AssertEquals(t, "Unexpected URL location", "s3://bucket/prefix/20260301-020000",
+		JoinLocation("s3://bucket/prefix/", "20260301-020000"))
+	testutils.AssertEquals(t, "Unexpected local location", "/backups/20260301-020000",
+		JoinLocation("/backups", "20260301-020000"))
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+// Backup error indicating if something was already backed up (resp. restored) or not.
+type BackupError struct {
+	Err         error
+	DataRemains bool
+	Abort       bool
+}
+
+func (e *BackupError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BackupError) Unwrap() error {
+	return e.Err
+}
+
+// Wrap error with metadata indicating this error was fatal and job was aborted.
+func AbortError(err error, dataRemains bool) error {
+	if err == nil {
+		return nil
+	}
+	return &BackupError{
+		Err:         err,
+		DataRemains: dataRemains,
+		Abort:       true,
+	}
+}
+
+// Wrap error with metadata indicating this error was not fatal.
+func ReportError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &BackupError{
+		Err:         err,
+		DataRemains: true,
+		Abort:       false,
+	}
+}
+
+// Map of podman secret name and value.
+type BackupSecretMap struct {
+	Name   string
+	Secret string
+}
@@ -2,13 +2,11 @@
 //
 // SPDX-License-Identifier: Apache-2.0
 
-package shared
+package backup
 
 import (
 	"errors"
-	"os"
 	"os/exec"
-	"path/filepath"
 
 	"github.com/rs/zerolog/log"
 	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
@@ -35,11 +33,7 @@ func StorageCheck(volumes []string, images []string, outputDirectory string) err
 
 	// calculate required space
 	for _, volume := range volumes {
-		mountPoint, err := podman.GetVolumeMountPoint(volume)
-		if err != nil {
-			return err
-		}
-		volumeSize, err := dirSize(mountPoint)
+		volumeSize, err := VolumeSize(volume)
 		if err != nil {
 			return err
 		}
@@ -50,7 +44,7 @@ func StorageCheck(volumes []string, images []string, outputDirectory string) err
 	for _, image := range images {
 		// This is over estimating the actual size on disk since the layers can be shared,
 		// but that can't be bad to have more disk than actually needed.
-		size, err := podman.GetImageVirtualSize(image)
+		size, err := podman.GetImageVirtualSize(nil, image)
 		if err != nil {
 			return err
 		}
@@ -71,18 +65,7 @@ func SanityChecks() error {
 	return nil
 }
 
-func dirSize(path string) (int64, error) {
-	var size int64
-	err := filepath.WalkDir(path, func(_ string, entry os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		info, err := entry.Info()
-		if err != nil {
-			return err
-		}
-		size += info.Size()
-		return nil
-	})
-	return size, err
+// VolumeSize returns the size of the files stored in a podman volume.
+func VolumeSize(volume string) (int64, error) {
+	return podman.GetVolumeSize(nil, volume)
 }
@@ -25,16 +25,18 @@ import (
 	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
-var runner = utils.NewRunner
+var runner = (*utils.Host).NewRunner
 
 // SetRunner allows mocking the runner for tests.
 func SetRunner(r func(command string, args ...string) types.Runner) {
-	runner = r
+	runner = func(_ *utils.Host, command string, args ...string) types.Runner {
+		return r(command, args...)
+	}
 }
 
 // ResetRunner resets the runner to the default implementation.
 func ResetRunner() {
-	runner = utils.NewRunner
+	runner = (*utils.Host).NewRunner
 }
 
 // Connection contains information about how to connect to the server.
@@ -47,6 +49,7 @@ type Connection struct {
 	container        string
 	user             string
 	systemd          podman.Systemd
+	host             *utils.Host
 }
 
 // NewConnection creates a new connection object.
@@ -69,9 +72,30 @@ func NewUserConnection(backend string, container string, kubernetesFilter string
 	return &cnx
 }
 
+// NewHostConnection creates a new connection object to a container running on a podman host.
+// A nil host is the local machine.
+func NewHostConnection(host *utils.Host, backend string, container string, kubernetesFilter string) *Connection {
+	cnx := NewConnection(backend, container, kubernetesFilter)
+	cnx.host = host
+	cnx.systemd = podman.NewSystemdOnHost(host)
+	return cnx
+}
+
+// Host returns the host where the container is running, nil for the local machine.
+func (c *Connection) Host() *utils.Host {
+	return c.host
+}
+
 // GetCommand validates or guesses the connection backend command.
 func (c *Connection) GetCommand() (string, error) {
 	var err error
+	if c.command == "" && c.host.IsRemote() {
+		// Only podman can reach the containers of a remote host
+		if c.backend != "" && c.backend != "podman" {
+			return "", fmt.Errorf(L("%s backend is not supported on a remote host"), c.backend)
+		}
+		c.command = "podman"
+	}
 	if c.command == "" {
 		switch c.backend {
 		case "podman":
@@ -93,7 +117,7 @@ func (c *Connection) GetCommand() (string, error) {
 			_, err = exec.LookPath("kubectl")
 			if err == nil {
 				hasKubectl = true
-				if out, err := runner("kubectl", "--request-timeout=30s", "get", "deploy", c.kubernetesFilter,
+				if out, err := runner(nil, "kubectl", "--request-timeout=30s", "get", "deploy", c.kubernetesFilter,
 					"-A", "-o=jsonpath={.items[*].metadata.name}",
 				).Log(zerolog.DebugLevel).Spinner("").Exec(); err != nil {
 					log.Info().Msg(L("kubectl not configured to connect to a cluster, ignoring"))
@@ -108,7 +132,7 @@ func (c *Connection) GetCommand() (string, error) {
 			for _, bin := range bins {
 				if _, err = exec.LookPath(bin); err == nil {
 					hasPodman = true
-					if _, checkErr := runner(bin, "inspect", c.container, "--format", "{{.Name}}").
+					if _, checkErr := runner(c.host, bin, "inspect", c.container, "--format", "{{.Name}}").
 						Spinner("").Exec(); checkErr == nil {
 						c.command = bin
 						break
@@ -178,7 +202,7 @@ func (c *Connection) GetNamespace(appName string) (string, error) {
 	// retrieving namespace from the first installed object we can find matching the filter.
 	// This assumes that the server or proxy has been installed only in one namespace
 	// with the current cluster credentials.
-	out, err := runner("kubectl", "get", "all", "-A", c.kubernetesFilter, "-o",
+	out, err := runner(nil, "kubectl", "get", "all", "-A", c.kubernetesFilter, "-o",
 		"jsonpath={.items[*].metadata.namespace}").Log(zerolog.DebugLevel).Spinner("").Exec()
 	if err != nil {
 		return "", utils.Errorf(err, L("failed to guest namespace"))
@@ -201,7 +225,7 @@ func (c *Connection) GetPodName() (string, error) {
 		case "podman-remote":
 			fallthrough
 		case "podman":
-			if out, _ := runner(c.command, "ps", "-q", "-f", "name="+c.container).
+			if out, _ := runner(c.host, c.command, "ps", "-q", "-f", "name="+c.container).
 				Log(zerolog.DebugLevel).Spinner("").Exec(); len(out) == 0 {
 				err = fmt.Errorf(L("container %s is not running on podman"), c.container)
 			} else {
@@ -210,7 +234,7 @@ func (c *Connection) GetPodName() (string, error) {
 			}
 		case "kubectl":
 			// We try the first item on purpose to make the command fail if not available
-			if podName, _ := runner("kubectl", "get", "pod", c.kubernetesFilter, "-A",
+			if podName, _ := runner(nil, "kubectl", "get", "pod", c.kubernetesFilter, "-A",
 				"-o=jsonpath={.items[0].metadata.name}").Log(zerolog.DebugLevel).Spinner("").Exec(); len(podName) == 0 {
 				err = fmt.Errorf(L("container labeled %s is not running on kubectl"), c.kubernetesFilter)
 			} else {
@@ -241,9 +265,10 @@ func (c *Connection) Exec(command string, args ...string) ([]byte, error) {
 	if cmd == "host" {
 		if c.user != "" {
 			fullCommand := quoteArgs(append([]string{command}, args...))
-			return runner("su", "-", c.user, "-c", fullCommand).Log(zerolog.DebugLevel).Spinner("").Exec()
+			return runner(c.host, "su", "-", c.user, "-c", fullCommand).
+				Log(zerolog.DebugLevel).Spinner("").Exec()
 		}
-		return runner(command, args...).Log(zerolog.DebugLevel).Spinner("").Exec()
+		return runner(c.host, command, args...).Log(zerolog.DebugLevel).Spinner("").Exec()
 	}
 
 	cmdArgs := []string{"exec", c.podName}
@@ -266,7 +291,7 @@ func (c *Connection) Exec(command string, args ...string) ([]byte, error) {
 	}
 	cmdArgs = append(cmdArgs, shellArgs...)
 
-	return runner(cmd, cmdArgs...).Log(zerolog.DebugLevel).Spinner("").Exec()
+	return runner(c.host, cmd, cmdArgs...).Log(zerolog.DebugLevel).Spinner("").Exec()
 }
 
 func quoteArgs(args []string) string {
@@ -326,7 +351,7 @@ func (c *Connection) Healthcheck() error {
 
 	cmdArgs := []string{"inspect", "--format", "{{ .State.Health.Status }}", c.podName}
 
-	out, err := runner(cmd, cmdArgs...).Log(zerolog.DebugLevel).Spinner("").Exec()
+	out, err := runner(c.host, cmd, cmdArgs...).Log(zerolog.DebugLevel).Spinner("").Exec()
 	if err != nil {
 		return err
 	}
@@ -360,7 +385,7 @@ func (c *Connection) WaitForContainer() error {
 			args = append(args, "--")
 		}
 		args = append(args, "true")
-		_, err = runner(command, args...).Spinner("").Exec()
+		_, err = runner(c.host, command, args...).Spinner("").Exec()
 		if err == nil {
 			return nil
 		}
@@ -455,7 +480,7 @@ func (c *Connection) Copy(src string, dst string, user string, group string) err
 		return fmt.Errorf(L("unknown container kind: %s"), command)
 	}
 
-	if _, err := runner(command, commandArgs...).Log(zerolog.DebugLevel).StdMapping().Exec(); err != nil {
+	if _, err := runner(c.host, command, commandArgs...).Log(zerolog.DebugLevel).StdMapping().Exec(); err != nil {
 		return err
 	}
 
@@ -479,7 +504,7 @@ func (c *Connection) Copy(src string, dst string, user string, group string) err
 			execArgs = append(execArgs, "chown", owner, dstPath)
 		}
 
-		_, err := runner(command, execArgs...).Log(zerolog.DebugLevel).StdMapping().Exec()
+		_, err := runner(c.host, command, execArgs...).Log(zerolog.DebugLevel).StdMapping().Exec()
 		return err
 	}
 	return nil
@@ -509,12 +534,12 @@ func (c *Connection) TestExistenceInPod(dstpath string) bool {
 		commandArgs = append(commandArgs, "-n", namespace)
 		commandArgs = append(commandArgs, "-c", "uyuni", "test", "-e", dstpath)
 	case "host":
-		return utils.FileExists(dstpath)
+		return c.host.FileExists(dstpath)
 	default:
 		log.Fatal().Msgf(L("unknown container kind: %s"), command)
 	}
 
-	if _, err := runner(command, commandArgs...).Log(zerolog.DebugLevel).Spinner("").Exec(); err != nil {
+	if _, err := runner(c.host, command, commandArgs...).Log(zerolog.DebugLevel).Spinner("").Exec(); err != nil {
 		return false
 	}
 	return true
@@ -525,11 +550,11 @@ func (c *Connection) CopyCaCertificate(fqdn string) error {
 	log.Info().Msg(L("Copying the SSL CA certificate to the host"))
 
 	pkiDir := "/etc/pki/trust/anchors/"
-	if !utils.FileExists(pkiDir) {
+	if !c.host.FileExists(pkiDir) {
 		pkiDir = "/etc/pki/ca-trust/source/anchors" // RedHat
-		if !utils.FileExists(pkiDir) {
+		if !c.host.FileExists(pkiDir) {
 			pkiDir = "/usr/local/share/ca-certificates" // Debian and Ubuntu
-			if !utils.FileExists(pkiDir) {
+			if !c.host.FileExists(pkiDir) {
 				pkiDir = "/etc/ssl/certs" // OpenSSL fallback
 			}
 		}
@@ -537,19 +562,28 @@ func (c *Connection) CopyCaCertificate(fqdn string) error {
 	hostPath := path.Join(pkiDir, fqdn+".crt")
 
 	const containerCertPath = "server:/etc/pki/trust/anchors/LOCAL-RHN-ORG-TRUSTED-SSL-CERT"
-	if err := c.Copy(containerCertPath, hostPath, "root", "root"); err != nil {
+	if c.host.IsRemote() {
+		// podman cp would copy the certificate on the local machine
+		cert, err := c.Exec("cat", strings.Replace(containerCertPath, "server:", "", 1))
+		if err != nil {
+			return err
+		}
+		if err := c.host.WriteFile(hostPath, cert, 0644); err != nil {
+			return err
+		}
+	} else if err := c.Copy(containerCertPath, hostPath, "root", "root"); err != nil {
 		return err
 	}
 
 	log.Info().Msg(L("Updating host trusted certificates"))
-	if utils.CommandExists("update-ca-certificates") {
-		_, err := runner("update-ca-certificates").Log(zerolog.DebugLevel).StdMapping().Exec()
+	if c.host.IsInstalled("update-ca-certificates") {
+		_, err := runner(c.host, "update-ca-certificates").Log(zerolog.DebugLevel).StdMapping().Exec()
 		return err // openSUSE, Debian and Ubuntu
-	} else if utils.CommandExists("update-ca-trust") {
-		_, err := runner("update-ca-trust").Log(zerolog.DebugLevel).StdMapping().Exec()
+	} else if c.host.IsInstalled("update-ca-trust") {
+		_, err := runner(c.host, "update-ca-trust").Log(zerolog.DebugLevel).StdMapping().Exec()
 		return err // RedHat
-	} else if utils.CommandExists("trust") {
-		_, err := runner("trust", "anchor", "--store", hostPath).Log(zerolog.DebugLevel).StdMapping().Exec()
+	} else if c.host.IsInstalled("trust") {
+		_, err := runner(c.host, "trust", "anchor", "--store", hostPath).Log(zerolog.DebugLevel).StdMapping().Exec()
 		return err // Fallback
 	}
 	return errors.New(L("Unable to update host trusted certificates."))
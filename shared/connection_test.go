@@ -11,6 +11,7 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
 type mockRunner struct {
@@ -37,7 +38,7 @@ func TestExecScript(t *testing.T) {
 
 	var capturedCommands [][]string
 
-	runner = func(command string, args ...string) types.Runner {
+	runner = func(_ *utils.Host, command string, args ...string) types.Runner {
 		capturedCommands = append(capturedCommands, append([]string{command}, args...))
 		if command == "podman" {
 			// exec command
@@ -111,7 +112,7 @@ func TestHostExec(t *testing.T) {
 	var capturedCommand string
 	var capturedArgs []string
 
-	runner = func(command string, args ...string) types.Runner {
+	runner = func(_ *utils.Host, command string, args ...string) types.Runner {
 		capturedCommand = command
 		capturedArgs = args
 		return &mockRunner{output: []byte("host output")}
@@ -144,7 +145,7 @@ func TestHostCopy(t *testing.T) {
 	var capturedCommand string
 	var capturedArgs []string
 
-	runner = func(command string, args ...string) types.Runner {
+	runner = func(_ *utils.Host, command string, args ...string) types.Runner {
 		capturedCommand = command
 		capturedArgs = args
 		return &mockRunner{}
@@ -177,7 +178,7 @@ func TestHostInferredCopy(t *testing.T) {
 	var capturedCommand string
 	var capturedArgs []string
 
-	runner = func(command string, args ...string) types.Runner {
+	runner = func(_ *utils.Host, command string, args ...string) types.Runner {
 		capturedCommand = command
 		capturedArgs = args
 		return &mockRunner{}
@@ -223,7 +224,7 @@ func TestHostUserExec(t *testing.T) {
 	var capturedCommand string
 	var capturedArgs []string
 
-	runner = func(command string, args ...string) types.Runner {
+	runner = func(_ *utils.Host, command string, args ...string) types.Runner {
 		capturedCommand = command
 		capturedArgs = args
 		return &mockRunner{output: []byte("host output")}
@@ -263,7 +264,7 @@ func TestPodmanUserExec(t *testing.T) {
 	var capturedCommand string
 	var capturedArgs []string
 
-	runner = func(command string, args ...string) types.Runner {
+	runner = func(_ *utils.Host, command string, args ...string) types.Runner {
 		capturedCommand = command
 		capturedArgs = args
 		return &mockRunner{output: []byte("podman output")}
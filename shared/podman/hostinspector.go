@@ -42,14 +42,14 @@ type HostInspectData struct {
 }
 
 // InspectHost gathers data on the host where to install the server or proxy.
-func InspectHost() (*HostInspectData, error) {
+func InspectHost(host *utils.Host) (*HostInspectData, error) {
 	inspector := NewHostInspector()
 	script, err := inspector.GenerateScript()
 	if err != nil {
 		return nil, err
 	}
 
-	out, err := newRunner("bash", "-c", script).Log(zerolog.DebugLevel).Exec()
+	out, err := newRunner(host, "bash", "-c", script).Log(zerolog.DebugLevel).Exec()
 	if err != nil {
 		return nil, utils.Errorf(err, L("failed to run inspect script in host system"))
 	}
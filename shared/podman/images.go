@@ -25,12 +25,20 @@ import (
 
 var rpmImageDir = "/usr/share/suse-docker-images/native/"
 
-var newRunner = utils.NewRunner
+var newRunner = (*utils.Host).NewRunner
 
 // PrepareImage ensures the container image is pulled or pull it if the pull policy allows it.
 //
 // Returns the image name to use. Note that it may be changed if the image has been loaded from a local RPM package.
-func PrepareImage(authFile string, image string, pullPolicy string, pullEnabled bool) (string, error) {
+//
+// If a signature policy is passed, the images are pulled with it and refused if their signature is not valid.
+// They are then pulled even if pullEnabled is false: they would otherwise be pulled unverified
+// when the component is enabled later.
+func PrepareImage(
+	host *utils.Host, authFile string, signaturePolicy string, image string, pullPolicy string, pullEnabled bool,
+) (string, error) {
+	verify := signaturePolicy != ""
+
 	//image here should start with registry and end with tag
 	if !hasRegistry(image) {
 		return "", fmt.Errorf(L("Cannot prepare image %s because registry is missing"), image)
@@ -42,13 +50,16 @@ func PrepareImage(authFile string, image string, pullPolicy string, pullEnabled
 	if strings.ToLower(pullPolicy) != "always" {
 		log.Info().Msgf(L("Ensure image %s is available"), image)
 
-		presentImage, err := IsImagePresent(image)
+		presentImage, err := IsImagePresent(host, image)
 		if err != nil {
 			return image, err
 		}
 
 		if len(presentImage) > 0 {
 			log.Debug().Msgf("Image %s already present", presentImage)
+			if verify {
+				return presentImage, verifyPresentImage(host, authFile, signaturePolicy, image, presentImage, pullPolicy)
+			}
 			return presentImage, nil
 		}
 		log.Debug().Msgf("Image %s is missing", image)
@@ -58,14 +69,19 @@ func PrepareImage(authFile string, image string, pullPolicy string, pullEnabled
 		)
 	}
 
-	if loadedImage, ok := tryLoadRpmImage(image); ok {
+	if verify {
+		// The signatures can only be verified when pulling: the RPM images are not used.
+		if strings.ToLower(pullPolicy) == "never" {
+			return image, unverifiableImageError(image)
+		}
+	} else if loadedImage, ok := tryLoadRpmImage(host, image); ok {
 		return loadedImage, nil
 	}
 
 	if strings.ToLower(pullPolicy) != "never" {
-		if pullEnabled {
+		if pullEnabled || verify {
 			log.Debug().Msgf("Pulling image %s because it is missing and pull policy is not 'never'", image)
-			return image, pullImage(authFile, image)
+			return image, pullImage(host, authFile, signaturePolicy, image)
 		}
 		log.Debug().Msgf("Not pulling image %s, although the pull policy is not 'never', maybe replicas is zero?", image)
 		return image, nil
@@ -74,7 +90,11 @@ func PrepareImage(authFile string, image string, pullPolicy string, pullEnabled
 	return image, fmt.Errorf(L("image %s is missing and cannot be fetched"), image)
 }
 
-func tryLoadRpmImage(image string) (string, bool) {
+func tryLoadRpmImage(host *utils.Host, image string) (string, bool) {
+	if host.IsRemote() {
+		log.Debug().Msgf("Not looking for an RPM image for %[1]s: the RPM images are not on %[2]s", image, host)
+		return "", false
+	}
 	rpmImageFile := GetRpmImagePath(image)
 
 	if len(rpmImageFile) == 0 {
@@ -83,7 +103,7 @@ func tryLoadRpmImage(image string) (string, bool) {
 	}
 
 	log.Debug().Msgf("Image %s present as RPM. Loading it", image)
-	loadedImage, err := loadRpmImage(rpmImageFile)
+	loadedImage, err := loadRpmImage(host, rpmImageFile)
 	if err != nil {
 		// Log warning but do not fail; allow falling back to standard pull
 		log.Warn().Err(err).Msgf(L("Cannot use RPM image for %s"), image)
@@ -96,9 +116,34 @@ func tryLoadRpmImage(image string) (string, bool) {
 }
 
 func PrepareImages(
+	host *utils.Host,
 	authFile string,
+	signaturePolicy string,
 	image types.ImageFlags,
 	pgsqlFlags types.PgsqlFlags,
+) (string, string, error) {
+	serverImage, pgsqlImage, err := ComputeServerImages(host, image, pgsqlFlags)
+	if err != nil {
+		return "", "", err
+	}
+
+	preparedServerImage, err := PrepareImage(host, authFile, signaturePolicy, serverImage, image.PullPolicy, true)
+	if err != nil {
+		return preparedServerImage, "", err
+	}
+
+	preparedPgsqlImage, err := PrepareImage(host, authFile, signaturePolicy, pgsqlImage, image.PullPolicy, true)
+	if err != nil {
+		return preparedServerImage, preparedPgsqlImage, err
+	}
+
+	return preparedServerImage, preparedPgsqlImage, nil
+}
+
+// ComputeServerImages computes the server and database images to use,
+// defaulting to the images of the running containers.
+func ComputeServerImages(
+	host *utils.Host, image types.ImageFlags, pgsqlFlags types.PgsqlFlags,
 ) (string, string, error) {
 	serverImage, err := utils.ComputeImage(image.Registry.Host, utils.DefaultTag, image)
 	if err != nil && len(serverImage) > 0 {
@@ -108,7 +153,7 @@ func PrepareImages(
 	if len(serverImage) <= 0 {
 		log.Debug().Msg("Use deployed image")
 
-		serverImage, err = GetRunningImage(ServerContainerName)
+		serverImage, err = GetRunningImage(host, ServerContainerName)
 		if err != nil {
 			return "", "", utils.Error(err, L("failed to find the image of the currently running server container"))
 		}
@@ -127,23 +172,12 @@ func PrepareImages(
 	if len(pgsqlImage) <= 0 {
 		log.Debug().Msg("Use deployed pgsqlimage")
 
-		pgsqlImage, err = GetRunningImage(DBContainerName)
+		pgsqlImage, err = GetRunningImage(host, DBContainerName)
 		if err != nil {
 			return "", "", utils.Error(err, L("failed to find the image of the currently running db container"))
 		}
 	}
-
-	preparedServerImage, err := PrepareImage(authFile, serverImage, image.PullPolicy, true)
-	if err != nil {
-		return preparedServerImage, "", err
-	}
-
-	preparedPgsqlImage, err := PrepareImage(authFile, pgsqlImage, image.PullPolicy, true)
-	if err != nil {
-		return preparedServerImage, preparedPgsqlImage, err
-	}
-
-	return preparedServerImage, preparedPgsqlImage, nil
+	return serverImage, pgsqlImage, nil
 }
 
 // GetRpmImageName return the RPM Image name and the tag, given an image.
@@ -234,8 +268,8 @@ func GetRpmImagePath(image string) string {
 	return ""
 }
 
-func loadRpmImage(rpmImageBasePath string) (string, error) {
-	out, err := utils.RunCmdOutput(zerolog.DebugLevel, "podman", "load", "--quiet", "--input", rpmImageBasePath)
+func loadRpmImage(host *utils.Host, rpmImageBasePath string) (string, error) {
+	out, err := host.RunCmdOutput(zerolog.DebugLevel, "podman", "load", "--quiet", "--input", rpmImageBasePath)
 	if err != nil {
 		return "", err
 	}
@@ -247,9 +281,9 @@ func loadRpmImage(rpmImageBasePath string) (string, error) {
 }
 
 // IsImagePresent returns the image name if the image is present.
-func IsImagePresent(image string) (string, error) {
+func IsImagePresent(host *utils.Host, image string) (string, error) {
 	log.Debug().Msgf("Checking for %s", image)
-	out, err := utils.RunCmdOutput(zerolog.DebugLevel, "podman", "images", "--format={{ .Repository }}", image)
+	out, err := host.RunCmdOutput(zerolog.DebugLevel, "podman", "images", "--format={{ .Repository }}", image)
 	if err != nil {
 		return "", fmt.Errorf(L("failed to check if image %s has already been pulled"), image)
 	}
@@ -263,7 +297,7 @@ func IsImagePresent(image string) (string, error) {
 		return "", nil
 	}
 	log.Debug().Msgf("Checking for local image of %s", image)
-	out, err = utils.RunCmdOutput(zerolog.DebugLevel, "podman", "images", "--quiet", "localhost/"+splitImage[1])
+	out, err = host.RunCmdOutput(zerolog.DebugLevel, "podman", "images", "--quiet", "localhost/"+splitImage[1])
 	if err != nil {
 		return "", fmt.Errorf(L("failed to check if image %s has already been pulled"), image)
 	}
@@ -275,32 +309,43 @@ func IsImagePresent(image string) (string, error) {
 }
 
 // GetPulledImageName returns the fullname of a pulled image.
-func GetPulledImageName(image string) (string, error) {
+func GetPulledImageName(host *utils.Host, image string) (string, error) {
 	parts := strings.Split(image, "/")
 	imageWithTag := parts[len(parts)-1]
-	out, err := utils.RunCmdOutput(zerolog.DebugLevel, "podman", "images", imageWithTag, "--format", "{{.Repository}}")
+	out, err := host.RunCmdOutput(zerolog.DebugLevel, "podman", "images", imageWithTag, "--format", "{{.Repository}}")
 	if err != nil {
 		return "", fmt.Errorf(L("failed to check if image %s has already been pulled"), parts[len(parts)-1])
 	}
 	return string(bytes.TrimSpace(out)), nil
 }
 
-func pullImage(authFile string, image string) error {
+func pullImage(host *utils.Host, authFile string, signaturePolicy string, image string) error {
 	if utils.ContainsUpperCase(image) {
 		return fmt.Errorf(L("%s should contains just lower case character, otherwise podman pull would fails"), image)
 	}
 	log.Info().Msgf(L("Running podman pull %s"), image)
-	podmanArgs := []string{"pull", image}
 
+	err := host.RunCmdStdMapping(zerolog.DebugLevel, "podman", pullArgs(authFile, signaturePolicy, image)...)
+	if err != nil && signaturePolicy != "" {
+		return utils.Errorf(err, L("failed to pull image %s or to verify its signature"), image)
+	}
+	return err
+}
+
+// pullArgs returns the podman arguments pulling the image, verifying its signature if set up.
+func pullArgs(authFile string, signaturePolicy string, image string) []string {
+	podmanArgs := []string{"pull", image}
+	if signaturePolicy != "" {
+		podmanArgs = append(podmanArgs, "--signature-policy", signaturePolicy)
+	}
 	if authFile != "" {
 		podmanArgs = append(podmanArgs, "--authfile", authFile)
 	}
-
-	return utils.RunCmdStdMapping(zerolog.DebugLevel, "podman", podmanArgs...)
+	return podmanArgs
 }
 
 // ShowAvailableTag returns the list of available tag for a given image.
-func ShowAvailableTag(registry string, image types.ImageFlags, authFile string) error {
+func ShowAvailableTag(host *utils.Host, registry string, image types.ImageFlags, authFile string) error {
 	log.Info().Msgf(L("Running podman image search --list-tags %s --format={{.Tag}}"), image.Name)
 
 	name, err := utils.ComputeImage(registry, utils.DefaultTag, image)
@@ -313,7 +358,7 @@ func ShowAvailableTag(registry string, image types.ImageFlags, authFile string)
 		args = append(args, "--authfile", authFile)
 	}
 
-	out, err := utils.RunCmdOutput(zerolog.DebugLevel, "podman", args...)
+	out, err := host.RunCmdOutput(zerolog.DebugLevel, "podman", args...)
 	if err != nil {
 		return utils.Errorf(err, L("cannot find any tag for image %s"), image)
 	}
@@ -328,10 +373,10 @@ func ShowAvailableTag(registry string, image types.ImageFlags, authFile string)
 }
 
 // GetRunningImage given a container name, return the image name.
-func GetRunningImage(container string) (string, error) {
+func GetRunningImage(host *utils.Host, container string) (string, error) {
 	log.Info().Msgf(L("Running podman ps --filter=name=%s --format={{ .Image }}"), container)
 
-	out, err := utils.RunCmdOutput(
+	out, err := host.RunCmdOutput(
 		zerolog.DebugLevel, "podman", "ps", fmt.Sprintf("--filter=name=%s", container), "--format={{ .Image }}",
 	)
 	if err != nil {
@@ -345,14 +390,14 @@ func GetRunningImage(container string) (string, error) {
 // HasRemoteImage returns true if the image is available remotely.
 //
 // The image has to be a full image with registry, path and tag.
-func HasRemoteImage(image string, authFile string) bool {
+func HasRemoteImage(host *utils.Host, image string, authFile string) bool {
 	args := []string{"search", "--list-tags", "--format", "{{.Name}}:{{.Tag}}", image}
 
 	if authFile != "" {
 		args = append(args, "--authfile", authFile)
 	}
 
-	out, err := newRunner("podman", args...).Exec()
+	out, err := newRunner(host, "podman", args...).Exec()
 
 	if err != nil {
 		return false
@@ -364,14 +409,14 @@ func HasRemoteImage(image string, authFile string) bool {
 
 // DeleteImage deletes a podman image based on its name.
 // If dryRun is set to true, nothing will be done, only messages logged to explain what would happen.
-func DeleteImage(name string, dryRun bool) error {
-	exists := imageExists(name)
+func DeleteImage(host *utils.Host, name string, dryRun bool) error {
+	exists := imageExists(host, name)
 	if exists {
 		if dryRun {
 			log.Info().Msgf(L("Would run %s"), "podman image rm "+name)
 		} else {
 			log.Info().Msgf(L("Run %s"), "podman image rm "+name)
-			err := utils.RunCmd("podman", "image", "rm", name)
+			err := host.RunCmd("podman", "image", "rm", name)
 			if err != nil {
 				return utils.Errorf(err, L("Failed to remove image %s"), name)
 			}
@@ -383,8 +428,10 @@ func DeleteImage(name string, dryRun bool) error {
 // ExportImage saves a podman image based on its name to a specified directory.
 // outputDir option expects already existing directory.
 // If dryRun is set to true, nothing will be done, only messages logged to explain what would happen.
-func ExportImage(name string, outputDir string, dryRun bool) error {
-	exists := imageExists(name)
+//
+// The image is saved on the machine running the command, even from a remote host.
+func ExportImage(host *utils.Host, name string, outputDir string, dryRun bool) error {
+	exists := imageExists(host, name)
 	if exists {
 		baseName, _, _ := strings.Cut(filepath.Base(name), ":")
 		saveCommand := []string{"podman", "image", "save", "--quiet", "-o", path.Join(outputDir, baseName+".tar"), name}
@@ -392,7 +439,7 @@ func ExportImage(name string, outputDir string, dryRun bool) error {
 			log.Info().Msgf(L("Would run %s"), strings.Join(saveCommand, " "))
 		} else {
 			log.Info().Msgf(L("Run %s"), strings.Join(saveCommand, " "))
-			_, err := newRunner(saveCommand[0], saveCommand[1:]...).Exec()
+			_, err := newRunner(host, saveCommand[0], saveCommand[1:]...).Exec()
 			if err != nil {
 				return utils.Errorf(err, L("Failed to export image %s"), name)
 			}
@@ -401,18 +448,18 @@ func ExportImage(name string, outputDir string, dryRun bool) error {
 	return nil
 }
 
-func imageExists(image string) bool {
-	err := utils.RunCmd("podman", "image", "exists", image)
+func imageExists(host *utils.Host, image string) bool {
+	err := host.RunCmd("podman", "image", "exists", image)
 	return err == nil
 }
 
-func RestoreImage(imageFile string, dryRun bool) error {
+func RestoreImage(host *utils.Host, imageFile string, dryRun bool) error {
 	restoreCommand := []string{"podman", "image", "load", "--quiet", "-i", imageFile}
 	if dryRun {
 		log.Info().Msgf(L("Would run %s"), strings.Join(restoreCommand, " "))
 	} else {
 		log.Info().Msgf(L("Run %s"), strings.Join(restoreCommand, " "))
-		_, err := newRunner(restoreCommand[0], restoreCommand[1:]...).Exec()
+		_, err := newRunner(host, restoreCommand[0], restoreCommand[1:]...).Exec()
 		if err != nil {
 			return utils.Errorf(err, L("Failed to restore image %s"), imageFile)
 		}
@@ -436,3 +483,82 @@ func hasTag(image string) bool {
 	lastColon := strings.LastIndex(image, ":")
 	return lastColon != -1
 }
+
+// ImageVersionLabel is the standard label of the images holding their version.
+const ImageVersionLabel = "org.opencontainers.image.version"
+
+// ImageMetadata are the data of an image that can be read without pulling or running it.
+type ImageMetadata struct {
+	Labels map[string]string
+	Env    []string
+	// Size is the size of the image, or of its compressed layers for a remote image.
+	Size int64
+	// Local is true if the image is present locally.
+	Local bool
+}
+
+// GetEnv returns the value of an environment variable of the image.
+func (m *ImageMetadata) GetEnv(name string) string {
+	for _, value := range m.Env {
+		if envValue, found := strings.CutPrefix(value, name+"="); found {
+			return envValue
+		}
+	}
+	return ""
+}
+
+// GetImageMetadata reads the metadata of a local image or of an image of the registry using skopeo.
+//
+// Nothing is pulled: an error is returned if the image is not present on the host and skopeo is not installed.
+// skopeo is always executed on the machine running the command.
+func GetImageMetadata(host *utils.Host, image string, authFile string) (*ImageMetadata, error) {
+	localImage, err := IsImagePresent(host, image)
+	if err != nil {
+		return nil, err
+	}
+	if localImage != "" {
+		out, err := newRunner(host, "podman", "image", "inspect", localImage).Log(zerolog.DebugLevel).Exec()
+		if err != nil {
+			return nil, utils.Errorf(err, L("failed to inspect image %s"), localImage)
+		}
+		var data []struct {
+			Labels map[string]string
+			Size   int64
+			Config struct {
+				Env []string
+			}
+		}
+		if err := json.Unmarshal(out, &data); err != nil || len(data) != 1 {
+			return nil, fmt.Errorf(L("invalid inspect data for image %s"), localImage)
+		}
+		return &ImageMetadata{Labels: data[0].Labels, Env: data[0].Config.Env, Size: data[0].Size, Local: true}, nil
+	}
+
+	if !utils.IsInstalled("skopeo") {
+		return nil, fmt.Errorf(L("image %s is not present and skopeo is not installed to inspect it in the registry"), image)
+	}
+	args := []string{"inspect"}
+	if authFile != "" {
+		args = append(args, "--authfile", authFile)
+	}
+	args = append(args, "docker://"+image)
+	out, err := newRunner(nil, "skopeo", args...).Log(zerolog.DebugLevel).Exec()
+	if err != nil {
+		return nil, utils.Errorf(err, L("image %s is not present and cannot be inspected in the registry"), image)
+	}
+	var data struct {
+		Labels     map[string]string
+		Env        []string
+		LayersData []struct {
+			Size int64
+		}
+	}
+	if err := json.Unmarshal(out, &data); err != nil {
+		return nil, utils.Errorf(err, L("invalid inspect data for image %s"), image)
+	}
+	metadata := ImageMetadata{Labels: data.Labels, Env: data.Env}
+	for _, layer := range data.LayersData {
+		metadata.Size += layer.Size
+	}
+	return &metadata, nil
+}
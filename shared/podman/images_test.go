@@ -155,7 +155,7 @@ func TestPrepareImage(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := PrepareImage("", tc.image, tc.pullPolicy, tc.pullEnabled)
+			res, err := PrepareImage(nil, "", "", tc.image, tc.pullPolicy, tc.pullEnabled)
 
 			if tc.expectError {
 				testutils.AssertError(t, fmt.Sprintf(tc.expectedMsg, tc.image), err)
@@ -4,6 +4,8 @@
 
 package podman
 
+import "github.com/uyuni-project/uyuni-tools/shared/utils"
+
 const (
 	// FragmentPath is a systemd property containing the path of the service file.
 	FragmentPath = "FragmentPath"
@@ -79,4 +81,7 @@ type Systemd interface {
 
 	// GetServiceDefinition returns the output of systemctl cat.
 	GetServiceDefinition(service string) (string, error)
+
+	// Host returns the host where the services are running, nil for the local machine.
+	Host() *utils.Host
 }
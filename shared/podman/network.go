@@ -5,7 +5,6 @@
 package podman
 
 import (
-	"os/exec"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -18,8 +17,8 @@ import (
 const UyuniNetwork = "uyuni"
 
 // HasIpv6Enabled returns whether a podman network has IPv6 enabled.
-func HasIpv6Enabled(network string) bool {
-	hasIpv6, err := utils.RunCmdOutput(zerolog.DebugLevel, "podman", "network", "inspect",
+func HasIpv6Enabled(host *utils.Host, network string) bool {
+	hasIpv6, err := host.RunCmdOutput(zerolog.DebugLevel, "podman", "network", "inspect",
 		"--format", "{{.IPv6Enabled}}", network)
 	if err == nil && strings.TrimSpace(string(hasIpv6)) == "true" {
 		return true
@@ -28,20 +27,20 @@ func HasIpv6Enabled(network string) bool {
 }
 
 // SetupNetwork creates the podman network.
-func SetupNetwork(isProxy bool) error {
+func SetupNetwork(host *utils.Host, isProxy bool) error {
 	log.Info().Msgf(L("Setting up %s network"), UyuniNetwork)
 
-	ipv6Enabled := isIpv6Enabled()
+	ipv6Enabled := IsIpv6Enabled(host)
 
 	// check if network exists before trying to get the IPV6 information
-	networkExists := IsNetworkPresent(UyuniNetwork)
+	networkExists := IsNetworkPresent(host, UyuniNetwork)
 	if networkExists {
 		log.Debug().Msgf("%s network already present", UyuniNetwork)
 		// Check if the uyuni network exists and is IPv6 enabled
-		hasIpv6 := HasIpv6Enabled(UyuniNetwork)
+		hasIpv6 := HasIpv6Enabled(host, UyuniNetwork)
 		if !hasIpv6 && ipv6Enabled {
 			log.Info().Msgf(L("%s network doesn't have IPv6, deleting existing network to enable IPv6 on it"), UyuniNetwork)
-			err := utils.RunCmd("podman", "network", "rm", UyuniNetwork,
+			err := host.RunCmd("podman", "network", "rm", UyuniNetwork,
 				"--log-level", log.Logger.GetLevel().String())
 			if err != nil {
 				return utils.Errorf(err, L("failed to remove %s podman network"), UyuniNetwork)
@@ -60,7 +59,7 @@ func SetupNetwork(isProxy bool) error {
 	if ipv6Enabled {
 		// An IPv6 network on a host where IPv6 is disabled doesn't work: don't try it.
 		// Check if the networkd backend is netavark
-		out, err := utils.RunCmdOutput(zerolog.DebugLevel, "podman", "info", "--format", "{{.Host.NetworkBackend}}")
+		out, err := host.RunCmdOutput(zerolog.DebugLevel, "podman", "info", "--format", "{{.Host.NetworkBackend}}")
 		backend := strings.Trim(string(out), "\n")
 		if err != nil {
 			return utils.Errorf(err, L("failed to find podman's network backend"))
@@ -72,14 +71,15 @@ func SetupNetwork(isProxy bool) error {
 		}
 	}
 	args = append(args, UyuniNetwork)
-	err := utils.RunCmd("podman", args...)
+	err := host.RunCmd("podman", args...)
 	if err != nil {
 		return utils.Errorf(err, L("failed to create %s network with IPv6 enabled"), UyuniNetwork)
 	}
 	return nil
 }
 
-func isIpv6Enabled() bool {
+// IsIpv6Enabled returns whether IPv6 is enabled on the host.
+func IsIpv6Enabled(host *utils.Host) bool {
 	files := []string{
 		"/sys/module/ipv6/parameters/disable",
 		"/proc/sys/net/ipv6/conf/default/disable_ipv6",
@@ -88,7 +88,7 @@ func isIpv6Enabled() bool {
 
 	for _, file := range files {
 		// Mind that we are checking disable files, the semantic is inverted
-		if utils.GetFileBoolean(file) {
+		if host.GetFileBoolean(file) {
 			log.Debug().Msgf("IPv6 is disabled")
 			return false
 		}
@@ -99,15 +99,15 @@ func isIpv6Enabled() bool {
 
 // DeleteNetwork deletes the uyuni podman network.
 // If dryRun is set to true, nothing will be done, only messages logged to explain what would happen.
-func DeleteNetwork(dryRun bool) {
-	err := utils.RunCmd("podman", "network", "exists", UyuniNetwork)
+func DeleteNetwork(host *utils.Host, dryRun bool) {
+	err := host.RunCmd("podman", "network", "exists", UyuniNetwork)
 	if err != nil {
 		log.Info().Msgf(L("Network %s already removed"), UyuniNetwork)
 	} else {
 		if dryRun {
 			log.Info().Msgf(L("Would run %s"), "podman network rm "+UyuniNetwork)
 		} else {
-			err := utils.RunCmd("podman", "network", "rm", UyuniNetwork)
+			err := host.RunCmd("podman", "network", "rm", UyuniNetwork)
 			if err != nil {
 				log.Error().Msgf(L("Failed to remove network %s"), UyuniNetwork)
 			} else {
@@ -118,8 +118,8 @@ func DeleteNetwork(dryRun bool) {
 }
 
 // IsNetworkPresent returns whether a network is already present.
-func IsNetworkPresent(network string) bool {
-	cmd := exec.Command("podman", "network", "exists", network)
+func IsNetworkPresent(host *utils.Host, network string) bool {
+	cmd := host.Command("podman", "network", "exists", network)
 	if err := cmd.Run(); err != nil {
 		return false
 	}
@@ -127,8 +127,8 @@ func IsNetworkPresent(network string) bool {
 }
 
 // IsSecretPresent returns true if podman secret is already present.
-func IsSecretPresent(secret string) bool {
-	cmd := exec.Command("podman", "secret", "exists", secret)
+func IsSecretPresent(host *utils.Host, secret string) bool {
+	cmd := host.Command("podman", "secret", "exists", secret)
 	if err := cmd.Run(); err != nil {
 		return false
 	}
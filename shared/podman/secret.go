@@ -55,10 +55,12 @@ const (
 )
 
 // CreateCredentialsSecretsIfMissing creates the podman secrets, one for the user name and one for the password.
-func CreateCredentialsSecretsIfMissing(userSecret string, user string, passwordSecret string, password string) error {
-	if !HasSecret(userSecret) && !HasSecret(passwordSecret) {
+func CreateCredentialsSecretsIfMissing(
+	host *utils.Host, userSecret string, user string, passwordSecret string, password string,
+) error {
+	if !HasSecret(host, userSecret) && !HasSecret(host, passwordSecret) {
 		if err := CreateCredentialsSecrets(
-			userSecret, user,
+			host, userSecret, user,
 			passwordSecret, password,
 		); err != nil {
 			return err
@@ -71,18 +73,21 @@ func CreateCredentialsSecretsIfMissing(userSecret string, user string, passwordS
 }
 
 // CreateCredentialsSecrets creates the podman secrets, one for the user name and one for the password.
-func CreateCredentialsSecrets(userSecret string, user string, passwordSecret string, password string) error {
-	if err := CreateSecret(userSecret, user); err != nil {
+func CreateCredentialsSecrets(
+	host *utils.Host, userSecret string, user string, passwordSecret string, password string,
+) error {
+	if err := CreateSecret(host, userSecret, user); err != nil {
 		return err
 	}
-	return CreateSecret(passwordSecret, password)
+	return CreateSecret(host, passwordSecret, password)
 }
 
 // CreateCASecrets creates SSL CA.
 func CreateCASecrets(
+	host *utils.Host,
 	caSecret string, caPath string,
 ) error {
-	if err := createSecretFromFile(caSecret, caPath); err != nil {
+	if err := createSecretFromFile(host, caSecret, caPath); err != nil {
 		return utils.Errorf(err, L("failed to create %s secret"), CASecret)
 	}
 	return nil
@@ -90,22 +95,23 @@ func CreateCASecrets(
 
 // CreateTLSSecrets creates SSL CA, Certificate and key secrets.
 func CreateTLSSecrets(
+	host *utils.Host,
 	caSecret string, caPath string,
 	certSecret string, certPath string,
 	keySecret string, keyPath string,
 ) error {
-	if err := createSecretFromFile(certSecret, certPath); err != nil {
+	if err := createSecretFromFile(host, certSecret, certPath); err != nil {
 		return utils.Errorf(err, L("failed to create %s secret"), SSLCertSecret)
 	}
 
-	if err := createSecretFromFile(keySecret, keyPath); err != nil {
+	if err := createSecretFromFile(host, keySecret, keyPath); err != nil {
 		return utils.Errorf(err, L("failed to create %s secret"), SSLKeySecret)
 	}
-	return CreateCASecrets(caSecret, caPath)
+	return CreateCASecrets(host, caSecret, caPath)
 }
 
 // createSecret creates a podman secret.
-func CreateSecret(name string, value string) error {
+func CreateSecret(host *utils.Host, name string, value string) error {
 	tmpDir, cleaner, err := utils.TempDir()
 	if err != nil {
 		return err
@@ -117,17 +123,18 @@ func CreateSecret(name string, value string) error {
 		return utils.Errorf(err, L("failed to write %s secret to file"), name)
 	}
 
-	return createSecretFromFile(name, secretFile)
+	return createSecretFromFile(host, name, secretFile)
 }
 
 // createSecretFromFile creates a podman secret from a file.
 // Removes any already existing secret with that name.
-func createSecretFromFile(name string, secretFile string) error {
-	if err := deleteSecret(name, false); err != nil {
+// The file is read on the machine running the command, even for a remote host.
+func createSecretFromFile(host *utils.Host, name string, secretFile string) error {
+	if err := deleteSecret(host, name, false); err != nil {
 		return err
 	}
 
-	runner := utils.NewRunner("podman", "secret", "create", name, secretFile).Log(zerolog.DebugLevel)
+	runner := host.NewRunner("podman", "secret", "create", name, secretFile).Log(zerolog.DebugLevel)
 	if _, err := runner.Exec(); err != nil {
 		return utils.Errorf(err, L("failed to create podman secret %s"), name)
 	}
@@ -136,19 +143,19 @@ func createSecretFromFile(name string, secretFile string) error {
 }
 
 // HasSecret returns whether the secret is defined or not.
-func HasSecret(name string) bool {
-	return utils.RunCmd("podman", "secret", "exists", name) == nil
+func HasSecret(host *utils.Host, name string) bool {
+	return host.RunCmd("podman", "secret", "exists", name) == nil
 }
 
 // DeleteSecret removes a podman secret.
-func DeleteSecret(name string, dryRun bool) {
-	if err := deleteSecret(name, dryRun); err != nil {
+func DeleteSecret(host *utils.Host, name string, dryRun bool) {
+	if err := deleteSecret(host, name, dryRun); err != nil {
 		log.Error().Err(err).Send()
 	}
 }
 
-func deleteSecret(name string, dryRun bool) error {
-	if !HasSecret(name) {
+func deleteSecret(host *utils.Host, name string, dryRun bool) error {
+	if !HasSecret(host, name) {
 		return nil
 	}
 
@@ -157,7 +164,7 @@ func deleteSecret(name string, dryRun bool) error {
 	if dryRun {
 		log.Info().Msgf(L("Would run %s"), command)
 	} else {
-		runner := utils.NewRunner("podman", args...).Log(zerolog.DebugLevel)
+		runner := host.NewRunner("podman", args...).Log(zerolog.DebugLevel)
 		if _, err := runner.Exec(); err != nil {
 			return utils.Errorf(err, L("Failed to delete %s secret"), name)
 		}
@@ -166,8 +173,8 @@ func deleteSecret(name string, dryRun bool) error {
 }
 
 // GetSecret gets the content of a podman secret given its name.
-func GetSecret(name string) (string, error) {
-	out, err := newRunner("podman", "secret", "inspect", "--showsecret", name, "--format", "{{.SecretData}}").
+func GetSecret(host *utils.Host, name string) (string, error) {
+	out, err := newRunner(host, "podman", "secret", "inspect", "--showsecret", name, "--format", "{{.SecretData}}").
 		Exec()
 	if err != nil {
 		return "", utils.Errorf(err, L("failed to get the content of the %s secret"), name)
@@ -4,11 +4,14 @@
 
 package podman
 
-import "github.com/rs/zerolog"
+import (
+	"github.com/rs/zerolog"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
 
 // IsSELinuxEnabled reports whether SELinux is enabled or not.
 // It relies on selinuxenabled tool.
-func IsSELinuxEnabled() bool {
-	_, err := runCmdOutput(zerolog.DebugLevel, "selinuxenabled")
+func IsSELinuxEnabled(host *utils.Host) bool {
+	_, err := runCmdOutput(host, zerolog.DebugLevel, "selinuxenabled")
 	return err == nil
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
 func TestIsSELinuxEnabled(t *testing.T) {
@@ -25,10 +26,10 @@ func TestIsSELinuxEnabled(t *testing.T) {
 	}
 
 	for i, testCase := range cases {
-		runCmdOutput = func(_ zerolog.Level, _ string, _ ...string) ([]byte, error) {
+		runCmdOutput = func(_ *utils.Host, _ zerolog.Level, _ string, _ ...string) ([]byte, error) {
 			return []byte(""), testCase.err
 		}
 		caseString := fmt.Sprintf("case %d: ", i)
-		testutils.AssertEquals(t, caseString+"unexpected return value", testCase.expected, IsSELinuxEnabled())
+		testutils.AssertEquals(t, caseString+"unexpected return value", testCase.expected, IsSELinuxEnabled(nil))
 	}
 }
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package podman
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+// policyRequirement is a requirement of a containers-policy.json(5) file.
+type policyRequirement struct {
+	Type    string `json:"type"`
+	KeyType string `json:"keyType,omitempty"`
+	KeyPath string `json:"keyPath,omitempty"`
+}
+
+// policy is the content of a containers-policy.json(5) file.
+type policy struct {
+	Default    []policyRequirement                       `json:"default"`
+	Transports map[string]map[string][]policyRequirement `json:"transports"`
+}
+
+// SetupSignatureVerification writes the containers policy refusing the images without a valid signature.
+//
+// Returns the path to the policy to pass to PrepareImage, empty if the verification is disabled,
+// and the function removing the generated policy.
+func SetupSignatureVerification(host *utils.Host, flags *types.SignatureFlags) (string, func(), error) {
+	if !flags.Verify {
+		return "", func() {}, nil
+	}
+	if host.IsRemote() {
+		return "", nil, errors.New(L("verifying the image signatures is not supported on a remote host"))
+	}
+
+	content, err := newPolicy(flags)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return "", nil, err
+	}
+
+	tempDir, cleaner, err := utils.TempDir()
+	if err != nil {
+		return "", nil, err
+	}
+	policyPath := path.Join(tempDir, "policy.json")
+	if err := os.WriteFile(policyPath, data, 0644); err != nil {
+		cleaner()
+		return "", nil, utils.Errorf(err, L("failed to write %s"), policyPath)
+	}
+	log.Debug().Msgf("Verifying the images signatures with policy:\n%s", data)
+
+	return policyPath, cleaner, nil
+}
+
+// newPolicy creates a policy refusing the images not signed by one of the keys.
+func newPolicy(flags *types.SignatureFlags) (*policy, error) {
+	result := policy{
+		Default:    []policyRequirement{{Type: "reject"}},
+		Transports: map[string]map[string][]policyRequirement{"docker": {}},
+	}
+
+	if flags.Key != "" {
+		requirement, err := newPolicyRequirement(flags.Key, flags.Type)
+		if err != nil {
+			return nil, err
+		}
+		result.Default = []policyRequirement{requirement}
+	}
+
+	for _, key := range flags.Keys {
+		if key.Scope == "" {
+			return nil, fmt.Errorf(L("missing scope for signature key %s"), key.Path)
+		}
+		requirement, err := newPolicyRequirement(key.Path, key.Type)
+		if err != nil {
+			return nil, err
+		}
+		docker := result.Transports["docker"]
+		docker[key.Scope] = append(docker[key.Scope], requirement)
+	}
+
+	if flags.Key == "" && len(flags.Keys) == 0 {
+		return nil, errors.New(L("a signature key is needed to verify the image signatures"))
+	}
+	return &result, nil
+}
+
+func newPolicyRequirement(key string, keyType string) (policyRequirement, error) {
+	keyPath, err := filepath.Abs(key)
+	if err != nil {
+		return policyRequirement{}, err
+	}
+	if !utils.FileExists(keyPath) {
+		return policyRequirement{}, fmt.Errorf(L("signature key %s does not exist"), keyPath)
+	}
+
+	switch keyType {
+	case "", "sigstore":
+		return policyRequirement{Type: "sigstoreSigned", KeyPath: keyPath}, nil
+	case "gpg":
+		return policyRequirement{Type: "signedBy", KeyType: "GPGKeys", KeyPath: keyPath}, nil
+	}
+	return policyRequirement{}, fmt.Errorf(L("unsupported signature key type %s: possible values are sigstore and gpg"),
+		keyType)
+}
+
+// verifyPresentImage verifies the signature of the presentImage already on the host for image.
+//
+// The image is pulled again by digest with the signature policy: only its manifest is downloaded
+// and its tag is not moved to a newer image.
+// The images loaded locally, like the RPM ones, and the ones not allowed to be pulled cannot be verified.
+func verifyPresentImage(
+	host *utils.Host, authFile string, signaturePolicy string, image string, presentImage string, pullPolicy string,
+) error {
+	if presentImage != image || strings.ToLower(pullPolicy) == "never" {
+		return unverifiableImageError(presentImage)
+	}
+
+	reference := image
+	if !strings.Contains(image, "@") {
+		out, err := newRunner(host, "podman", "image", "inspect", "--format", "{{.Digest}}", image).
+			Log(zerolog.DebugLevel).Exec()
+		if err != nil {
+			return utils.Errorf(err, L("failed to get the digest of image %s"), image)
+		}
+		digest := strings.TrimSpace(string(out))
+		reference = strings.TrimSuffix(image, ":"+utils.ImageTag(image)) + "@" + digest
+	}
+
+	log.Info().Msgf(L("Verifying the signature of image %s"), image)
+	return pullImage(host, authFile, signaturePolicy, reference)
+}
+
+func unverifiableImageError(image string) error {
+	return fmt.Errorf(L("cannot verify a locally loaded image: %s needs to be pulled from a registry "+
+		"to verify its signature"), image)
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package podman
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
+)
+
+func TestNewPolicy(t *testing.T) {
+	dir := t.TempDir()
+	cosignKey := path.Join(dir, "cosign.pub")
+	gpgKey := path.Join(dir, "key.gpg")
+	testutils.WriteFile(t, cosignKey, "cosign key")
+	testutils.WriteFile(t, gpgKey, "gpg key")
+
+	result, err := newPolicy(&types.SignatureFlags{
+		Verify: true,
+		Key:    cosignKey,
+		Type:   "sigstore",
+		Keys: []types.SignatureKey{
+			{Scope: "registry.example.com/uyuni", Path: gpgKey, Type: "gpg"},
+			{Scope: "localhost:5000", Path: cosignKey},
+		},
+	})
+	testutils.AssertNoError(t, "failed to create policy", err)
+	testutils.AssertEquals(t, "Unexpected policy", &policy{
+		Default: []policyRequirement{{Type: "sigstoreSigned", KeyPath: cosignKey}},
+		Transports: map[string]map[string][]policyRequirement{
+			"docker": {
+				"registry.example.com/uyuni": {{Type: "signedBy", KeyType: "GPGKeys", KeyPath: gpgKey}},
+				"localhost:5000":             {{Type: "sigstoreSigned", KeyPath: cosignKey}},
+			},
+		},
+	}, result)
+
+	// Without global key, the images outside of the scopes are rejected
+	result, err = newPolicy(&types.SignatureFlags{
+		Verify: true,
+		Keys:   []types.SignatureKey{{Scope: "localhost:5000", Path: cosignKey}},
+	})
+	testutils.AssertNoError(t, "failed to create policy", err)
+	testutils.AssertEquals(t, "Unexpected default policy", []policyRequirement{{Type: "reject"}}, result.Default)
+}
+
+func TestNewPolicyErrors(t *testing.T) {
+	key := path.Join(t.TempDir(), "cosign.pub")
+	testutils.WriteFile(t, key, "cosign key")
+
+	data := []struct {
+		flags    types.SignatureFlags
+		expected string
+	}{
+		{types.SignatureFlags{Verify: true}, "a signature key is needed"},
+		{types.SignatureFlags{Verify: true, Key: "/does/not/exist.pub"}, "signature key /does/not/exist.pub does not exist"},
+		{types.SignatureFlags{Verify: true, Key: key, Type: "x509"}, "unsupported signature key type x509"},
+		{types.SignatureFlags{Verify: true, Keys: []types.SignatureKey{{Path: key}}}, "missing scope for signature key"},
+	}
+	for _, test := range data {
+		_, err := newPolicy(&test.flags)
+		testutils.AssertError(t, test.expected, err)
+	}
+}
+
+func TestSetupSignatureVerification(t *testing.T) {
+	key := path.Join(t.TempDir(), "cosign.pub")
+	testutils.WriteFile(t, key, "cosign key")
+
+	policyPath, cleaner, err := SetupSignatureVerification(nil, &types.SignatureFlags{})
+	testutils.AssertNoError(t, "failed to disable verification", err)
+	cleaner()
+	testutils.AssertEquals(t, "Verification should be disabled", "", policyPath)
+
+	policyPath, cleaner, err = SetupSignatureVerification(nil, &types.SignatureFlags{Verify: true, Key: key})
+	testutils.AssertNoError(t, "failed to set up verification", err)
+	data, err := os.ReadFile(policyPath)
+	testutils.AssertNoError(t, "failed to read policy", err)
+	var content policy
+	testutils.AssertNoError(t, "invalid policy", json.Unmarshal(data, &content))
+	testutils.AssertEquals(t, "Unexpected policy type", "sigstoreSigned", content.Default[0].Type)
+
+	cleaner()
+	testutils.AssertTrue(t, "Policy should be removed", !utils.FileExists(policyPath))
+}
+
+func TestPullArgs(t *testing.T) {
+	const image = "registry.example.com/uyuni/server:latest"
+	testutils.AssertEquals(t, "Unexpected pull arguments without verification",
+		[]string{"pull", image, "--authfile", "auth.json"}, pullArgs("auth.json", "", image))
+	testutils.AssertEquals(t, "The policy should be passed to the pull",
+		[]string{"pull", image, "--signature-policy", "/tmp/policy.json"}, pullArgs("", "/tmp/policy.json", image))
+}
+
+func TestVerifyLocalImage(t *testing.T) {
+	const image = "registry.example.com/uyuni/server:latest"
+	testutils.AssertError(t, "cannot verify a locally loaded image: localhost/uyuni/server:latest",
+		verifyPresentImage(nil, "", "/tmp/policy.json", image, "localhost/uyuni/server:latest", "IfNotPresent"))
+	testutils.AssertError(t, "cannot verify a locally loaded image: "+image,
+		verifyPresentImage(nil, "", "/tmp/policy.json", image, image, "Never"))
+}
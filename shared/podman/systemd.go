@@ -7,8 +7,6 @@ package podman
 import (
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
 	"path"
 	"strings"
 
@@ -89,19 +87,21 @@ type SystemdDriver interface {
 }
 
 type systemdDriverImpl struct {
+	// host is where systemctl is executed.
+	host *utils.Host
 }
 
 // HasService returns if a systemd service is installed.
 // name is the name of the service without the '.service' part.
 func (d *systemdDriverImpl) HasService(name string) bool {
-	err := utils.RunCmd("systemctl", "list-unit-files", name+".service")
+	err := d.host.RunCmd("systemctl", "list-unit-files", unitName(name))
 	return err == nil
 }
 
 // ServiceIsEnabled returns if a service is enabled
 // name is the name of the service without the '.service' part.
 func (d *systemdDriverImpl) ServiceIsEnabled(name string) bool {
-	err := utils.RunCmd("systemctl", "is-enabled", name+".service")
+	err := d.host.RunCmd("systemctl", "is-enabled", unitName(name))
 	return err == nil
 }
 
@@ -112,7 +112,7 @@ func (d *systemdDriverImpl) DisableService(name string) error {
 		log.Debug().Msgf("%s is already disabled.", name)
 		return nil
 	}
-	if err := utils.RunCmd("systemctl", "disable", "--now", name); err != nil {
+	if err := d.host.RunCmd("systemctl", "disable", "--now", name); err != nil {
 		return utils.Errorf(err, L("failed to disable %s systemd service"), name)
 	}
 	return nil
@@ -121,11 +121,11 @@ func (d *systemdDriverImpl) DisableService(name string) error {
 // ReloadDaemon resets the failed state of services and reload the systemd daemon.
 // If dryRun is set to true, nothing happens but messages are logged to explain what would be done.
 func (d *systemdDriverImpl) ReloadDaemon() error {
-	err := utils.RunCmd("systemctl", "reset-failed")
+	err := d.host.RunCmd("systemctl", "reset-failed")
 	if err != nil {
 		return errors.New(L("failed to reset-failed systemd"))
 	}
-	err = utils.RunCmd("systemctl", "daemon-reload")
+	err = d.host.RunCmd("systemctl", "daemon-reload")
 	if err != nil {
 		return errors.New(L("failed to reload systemd daemon"))
 	}
@@ -134,7 +134,7 @@ func (d *systemdDriverImpl) ReloadDaemon() error {
 
 // IsServiceRunning returns whether the systemd service is started or not.
 func (d *systemdDriverImpl) IsServiceRunning(service string) bool {
-	cmd := exec.Command("systemctl", "is-active", "-q", service)
+	cmd := d.host.Command("systemctl", "is-active", "-q", service)
 	if err := cmd.Run(); err != nil {
 		return false
 	}
@@ -143,7 +143,7 @@ func (d *systemdDriverImpl) IsServiceRunning(service string) bool {
 
 // RestartService restarts the systemd service.
 func (d *systemdDriverImpl) RestartService(service string) error {
-	if err := utils.RunCmd("systemctl", "restart", service); err != nil {
+	if err := d.host.RunCmd("systemctl", "restart", service); err != nil {
 		return utils.Errorf(err, L("failed to restart systemd %s.service"), service)
 	}
 	return nil
@@ -151,7 +151,7 @@ func (d *systemdDriverImpl) RestartService(service string) error {
 
 // StartService starts the systemd service.
 func (d *systemdDriverImpl) StartService(service string) error {
-	if err := utils.RunCmd("systemctl", "start", service); err != nil {
+	if err := d.host.RunCmd("systemctl", "start", service); err != nil {
 		return utils.Errorf(err, L("failed to start systemd %s.service"), service)
 	}
 	return nil
@@ -159,7 +159,7 @@ func (d *systemdDriverImpl) StartService(service string) error {
 
 // StopService starts the systemd service.
 func (d *systemdDriverImpl) StopService(service string) error {
-	if err := utils.RunCmd("systemctl", "stop", service); err != nil {
+	if err := d.host.RunCmd("systemctl", "stop", service); err != nil {
 		return utils.Errorf(err, L("failed to stop systemd %s.service"), service)
 	}
 	return nil
@@ -171,7 +171,7 @@ func (d *systemdDriverImpl) EnableService(service string) error {
 		log.Debug().Msgf("%s is already enabled.", service)
 		return d.StartService(service)
 	}
-	if err := utils.RunCmd("systemctl", "enable", "--now", service); err != nil {
+	if err := d.host.RunCmd("systemctl", "enable", "--now", service); err != nil {
 		return utils.Errorf(err, L("failed to enable %s systemd service"), service)
 	}
 	return nil
@@ -182,7 +182,7 @@ func (d *systemdDriverImpl) GetServiceProperty(service string, property string)
 	if strings.HasSuffix(service, "@") {
 		serviceName = service + "0"
 	}
-	out, err := newRunner("systemctl", "show", "-p", property, serviceName).Exec()
+	out, err := newRunner(d.host, "systemctl", "show", "-p", property, serviceName).Exec()
 	if err != nil {
 		return "", utils.Errorf(err, L("Failed to get the %[1]s property from %[2]s service"), property, service)
 	}
@@ -194,18 +194,25 @@ func (d *systemdDriverImpl) GetServiceDefinition(service string) (string, error)
 	if strings.HasSuffix(service, "@") {
 		serviceName = service + "0"
 	}
-	out, err := newRunner("systemctl", "cat", serviceName).Exec()
+	out, err := newRunner(d.host, "systemctl", "cat", serviceName).Exec()
 	if err != nil {
 		return "", utils.Errorf(err, L("Failed to get the definition from %[2]s service"), service)
 	}
 	return strings.TrimSpace(string(out)), nil
 }
 
-// NewSystemd returns a new Systemd instance.
+// NewSystemd returns a new Systemd instance for the local machine.
 func NewSystemd() Systemd {
-	driver := systemdDriverImpl{}
+	return NewSystemdOnHost(nil)
+}
+
+// NewSystemdOnHost returns a new Systemd instance managing the services of a host.
+// A nil host is the local machine.
+func NewSystemdOnHost(host *utils.Host) Systemd {
+	driver := systemdDriverImpl{host: host}
 	return SystemdImpl{
 		driver: &driver,
+		host:   host,
 	}
 }
 
@@ -220,6 +227,14 @@ func NewSystemdWithDriver(driver SystemdDriver) Systemd {
 type SystemdImpl struct {
 	// driver actually calls the systemd executable.
 	driver SystemdDriver
+
+	// host is where the services and their files are.
+	host *utils.Host
+}
+
+// Host returns the host where the services are running.
+func (s SystemdImpl) Host() *utils.Host {
+	return s.host
 }
 
 // HasService returns if a systemd service is installed.
@@ -253,6 +268,19 @@ func (s SystemdImpl) GetServiceDefinition(service string) (string, error) {
 	return s.driver.GetServiceDefinition(service)
 }
 
+// GetTimerPath return the path for a given timer.
+func GetTimerPath(name string) string {
+	return path.Join(servicesPath, name+".timer")
+}
+
+// unitName adds the .service suffix to the name unless it already has a unit type suffix like .timer.
+func unitName(name string) string {
+	if strings.HasSuffix(name, ".service") || strings.HasSuffix(name, ".timer") {
+		return name
+	}
+	return name + ".service"
+}
+
 // GetServiceConfFolder return the conf folder for systemd services.
 func GetServiceConfFolder(name string) string {
 	return path.Join(servicesPath, name+".service.d")
@@ -278,11 +306,11 @@ func (s SystemdImpl) UninstallService(name string, dryRun bool) {
 				log.Error().Err(err).Send()
 			}
 		}
-		uninstallServiceFiles(name, dryRun)
+		uninstallServiceFiles(s.host, name, dryRun)
 	}
 }
 
-func uninstallServiceFiles(name string, dryRun bool) {
+func uninstallServiceFiles(host *utils.Host, name string, dryRun bool) {
 	servicePath := GetServicePath(name)
 	serviceConfFolder := GetServiceConfFolder(name)
 
@@ -291,24 +319,24 @@ func uninstallServiceFiles(name string, dryRun bool) {
 	} else {
 		// Remove the service unit
 		log.Info().Msgf(L("Remove %s"), servicePath)
-		if err := os.Remove(servicePath); err != nil {
+		if err := host.Remove(servicePath); err != nil {
 			log.Error().Err(err).Msgf(L("Failed to remove %s.service file"), name)
 		}
 	}
 
-	if utils.FileExists(serviceConfFolder) {
+	if host.FileExists(serviceConfFolder) {
 		confPaths := []string{
 			GetServiceConfPath(name, GeneratedConf),
 			GetServiceConfPath(name, ServerEnvironmentFile),
 			GetServiceConfPath(name, "Service.conf"),
 		}
 		for _, confPath := range confPaths {
-			if utils.FileExists(confPath) {
+			if host.FileExists(confPath) {
 				if dryRun {
 					log.Info().Msgf(L("Would remove %s"), confPath)
 				} else {
 					log.Info().Msgf(L("Remove %s"), confPath)
-					if err := os.Remove(confPath); err != nil {
+					if err := host.Remove(confPath); err != nil {
 						log.Error().Err(err).Msgf(L("Failed to remove %s file"), confPath)
 					}
 				}
@@ -318,9 +346,9 @@ func uninstallServiceFiles(name string, dryRun bool) {
 		if dryRun {
 			log.Info().Msgf(L("Would remove %s if empty"), serviceConfFolder)
 		} else {
-			if utils.IsEmptyDirectory(serviceConfFolder) {
+			if host.IsEmptyDirectory(serviceConfFolder) {
 				log.Debug().Msgf("Removing %s folder, since it's empty", serviceConfFolder)
-				_ = utils.RemoveDirectory(serviceConfFolder)
+				_ = host.Remove(serviceConfFolder)
 			} else {
 				log.Warn().Msgf(
 					L("%s folder contains file created by the user. Please remove them when uninstallation is completed."),
@@ -342,7 +370,7 @@ func (s SystemdImpl) UninstallInstantiatedService(name string, dryRun bool) {
 		}
 	}
 
-	uninstallServiceFiles(name+"@", dryRun)
+	uninstallServiceFiles(s.host, name+"@", dryRun)
 }
 
 // ReloadDaemon resets the failed state of services and reload the systemd daemon.
@@ -448,7 +476,7 @@ func (s SystemdImpl) ScaleService(replicas int, name string) error {
 
 // Show calls the systemctl show command and returns the output.
 func (s SystemdImpl) Show(service string, property string) (string, error) {
-	out, err := newRunner("systemctl", "show", "--property", property, service).Log(zerolog.DebugLevel).Exec()
+	out, err := newRunner(s.host, "systemctl", "show", "--property", property, service).Log(zerolog.DebugLevel).Exec()
 	if err != nil {
 		return "", utils.Errorf(err, L("failed to show %[1]s property of %[2] systemd service"), property, service)
 	}
@@ -462,9 +490,11 @@ const confHeader = `# This file is generated by mgradm and will be overwritten d
 `
 
 // GenerateSystemdConfFile creates a new systemd service configuration file (e.g. Service.conf).
-func GenerateSystemdConfFile(serviceName string, filename string, body string, withHeader bool) error {
+func GenerateSystemdConfFile(
+	host *utils.Host, serviceName string, filename string, body string, withHeader bool,
+) error {
 	confDir := GetServiceConfFolder(serviceName)
-	if err := os.MkdirAll(confDir, 0755); err != nil {
+	if err := host.MkdirAll(confDir, 0755); err != nil {
 		return utils.Errorf(err, L("failed to create %s folder"), confDir)
 	}
 
@@ -474,7 +504,7 @@ func GenerateSystemdConfFile(serviceName string, filename string, body string, w
 	}
 	content := fmt.Appendf([]byte(header), "[Service]\n%s\n", body)
 	systemdConfFilePath := GetServiceConfPath(serviceName, filename)
-	if err := os.WriteFile(systemdConfFilePath, content, 0644); err != nil {
+	if err := host.WriteFile(systemdConfFilePath, content, 0644); err != nil {
 		return utils.Errorf(err, L("cannot write %s file"), systemdConfFilePath)
 	}
 
@@ -482,7 +512,7 @@ func GenerateSystemdConfFile(serviceName string, filename string, body string, w
 }
 
 // CleanSystemdConfFile separates the Service.conf file once generated into generated.conf and custom.conf.
-func CleanSystemdConfFile(serviceName string) error {
+func CleanSystemdConfFile(host *utils.Host, serviceName string) error {
 	oldConfPath := GetServiceConfPath(serviceName, "Service.conf")
 
 	// The first containerized release generated a Service.conf where the image and the configuration
@@ -490,8 +520,12 @@ func CleanSystemdConfFile(serviceName string) error {
 	// If this file exists split it in two:
 	// - generated.conf with the image
 	// - custom.conf with everything that shouldn't be touched at upgrade
-	if utils.FileExists(oldConfPath) {
-		content := string(utils.ReadFile(oldConfPath))
+	if host.FileExists(oldConfPath) {
+		data, err := host.ReadFile(oldConfPath)
+		if err != nil {
+			return utils.Errorf(err, L("failed to read %s"), oldConfPath)
+		}
+		content := string(data)
 		lines := strings.Split(content, "\n")
 
 		generated := ""
@@ -511,19 +545,19 @@ func CleanSystemdConfFile(serviceName string) error {
 		}
 
 		if generated != "" {
-			if err := GenerateSystemdConfFile(serviceName, GeneratedConf, generated, true); err != nil {
+			if err := GenerateSystemdConfFile(host, serviceName, GeneratedConf, generated, true); err != nil {
 				return err
 			}
 		}
 
 		if hasCustom {
 			customPath := GetServiceConfPath(serviceName, CustomConf)
-			if err := os.WriteFile(customPath, []byte(custom), 0644); err != nil {
+			if err := host.WriteFile(customPath, []byte(custom), 0644); err != nil {
 				return utils.Errorf(err, L("failed to write %s file"), customPath)
 			}
 		}
 
-		if err := os.Remove(oldConfPath); err != nil {
+		if err := host.Remove(oldConfPath); err != nil {
 			return utils.Errorf(err, L("failed to remove old %s systemd service configuration file"), oldConfPath)
 		}
 	}
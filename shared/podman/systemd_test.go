@@ -45,7 +45,7 @@ Environment="PODMAN_EXTRA_ARGS="
 
 	testutils.WriteFile(t, path.Join(serviceConfDir, "Service.conf"), currentFile)
 
-	if err := CleanSystemdConfFile("uyuni-server"); err != nil {
+	if err := CleanSystemdConfFile(nil, "uyuni-server"); err != nil {
 		t.Errorf("unexpected error: %s", err)
 	}
 
@@ -83,7 +83,7 @@ Environment="PODMAN_EXTRA_ARGS="
 	testutils.WriteFile(t, path.Join(serviceConfDir, GeneratedConf), generatedFile)
 	testutils.WriteFile(t, path.Join(serviceConfDir, CustomConf), customFile)
 
-	if err := CleanSystemdConfFile("uyuni-server"); err != nil {
+	if err := CleanSystemdConfFile(nil, "uyuni-server"); err != nil {
 		t.Errorf("unexpected error: %s", err)
 	}
 
@@ -95,7 +95,7 @@ Environment="PODMAN_EXTRA_ARGS="
 }
 
 func TestGetServiceProperty(t *testing.T) {
-	newRunner = testutils.FakeRunnerGenerator("TestProperty=foo bar\n", nil)
+	newRunner = fakeHostRunnerGenerator("TestProperty=foo bar\n", nil)
 	tested := NewSystemd()
 	actual, err := tested.GetServiceProperty("myservice", "TestProperty")
 	testutils.AssertTrue(t, "No error expected", err == nil)
@@ -103,7 +103,7 @@ func TestGetServiceProperty(t *testing.T) {
 }
 
 func TestGetServicePropertyError(t *testing.T) {
-	newRunner = testutils.FakeRunnerGenerator("", errors.New("Test error"))
+	newRunner = fakeHostRunnerGenerator("", errors.New("Test error"))
 	tested := NewSystemd()
 	actual, err := tested.GetServiceProperty("myservice", "TestProperty")
 	testutils.AssertTrue(t, "Error message missing the root error message", strings.Contains(err.Error(), "Test error"))
@@ -6,8 +6,7 @@ package podman
 
 import (
 	"errors"
-	"io"
-	"os"
+	"fmt"
 	"os/exec"
 	"path"
 	"regexp"
@@ -24,19 +23,21 @@ import (
 )
 
 // runCmd* are a function pointers to use for easier unit testing.
-var runCmdOutput = utils.RunCmdOutput
-var runCmd = utils.RunCmd
+var runCmdOutput = (*utils.Host).RunCmdOutput
+var runCmd = (*utils.Host).RunCmd
 
-var runner = utils.NewRunner
+var runner = (*utils.Host).NewRunner
 
 // SetRunner allows mocking the runner for tests.
 func SetRunner(r func(command string, args ...string) types.Runner) {
-	runner = r
+	runner = func(_ *utils.Host, command string, args ...string) types.Runner {
+		return r(command, args...)
+	}
 }
 
 // ResetRunner resets the runner to the default implementation.
 func ResetRunner() {
-	runner = utils.NewRunner
+	runner = (*utils.Host).NewRunner
 }
 
 const commonArgs = "--rm --cap-add NET_RAW"
@@ -81,8 +82,8 @@ func AddPodmanArgFlag(cmd *cobra.Command) {
 }
 
 // EnablePodmanSocket enables the podman socket.
-func EnablePodmanSocket() error {
-	err := utils.RunCmd("systemctl", "enable", "--now", "podman.socket")
+func EnablePodmanSocket(host *utils.Host) error {
+	err := host.RunCmd("systemctl", "enable", "--now", "podman.socket")
 	if err != nil {
 		return utils.Errorf(err, L("failed to enable podman.socket unit"))
 	}
@@ -90,12 +91,12 @@ func EnablePodmanSocket() error {
 }
 
 // ReadFromContainer reads a file from a container.
-func ReadFromContainer(name string, image string, volumes []types.VolumeMount,
+func ReadFromContainer(host *utils.Host, name string, image string, volumes []types.VolumeMount,
 	extraArgs []string, file string) ([]byte, error) {
 	podmanArgs := append([]string{"run", "--name", name}, GetCommonParams()...)
 	podmanArgs = append(podmanArgs, extraArgs...)
 	for _, volume := range volumes {
-		if IsVolumePresent(volume.Name) {
+		if IsVolumePresent(host, volume.Name) {
 			podmanArgs = append(podmanArgs, "-v", volume.Name+":"+volume.MountPath)
 		}
 	}
@@ -103,7 +104,7 @@ func ReadFromContainer(name string, image string, volumes []types.VolumeMount,
 	podmanArgs = append(podmanArgs, image)
 	podmanArgs = append(podmanArgs, []string{"cat", file}...)
 
-	out, err := runner("podman", podmanArgs...).Spinner("").Exec()
+	out, err := runner(host, "podman", podmanArgs...).Spinner("").Exec()
 	if err != nil {
 		return []byte{}, utils.Errorf(err, L("failed to run %s container"), name)
 	}
@@ -122,7 +123,16 @@ func PrepareContainerRunArgs(
 	for _, volume := range volumes {
 		podmanArgs = append(podmanArgs, "-v", volume.Name+":"+volume.MountPath)
 	}
-	podmanArgs = append(podmanArgs, "--network", UyuniNetwork)
+	// Use the uyuni network unless another one is requested in the extra arguments
+	hasNetwork := false
+	for _, arg := range extraArgs {
+		if arg == "--network" || strings.HasPrefix(arg, "--network=") {
+			hasNetwork = true
+		}
+	}
+	if !hasNetwork {
+		podmanArgs = append(podmanArgs, "--network", UyuniNetwork)
+	}
 	podmanArgs = append(podmanArgs, image)
 	podmanArgs = append(podmanArgs, cmd...)
 
@@ -130,9 +140,11 @@ func PrepareContainerRunArgs(
 }
 
 // RunContainer execute a container.
-func RunContainer(name string, image string, volumes []types.VolumeMount, extraArgs []string, cmd []string) error {
+func RunContainer(
+	host *utils.Host, name string, image string, volumes []types.VolumeMount, extraArgs []string, cmd []string,
+) error {
 	podmanArgs := PrepareContainerRunArgs(name, image, volumes, extraArgs, cmd)
-	err := utils.RunCmdStdMapping(zerolog.DebugLevel, "podman", podmanArgs...)
+	err := host.RunCmdStdMapping(zerolog.DebugLevel, "podman", podmanArgs...)
 	if err != nil {
 		return utils.Errorf(err, L("failed to run %s container"), name)
 	}
@@ -142,19 +154,19 @@ func RunContainer(name string, image string, volumes []types.VolumeMount, extraA
 
 // DeleteContainer deletes a container based on its name.
 // If dryRun is set to true, nothing will be done, only messages logged to explain what would happen.
-func DeleteContainer(name string, dryRun bool) {
-	if out, _ := utils.RunCmdOutput(zerolog.DebugLevel, "podman", "ps", "-a", "-q", "-f", "name="+name); len(out) > 0 {
+func DeleteContainer(host *utils.Host, name string, dryRun bool) {
+	if out, _ := host.RunCmdOutput(zerolog.DebugLevel, "podman", "ps", "-a", "-q", "-f", "name="+name); len(out) > 0 {
 		if dryRun {
 			log.Info().Msgf(L("Would run podman kill %[1]s for container id %[2]s"), name, out)
 			log.Info().Msgf(L("Would run podman remove %[1]s for container id %[2]s"), name, out)
 		} else {
 			log.Info().Msgf(L("Run podman kill %[1]s for container id %[2]s"), name, out)
-			err := utils.RunCmd("podman", "kill", name)
+			err := host.RunCmd("podman", "kill", name)
 			if err != nil {
 				log.Error().Err(err).Msg(L("Failed to kill the server"))
 
 				log.Info().Msgf(L("Run podman remove %[1]s for container id %[2]s"), name, out)
-				err = utils.RunCmd("podman", "rm", name)
+				err = host.RunCmd("podman", "rm", name)
 				if err != nil {
 					log.Error().Err(err).Msg(L("Error removing container"))
 				}
@@ -166,8 +178,8 @@ func DeleteContainer(name string, dryRun bool) {
 }
 
 // GetServiceImage returns the value of the UYUNI_IMAGE variable for a systemd service.
-func GetServiceImage(service string) string {
-	out, err := runCmdOutput(zerolog.DebugLevel, "systemctl", "cat", service)
+func GetServiceImage(host *utils.Host, service string) string {
+	out, err := runCmdOutput(host, zerolog.DebugLevel, "systemctl", "cat", service)
 	if err != nil {
 		log.Warn().Err(err).Msgf(L("failed to get %s systemd service definition"), service)
 		return ""
@@ -183,8 +195,8 @@ func GetServiceImage(service string) string {
 }
 
 // GetImageVirtualSize returns the size of the image with its layers.
-func GetImageVirtualSize(name string) (size int64, err error) {
-	out, err := utils.NewRunner("podman", "inspect", "--format", "{{.VirtualSize}}", name).
+func GetImageVirtualSize(host *utils.Host, name string) (size int64, err error) {
+	out, err := host.NewRunner("podman", "inspect", "--format", "{{.VirtualSize}}", name).
 		Log(zerolog.DebugLevel).
 		Exec()
 	if err != nil {
@@ -197,24 +209,24 @@ func GetImageVirtualSize(name string) (size int64, err error) {
 
 // DeleteVolume deletes a podman volume based on its name.
 // If dryRun is set to true, nothing will be done, only messages logged to explain what would happen.
-func DeleteVolume(name string, dryRun bool) error {
-	exists := IsVolumePresent(name)
+func DeleteVolume(host *utils.Host, name string, dryRun bool) error {
+	exists := IsVolumePresent(host, name)
 	if exists {
 		if dryRun {
 			log.Info().Msgf(L("Would run %s"), "podman volume rm "+name)
 		} else {
 			log.Info().Msgf(L("Run %s"), "podman volume rm "+name)
-			if err := utils.RunCmd("podman", "volume", "rm", name); err != nil {
+			if err := host.RunCmd("podman", "volume", "rm", name); err != nil {
 				log.Trace().Err(err).Msgf("podman volume rm %s", name)
 				// Check if the volume is not mounted - for example var-pgsql - as second storage device
 				// We need to compute volume path ourselves because above `podman volume rm` call may have
 				// already removed volume from podman internal structures
-				basePath, errBasePath := GetPodmanVolumeBasePath()
+				basePath, errBasePath := GetPodmanVolumeBasePath(host)
 				if errBasePath != nil {
 					return errBasePath
 				}
 				target := path.Join(basePath, name)
-				if isVolumePathEmpty(target) && isVolumePathMounted(target) {
+				if host.IsEmptyDirectory(target) && isVolumePathMounted(host, target) {
 					log.Info().Msgf(L("Volume %s is externally mounted, directory cannot be removed"), name)
 					return nil
 				}
@@ -228,8 +240,10 @@ func DeleteVolume(name string, dryRun bool) error {
 // ExportVolume exports a podman volume based on its name to the specified targed directory.
 // outputDir option expects already existing directory.
 // If dryRun is set to true, only messages will be logged to explain what would happen.
-func ExportVolume(name string, outputDir string, dryRun bool) error {
-	exists := IsVolumePresent(name)
+//
+// The volume is exported to the machine running the command, even from a remote host.
+func ExportVolume(host *utils.Host, name string, outputDir string, dryRun bool) error {
+	exists := IsVolumePresent(host, name)
 	if exists {
 		outputFile := path.Join(outputDir, name+".tar")
 		exportCommand := []string{"podman", "volume", "export", "-o", outputFile, name}
@@ -238,7 +252,7 @@ func ExportVolume(name string, outputDir string, dryRun bool) error {
 			return nil
 		}
 		log.Info().Msgf(L("Run %s"), strings.Join(exportCommand, " "))
-		if err := runCmd(exportCommand[0], exportCommand[1:]...); err != nil {
+		if err := runCmd(host, exportCommand[0], exportCommand[1:]...); err != nil {
 			return utils.Errorf(err, L("Failed to export volume %s"), name)
 		}
 		if err := utils.CreateChecksum(outputFile); err != nil {
@@ -250,17 +264,19 @@ func ExportVolume(name string, outputDir string, dryRun bool) error {
 
 // ImportVolume imports a podman volume from provided volumePath.
 // If dryRun is set to true, only messages will be logged to exmplain what would happen.
-func ImportVolume(name string, volumePath string, skipVerify bool, dryRun bool) error {
-	createCommand := []string{"podman", "volume", "create", "--ignore", name}
-
-	basePath, err := GetPodmanVolumeBasePath()
+//
+// The volumePath archive is extracted on the host: this is not supported on a remote host.
+func ImportVolume(host *utils.Host, name string, volumePath string, skipVerify bool, dryRun bool) error {
+	if host.IsRemote() {
+		return fmt.Errorf(L("importing the %s volume is not supported on a remote host"), name)
+	}
+	basePath, err := GetPodmanVolumeBasePath(host)
 	if err != nil {
 		log.Debug().Msg("cannot get base volume path")
 		return err
 	}
 	targetPath := path.Join(basePath, name, "_data")
 	importCommand := []string{"tar", "xf", volumePath, "-C", targetPath}
-	restoreconCommand := []string{"restorecon", "-rF", targetPath}
 
 	if dryRun {
 		log.Info().Msgf(L("Would run %s"), strings.Join(importCommand, " "))
@@ -271,23 +287,42 @@ func ImportVolume(name string, volumePath string, skipVerify bool, dryRun bool)
 			return utils.Errorf(err, L("Checksum does not match for volume %s"), volumePath)
 		}
 	}
-	if err := runCmd(createCommand[0], createCommand[1:]...); err != nil {
-		return utils.Errorf(err, L("Failed to precreate empty volume %s"), name)
+	if _, err := PrepareVolumeImport(host, name); err != nil {
+		return err
 	}
 	log.Info().Msgf(L("Run %s"), strings.Join(importCommand, " "))
-	if err := runCmd(importCommand[0], importCommand[1:]...); err != nil {
+	if err := runCmd(host, importCommand[0], importCommand[1:]...); err != nil {
 		return utils.Errorf(err, L("Failed to import volume %s"), name)
 	}
-	if utils.IsInstalled("restorecon") {
-		if err := utils.RunCmd(restoreconCommand[0], restoreconCommand[1:]...); err != nil {
+	RestoreVolumeContext(host, targetPath)
+	return nil
+}
+
+// PrepareVolumeImport creates the volume if needed and returns the path to extract its data to.
+func PrepareVolumeImport(host *utils.Host, name string) (string, error) {
+	basePath, err := GetPodmanVolumeBasePath(host)
+	if err != nil {
+		log.Debug().Msg("cannot get base volume path")
+		return "", err
+	}
+	createCommand := []string{"podman", "volume", "create", "--ignore", name}
+	if err := runCmd(host, createCommand[0], createCommand[1:]...); err != nil {
+		return "", utils.Errorf(err, L("Failed to precreate empty volume %s"), name)
+	}
+	return path.Join(basePath, name, "_data"), nil
+}
+
+// RestoreVolumeContext restores the SELinux context of the imported volume data if possible.
+func RestoreVolumeContext(host *utils.Host, targetPath string) {
+	if host.IsInstalled("restorecon") {
+		if err := host.RunCmd("restorecon", "-rF", targetPath); err != nil {
 			log.Warn().Err(err).Msgf(L("Unable to restore selinux context for %s, manual action is required"), targetPath)
 		}
 	}
-	return nil
 }
 
-func IsVolumePresent(volume string) bool {
-	cmd := runner("podman", "volume", "exists", volume)
+func IsVolumePresent(host *utils.Host, volume string) bool {
+	cmd := runner(host, "podman", "volume", "exists", volume)
 	if _, err := cmd.Exec(); err != nil {
 		log.Debug().Err(err).Msgf("podman volume does not exists %s", volume)
 		return false
@@ -295,8 +330,8 @@ func IsVolumePresent(volume string) bool {
 	return true
 }
 
-func isVolumePathMounted(volume string) bool {
-	cmd := exec.Command("findmnt", "--target", volume)
+func isVolumePathMounted(host *utils.Host, volume string) bool {
+	cmd := host.Command("findmnt", "--target", volume)
 	var exitError *exec.ExitError
 	if err := cmd.Run(); err != nil && errors.As(err, &exitError) {
 		log.Debug().Err(err).Msgf("findmnt --target %s", volume)
@@ -305,28 +340,17 @@ func isVolumePathMounted(volume string) bool {
 	return cmd.ProcessState.Success()
 }
 
-func isVolumePathEmpty(volume string) bool {
-	f, err := os.Open(volume)
-	if err != nil {
-		return false
-	}
-	defer f.Close()
-
-	_, err = f.Readdirnames(1)
-	return errors.Is(err, io.EOF)
-}
-
 // GetPodmanVolumeBasePath returns the path to all volumes on the host system.
-func GetPodmanVolumeBasePath() (string, error) {
-	cmd := exec.Command("podman", "system", "info", "--format={{ .Store.VolumePath }}")
+func GetPodmanVolumeBasePath(host *utils.Host) (string, error) {
+	cmd := host.Command("podman", "system", "info", "--format={{ .Store.VolumePath }}")
 	out, err := cmd.Output()
 	return strings.TrimSpace(string(out)), err
 }
 
 // GetVolumeMountPoint returns the path to the volume mount point on the host system.
 // This shouldn't be confused with GetPodmanVolumeBasePath() that returns the path to the folder containing all volumes.
-func GetVolumeMountPoint(name string) (path string, err error) {
-	out, err := runner("podman", "volume", "inspect", "--format", "{{.Mountpoint}}", name).
+func GetVolumeMountPoint(host *utils.Host, name string) (path string, err error) {
+	out, err := runner(host, "podman", "volume", "inspect", "--format", "{{.Mountpoint}}", name).
 		Log(zerolog.DebugLevel).
 		Exec()
 	if err != nil {
@@ -336,25 +360,42 @@ func GetVolumeMountPoint(name string) (path string, err error) {
 	return
 }
 
+// GetVolumeSize returns the size of the files stored in a podman volume.
+func GetVolumeSize(host *utils.Host, name string) (int64, error) {
+	mountPoint, err := GetVolumeMountPoint(host, name)
+	if err != nil {
+		return 0, err
+	}
+	out, err := runner(host, "du", "-sb", mountPoint).Log(zerolog.DebugLevel).Exec()
+	if err != nil {
+		return 0, utils.Errorf(err, L("failed to compute the size of the %s volume"), name)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf(L("invalid size of the %s volume"), name)
+	}
+	return strconv.ParseInt(fields[0], 10, 64)
+}
+
 // Inspect check values on given images.
 // The images are assumed to be already available locally.
-func Inspect(serverImage string, pgsqlImage string) (*utils.InspectData, error) {
+func Inspect(host *utils.Host, serverImage string, pgsqlImage string) (*utils.InspectData, error) {
 	serverData, err := ImageInspect[utils.ServerInspectData](
-		serverImage, utils.ServerVolumeMounts, utils.NewServerInspector(),
+		host, serverImage, utils.ServerVolumeMounts, utils.NewServerInspector(),
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	dbData, err := ImageInspect[utils.DBInspectData](
-		pgsqlImage, utils.PgsqlRequiredVolumeMounts, utils.NewDBInspector(),
+		host, pgsqlImage, utils.PgsqlRequiredVolumeMounts, utils.NewDBInspector(),
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	runningData, err := RunningContainerInspect[utils.ContainerInspectData](
-		utils.NewContainerInspector(),
+		host, utils.NewContainerInspector(),
 	)
 	if err != nil {
 		return nil, err
@@ -369,7 +410,7 @@ func Inspect(serverImage string, pgsqlImage string) (*utils.InspectData, error)
 
 // ImageInspect runs an inspector script on a image.
 func ImageInspect[T any](
-	image string, volumes []types.VolumeMount, inspector templates.InspectTemplateData,
+	host *utils.Host, image string, volumes []types.VolumeMount, inspector templates.InspectTemplateData,
 ) (*T, error) {
 	podmanArgs := []string{
 		"--security-opt", "label=disable",
@@ -381,7 +422,7 @@ func ImageInspect[T any](
 	}
 
 	args := PrepareContainerRunArgs("uyuni-inspect", image, volumes, podmanArgs, []string{"bash", "-c", script})
-	out, err := newRunner("podman", args...).Log(zerolog.DebugLevel).Exec()
+	out, err := newRunner(host, "podman", args...).Log(zerolog.DebugLevel).Exec()
 	if err != nil {
 		return nil, err
 	}
@@ -396,7 +437,7 @@ func ImageInspect[T any](
 
 // RunningContainerInspect runs an inspector script on a container image.
 func RunningContainerInspect[T any](
-	inspector templates.InspectTemplateData,
+	host *utils.Host, inspector templates.InspectTemplateData,
 ) (*T, error) {
 	script, err := inspector.GenerateScript()
 	if err != nil {
@@ -404,7 +445,7 @@ func RunningContainerInspect[T any](
 	}
 
 	args := []string{"exec", "-ti", "uyuni-server", "bash", "-c", script}
-	out, err := newRunner("podman", args...).Log(zerolog.DebugLevel).Exec()
+	out, err := newRunner(host, "podman", args...).Log(zerolog.DebugLevel).Exec()
 	if err != nil {
 		return nil, err
 	}
@@ -6,12 +6,23 @@ package podman
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/rs/zerolog"
 	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+	"github.com/uyuni-project/uyuni-tools/shared/utils"
 )
 
+// fakeHostRunnerGenerator returns a host runner generator returning a fake runner with the given output and error.
+func fakeHostRunnerGenerator(out string, err error) func(*utils.Host, string, ...string) types.Runner {
+	generator := testutils.FakeRunnerGenerator(out, err)
+	return func(_ *utils.Host, command string, args ...string) types.Runner {
+		return generator(command, args...)
+	}
+}
+
 func TestGetServiceImage(t *testing.T) {
 	type dataType struct {
 		catOut   string
@@ -39,10 +50,19 @@ Environment=UYUNI_IMAGE=myregistry.org/silly/image:tag
 	}
 
 	for _, testData := range data {
-		runCmdOutput = func(_ zerolog.Level, _ string, _ ...string) ([]byte, error) {
+		runCmdOutput = func(_ *utils.Host, _ zerolog.Level, _ string, _ ...string) ([]byte, error) {
 			return []byte(testData.catOut), testData.catErr
 		}
 
-		testutils.AssertEquals(t, "Wrong image found", testData.expected, GetServiceImage("myservice"))
+		testutils.AssertEquals(t, "Wrong image found", testData.expected, GetServiceImage(nil, "myservice"))
 	}
 }
+
+func TestPrepareContainerRunArgsNetwork(t *testing.T) {
+	args := PrepareContainerRunArgs("test", "image", nil, []string{}, []string{"true"})
+	testutils.AssertTrue(t, "Uyuni network expected by default",
+		strings.Contains(strings.Join(args, " "), "--network "+UyuniNetwork+" image"))
+
+	args = PrepareContainerRunArgs("test", "image", nil, []string{"--network", "isolated"}, []string{"true"})
+	testutils.AssertTrue(t, "Uyuni network should not be added", !strings.Contains(strings.Join(args, " "), UyuniNetwork))
+}
@@ -139,6 +139,20 @@ func AssertRegistryFlag(t *testing.T, flags *types.Registry) {
 	testutils.AssertEquals(t, "Error parsing --registry-password", "password", flags.Password)
 }
 
+// SignatureFlagsTestArgs is the expected values for AssertSignatureFlags.
+var SignatureFlagsTestArgs = []string{
+	"--verify-signatures",
+	"--signatures-key", "path/to/cosign.pub",
+	"--signatures-type", "gpg",
+}
+
+// AssertSignatureFlags asserts that all the image signatures flags are parsed correctly.
+func AssertSignatureFlags(t *testing.T, flags *types.SignatureFlags) {
+	testutils.AssertTrue(t, "Error parsing --verify-signatures", flags.Verify)
+	testutils.AssertEquals(t, "Error parsing --signatures-key", "path/to/cosign.pub", flags.Key)
+	testutils.AssertEquals(t, "Error parsing --signatures-type", "gpg", flags.Type)
+}
+
 // DBUpdateImageFlagTestArgs is the expected values for AssertDBUpgradeImageFlag.
 var DBUpdateImageFlagTestArgs = []string{
 	"--dbupgrade-image", "dbupgradeimg",
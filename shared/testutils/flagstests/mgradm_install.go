@@ -32,6 +32,7 @@ var InstallFlagsTestArgs = func() []string {
 
 	args = append(args, SCCFlagTestArgs...)
 	args = append(args, ImageFlagsTestArgs...)
+	args = append(args, SignatureFlagsTestArgs...)
 	args = append(args, CocoFlagsTestArgs...)
 	args = append(args, HubXmlrpcFlagsTestArgs...)
 	args = append(args, SalineFlagsTestArgs...)
@@ -60,6 +61,7 @@ func AssertInstallFlags(t *testing.T, flags *utils.ServerFlags) {
 	testutils.AssertEquals(t, "Error parsing --organization", "someorg", flags.Installation.Organization)
 	AssertSCCFlag(t, &flags.Installation.SCC)
 	AssertImageFlag(t, &flags.Image)
+	AssertSignatureFlags(t, &flags.Signatures)
 	AssertCocoFlag(t, &flags.Coco)
 	AssertHubXmlrpcFlag(t, &flags.HubXmlrpc)
 	AssertSalineFlag(t, &flags.Saline)
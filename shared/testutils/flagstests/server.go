@@ -22,6 +22,7 @@ var ServerFlagsTestArgs = func() []string {
 	args = append(args, SSLGenerationFlagsTestArgs...)
 	args = append(args, SalineFlagsTestArgs...)
 	args = append(args, ImageFlagsTestArgs...)
+	args = append(args, SignatureFlagsTestArgs...)
 	args = append(args, RegistryImageFlagsTestArgs...)
 	args = append(args, DBUpdateImageFlagTestArgs...)
 	args = append(args, CocoFlagsTestArgs...)
@@ -35,6 +36,7 @@ var ServerFlagsTestArgs = func() []string {
 func AssertServerFlags(t *testing.T, flags *utils.ServerFlags) {
 	AssertImageFlag(t, &flags.Image)
 	AssertRegistryFlag(t, &flags.Image.Registry)
+	AssertSignatureFlags(t, &flags.Signatures)
 	AssertDBUpgradeImageFlag(t, &flags.DBUpgradeImage)
 	AssertCocoFlag(t, &flags.Coco)
 	AssertHubXmlrpcFlag(t, &flags.HubXmlrpc)
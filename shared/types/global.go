@@ -8,4 +8,6 @@ package types
 type GlobalFlags struct {
 	ConfigPath string
 	LogLevel   string
+	// Host is the ssh URL of the remote host managing the server, empty for the local host.
+	Host string
 }
@@ -19,6 +19,27 @@ type PgsqlFlags struct {
 	IsChanged bool
 }
 
+// SignatureFlags configures the verification of the container images signatures.
+type SignatureFlags struct {
+	// Verify is true to refuse the images without a valid signature.
+	Verify bool
+	// Key is the path to the public key verifying all the images.
+	Key string
+	// Type is the type of Key: sigstore for cosign keys or gpg for simple signing keys.
+	Type string
+	// Keys are additional public keys restricted to a registry, repository or image.
+	Keys []SignatureKey
+}
+
+// SignatureKey is a public key verifying the signatures of the images matching its scope.
+type SignatureKey struct {
+	// Scope is a registry, repository or image like registry.example.com/uyuni.
+	Scope string
+	Path  string
+	// Type is either sigstore for cosign keys or gpg for simple signing keys.
+	Type string
+}
+
 // ImageMetadata represents the image metadata of an RPM image.
 type ImageMetadata struct {
 	Name string   `json:"name"`
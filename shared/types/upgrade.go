@@ -0,0 +1,23 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// UpgradePath is a release that cannot be skipped when upgrading.
+//
+// Upgrading to the To release or a later one requires the From release or a later one to be running.
+type UpgradePath struct {
+	// Product is either uyuni or suse-manager.
+	Product string
+	From    string
+	To      string
+}
+
+// UpgradePolicyFlags configures the check of the supported upgrade paths.
+type UpgradePolicyFlags struct {
+	// Force is true to upgrade even if the upgrade path is not supported.
+	Force bool
+	// UpgradePaths replaces the upgrade paths embedded in the tool if defined in the configuration file.
+	UpgradePaths []UpgradePath `mapstructure:"upgradePaths"`
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+// HashingWriter computes the size and SHA256 checksum of the data written through it.
+type HashingWriter struct {
+	writer io.Writer
+	hash   hash.Hash
+	size   int64
+}
+
+// NewHashingWriter creates a writer computing the checksum of what is written to writer.
+func NewHashingWriter(writer io.Writer) *HashingWriter {
+	return &HashingWriter{writer: writer, hash: sha256.New()}
+}
+
+func (w *HashingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+// Size returns the number of written bytes.
+func (w *HashingWriter) Size() int64 {
+	return w.size
+}
+
+// Checksum returns the hex encoded SHA256 of the written data.
+func (w *HashingWriter) Checksum() string {
+	return hex.EncodeToString(w.hash.Sum(nil))
+}
+
+// HashingReader computes the size and SHA256 checksum of the data read through it.
+type HashingReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	size   int64
+}
+
+// NewHashingReader creates a reader computing the checksum of what is read from reader.
+func NewHashingReader(reader io.Reader) *HashingReader {
+	return &HashingReader{reader: reader, hash: sha256.New()}
+}
+
+func (r *HashingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	r.size += int64(n)
+	return n, err
+}
+
+// Size returns the number of read bytes.
+func (r *HashingReader) Size() int64 {
+	return r.size
+}
+
+// Checksum returns the hex encoded SHA256 of the read data.
+func (r *HashingReader) Checksum() string {
+	return hex.EncodeToString(r.hash.Sum(nil))
+}
+
+// ComputeReaderChecksum reads all the data to compute their SHA256 checksum.
+func ComputeReaderChecksum(reader io.Reader) (string, error) {
+	hashed := NewHashingReader(reader)
+	if _, err := io.Copy(io.Discard, hashed); err != nil {
+		return "", err
+	}
+	return hashed.Checksum(), nil
+}
@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+const testfiledataChecksum = "886d35a29af629be5c45ff24320dd4d48ee8860b25a9a724f8ac88cf15755a22"
+
+func TestHashingWriter(t *testing.T) {
+	output := bytes.Buffer{}
+	writer := NewHashingWriter(&output)
+	_, err := writer.Write([]byte("testfiledata"))
+	testutils.AssertNoError(t, "failed to write", err)
+	testutils.AssertEquals(t, "Unexpected output", "testfiledata", output.String())
+	testutils.AssertEquals(t, "Unexpected size", int64(12), writer.Size())
+	testutils.AssertEquals(t, "Unexpected checksum", testfiledataChecksum, writer.Checksum())
+}
+
+func TestComputeReaderChecksum(t *testing.T) {
+	checksum, err := ComputeReaderChecksum(strings.NewReader("testfiledata"))
+	testutils.AssertNoError(t, "failed to compute checksum", err)
+	testutils.AssertEquals(t, "Unexpected checksum", testfiledataChecksum, checksum)
+}
@@ -157,6 +157,24 @@ func AddRegistryFlag(cmd *cobra.Command) {
 	_ = AddFlagToHelpGroupID(cmd, "registry-password", "registry")
 }
 
+// AddSignatureFlags adds the flags verifying the signatures of the container images.
+func AddSignatureFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("verify-signatures", false,
+		L(`Refuse the images without a valid signature.
+The images are verified when pulled: the ones already present are pulled again by digest to verify them.
+The images loaded locally, like the RPM ones, or with the never pull policy cannot be verified and are refused.
+Sigstore signatures require the use-sigstore-attachments option for the registry in /etc/containers/registries.d.
+Additional keys restricted to a registry or repository can be set in the signatures.keys configuration.`))
+	cmd.Flags().String("signatures-key", "", L("Path to the public key verifying the signatures of all the images"))
+	cmd.Flags().String("signatures-type", "sigstore",
+		L("Type of the signatures public key. Possible values: sigstore, gpg"))
+
+	_ = AddFlagHelpGroup(cmd, &Group{ID: "signatures", Title: L("Image Signatures Flags")})
+	_ = AddFlagToHelpGroupID(cmd, "verify-signatures", "signatures")
+	_ = AddFlagToHelpGroupID(cmd, "signatures-key", "signatures")
+	_ = AddFlagToHelpGroupID(cmd, "signatures-type", "signatures")
+}
+
 // AddPTFFlag add PTF flag to a command.
 func AddPTFFlag(cmd *cobra.Command) {
 	cmd.Flags().String("ptf", "", L("PTF ID"))
@@ -97,6 +97,14 @@ func bindFlags(cmd *cobra.Command, v *viper.Viper) error {
 		if configName == "registry" {
 			configName = "registry.host"
 		}
+		// Keep the signatures configuration in a single section
+		if configName == "verify.signatures" {
+			configName = "signatures.verify"
+		}
+		// The prepare key is already used by the migration --prepare flag
+		if configName == "prepare.only" {
+			configName = "prepareonly"
+		}
 		if err := v.BindPFlag(configName, f); err != nil {
 			errors = append(errors, Errorf(err, L("failed to bind %[1]s config to parameter %[2]s"), configName, f.Name))
 		}
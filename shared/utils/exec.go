@@ -195,21 +195,28 @@ func (e *CmdError) Error() string {
 
 // RunCmd execute a shell command.
 func RunCmd(command string, args ...string) error {
+	return runCmd(exec.Command(command, args...))
+}
+
+func runCmd(cmd *exec.Cmd) error {
 	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond) // Build our new spinner
-	s.Suffix = fmt.Sprintf(" %s %s\n", command, strings.Join(args, " "))
+	s.Suffix = fmt.Sprintf(" %s\n", strings.Join(cmd.Args, " "))
 	s.Start() // Start the spinner
-	log.Debug().Msgf("Running: %s %s", command, strings.Join(args, " "))
-	err := exec.Command(command, args...).Run()
+	log.Debug().Msgf("Running: %s", strings.Join(cmd.Args, " "))
+	err := cmd.Run()
 	s.Stop()
 	return err
 }
 
 // RunCmdStdMapping execute a shell command mapping the stdout and stderr.
 func RunCmdStdMapping(logLevel zerolog.Level, command string, args ...string) error {
+	return runCmdStdMapping(logLevel, exec.Command(command, args...))
+}
+
+func runCmdStdMapping(logLevel zerolog.Level, runCmd *exec.Cmd) error {
 	localLogger := log.Logger.Level(logLevel)
-	localLogger.Debug().Msgf("Running: %s %s", command, strings.Join(args, " "))
+	localLogger.Debug().Msgf("Running: %s", strings.Join(runCmd.Args, " "))
 
-	runCmd := exec.Command(command, args...)
 	runCmd.Stdout = localLogger
 	runCmd.Stderr = localLogger
 	err := runCmd.Run()
@@ -218,14 +225,17 @@ func RunCmdStdMapping(logLevel zerolog.Level, command string, args ...string) er
 
 // RunCmdOutput execute a shell command and collects output.
 func RunCmdOutput(logLevel zerolog.Level, command string, args ...string) ([]byte, error) {
+	return runCmdOutput(logLevel, exec.Command(command, args...))
+}
+
+func runCmdOutput(logLevel zerolog.Level, cmd *exec.Cmd) ([]byte, error) {
 	localLogger := log.Level(logLevel)
 	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond) // Build our new spinner
-	s.Suffix = fmt.Sprintf(" %s %s\n", command, strings.Join(args, " "))
+	s.Suffix = fmt.Sprintf(" %s\n", strings.Join(cmd.Args, " "))
 	if logLevel != zerolog.Disabled {
 		s.Start() // Start the spinner
 	}
-	localLogger.Debug().Msgf("Running: %s %s", command, strings.Join(args, " "))
-	cmd := exec.Command(command, args...)
+	localLogger.Debug().Msgf("Running: %s", strings.Join(cmd.Args, " "))
 	var errBuf bytes.Buffer
 	cmd.Stderr = &errBuf
 	output, err := cmd.Output()
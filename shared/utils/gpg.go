@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+)
+
+// CheckSignKey ensures a manifest can be signed with the key, manifest naming it in the errors.
+// Unsigned manifests need to be explicitly requested as they do not allow to detect tampering.
+func CheckSignKey(signKey string, unsigned bool, manifest string) error {
+	if unsigned {
+		if signKey != "" {
+			return errors.New(L("--sign-key and --unsigned cannot be used together"))
+		}
+		return nil
+	}
+	if signKey == "" {
+		return fmt.Errorf(L("a GPG key is needed to sign the %s: "+
+			"set it with --sign-key or the sign.key configuration value, or use --unsigned"), manifest)
+	}
+	if !IsInstalled("gpg") {
+		return errors.New(L("install gpg before running this command"))
+	}
+	if _, err := NewRunner("gpg", "--batch", "--list-secret-keys", signKey).Exec(); err != nil {
+		return Errorf(err, L("no GPG secret key found for %s"), signKey)
+	}
+	return nil
+}
+
+// SignFile writes the armored detached signature of file made with the GPG key.
+func SignFile(signKey string, file string, signature string) error {
+	_, err := NewRunner("gpg", "--batch", "--yes", "--armor", "--detach-sign",
+		"--local-user", signKey, "--output", signature, file).Exec()
+	return err
+}
+
+// VerifyFileSignature checks the detached signature of file using the keys of the GPG keyring.
+func VerifyFileSignature(file string, signature string) error {
+	_, err := NewRunner("gpg", "--batch", "--verify", signature, file).Exec()
+	return err
+}
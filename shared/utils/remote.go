@@ -0,0 +1,323 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+)
+
+// defaultPodmanSocket is the path to the podman socket of the root user on the remote host.
+const defaultPodmanSocket = "/run/podman/podman.sock"
+
+// Host is the machine running podman and systemd for the server.
+//
+// A nil Host is the local machine.
+// On a remote host, podman uses its remote connection and the other commands are run using ssh
+// with the ssh configuration, agent and known hosts of the user running the command.
+type Host struct {
+	// destination is the ssh destination in the user@host form.
+	destination string
+	port        string
+	// socket is the path to the podman socket on the remote host.
+	socket string
+}
+
+// NewHost parses the location of the host managing the server.
+//
+// The location is formatted like ssh://user@host[:port][/path/to/podman.sock].
+// An empty location is the local host and returns nil.
+func NewHost(location string) (*Host, error) {
+	if location == "" {
+		return nil, nil
+	}
+	hostURL, err := url.Parse(location)
+	if err != nil {
+		return nil, Errorf(err, L("invalid host %s"), location)
+	}
+	if hostURL.Scheme != "ssh" || hostURL.Hostname() == "" {
+		return nil, fmt.Errorf(L("invalid host %s: only ssh://[user@]host[:port] is supported"), location)
+	}
+	if !IsInstalled("ssh") {
+		return nil, errors.New(L("install ssh before running this command"))
+	}
+
+	host := Host{destination: hostURL.Hostname(), port: hostURL.Port(), socket: hostURL.Path}
+	if hostURL.User != nil {
+		host.destination = hostURL.User.Username() + "@" + host.destination
+	}
+	if host.socket == "" {
+		host.socket = defaultPodmanSocket
+	}
+	return &host, nil
+}
+
+// IsRemote returns whether the host is not the local machine.
+func (h *Host) IsRemote() bool {
+	return h != nil
+}
+
+// String returns the ssh destination of a remote host or localhost.
+func (h *Host) String() string {
+	if h == nil {
+		return "localhost"
+	}
+	return h.destination
+}
+
+// podmanURL returns the URL of the podman service of the remote host.
+func (h *Host) podmanURL() string {
+	host := h.destination
+	if h.port != "" {
+		host += ":" + h.port
+	}
+	return "ssh://" + host + h.socket
+}
+
+// sshArgs returns the ssh arguments running a shell command on the remote host.
+func (h *Host) sshArgs(shellCommand string) []string {
+	args := []string{"-o", "BatchMode=yes"}
+	if h.port != "" {
+		args = append(args, "-p", h.port)
+	}
+	return append(args, h.destination, "--", shellCommand)
+}
+
+// Command prepares a command to run on the host.
+func (h *Host) Command(command string, args ...string) *exec.Cmd {
+	return h.CommandContext(context.Background(), command, args...)
+}
+
+// CommandContext prepares a command to run on the host, interruptable by the context.
+func (h *Host) CommandContext(ctx context.Context, command string, args ...string) *exec.Cmd {
+	if h == nil {
+		return exec.CommandContext(ctx, command, args...)
+	}
+	if command == "podman" {
+		return exec.CommandContext(ctx, "podman", append([]string{"--url", h.podmanURL()}, args...)...)
+	}
+	return exec.CommandContext(ctx, "ssh", h.sshArgs(ShellJoin(command, args...))...)
+}
+
+// NewRunner creates a new runner instance for a command to run on the host.
+func (h *Host) NewRunner(command string, args ...string) types.Runner {
+	return &runnerImpl{logger: log.Logger, cmd: h.Command(command, args...)}
+}
+
+// RunCmd executes a command on the host.
+func (h *Host) RunCmd(command string, args ...string) error {
+	return runCmd(h.Command(command, args...))
+}
+
+// RunCmdStdMapping executes a command on the host mapping the stdout and stderr.
+func (h *Host) RunCmdStdMapping(logLevel zerolog.Level, command string, args ...string) error {
+	return runCmdStdMapping(logLevel, h.Command(command, args...))
+}
+
+// RunCmdOutput executes a command on the host and collects its output.
+func (h *Host) RunCmdOutput(logLevel zerolog.Level, command string, args ...string) ([]byte, error) {
+	return runCmdOutput(logLevel, h.Command(command, args...))
+}
+
+// IsInstalled checks if a tool is in the path of the host.
+func (h *Host) IsInstalled(tool string) bool {
+	if h == nil {
+		return IsInstalled(tool)
+	}
+	_, err := h.runShell("command -v "+ShellQuote(tool), nil)
+	return err == nil
+}
+
+// ShellQuote quotes a value to use it in a shell command.
+func ShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// ShellJoin quotes a command and its arguments to run them in a shell.
+func ShellJoin(command string, args ...string) string {
+	quoted := []string{ShellQuote(command)}
+	for _, arg := range args {
+		quoted = append(quoted, ShellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// runShell runs a shell command on the remote host and returns its output.
+func (h *Host) runShell(shellCommand string, input []byte) ([]byte, error) {
+	log.Debug().Msgf("Running on %s: %s", h.destination, shellCommand)
+	cmd := exec.Command("ssh", h.sshArgs(shellCommand)...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if message := strings.TrimSpace(stderr.String()); err != nil && message != "" {
+		err = errors.New(message)
+	}
+	return out, err
+}
+
+// FileExists returns whether a file exists on the host.
+func (h *Host) FileExists(file string) bool {
+	if h == nil {
+		return FileExists(file)
+	}
+	_, err := h.runShell("test -e "+ShellQuote(file), nil)
+	return err == nil
+}
+
+// ReadFile reads a file on the host.
+func (h *Host) ReadFile(file string) ([]byte, error) {
+	if h == nil {
+		return os.ReadFile(file)
+	}
+	return h.runShell("cat "+ShellQuote(file), nil)
+}
+
+// WriteFile writes a file on the host.
+func (h *Host) WriteFile(file string, data []byte, perm os.FileMode) error {
+	if h == nil {
+		return os.WriteFile(file, data, perm)
+	}
+	_, err := h.runShell(fmt.Sprintf("cat > %[1]s && chmod %[2]o %[1]s", ShellQuote(file), perm.Perm()), data)
+	return err
+}
+
+// FileMode returns the permissions of a file on the host.
+func (h *Host) FileMode(file string) (os.FileMode, error) {
+	if h == nil {
+		info, err := os.Stat(file)
+		if err != nil {
+			return 0, err
+		}
+		return info.Mode().Perm(), nil
+	}
+	out, err := h.runShell("stat -c %a "+ShellQuote(file), nil)
+	if err != nil {
+		return 0, err
+	}
+	mode, err := strconv.ParseUint(strings.TrimSpace(string(out)), 8, 32)
+	if err != nil {
+		return 0, Errorf(err, L("failed to parse the mode of %s"), file)
+	}
+	return os.FileMode(mode).Perm(), nil
+}
+
+// MkdirAll creates a folder and its parents on the host.
+func (h *Host) MkdirAll(dir string, perm os.FileMode) error {
+	if h == nil {
+		return os.MkdirAll(dir, perm)
+	}
+	_, err := h.runShell(fmt.Sprintf("mkdir -p -m %o %s", perm.Perm(), ShellQuote(dir)), nil)
+	return err
+}
+
+// Remove removes a file or an empty folder on the host.
+func (h *Host) Remove(file string) error {
+	if h == nil {
+		return os.Remove(file)
+	}
+	_, err := h.runShell("rm -d "+ShellQuote(file), nil)
+	return err
+}
+
+// IsEmptyDirectory returns whether a folder on the host is empty.
+func (h *Host) IsEmptyDirectory(dir string) bool {
+	if h == nil {
+		return IsEmptyDirectory(dir)
+	}
+	out, err := h.runShell("ls -A "+ShellQuote(dir), nil)
+	return err == nil && len(bytes.TrimSpace(out)) == 0
+}
+
+// GetTimezone returns the timezone set on the host.
+func (h *Host) GetTimezone() string {
+	if h == nil {
+		return GetLocalTimezone()
+	}
+	out, err := h.RunCmdOutput(zerolog.DebugLevel, "timedatectl", "show", "--value", "-p", "Timezone")
+	if err != nil {
+		log.Fatal().Err(err).Msgf(L("Failed to run %s"), "timedatectl show --value -p Timezone")
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// GetFqdn returns and checks the FQDN of the host, or the one in args if any.
+func (h *Host) GetFqdn(args []string) (string, error) {
+	if h == nil || len(args) == 1 {
+		return GetFqdn(args)
+	}
+	out, err := h.RunCmdOutput(zerolog.DebugLevel, "hostname", "-f")
+	if err != nil {
+		return "", Error(err, L("failed to compute server FQDN"))
+	}
+	return GetFqdn([]string{strings.TrimSpace(string(out))})
+}
+
+// GetFileBoolean gets the value of a file of the host containing a boolean.
+func (h *Host) GetFileBoolean(file string) bool {
+	if h == nil {
+		return GetFileBoolean(file)
+	}
+	out, err := h.ReadFile(file)
+	if err != nil {
+		log.Fatal().Err(err).Msgf(L("Failed to read file %s"), file)
+	}
+	return strings.TrimSpace(string(out)) != "0"
+}
+
+// WorkDir returns a folder of the host to bind mount in containers instead of localDir.
+//
+// On a remote host, a temporary folder is created with the content of localDir.
+// The returned function copies the content of the folder back to localDir and removes it.
+func (h *Host) WorkDir(localDir string) (string, func() error, error) {
+	if h == nil {
+		return localDir, func() error { return nil }, nil
+	}
+	out, err := h.runShell("mktemp -d", nil)
+	if err != nil {
+		return "", nil, Error(err, L("failed to create temporary directory on the remote host"))
+	}
+	hostDir := strings.TrimSpace(string(out))
+	cleaner := func() error {
+		_, err := h.runShell("rm -rf "+ShellQuote(hostDir), nil)
+		return err
+	}
+
+	archive, err := exec.Command("tar", "-C", localDir, "-cf", "-", ".").Output()
+	if err == nil {
+		_, err = h.runShell("tar -C "+ShellQuote(hostDir)+" -xf -", archive)
+	}
+	if err != nil {
+		return "", nil, JoinErrors(Errorf(err, L("failed to copy %s to the remote host"), localDir), cleaner())
+	}
+
+	sync := func() error {
+		archive, err := h.runShell("tar -C "+ShellQuote(hostDir)+" -cf - .", nil)
+		if err == nil {
+			extract := exec.Command("tar", "-C", localDir, "-xf", "-")
+			extract.Stdin = bytes.NewReader(archive)
+			err = extract.Run()
+		}
+		if err != nil {
+			err = Errorf(err, L("failed to copy %s from the remote host"), hostDir)
+		}
+		return JoinErrors(err, cleaner())
+	}
+	return hostDir, sync, nil
+}
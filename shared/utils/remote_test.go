@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+)
+
+func TestNewHost(t *testing.T) {
+	data := []struct {
+		location string
+		expected *Host
+	}{
+		{"ssh://root@server.example.com", &Host{"root@server.example.com", "", defaultPodmanSocket}},
+		{"ssh://server.example.com:2222", &Host{"server.example.com", "2222", defaultPodmanSocket}},
+		{"ssh://admin@server.example.com/run/user/1000/podman/podman.sock",
+			&Host{"admin@server.example.com", "", "/run/user/1000/podman/podman.sock"},
+		},
+		{"", nil},
+	}
+
+	for i, test := range data {
+		host, err := NewHost(test.location)
+		testutils.AssertNoError(t, "unexpected error", err)
+		testutils.AssertEquals(t, fmt.Sprintf("Unexpected host for case %d", i), test.expected, host)
+	}
+}
+
+func TestNewHostInvalid(t *testing.T) {
+	for _, location := range []string{"server.example.com", "tcp://server.example.com:8080", "ssh://"} {
+		_, err := NewHost(location)
+		testutils.AssertError(t, "only ssh://[user@]host[:port] is supported", err)
+	}
+}
+
+func TestRemoteCommand(t *testing.T) {
+	host := &Host{"root@server.example.com", "2222", defaultPodmanSocket}
+
+	cmd := host.Command("podman", "ps", "-a")
+	testutils.AssertEquals(t, "Unexpected podman command",
+		[]string{"podman", "--url", "ssh://root@server.example.com:2222/run/podman/podman.sock", "ps", "-a"},
+		cmd.Args,
+	)
+
+	cmd = host.Command("systemctl", "cat", "uyuni-server.service")
+	testutils.AssertEquals(t, "Unexpected ssh command",
+		[]string{"ssh", "-o", "BatchMode=yes", "-p", "2222", "root@server.example.com", "--",
+			"'systemctl' 'cat' 'uyuni-server.service'"},
+		cmd.Args,
+	)
+}
+
+func TestLocalCommand(t *testing.T) {
+	var host *Host
+	cmd := host.Command("podman", "ps")
+	testutils.AssertEquals(t, "Unexpected local command", []string{"podman", "ps"}, cmd.Args)
+	testutils.AssertTrue(t, "nil host should be local", !host.IsRemote())
+}
+
+func TestShellJoin(t *testing.T) {
+	testutils.AssertEquals(t, "Unexpected quoted value", `'it'\''s'`, ShellQuote("it's"))
+	testutils.AssertEquals(t, "Unexpected joined command", `'echo' 'a b' '$HOME'`, ShellJoin("echo", "a b", "$HOME"))
+}
+
+func TestLocalHostFiles(t *testing.T) {
+	var host *Host
+	dir := path.Join(t.TempDir(), "sub", "dir")
+	testutils.AssertNoError(t, "failed to create folder", host.MkdirAll(dir, 0755))
+	testutils.AssertTrue(t, "Folder should be empty", host.IsEmptyDirectory(dir))
+
+	file := path.Join(dir, "file.txt")
+	testutils.AssertTrue(t, "File should not exist", !host.FileExists(file))
+	testutils.AssertNoError(t, "failed to write file", host.WriteFile(file, []byte("content"), 0600))
+	testutils.AssertTrue(t, "File should exist", host.FileExists(file))
+
+	info, err := os.Stat(file)
+	testutils.AssertNoError(t, "failed to stat file", err)
+	testutils.AssertEquals(t, "Unexpected file mode", os.FileMode(0600), info.Mode().Perm())
+	mode, err := host.FileMode(file)
+	testutils.AssertNoError(t, "failed to get file mode", err)
+	testutils.AssertEquals(t, "Unexpected host file mode", os.FileMode(0600), mode)
+
+	content, err := host.ReadFile(file)
+	testutils.AssertNoError(t, "failed to read file", err)
+	testutils.AssertEquals(t, "Unexpected file content", "content", string(content))
+
+	testutils.AssertNoError(t, "failed to remove file", host.Remove(file))
+	testutils.AssertTrue(t, "File should be removed", !host.FileExists(file))
+
+	hostDir, sync, err := host.WorkDir(dir)
+	testutils.AssertNoError(t, "failed to get work dir", err)
+	testutils.AssertEquals(t, "Local work dir should be used", dir, hostDir)
+	testutils.AssertNoError(t, "failed to sync work dir", sync())
+}
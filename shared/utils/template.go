@@ -5,6 +5,7 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -35,3 +36,22 @@ func WriteTemplateToFile(template Template, path string, perm os.FileMode, overw
 
 	return template.Render(file)
 }
+
+// WriteTemplateToFile writes a template to a file on the host.
+func (h *Host) WriteTemplateToFile(template Template, path string, perm os.FileMode, overwrite bool) error {
+	if h == nil {
+		return WriteTemplateToFile(template, path, perm, overwrite)
+	}
+	if !overwrite && h.FileExists(path) {
+		return fmt.Errorf(L("%s file already present, not overwriting"), path)
+	}
+
+	var content bytes.Buffer
+	if err := template.Render(&content); err != nil {
+		return err
+	}
+	if err := h.WriteFile(path, content.Bytes(), perm); err != nil {
+		return Errorf(err, L("failed to write %s"), path)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	. "github.com/uyuni-project/uyuni-tools/shared/l10n"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+)
+
+// Products of the upgrade paths.
+const (
+	UyuniProduct       = "uyuni"
+	SuseManagerProduct = "suse-manager"
+)
+
+// DefaultUpgradePaths are the releases that cannot be skipped when upgrading.
+// They can be replaced by the upgradePaths entry of the configuration file.
+var DefaultUpgradePaths = []types.UpgradePath{
+	{Product: SuseManagerProduct, From: "5.0", To: "5.1"},
+}
+
+// Release is the product and version of a server or proxy.
+type Release struct {
+	Product string
+	Version string
+}
+
+func (r Release) String() string {
+	if r.Product == UyuniProduct {
+		return "Uyuni " + r.Version
+	}
+	return "SUSE Manager " + r.Version
+}
+
+var versionRegex = regexp.MustCompile(`^([0-9]+)(\.[0-9]+)+`)
+
+// ReleaseFromVersion guesses the product of a version: Uyuni versions are dates like 2025.10.
+//
+// The returned boolean is false if the value is not a version, like the latest tag.
+func ReleaseFromVersion(version string) (Release, bool) {
+	matches := versionRegex.FindStringSubmatch(version)
+	if matches == nil {
+		return Release{}, false
+	}
+	product := SuseManagerProduct
+	if major, _ := strconv.Atoi(matches[1]); major >= 2000 {
+		product = UyuniProduct
+	}
+	return Release{Product: product, Version: matches[0]}, true
+}
+
+// AddUpgradePolicyFlags adds the flag to bypass the upgrade path check.
+func AddUpgradePolicyFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("force", false, L("Upgrade even if the upgrade path is not supported"))
+}
+
+// CheckUpgradePath returns an error if upgrading from the current release to the target one is not supported.
+func CheckUpgradePath(policy *types.UpgradePolicyFlags, current Release, target Release) error {
+	return ApplyUpgradePolicy(policy, checkUpgradePath(upgradePaths(policy), current, target))
+}
+
+// CheckProxyRelease returns an error if a proxy release cannot be used with the server release.
+func CheckProxyRelease(policy *types.UpgradePolicyFlags, server Release, proxy Release) error {
+	var err error
+	if server.Product != proxy.Product {
+		err = fmt.Errorf(L("a %[1]s proxy cannot be used with a %[2]s server"), proxy, server)
+	} else if CompareVersion(proxy.Version, server.Version) > 0 {
+		err = fmt.Errorf(L("the %[1]s proxy is newer than the %[2]s server: upgrade the server first"), proxy, server)
+	}
+	return ApplyUpgradePolicy(policy, err)
+}
+
+func upgradePaths(policy *types.UpgradePolicyFlags) []types.UpgradePath {
+	if policy != nil && len(policy.UpgradePaths) > 0 {
+		return policy.UpgradePaths
+	}
+	return DefaultUpgradePaths
+}
+
+func checkUpgradePath(paths []types.UpgradePath, current Release, target Release) error {
+	if current.Product != target.Product {
+		return fmt.Errorf(L("%[1]s is installed and the image is %[2]s: upgrade is not supported"), current, target)
+	}
+	if CompareVersion(target.Version, current.Version) < 0 {
+		return fmt.Errorf(L("cannot downgrade from version %[1]s to %[2]s"), current.Version, target.Version)
+	}
+	for _, path := range paths {
+		if !strings.EqualFold(path.Product, current.Product) {
+			continue
+		}
+		if CompareVersion(target.Version, path.To) >= 0 && CompareVersion(current.Version, path.From) < 0 {
+			return fmt.Errorf(L("%[1]s cannot be upgraded directly to %[2]s: upgrade to %[3]s first"),
+				current, target, Release{Product: current.Product, Version: path.From})
+		}
+	}
+	return nil
+}
+
+// ApplyUpgradePolicy returns the error of an upgrade check unless the check is forced.
+//
+// Forced checks only log the error.
+func ApplyUpgradePolicy(policy *types.UpgradePolicyFlags, err error) error {
+	if err == nil {
+		return nil
+	}
+	if policy != nil && policy.Force {
+		log.Warn().Err(err).Msg(L("Ignoring the failed upgrade check"))
+		return nil
+	}
+	return JoinErrors(err, errors.New(L("use --force to bypass this check")))
+}
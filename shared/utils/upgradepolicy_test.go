@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2026 SUSE LLC
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/uyuni-project/uyuni-tools/shared/testutils"
+	"github.com/uyuni-project/uyuni-tools/shared/types"
+)
+
+func TestReleaseFromVersion(t *testing.T) {
+	data := []struct {
+		version  string
+		expected Release
+		ok       bool
+	}{
+		{"2025.10", Release{UyuniProduct, "2025.10"}, true},
+		{"5.1.0", Release{SuseManagerProduct, "5.1.0"}, true},
+		{"5.1.0-beta1", Release{SuseManagerProduct, "5.1.0"}, true},
+		{"latest", Release{}, false},
+		{"5", Release{}, false},
+	}
+	for _, test := range data {
+		release, ok := ReleaseFromVersion(test.version)
+		testutils.AssertEquals(t, "Unexpected release for "+test.version, test.expected, release)
+		testutils.AssertEquals(t, "Unexpected validity for "+test.version, test.ok, ok)
+	}
+}
+
+func TestCheckUpgradePath(t *testing.T) {
+	uyuni := func(version string) Release { return Release{UyuniProduct, version} }
+	suma := func(version string) Release { return Release{SuseManagerProduct, version} }
+
+	data := []struct {
+		current  Release
+		target   Release
+		policy   types.UpgradePolicyFlags
+		expected string
+	}{
+		{uyuni("2025.10"), uyuni("2026.04"), types.UpgradePolicyFlags{}, ""},
+		{suma("5.0.5"), suma("5.1.0"), types.UpgradePolicyFlags{}, ""},
+		{suma("5.1.0"), suma("5.1.2"), types.UpgradePolicyFlags{}, ""},
+		{suma("4.3.14"), suma("5.1.0"), types.UpgradePolicyFlags{},
+			"SUSE Manager 4.3.14 cannot be upgraded directly to SUSE Manager 5.1.0: upgrade to SUSE Manager 5.0 first"},
+		{suma("5.1.0"), suma("5.0.5"), types.UpgradePolicyFlags{}, "cannot downgrade from version 5.1.0 to 5.0.5"},
+		{uyuni("2025.10"), suma("5.1.0"), types.UpgradePolicyFlags{},
+			"Uyuni 2025.10 is installed and the image is SUSE Manager 5.1.0: upgrade is not supported"},
+		// The configured upgrade paths replace the default ones
+		{uyuni("2025.04"), uyuni("2026.04"), types.UpgradePolicyFlags{
+			UpgradePaths: []types.UpgradePath{{Product: UyuniProduct, From: "2025.10", To: "2026.04"}},
+		}, "upgrade to Uyuni 2025.10 first"},
+		{suma("4.3.14"), suma("5.1.0"), types.UpgradePolicyFlags{
+			UpgradePaths: []types.UpgradePath{{Product: UyuniProduct, From: "2025.10", To: "2026.04"}},
+		}, ""},
+		// Forced checks only log the error
+		{suma("5.1.0"), suma("5.0.5"), types.UpgradePolicyFlags{Force: true}, ""},
+	}
+	for i, test := range data {
+		err := CheckUpgradePath(&test.policy, test.current, test.target)
+		if test.expected == "" {
+			testutils.AssertNoError(t, fmt.Sprintf("case %d: unexpected error", i), err)
+		} else {
+			testutils.AssertError(t, test.expected, err)
+			testutils.AssertError(t, "use --force to bypass this check", err)
+		}
+	}
+}
+
+func TestCheckProxyRelease(t *testing.T) {
+	server := Release{SuseManagerProduct, "5.1.2"}
+	policy := types.UpgradePolicyFlags{}
+
+	testutils.AssertNoError(t, "same version should be accepted",
+		CheckProxyRelease(&policy, server, Release{SuseManagerProduct, "5.1.2"}))
+	testutils.AssertNoError(t, "older proxy should be accepted",
+		CheckProxyRelease(&policy, server, Release{SuseManagerProduct, "5.1"}))
+	testutils.AssertError(t, "the SUSE Manager 5.2.0 proxy is newer than the SUSE Manager 5.1.2 server",
+		CheckProxyRelease(&policy, server, Release{SuseManagerProduct, "5.2.0"}))
+	testutils.AssertError(t, "a Uyuni 2025.10 proxy cannot be used with a SUSE Manager 5.1.2 server",
+		CheckProxyRelease(&policy, server, Release{UyuniProduct, "2025.10"}))
+
+	policy.Force = true
+	testutils.AssertNoError(t, "forced check should pass",
+		CheckProxyRelease(&policy, server, Release{SuseManagerProduct, "5.2.0"}))
+}
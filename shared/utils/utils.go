@@ -240,6 +240,18 @@ func ComputeImage(
 	return imageName, nil
 }
 
+// ImageTag returns the tag of an image or an empty string if there is none.
+func ImageTag(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	if index := strings.Index(name, "@"); index >= 0 {
+		name = name[:index]
+	}
+	if index := strings.LastIndex(name, ":"); index >= 0 {
+		return name[index+1:]
+	}
+	return ""
+}
+
 // The fullImage must contain the pattern `suse/manager/...` or `suse/multi-linux-manager/...`
 // If registry has a path, then, the fullImage must start with that path.
 func ComputePTF(registry string, user string, ptfID string, fullImage string, suffix string) (string, error) {
@@ -344,12 +356,18 @@ func maxInts(a int, b int) int {
 }
 
 // CompareVersion compare the server image version and the server deployed  version.
+//
+// The versions are compared number by number: the result is negative if the image version is older.
 func CompareVersion(imageVersion string, deployedVersion string) int {
 	image := versionAsSlice(imageVersion)
 	deployed := versionAsSlice(deployedVersion)
 
-	maxLen := maxInts(len(image), len(deployed))
-	return getPaddedVersion(image, maxLen) - getPaddedVersion(deployed, maxLen)
+	for i := 0; i < maxInts(len(image), len(deployed)); i++ {
+		if diff := versionPart(image, i) - versionPart(deployed, i); diff != 0 {
+			return diff
+		}
+	}
+	return 0
 }
 
 func versionAsSlice(version string) []string {
@@ -362,19 +380,12 @@ func versionAsSlice(version string) []string {
 	return result
 }
 
-func getPaddedVersion(version []string, size int) int {
-	padded := version
-	if len(version) != size {
-		padded = make([]string, size)
-		copy(padded, version)
-		for i, part := range padded {
-			if part == "" {
-				padded[i] = "0"
-			}
-		}
+// versionPart returns the number at the index position of a version, 0 if missing.
+func versionPart(version []string, index int) int {
+	if index >= len(version) {
+		return 0
 	}
-
-	result, _ := strconv.Atoi(strings.Join(padded, ""))
+	result, _ := strconv.Atoi(version[index])
 	return result
 }
 
@@ -481,39 +492,56 @@ func SaveBinaryData(filename string, data []int8) error {
 	return nil
 }
 
+// ComputeChecksum computes the sha256 checksum of provided file.
+func ComputeChecksum(file string) (string, error) {
+	reader, err := os.Open(file)
+	if err != nil {
+		return "", Errorf(err, L("Failed to calculate checksum of the file %s"), file)
+	}
+	defer reader.Close()
+	checksum, err := ComputeReaderChecksum(reader)
+	if err != nil {
+		return "", Errorf(err, L("Failed to calculate checksum of the file %s"), file)
+	}
+	return checksum, nil
+}
+
 // CreateChecksum creates sha256 checksum of provided file.
-// Uses system `sha256sum` binary to avoid pulling crypto dependencies.
 func CreateChecksum(file string) error {
 	outputFile := file + ".sha256sum"
 
-	output, err := NewRunner("sha256sum", file).Exec()
+	checksum, err := ComputeChecksum(file)
 	if err != nil {
-		return Errorf(err, L("Failed to calculate checksum of the file %s"), file)
+		return err
 	}
-	// We want only checksum, drop the filepath
-	output = bytes.Split(output, []byte(" "))[0]
-	if err := os.WriteFile(outputFile, output, 0622); err != nil {
+	if err := os.WriteFile(outputFile, []byte(checksum), 0622); err != nil {
 		return Errorf(err, L("Failed to write checksum of the file %[1]s to the %[2]s"), file, outputFile)
 	}
 	return nil
 }
 
-// ValidateChecksum checks integrity of the file by checking against stored checksum
-// Uses system `sha256sum` binary to avoid pulling crypt dependencies.
+// ReadChecksum reads the sha256 checksum stored next to the file.
+func ReadChecksum(file string) (string, error) {
+	output, err := os.ReadFile(file + ".sha256sum")
+	if err != nil {
+		return "", Errorf(err, L("Failed to read checksum of the file %[1]s"), file)
+	}
+	// Split by space to work with older backups
+	return string(bytes.Split(bytes.TrimSpace(output), []byte(" "))[0]), nil
+}
+
+// ValidateChecksum checks integrity of the file by checking against stored checksum.
 func ValidateChecksum(file string) error {
-	checksum, err := NewRunner("sha256sum", file).Exec()
+	checksum, err := ComputeChecksum(file)
 	if err != nil {
-		return Errorf(err, L("Failed to calculate checksum of the file %s"), file)
+		return err
 	}
-	// We want only checksum, drop the filepath
-	checksum = bytes.Split(checksum, []byte(" "))[0]
 
-	output, err := os.ReadFile(file + ".sha256sum")
+	expected, err := ReadChecksum(file)
 	if err != nil {
-		return Errorf(err, L("Failed to read checksum of the file %[1]s"), file)
+		return err
 	}
-	// Split by space to work with older backups
-	if !bytes.Equal(checksum, bytes.Split(output, []byte(" "))[0]) {
+	if checksum != expected {
 		return fmt.Errorf(L("Checksum of %s does not match"), file)
 	}
 	return nil
@@ -532,3 +560,17 @@ func RandomHexString(nBytes int) (string, error) {
 
 	return hex.EncodeToString(b), nil
 }
+
+// FormatSize returns a human readable size.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -455,6 +455,19 @@ func TestComputeImageError(t *testing.T) {
 	}
 }
 
+func TestImageTag(t *testing.T) {
+	data := [][]string{
+		{"registry.suse.com/suse/manager/5.1/x86_64/server:5.1.0", "5.1.0"},
+		{"localhost:5000/uyuni/server", ""},
+		{"localhost:5000/uyuni/server:2025.10", "2025.10"},
+		{"localhost:5000/uyuni/server@sha256:0123abcd", ""},
+		{"server", ""},
+	}
+	for _, testCase := range data {
+		testutils.AssertEquals(t, "Wrong tag for "+testCase[0], testCase[1], ImageTag(testCase[0]))
+	}
+}
+
 func TestSplitRegistryHostAndPath(t *testing.T) {
 	data := [][]string{
 		{"registry.suse.com", "registry.suse.com", ""},
@@ -534,10 +547,11 @@ func TestCompareVersion(t *testing.T) {
 	testutils.AssertTrue(t, "2024.13 is not superior to 2024.07", CompareVersion("2024.13", "2024.07") > 0)
 	testutils.AssertTrue(t, "2024.13 is not equal to 2024.13", CompareVersion("2024.13", "2024.13") == 0)
 
-	testutils.AssertEquals(t, "invalid padded version", 5041, getPaddedVersion(versionAsSlice("5.0.4.1"), 4))
-	testutils.AssertEquals(t, "invalid padded version", 5100, getPaddedVersion(versionAsSlice("5.1.0"), 4))
 	testutils.AssertTrue(t, "5.1.0 is not superior to 5.0.4.1", CompareVersion("5.1.0", "5.0.4.1") > 0)
 	testutils.AssertTrue(t, "5.1-rc is not superior to 5.0.4.1", CompareVersion("5.1-rc", "5.0.4.1") > 0)
+	testutils.AssertTrue(t, "5.1.0 is not superior to 4.3.14", CompareVersion("5.1.0", "4.3.14") > 0)
+	testutils.AssertTrue(t, "5.10 is not superior to 5.9", CompareVersion("5.10", "5.9") > 0)
+	testutils.AssertTrue(t, "5.1 is not equal to 5.1.0", CompareVersion("5.1", "5.1.0") == 0)
 }
 
 func TestCreatingChecksumFile(t *testing.T) {
@@ -635,3 +649,9 @@ func TestValidatingOlderChecksumFile(t *testing.T) {
 	err = ValidateChecksum(filepath)
 	testutils.AssertTrue(t, "Failed to validate checksum", err == nil)
 }
+
+func TestFormatSize(t *testing.T) {
+	testutils.AssertEquals(t, "Unexpected bytes size", "512 B", FormatSize(512))
+	testutils.AssertEquals(t, "Unexpected KiB size", "1.5 KiB", FormatSize(1536))
+	testutils.AssertEquals(t, "Unexpected TiB size", "2.0 TiB", FormatSize(2*1024*1024*1024*1024))
+}